@@ -0,0 +1,32 @@
+// Command mockoidc runs a mock OpenID Connect provider to try social login locally.
+//
+//	go run ./cmd/mockoidc --addr localhost:9000
+//
+// and add the provider to configs.
+//
+//	oauth:
+//	  providers:
+//	    - name: mock
+//	      type: oidc
+//	      issuer: http://localhost:9000
+//	      clientId: client-id
+//	      clientSecret: client-secret
+package main
+
+import (
+	"flag"
+	"gin-rest-api-example/pkg/oauth/oauthtest"
+	"log"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "address to listen")
+	clientID := flag.String("client-id", "client-id", "client id")
+	clientSecret := flag.String("client-secret", "client-secret", "client secret")
+	flag.Parse()
+
+	log.Printf("mock OpenID Connect provider is listening on http://%s", *addr)
+	if err := oauthtest.ListenAndServe(*addr, *clientID, *clientSecret); err != nil {
+		log.Fatal(err)
+	}
+}
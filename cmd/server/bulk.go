@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	accountDB "gin-rest-api-example/internal/account/database"
+	"gin-rest-api-example/internal/article/bulk"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/logging"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	bulkFormat    string
+	bulkBatchSize int
+	bulkDryRun    bool
+	bulkAuthor    string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file or directory>",
+	Short: "Import articles of a NDJSON file(\"-\" for stdin) or a directory of Markdown files",
+	Long: `Import articles of a NDJSON file("-" for stdin) or a directory of Markdown files with front matters
+in batched transactions and print the report of imported and failed articles.
+It exits with a non-zero code if any article failed to import.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImport(cmd.OutOrStdout(), args[0])
+	},
+}
+
+var exportCmd = &cobra.Command{
+	Use:          "export <file or directory>",
+	Short:        "Export articles to a NDJSON file or a directory of Markdown files",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExport(args[0])
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{importCmd, exportCmd} {
+		cmd.Flags().StringVar(&bulkFormat, "format", bulk.FormatNDJSON, "format of articles: ndjson or markdown")
+	}
+	importCmd.Flags().IntVar(&bulkBatchSize, "batch-size", bulk.DefaultBatchSize, "number of articles saved in a transaction")
+	importCmd.Flags().BoolVar(&bulkDryRun, "dry-run", false, "check articles without saving them")
+	importCmd.Flags().StringVar(&bulkAuthor, "author", "", "username of the author of articles without an author")
+}
+
+// openBulkDB opens the database without the cache, because imported articles are not cached
+// until transactions are committed and exported articles are read from the database.
+func openBulkDB() (*gorm.DB, func(), error) {
+	db, err := database.NewDatabase(loadConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	closeDB := func() {
+		if rawDB, err := db.DB(); err == nil {
+			rawDB.Close()
+		}
+		logging.DefaultLogger().Sync()
+	}
+	return db, closeDB, nil
+}
+
+func runImport(out io.Writer, path string) error {
+	var reader bulk.Reader
+	switch bulkFormat {
+	case bulk.FormatNDJSON:
+		f := os.Stdin
+		if path != "-" {
+			var err error
+			if f, err = os.Open(path); err != nil {
+				return err
+			}
+			defer f.Close()
+		}
+		reader = bulk.NewNDJSONReader(f)
+	case bulk.FormatMarkdown:
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory of Markdown files", path)
+		}
+		reader = bulk.NewMarkdownReader(os.DirFS(path))
+	default:
+		return fmt.Errorf("unknown format: %s", bulkFormat)
+	}
+
+	db, closeDB, err := openBulkDB()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	importer := bulk.NewImporter(articleDB.NewArticleDB(db, nil, nil), accountDB.NewAccountDB(db, nil, nil))
+	report, err := importer.Import(context.Background(), reader, bulk.Options{
+		BatchSize:     bulkBatchSize,
+		DryRun:        bulkDryRun,
+		DefaultAuthor: bulkAuthor,
+	})
+	b, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Fprintln(out, string(b))
+	if err != nil {
+		return err
+	}
+	if report.Failed != 0 {
+		return fmt.Errorf("failed to import %d of %d articles", report.Failed, report.Total)
+	}
+	return nil
+}
+
+// runExport writes articles to given path. Articles are not written to stdout which logs are written to.
+func runExport(path string) error {
+	var (
+		w           bulk.Writer
+		closeOutput = func() error { return nil }
+	)
+	switch bulkFormat {
+	case bulk.FormatNDJSON:
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		w, closeOutput = bulk.NewNDJSONWriter(f), f.Close
+	case bulk.FormatMarkdown:
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+		w = bulk.NewMarkdownWriter(func(name string, data []byte) error {
+			return os.WriteFile(filepath.Join(path, name), data, 0644)
+		})
+	default:
+		return fmt.Errorf("unknown format: %s", bulkFormat)
+	}
+
+	db, closeDB, err := openBulkDB()
+	if err != nil {
+		closeOutput()
+		return err
+	}
+	defer closeDB()
+	written, err := bulk.NewExporter(articleDB.NewArticleDB(db, nil, nil)).Export(context.Background(), w)
+	if err1 := closeOutput(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+	logging.DefaultLogger().Infof("exported %d articles to %s", written, path)
+	return nil
+}
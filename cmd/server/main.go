@@ -18,7 +18,7 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(serverCmd, importCmd, exportCmd)
 	rootCmd.PersistentFlags().StringVarP(&configFile, "conf", "", "", "config file path")
 }
 
@@ -6,15 +6,27 @@ import (
 	"fmt"
 	"gin-rest-api-example/internal/account"
 	accountDB "gin-rest-api-example/internal/account/database"
+	"gin-rest-api-example/internal/admin"
 	"gin-rest-api-example/internal/article"
+	"gin-rest-api-example/internal/article/bulk"
 	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/audit"
+	auditDB "gin-rest-api-example/internal/audit/database"
 	"gin-rest-api-example/internal/cache"
 	"gin-rest-api-example/internal/config"
 	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/graphql"
+	"gin-rest-api-example/internal/mailer"
 	"gin-rest-api-example/internal/metric"
 	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/internal/privacy"
+	"gin-rest-api-example/pkg/i18n"
 	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/openapi"
+	"gin-rest-api-example/pkg/validate"
 	"log"
+	"net"
 	"net/http"
 	"time"
 
@@ -24,6 +36,7 @@ import (
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
 var serverCmd = &cobra.Command{
@@ -33,8 +46,8 @@ var serverCmd = &cobra.Command{
 	},
 }
 
-func runApplication() {
-	// load configs and sets default logger configs.
+// loadConfig loads configs, sets default logger configs and registers custom validators of binding tags.
+func loadConfig() *config.Config {
 	conf, err := config.Load(configFile)
 	if err != nil {
 		log.Fatal(err)
@@ -44,6 +57,14 @@ func runApplication() {
 		Level:       zapcore.Level(conf.LoggingConfig.Level),
 		Development: conf.LoggingConfig.Development,
 	})
+	if err := validate.SetupBinding(); err != nil {
+		log.Fatal(err)
+	}
+	return conf
+}
+
+func runApplication() {
+	conf := loadConfig()
 	defer logging.DefaultLogger().Sync()
 
 	// setup application(di + run server)
@@ -63,38 +84,82 @@ func runApplication() {
 			database.NewDatabase,
 			// setup cache
 			cache.NewCacher,
+			// setup messages of locales
+			middleware.NewCatalog,
+			// setup rate limits and idempotency keys
+			middleware.NewRateLimiter,
+			middleware.NewIdempotency,
+			middleware.NewCustomMethodRegistry,
+			// setup mailer
+			mailer.NewSender,
+			// setup audit packages
+			auditDB.NewAuditDB,
+			audit.NewAuditor,
 			// setup account packages
 			accountDB.NewAccountDB,
 			account.NewAuthMiddleware,
+			account.NewOAuthProviders,
+			account.NewPasswordPolicy,
 			account.NewHandler,
+			account.NewGRPCServer,
 			// setup article packages
 			articleDB.NewArticleDB,
 			article.NewHandler,
+			article.NewGRPCServer,
+			// setup privacy packages
+			privacy.NewHandler,
+			// setup admin packages
+			bulk.NewImporter,
+			bulk.NewExporter,
+			admin.NewHandler,
+			// setup graphql packages
+			graphql.NewHandler,
 			// server
+			newOpenAPISpec,
 			newServer,
+			newGRPCServer,
 		),
 		fx.Invoke(
 			account.RouteV1,
 			article.RouteV1,
+			article.RouteV2,
+			privacy.RouteV1,
+			admin.RouteV1,
+			graphql.Route,
+			routeOpenAPI,
+			registerGRPC,
 			func(r *gin.Engine) {},
 		),
 	)
 	app.Run()
 }
 
-func newServer(lc fx.Lifecycle, cfg *config.Config, mp *metric.MetricsProvider) *gin.Engine {
+func newServer(lc fx.Lifecycle, cfg *config.Config, mp *metric.MetricsProvider, catalog *i18n.Catalog,
+	customMethods *middleware.CustomMethodRegistry) *gin.Engine {
 	gin.SetMode(gin.DebugMode)
 	r := gin.New()
-	r.Use(middleware.LoggingMiddleware("/metric"), gin.Recovery())
+	r.Use(handler.ErrorFormatMiddleware(cfg), middleware.LocaleMiddleware(catalog), middleware.LoggingMiddleware("/metric"), middleware.RecoveryMiddleware(),
+		middleware.RejectRawCustomMethods(customMethods))
+	r.NoRoute(func(c *gin.Context) {
+		handler.AbortWithError(c, http.StatusNotFound, &handler.ErrorResponse{
+			Code:    handler.NotFoundRoute,
+			Message: fmt.Sprintf("%s %s is not found", c.Request.Method, c.Request.URL.Path),
+		})
+	})
 
 	metric.Route(r)
 	r.Use(metric.MetricsMiddleware(mp))
 
+	// apis are timed out by their own timeout middlewares, so the write timeout of the server allows bulk apis
+	writeTimeout := cfg.ServerConfig.WriteTimeout
+	if cfg.ServerConfig.BulkTimeout > writeTimeout {
+		writeTimeout = cfg.ServerConfig.BulkTimeout
+	}
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.ServerConfig.Port),
-		Handler:      r,
+		Handler:      middleware.CustomMethods(customMethods, middleware.NegotiateVersion(r)),
 		ReadTimeout:  cfg.ServerConfig.ReadTimeout,
-		WriteTimeout: cfg.ServerConfig.WriteTimeout,
+		WriteTimeout: writeTimeout,
 	}
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -114,6 +179,76 @@ func newServer(lc fx.Lifecycle, cfg *config.Config, mp *metric.MetricsProvider)
 	return r
 }
 
+// newGRPCServer returns the gRPC server with interceptors equivalent to middlewares of newServer and the authentication.
+// It is not started if the port is zero.
+func newGRPCServer(lc fx.Lifecycle, cfg *config.Config, mp *metric.MetricsProvider, auth *account.AuthMiddleware) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		middleware.RequestIDUnaryInterceptor(),
+		middleware.LoggingUnaryInterceptor(),
+		middleware.RecoveryUnaryInterceptor(),
+		metric.MetricsUnaryInterceptor(mp),
+		auth.UnaryServerInterceptor(),
+	))
+	port := cfg.ServerConfig.Grpc.Port
+	if port == 0 {
+		return srv
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+			if err != nil {
+				return err
+			}
+			logging.FromContext(ctx).Infof("Start to grpc server :%d", port)
+			go func() {
+				if err := srv.Serve(lis); err != nil {
+					logging.DefaultLogger().Errorw("failed to close grpc server", "err", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logging.FromContext(ctx).Info("Stopped grpc server")
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				srv.Stop()
+				return ctx.Err()
+			}
+		},
+	})
+	return srv
+}
+
+// registerGRPC registers account and article services to the gRPC server.
+func registerGRPC(srv *grpc.Server, accountServer *account.GRPCServer, articleServer *article.GRPCServer) {
+	accountServer.Register(srv)
+	articleServer.Register(srv)
+}
+
+// newOpenAPISpec returns the OpenAPI document of account and article routes validating requests of them.
+func newOpenAPISpec() *openapi.Spec {
+	spec := openapi.NewSpec(openapi.Info{
+		Title:   "gin-rest-api-example",
+		Version: "v1",
+	}, handler.Problem{})
+	spec.Add(account.OpenAPIRoutes()...)
+	spec.Add(article.OpenAPIRoutes()...)
+	return spec
+}
+
+// routeOpenAPI serves the OpenAPI document at /openapi.json and Swagger UI of it at /swagger.
+func routeOpenAPI(r *gin.Engine, spec *openapi.Spec) {
+	r.GET("/openapi.json", spec.Handler())
+	r.GET("/swagger", openapi.UIHandler("/openapi.json"))
+}
+
 func printAppInfo(cfg *config.Config) {
 	b, _ := json.MarshalIndent(&cfg, "", "  ")
 	logging.DefaultLogger().Infof("application information\n%s", string(b))
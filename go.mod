@@ -1,16 +1,16 @@
 module gin-rest-api-example
 
-go 1.14
+go 1.16
 
 require (
 	github.com/alicebob/miniredis/v2 v2.23.1
-	github.com/appleboy/gin-jwt/v2 v2.6.4
 	github.com/containerd/containerd v1.6.6 // indirect
 	github.com/gin-gonic/gin v1.6.3
 	github.com/go-playground/validator/v10 v10.2.0
 	github.com/go-redis/cache/v8 v8.4.4
 	github.com/go-redis/redis/v8 v8.11.3
 	github.com/go-sql-driver/mysql v1.6.0
+	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/golang-migrate/migrate v3.5.4+incompatible
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/mux v1.8.0 // indirect
@@ -24,9 +24,14 @@ require (
 	github.com/spf13/cobra v1.1.3
 	github.com/stretchr/testify v1.8.0
 	github.com/tidwall/gjson v1.6.0
+	github.com/vmihailenco/msgpack/v5 v5.3.4
 	go.uber.org/fx v1.18.2
 	go.uber.org/zap v1.23.0
 	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
+	google.golang.org/genproto v0.0.0-20211208223120-3a66f561d7aa
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/driver/mysql v1.4.4
 	gorm.io/gorm v1.24.0
 )
@@ -0,0 +1,93 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyHeadName = "ApiKey"
+	apiKeyPrefix   = "ak_"
+	apiKeyCtxKey   = "apiKey"
+)
+
+// Scopes of api keys. Access tokens of users are not limited by scopes.
+const (
+	ScopeUserRead     = "user:read"
+	ScopeUserWrite    = "user:write"
+	ScopeArticleWrite = "article:write"
+	ScopeCommentWrite = "comment:write"
+)
+
+var apiKeyScopes = []string{ScopeUserRead, ScopeUserWrite, ScopeArticleWrite, ScopeCommentWrite}
+
+// CurrentAPIKey returns the api key of the current request if authenticated by an api key
+func CurrentAPIKey(c *gin.Context) (*model.APIKey, bool) {
+	data, ok := c.Get(apiKeyCtxKey)
+	if !ok {
+		return nil, false
+	}
+	key, ok := data.(*model.APIKey)
+	return key, ok
+}
+
+// RequireScope returns a gin.HandlerFunc that rejects requests authenticated by an api key without given scope.
+// Must be used after AuthMiddleware.MiddlewareFunc.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := CurrentAPIKey(c); ok && !key.HasScope(scope) {
+			handler.AbortWithError(c, http.StatusForbidden, &handler.ErrorResponse{
+				Code:    handler.InsufficientScope,
+				Message: "api key requires " + scope + " scope",
+			})
+		}
+	}
+}
+
+// RejectAPIKey returns a gin.HandlerFunc that rejects requests authenticated by an api key
+// e.g. managing api keys requires an access token of the user.
+// Must be used after AuthMiddleware.MiddlewareFunc.
+func RejectAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentAPIKey(c); ok {
+			handler.AbortWithError(c, http.StatusForbidden, &handler.ErrorResponse{
+				Code:    handler.InsufficientScope,
+				Message: "api keys are not allowed",
+			})
+		}
+	}
+}
+
+// generateAPIKey returns a new api key like "ak_<prefix>_<secret>", the displayable prefix and the hash of the key
+func generateAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 36)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix = apiKeyPrefix + hex.EncodeToString(b[:4])
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(b[4:])
+	return key, prefix, hashAPIKey(key), nil
+}
+
+// hashAPIKey returns a sha256 digest of given key. Api keys have enough entropy,
+// so a fast hash is used to look up keys by the hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, s := range apiKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -2,25 +2,107 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"gin-rest-api-example/internal/account/model"
 	"gin-rest-api-example/internal/cache"
 	"gin-rest-api-example/internal/database"
 	"gin-rest-api-example/internal/metric"
 	"gin-rest-api-example/pkg/logging"
+	"time"
 
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
+// AnonymizedUsernamePrefix is the prefix of usernames of deleted accounts followed by the account id
+const AnonymizedUsernamePrefix = "deleted-user-"
+
+const uniqueUsernameKey = "unique_accounts_username"
+
+// ErrUsernameConflict is returned if the username is taken by another account.
+// database.IsKeyConflictErr returns true for the error.
+var ErrUsernameConflict = fmt.Errorf("username %w", database.ErrKeyConflict)
+
 //go:generate mockery --name AccountDB --filename account_mock.go
 type AccountDB interface {
-	// Save saves a given account
+	// RunInTx runs given function in a transaction
+	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
+
+	// Save saves a given account.
+	// ErrUsernameConflict is returned if the username is taken, database.ErrKeyConflict if the email address is.
 	Save(ctx context.Context, account *model.Account) error
 
-	// Update updates a given account
+	// Update updates non empty fields of a given account.
+	// email_verified is updated only if account.EmailVerified is true.
+	// ErrUsernameConflict is returned if the username is taken by another account.
 	Update(ctx context.Context, email string, account *model.Account) error
 
 	// FindByEmail returns an account with given email if exist
 	FindByEmail(ctx context.Context, email string) (*model.Account, error)
+
+	// FindByUsername returns an account with given username if exist
+	FindByUsername(ctx context.Context, username string) (*model.Account, error)
+
+	// FindByUsernames returns accounts with given usernames which exist
+	FindByUsernames(ctx context.Context, usernames []string) ([]*model.Account, error)
+
+	// FindByID returns an account with given id if exist
+	FindByID(ctx context.Context, id uint) (*model.Account, error)
+
+	// UpdateEmail changes the email address of an account with given id to a confirmed email address.
+	// database.ErrKeyConflict is returned if the email address is registered already.
+	UpdateEmail(ctx context.Context, id uint, email string) error
+
+	// UpdateMFA updates the mfa secret and whether mfa is enabled of an account with given email.
+	// An empty secret clears the secret.
+	UpdateMFA(ctx context.Context, email string, secret string, enabled bool) error
+
+	// UseMFAStep saves given time step of an accepted TOTP code as the last step of an account.
+	// database.ErrNotFound is returned if the step is at or before the last step, so that codes are not replayed.
+	UseMFAStep(ctx context.Context, accountID uint, step int64) error
+
+	// ReplaceRecoveryCodes deletes all recovery codes of given account and saves given hashed codes
+	ReplaceRecoveryCodes(ctx context.Context, accountID uint, codeHashes []string) error
+
+	// UseRecoveryCode marks an unused recovery code with given hash as used.
+	// database.ErrNotFound is returned if there is no unused code.
+	UseRecoveryCode(ctx context.Context, accountID uint, codeHash string) error
+
+	// SaveIdentity saves a given identity of an external provider.
+	// database.ErrKeyConflict is returned if the identity is linked already.
+	SaveIdentity(ctx context.Context, identity *model.AccountIdentity) error
+
+	// FindByIdentity returns an account linked to the identity with given provider and subject if exist
+	FindByIdentity(ctx context.Context, provider, subject string) (*model.Account, error)
+
+	// SaveAPIKey saves a given api key
+	SaveAPIKey(ctx context.Context, key *model.APIKey) error
+
+	// UpdateAPIKey updates the name and scopes of a given api key of the account
+	UpdateAPIKey(ctx context.Context, key *model.APIKey) error
+
+	// DeleteAPIKey deletes an api key with given id of the account
+	DeleteAPIKey(ctx context.Context, accountID, id uint) error
+
+	// FindAPIKey returns an api key with given id of the account if exist
+	FindAPIKey(ctx context.Context, accountID, id uint) (*model.APIKey, error)
+
+	// FindAPIKeys returns api keys of the account ordered by created time
+	FindAPIKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error)
+
+	// FindAPIKeyByHash returns an api key with given hash if exist
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+
+	// TouchAPIKey updates the last used time of an api key with given id
+	TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error
+
+	// FindIdentities returns identities of external providers linked to the account
+	FindIdentities(ctx context.Context, accountID uint) ([]*model.AccountIdentity, error)
+
+	// Anonymize clears personal data of an account with given id, disables the account and deletes
+	// its identities, api keys and recovery codes. The account is kept for contents referring to it.
+	// database.ErrNotFound is returned if not exist or deleted already.
+	Anonymize(ctx context.Context, id uint) error
 }
 
 // NewAccountDB creates a new account db with given db
@@ -35,6 +117,25 @@ type accountDB struct {
 	db *gorm.DB
 }
 
+func (a *accountDB) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	tx := a.db.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "start tx")
+	}
+
+	ctx = database.WithDB(ctx, tx)
+	if err := f(ctx); err != nil {
+		if err1 := tx.Rollback().Error; err1 != nil {
+			return errors.Wrap(err, fmt.Sprintf("rollback tx: %v", err1.Error()))
+		}
+		return errors.Wrap(err, "invoke function")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit tx: %v", err)
+	}
+	return nil
+}
+
 func (a *accountDB) Save(ctx context.Context, account *model.Account) error {
 	logger := logging.FromContext(ctx)
 	db := database.FromContext(ctx, a.db)
@@ -42,6 +143,9 @@ func (a *accountDB) Save(ctx context.Context, account *model.Account) error {
 
 	if err := db.WithContext(ctx).Create(account).Error; err != nil {
 		logger.Error("account.db.Save failed to save", "err", err)
+		if database.IsKeyConflictOn(err, uniqueUsernameKey) {
+			return ErrUsernameConflict
+		}
 		if database.IsKeyConflictErr(err) {
 			return database.ErrKeyConflict
 		}
@@ -68,6 +172,9 @@ func (a *accountDB) Update(ctx context.Context, email string, account *model.Acc
 	if account.Image != "" {
 		fields["image"] = account.Image
 	}
+	if account.EmailVerified {
+		fields["email_verified"] = true
+	}
 
 	chain := db.WithContext(ctx).
 		Model(&model.Account{}).
@@ -75,6 +182,9 @@ func (a *accountDB) Update(ctx context.Context, email string, account *model.Acc
 		UpdateColumns(fields)
 	if chain.Error != nil {
 		logger.Error("account.db.Update failed to update", "err", chain.Error)
+		if database.IsKeyConflictOn(chain.Error, uniqueUsernameKey) {
+			return ErrUsernameConflict
+		}
 		return chain.Error
 	}
 	if chain.RowsAffected == 0 {
@@ -98,3 +208,373 @@ func (a *accountDB) FindByEmail(ctx context.Context, email string) (*model.Accou
 	}
 	return &acc, nil
 }
+
+func (a *accountDB) UpdateEmail(ctx context.Context, id uint, email string) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.UpdateEmail", "id", id, "email", email)
+
+	chain := db.WithContext(ctx).
+		Model(&model.Account{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"email":          email,
+			"email_verified": true,
+			"updated_at":     time.Now(),
+		})
+	if chain.Error != nil {
+		logger.Error("account.db.UpdateEmail failed to update", "err", chain.Error)
+		if database.IsKeyConflictErr(chain.Error) {
+			return database.ErrKeyConflict
+		}
+		return chain.Error
+	}
+	if chain.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (a *accountDB) FindByUsername(ctx context.Context, username string) (*model.Account, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindByUsername", "username", username)
+
+	var acc model.Account
+	if err := db.WithContext(ctx).Where("username = ?", username).First(&acc).Error; err != nil {
+		logger.Error("account.db.FindByUsername failed to find", "err", err)
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (a *accountDB) FindByUsernames(ctx context.Context, usernames []string) ([]*model.Account, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindByUsernames", "usernames", usernames)
+
+	ret := []*model.Account{}
+	if len(usernames) == 0 {
+		return ret, nil
+	}
+	if err := db.WithContext(ctx).Where("username IN ?", usernames).Find(&ret).Error; err != nil {
+		logger.Errorw("account.db.FindByUsernames failed to find", "err", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (a *accountDB) UpdateMFA(ctx context.Context, email string, secret string, enabled bool) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.UpdateMFA", "email", email, "enabled", enabled)
+
+	var secretValue interface{}
+	if secret != "" {
+		secretValue = secret
+	}
+	chain := db.WithContext(ctx).
+		Model(&model.Account{}).
+		Where("email = ?", email).
+		UpdateColumns(map[string]interface{}{
+			"mfa_secret":  secretValue,
+			"mfa_enabled": enabled,
+		})
+	if chain.Error != nil {
+		logger.Error("account.db.UpdateMFA failed to update", "err", chain.Error)
+		return chain.Error
+	}
+	if chain.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (a *accountDB) UseMFAStep(ctx context.Context, accountID uint, step int64) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.UseMFAStep", "accountID", accountID, "step", step)
+
+	chain := db.WithContext(ctx).
+		Model(&model.Account{}).
+		Where("id = ? AND (mfa_last_step IS NULL OR mfa_last_step < ?)", accountID, step).
+		UpdateColumn("mfa_last_step", step)
+	if chain.Error != nil {
+		logger.Error("account.db.UseMFAStep failed to update", "err", chain.Error)
+		return chain.Error
+	}
+	if chain.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (a *accountDB) ReplaceRecoveryCodes(ctx context.Context, accountID uint, codeHashes []string) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.ReplaceRecoveryCodes", "accountID", accountID, "codes", len(codeHashes))
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("account_id = ?", accountID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			return nil
+		}
+		now := time.Now()
+		codes := make([]*model.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = &model.RecoveryCode{AccountID: accountID, CodeHash: hash, CreatedAt: now}
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		logger.Error("account.db.ReplaceRecoveryCodes failed to replace", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (a *accountDB) UseRecoveryCode(ctx context.Context, accountID uint, codeHash string) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.UseRecoveryCode", "accountID", accountID)
+
+	chain := db.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("account_id = ? AND code_hash = ? AND used_at IS NULL", accountID, codeHash).
+		UpdateColumn("used_at", time.Now())
+	if chain.Error != nil {
+		logger.Error("account.db.UseRecoveryCode failed to update", "err", chain.Error)
+		return chain.Error
+	}
+	if chain.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (a *accountDB) SaveIdentity(ctx context.Context, identity *model.AccountIdentity) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.SaveIdentity", "accountID", identity.AccountID, "provider", identity.Provider)
+
+	if err := db.WithContext(ctx).Create(identity).Error; err != nil {
+		logger.Error("account.db.SaveIdentity failed to save", "err", err)
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (a *accountDB) FindByIdentity(ctx context.Context, provider, subject string) (*model.Account, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindByIdentity", "provider", provider)
+
+	var acc model.Account
+	err := db.WithContext(ctx).
+		Joins("JOIN account_identities ai ON ai.account_id = accounts.id").
+		Where("ai.provider = ? AND ai.subject = ?", provider, subject).
+		First(&acc).Error
+	if err != nil {
+		logger.Error("account.db.FindByIdentity failed to find", "err", err)
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (a *accountDB) FindByID(ctx context.Context, id uint) (*model.Account, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindByID", "id", id)
+
+	var acc model.Account
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&acc).Error; err != nil {
+		logger.Error("account.db.FindByID failed to find", "err", err)
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (a *accountDB) SaveAPIKey(ctx context.Context, key *model.APIKey) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.SaveAPIKey", "accountID", key.AccountID, "name", key.Name)
+
+	if err := db.WithContext(ctx).Create(key).Error; err != nil {
+		logger.Error("account.db.SaveAPIKey failed to save", "err", err)
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (a *accountDB) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.UpdateAPIKey", "accountID", key.AccountID, "id", key.ID)
+
+	chain := db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND account_id = ?", key.ID, key.AccountID).
+		Updates(map[string]interface{}{
+			"name":   key.Name,
+			"scopes": key.Scopes,
+		})
+	if chain.Error != nil {
+		logger.Error("account.db.UpdateAPIKey failed to update", "err", chain.Error)
+		return chain.Error
+	}
+	if chain.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (a *accountDB) DeleteAPIKey(ctx context.Context, accountID, id uint) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.DeleteAPIKey", "accountID", accountID, "id", id)
+
+	chain := db.WithContext(ctx).Where("id = ? AND account_id = ?", id, accountID).Delete(&model.APIKey{})
+	if chain.Error != nil {
+		logger.Error("account.db.DeleteAPIKey failed to delete", "err", chain.Error)
+		return chain.Error
+	}
+	if chain.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (a *accountDB) FindAPIKey(ctx context.Context, accountID, id uint) (*model.APIKey, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindAPIKey", "accountID", accountID, "id", id)
+
+	var key model.APIKey
+	if err := db.WithContext(ctx).Where("id = ? AND account_id = ?", id, accountID).First(&key).Error; err != nil {
+		logger.Error("account.db.FindAPIKey failed to find", "err", err)
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (a *accountDB) FindAPIKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindAPIKeys", "accountID", accountID)
+
+	var keys []*model.APIKey
+	if err := db.WithContext(ctx).Where("account_id = ?", accountID).Order("created_at ASC, id ASC").Find(&keys).Error; err != nil {
+		logger.Error("account.db.FindAPIKeys failed to find", "err", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (a *accountDB) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindAPIKeyByHash")
+
+	var key model.APIKey
+	if err := db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		logger.Error("account.db.FindAPIKeyByHash failed to find", "err", err)
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (a *accountDB) TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.TouchAPIKey", "id", id)
+
+	err := db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+	if err != nil {
+		logger.Error("account.db.TouchAPIKey failed to update", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (a *accountDB) FindIdentities(ctx context.Context, accountID uint) ([]*model.AccountIdentity, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.FindIdentities", "accountID", accountID)
+
+	var identities []*model.AccountIdentity
+	if err := db.WithContext(ctx).Where("account_id = ?", accountID).Order("id ASC").Find(&identities).Error; err != nil {
+		logger.Error("account.db.FindIdentities failed to find", "err", err)
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (a *accountDB) Anonymize(ctx context.Context, id uint) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("account.db.Anonymize", "id", id)
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{&model.RecoveryCode{}, &model.AccountIdentity{}, &model.APIKey{}} {
+			if err := tx.Where("account_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		chain := tx.Model(&model.Account{}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			UpdateColumns(map[string]interface{}{
+				"username":       fmt.Sprintf("%s%d", AnonymizedUsernamePrefix, id),
+				"email":          fmt.Sprintf("deleted-user-%d@deleted.invalid", id),
+				"password":       "",
+				"bio":            "",
+				"image":          "",
+				"disabled":       true,
+				"email_verified": false,
+				"role":           model.RoleUser,
+				"mfa_secret":     "",
+				"mfa_enabled":    false,
+				"mfa_last_step":  nil,
+				"deleted_at":     now,
+				"updated_at":     now,
+			})
+		if chain.Error != nil {
+			return chain.Error
+		}
+		if chain.RowsAffected == 0 {
+			return database.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("account.db.Anonymize failed to anonymize", "err", err)
+		return err
+	}
+	return nil
+}
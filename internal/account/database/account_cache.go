@@ -6,12 +6,14 @@ import (
 	"gin-rest-api-example/internal/account/model"
 	"gin-rest-api-example/internal/cache"
 	"gin-rest-api-example/internal/metric"
+	"time"
 )
 
 var _ AccountDB = (*accountCachedDB)(nil)
 
+// accounts are cached by ids which never change, so email addresses can be changed without stale caches.
 const (
-	cacheKeyUserByEmail = "user-by-email"
+	cacheKeyUserByID = "user-by-id"
 )
 
 type accountCachedDB struct {
@@ -28,12 +30,16 @@ func newAccountCacheDB(cacher cache.Cacher, mp *metric.MetricsProvider, delegate
 	}
 }
 
+func (ac *accountCachedDB) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return ac.delegate.RunInTx(ctx, f)
+}
+
 func (ac *accountCachedDB) Save(ctx context.Context, account *model.Account) error {
 	if err := ac.delegate.Save(ctx, account); err != nil {
 		return err
 	}
-	key := ac.userByEmailCacheKey(account.Email)
-	ac.cacher.Set(ctx, key, account)
+	key := ac.userByIDCacheKey(account.ID)
+	ac.cacher.Set(ctx, key, withoutSecrets(account))
 	return nil
 }
 
@@ -41,42 +47,141 @@ func (ac *accountCachedDB) Update(ctx context.Context, email string, account *mo
 	if err := ac.delegate.Update(ctx, email, account); err != nil {
 		return err
 	}
-	key := ac.userByEmailCacheKey(email)
+	find, err := ac.delegate.FindByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+	key := ac.userByIDCacheKey(find.ID)
 	if exists, _ := ac.cacher.Exists(ctx, key); exists {
-		find, err := ac.delegate.FindByEmail(ctx, email)
-		if err == nil {
-			ac.cacher.Set(ctx, key, find)
-		}
+		ac.cacher.Set(ctx, key, withoutSecrets(find))
 	}
 	return nil
 }
 
+// FindByEmail is not cached since email addresses are used to sign in and change on requests.
 func (ac *accountCachedDB) FindByEmail(ctx context.Context, email string) (*model.Account, error) {
+	return ac.delegate.FindByEmail(ctx, email)
+}
+
+func (ac *accountCachedDB) FindByUsername(ctx context.Context, username string) (*model.Account, error) {
+	return ac.delegate.FindByUsername(ctx, username)
+}
+
+func (ac *accountCachedDB) FindByUsernames(ctx context.Context, usernames []string) ([]*model.Account, error) {
+	return ac.delegate.FindByUsernames(ctx, usernames)
+}
+
+func (ac *accountCachedDB) UpdateEmail(ctx context.Context, id uint, email string) error {
+	if err := ac.delegate.UpdateEmail(ctx, id, email); err != nil {
+		return err
+	}
+	ac.cacher.Delete(ctx, ac.userByIDCacheKey(id))
+	return nil
+}
+
+func (ac *accountCachedDB) UpdateMFA(ctx context.Context, email string, secret string, enabled bool) error {
+	if err := ac.delegate.UpdateMFA(ctx, email, secret, enabled); err != nil {
+		return err
+	}
+	if find, err := ac.delegate.FindByEmail(ctx, email); err == nil {
+		ac.cacher.Delete(ctx, ac.userByIDCacheKey(find.ID))
+	}
+	return nil
+}
+
+func (ac *accountCachedDB) ReplaceRecoveryCodes(ctx context.Context, accountID uint, codeHashes []string) error {
+	return ac.delegate.ReplaceRecoveryCodes(ctx, accountID, codeHashes)
+}
+
+func (ac *accountCachedDB) UseMFAStep(ctx context.Context, accountID uint, step int64) error {
+	return ac.delegate.UseMFAStep(ctx, accountID, step)
+}
+
+func (ac *accountCachedDB) UseRecoveryCode(ctx context.Context, accountID uint, codeHash string) error {
+	return ac.delegate.UseRecoveryCode(ctx, accountID, codeHash)
+}
+
+func (ac *accountCachedDB) SaveIdentity(ctx context.Context, identity *model.AccountIdentity) error {
+	return ac.delegate.SaveIdentity(ctx, identity)
+}
+
+func (ac *accountCachedDB) FindByIdentity(ctx context.Context, provider, subject string) (*model.Account, error) {
+	return ac.delegate.FindByIdentity(ctx, provider, subject)
+}
+
+func (ac *accountCachedDB) FindByID(ctx context.Context, id uint) (*model.Account, error) {
 	if cache.IsCacheSkip(ctx) {
-		return ac.delegate.FindByEmail(ctx, email)
+		return ac.delegate.FindByID(ctx, id)
 	}
 
 	var (
 		item     model.Account
-		key      = ac.userByEmailCacheKey(email)
+		key      = ac.userByIDCacheKey(id)
 		cacheHit = true
 	)
 	err := ac.cacher.Fetch(ctx, key, &item, func() (interface{}, error) {
 		cacheHit = false
-		account, err := ac.delegate.FindByEmail(ctx, email)
+		account, err := ac.delegate.FindByID(ctx, id)
 		if err != nil {
 			return nil, err
 		}
-		account.Password = ""
-		return account, nil
+		return withoutSecrets(account), nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	ac.mp.RecordCache(cacheKeyUserByEmail, cacheHit)
+	ac.mp.RecordCache(cacheKeyUserByID, cacheHit)
 	return &item, nil
 }
 
-func (ac *accountCachedDB) userByEmailCacheKey(email string) string {
-	return fmt.Sprintf("%s.%s", cacheKeyUserByEmail, email)
+func (ac *accountCachedDB) SaveAPIKey(ctx context.Context, key *model.APIKey) error {
+	return ac.delegate.SaveAPIKey(ctx, key)
+}
+
+func (ac *accountCachedDB) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	return ac.delegate.UpdateAPIKey(ctx, key)
+}
+
+func (ac *accountCachedDB) DeleteAPIKey(ctx context.Context, accountID, id uint) error {
+	return ac.delegate.DeleteAPIKey(ctx, accountID, id)
+}
+
+func (ac *accountCachedDB) FindAPIKey(ctx context.Context, accountID, id uint) (*model.APIKey, error) {
+	return ac.delegate.FindAPIKey(ctx, accountID, id)
+}
+
+func (ac *accountCachedDB) FindAPIKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error) {
+	return ac.delegate.FindAPIKeys(ctx, accountID)
+}
+
+func (ac *accountCachedDB) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	return ac.delegate.FindAPIKeyByHash(ctx, keyHash)
+}
+
+func (ac *accountCachedDB) TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error {
+	return ac.delegate.TouchAPIKey(ctx, id, usedAt)
+}
+
+func (ac *accountCachedDB) userByIDCacheKey(id uint) string {
+	return fmt.Sprintf("%s.%d", cacheKeyUserByID, id)
+}
+
+// withoutSecrets returns a copy of given account without the password and the mfa secret to cache.
+func withoutSecrets(account *model.Account) *model.Account {
+	acc := *account
+	acc.Password = ""
+	acc.MFASecret = ""
+	return &acc
+}
+
+func (ac *accountCachedDB) FindIdentities(ctx context.Context, accountID uint) ([]*model.AccountIdentity, error) {
+	return ac.delegate.FindIdentities(ctx, accountID)
+}
+
+func (ac *accountCachedDB) Anonymize(ctx context.Context, id uint) error {
+	if err := ac.delegate.Anonymize(ctx, id); err != nil {
+		return err
+	}
+	ac.cacher.Delete(ctx, ac.userByIDCacheKey(id))
+	return nil
 }
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"gin-rest-api-example/internal/account/model"
 	"gin-rest-api-example/internal/database"
 	"gin-rest-api-example/pkg/logging"
@@ -27,6 +28,9 @@ func (s *DBSuite) SetupSuite() {
 }
 
 func (s *DBSuite) SetupTest() {
+	s.originDB.Where("id > 0").Delete(&model.RecoveryCode{})
+	s.originDB.Where("id > 0").Delete(&model.APIKey{})
+	s.originDB.Where("id > 0").Delete(&model.AccountIdentity{})
 	s.originDB.Where("id > 0").Delete(&model.Account{})
 }
 
@@ -83,6 +87,22 @@ func (s *DBSuite) TestSave_ErrorIfExistEmail() {
 	s.Equal(database.ErrKeyConflict, err)
 }
 
+func (s *DBSuite) TestSave_ErrorIfExistUsername() {
+	// given
+	s.NoError(s.db.Save(nil, &model.Account{Username: "user1", Email: "user1@email.com", Password: "pass"}))
+	other := model.Account{Username: "user2", Email: "user2@email.com", Password: "pass2"}
+	s.NoError(s.db.Save(nil, &other))
+
+	// when
+	err := s.db.Save(nil, &model.Account{Username: "user1", Email: "user3@email.com", Password: "pass3"})
+
+	// then
+	s.Equal(ErrUsernameConflict, err)
+	s.True(database.IsKeyConflictErr(err))
+	other.Username = "user1"
+	s.Equal(ErrUsernameConflict, s.db.Update(nil, other.Email, &other))
+}
+
 func (s *DBSuite) TestUpdate() {
 	// given
 	acc := model.Account{
@@ -115,6 +135,27 @@ func (s *DBSuite) TestUpdate() {
 	s.Equal(updated.Username, find.Username)
 	s.Equal(updated.Bio, find.Bio)
 	s.Equal(updated.Image, find.Image)
+	s.False(find.EmailVerified)
+}
+
+func (s *DBSuite) TestUpdate_EmailVerified() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+
+	// when
+	err := s.db.Update(nil, acc.Email, &model.Account{EmailVerified: true})
+
+	// then
+	s.NoError(err)
+	find, err := s.db.FindByEmail(nil, acc.Email)
+	s.NoError(err)
+	s.True(find.EmailVerified)
+	s.Equal(acc.Username, find.Username)
 }
 
 func (s *DBSuite) TestUpdate_FailIfNotExist() {
@@ -153,6 +194,41 @@ func (s *DBSuite) TestFindByEmail() {
 	s.False(find.Disabled)
 }
 
+func (s *DBSuite) TestFindByUsername() {
+	// given
+	acc := model.Account{Username: "user1", Email: "user1@gmail.com", Password: "pass1"}
+	s.NoError(s.db.Save(nil, &acc))
+
+	// when
+	find, err := s.db.FindByUsername(nil, acc.Username)
+
+	// then
+	s.NoError(err)
+	s.Equal(acc.ID, find.ID)
+	_, err = s.db.FindByUsername(nil, "user2")
+	s.Equal(database.ErrNotFound, err)
+}
+
+func (s *DBSuite) TestFindByUsernames() {
+	// given
+	acc1 := model.Account{Username: "user1", Email: "user1@gmail.com", Password: "pass1"}
+	s.NoError(s.db.Save(nil, &acc1))
+	acc2 := model.Account{Username: "user2", Email: "user2@gmail.com", Password: "pass2"}
+	s.NoError(s.db.Save(nil, &acc2))
+
+	// when
+	find, err := s.db.FindByUsernames(nil, []string{"user1", "user2", "user3"})
+
+	// then
+	s.NoError(err)
+	s.Len(find, 2)
+	var usernames []string
+	for _, acc := range find {
+		usernames = append(usernames, acc.Username)
+	}
+	s.ElementsMatch([]string{"user1", "user2"}, usernames)
+}
+
 func (s *DBSuite) TestFindByEmail_ErrorIfNotExist() {
 	// when
 	find, err := s.db.FindByEmail(nil, "unknown@email.com")
@@ -162,3 +238,220 @@ func (s *DBSuite) TestFindByEmail_ErrorIfNotExist() {
 	s.Error(err)
 	s.Equal(database.ErrNotFound, err)
 }
+
+func (s *DBSuite) TestUpdateMFA() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+
+	// when
+	err := s.db.UpdateMFA(nil, acc.Email, "JBSWY3DPEHPK3PXP", true)
+
+	// then
+	s.NoError(err)
+	find, err := s.db.FindByEmail(nil, acc.Email)
+	s.NoError(err)
+	s.Equal("JBSWY3DPEHPK3PXP", find.MFASecret)
+	s.True(find.MFAEnabled)
+	s.Equal(model.RoleUser, find.Role)
+
+	// when then: clear
+	s.NoError(s.db.UpdateMFA(nil, acc.Email, "", false))
+	find, err = s.db.FindByEmail(nil, acc.Email)
+	s.NoError(err)
+	s.Empty(find.MFASecret)
+	s.False(find.MFAEnabled)
+}
+
+func (s *DBSuite) TestRecoveryCodes() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+	s.NoError(s.db.ReplaceRecoveryCodes(nil, acc.ID, []string{"hash1", "hash2"}))
+
+	// when
+	err := s.db.UseRecoveryCode(nil, acc.ID, "hash1")
+
+	// then
+	s.NoError(err)
+	s.Equal(database.ErrNotFound, s.db.UseRecoveryCode(nil, acc.ID, "hash1"))
+	s.Equal(database.ErrNotFound, s.db.UseRecoveryCode(nil, acc.ID, "unknown"))
+
+	// when then: replaced codes can not be used
+	s.NoError(s.db.ReplaceRecoveryCodes(nil, acc.ID, []string{"hash3"}))
+	s.Equal(database.ErrNotFound, s.db.UseRecoveryCode(nil, acc.ID, "hash2"))
+	s.NoError(s.db.UseRecoveryCode(nil, acc.ID, "hash3"))
+}
+
+func (s *DBSuite) TestUseMFAStep() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+
+	// when
+	err := s.db.UseMFAStep(nil, acc.ID, 100)
+
+	// then
+	s.NoError(err)
+	s.Equal(database.ErrNotFound, s.db.UseMFAStep(nil, acc.ID, 100))
+	s.Equal(database.ErrNotFound, s.db.UseMFAStep(nil, acc.ID, 99))
+	s.NoError(s.db.UseMFAStep(nil, acc.ID, 101))
+}
+
+func (s *DBSuite) TestIdentity() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+	identity := model.AccountIdentity{AccountID: acc.ID, Provider: "github", Subject: "1234", Email: acc.Email}
+
+	// when
+	err := s.db.SaveIdentity(nil, &identity)
+
+	// then
+	s.NoError(err)
+	find, err := s.db.FindByIdentity(nil, "github", "1234")
+	s.NoError(err)
+	s.Equal(acc.ID, find.ID)
+	s.Equal(acc.Email, find.Email)
+	_, err = s.db.FindByIdentity(nil, "google", "1234")
+	s.Equal(database.ErrNotFound, err)
+	s.Equal(database.ErrKeyConflict, s.db.SaveIdentity(nil, &model.AccountIdentity{AccountID: acc.ID, Provider: "github", Subject: "1234"}))
+}
+
+func (s *DBSuite) TestRunInTx() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+
+	// when
+	err := s.db.RunInTx(context.Background(), func(ctx context.Context) error {
+		s.NoError(s.db.Save(ctx, &acc))
+		return s.db.SaveIdentity(ctx, &model.AccountIdentity{AccountID: acc.ID + 100, Provider: "github", Subject: "1234"})
+	})
+
+	// then
+	s.Error(err)
+	_, err = s.db.FindByEmail(nil, acc.Email)
+	s.Equal(database.ErrNotFound, err)
+}
+
+func (s *DBSuite) TestAPIKeys() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	key := model.APIKey{AccountID: acc.ID, Name: "ci", Prefix: "ak_00000001", KeyHash: "hash1", Scopes: "user:read", ExpiresAt: expiresAt}
+
+	// when
+	err := s.db.SaveAPIKey(nil, &key)
+
+	// then
+	s.NoError(err)
+	find, err := s.db.FindAPIKeyByHash(nil, "hash1")
+	s.NoError(err)
+	s.Equal(key.ID, find.ID)
+	s.Equal(acc.ID, find.AccountID)
+	s.Equal([]string{"user:read"}, find.ScopeList())
+	s.True(expiresAt.Equal(find.ExpiresAt))
+	s.Nil(find.LastUsedAt)
+
+	// when then: update and touch
+	key.Name = "updated"
+	key.Scopes = "user:read,article:write"
+	s.NoError(s.db.UpdateAPIKey(nil, &key))
+	s.NoError(s.db.TouchAPIKey(nil, key.ID, time.Now()))
+	find, err = s.db.FindAPIKey(nil, acc.ID, key.ID)
+	s.NoError(err)
+	s.Equal("updated", find.Name)
+	s.True(find.HasScope("article:write"))
+	s.NotNil(find.LastUsedAt)
+	keys, err := s.db.FindAPIKeys(nil, acc.ID)
+	s.NoError(err)
+	s.Len(keys, 1)
+
+	// when then: other accounts can not delete
+	s.Equal(database.ErrNotFound, s.db.DeleteAPIKey(nil, acc.ID+1, key.ID))
+	s.NoError(s.db.DeleteAPIKey(nil, acc.ID, key.ID))
+	_, err = s.db.FindAPIKeyByHash(nil, "hash1")
+	s.Equal(database.ErrNotFound, err)
+}
+
+func (s *DBSuite) TestAnonymize() {
+	// given
+	acc := model.Account{
+		Username: "user1",
+		Email:    "user@gmail.com",
+		Password: "pass1",
+		Bio:      "bio",
+	}
+	s.NoError(s.db.Save(nil, &acc))
+	s.NoError(s.db.SaveIdentity(nil, &model.AccountIdentity{AccountID: acc.ID, Provider: "github", Subject: "1234", Email: acc.Email}))
+	s.NoError(s.db.SaveAPIKey(nil, &model.APIKey{AccountID: acc.ID, Name: "ci", Prefix: "ak_00000001", KeyHash: "hash1", Scopes: "user:read", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	// when
+	err := s.db.Anonymize(nil, acc.ID)
+
+	// then
+	s.NoError(err)
+	_, err = s.db.FindByEmail(nil, acc.Email)
+	s.Equal(database.ErrNotFound, err)
+	find, err := s.db.FindByID(nil, acc.ID)
+	s.NoError(err)
+	s.NotEqual(acc.Username, find.Username)
+	s.Empty(find.Password)
+	s.Empty(find.Bio)
+	s.True(find.Disabled)
+	s.NotNil(find.DeletedAt)
+	identities, err := s.db.FindIdentities(nil, acc.ID)
+	s.NoError(err)
+	s.Empty(identities)
+	keys, err := s.db.FindAPIKeys(nil, acc.ID)
+	s.NoError(err)
+	s.Empty(keys)
+
+	// when then: deleted already
+	s.Equal(database.ErrNotFound, s.db.Anonymize(nil, acc.ID))
+}
+
+func (s *DBSuite) TestUpdateEmail() {
+	// given
+	acc := model.Account{Username: "user1", Email: "user1@gmail.com", Password: "pass1"}
+	s.NoError(s.db.Save(nil, &acc))
+	other := model.Account{Username: "user2", Email: "user2@gmail.com", Password: "pass2"}
+	s.NoError(s.db.Save(nil, &other))
+
+	// when
+	err := s.db.UpdateEmail(nil, acc.ID, "new-user1@gmail.com")
+
+	// then
+	s.NoError(err)
+	find, err := s.db.FindByID(nil, acc.ID)
+	s.NoError(err)
+	s.Equal("new-user1@gmail.com", find.Email)
+	s.True(find.EmailVerified)
+	s.Equal(database.ErrKeyConflict, s.db.UpdateEmail(nil, acc.ID, other.Email))
+	s.Equal(database.ErrNotFound, s.db.UpdateEmail(nil, other.ID+1, "none@gmail.com"))
+}
@@ -8,6 +8,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	model "gin-rest-api-example/internal/account/model"
+
+	time "time"
 )
 
 // AccountDB is an autogenerated mock type for the AccountDB type
@@ -15,6 +17,103 @@ type AccountDB struct {
 	mock.Mock
 }
 
+// Anonymize provides a mock function with given fields: ctx, id
+func (_m *AccountDB) Anonymize(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAPIKey provides a mock function with given fields: ctx, accountID, id
+func (_m *AccountDB) DeleteAPIKey(ctx context.Context, accountID uint, id uint) error {
+	ret := _m.Called(ctx, accountID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, accountID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindAPIKey provides a mock function with given fields: ctx, accountID, id
+func (_m *AccountDB) FindAPIKey(ctx context.Context, accountID uint, id uint) (*model.APIKey, error) {
+	ret := _m.Called(ctx, accountID, id)
+
+	var r0 *model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) *model.APIKey); ok {
+		r0 = rf(ctx, accountID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, accountID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAPIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *AccountDB) FindAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	var r0 *model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAPIKeys provides a mock function with given fields: ctx, accountID
+func (_m *AccountDB) FindAPIKeys(ctx context.Context, accountID uint) ([]*model.APIKey, error) {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 []*model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []*model.APIKey); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByEmail provides a mock function with given fields: ctx, email
 func (_m *AccountDB) FindByEmail(ctx context.Context, email string) (*model.Account, error) {
 	ret := _m.Called(ctx, email)
@@ -38,6 +137,149 @@ func (_m *AccountDB) FindByEmail(ctx context.Context, email string) (*model.Acco
 	return r0, r1
 }
 
+// FindByID provides a mock function with given fields: ctx, id
+func (_m *AccountDB) FindByID(ctx context.Context, id uint) (*model.Account, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *model.Account); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByIdentity provides a mock function with given fields: ctx, provider, subject
+func (_m *AccountDB) FindByIdentity(ctx context.Context, provider string, subject string) (*model.Account, error) {
+	ret := _m.Called(ctx, provider, subject)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Account); ok {
+		r0 = rf(ctx, provider, subject)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, provider, subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByUsername provides a mock function with given fields: ctx, username
+func (_m *AccountDB) FindByUsername(ctx context.Context, username string) (*model.Account, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Account); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByUsernames provides a mock function with given fields: ctx, usernames
+func (_m *AccountDB) FindByUsernames(ctx context.Context, usernames []string) ([]*model.Account, error) {
+	ret := _m.Called(ctx, usernames)
+
+	var r0 []*model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*model.Account); ok {
+		r0 = rf(ctx, usernames)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, usernames)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindIdentities provides a mock function with given fields: ctx, accountID
+func (_m *AccountDB) FindIdentities(ctx context.Context, accountID uint) ([]*model.AccountIdentity, error) {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 []*model.AccountIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []*model.AccountIdentity); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.AccountIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceRecoveryCodes provides a mock function with given fields: ctx, accountID, codeHashes
+func (_m *AccountDB) ReplaceRecoveryCodes(ctx context.Context, accountID uint, codeHashes []string) error {
+	ret := _m.Called(ctx, accountID, codeHashes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []string) error); ok {
+		r0 = rf(ctx, accountID, codeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunInTx provides a mock function with given fields: ctx, f
+func (_m *AccountDB) RunInTx(ctx context.Context, f func(context.Context) error) error {
+	ret := _m.Called(ctx, f)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, f)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: ctx, account
 func (_m *AccountDB) Save(ctx context.Context, account *model.Account) error {
 	ret := _m.Called(ctx, account)
@@ -52,6 +294,48 @@ func (_m *AccountDB) Save(ctx context.Context, account *model.Account) error {
 	return r0
 }
 
+// SaveAPIKey provides a mock function with given fields: ctx, key
+func (_m *AccountDB) SaveAPIKey(ctx context.Context, key *model.APIKey) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.APIKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveIdentity provides a mock function with given fields: ctx, identity
+func (_m *AccountDB) SaveIdentity(ctx context.Context, identity *model.AccountIdentity) error {
+	ret := _m.Called(ctx, identity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.AccountIdentity) error); ok {
+		r0 = rf(ctx, identity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchAPIKey provides a mock function with given fields: ctx, id, usedAt
+func (_m *AccountDB) TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error {
+	ret := _m.Called(ctx, id, usedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Time) error); ok {
+		r0 = rf(ctx, id, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, email, account
 func (_m *AccountDB) Update(ctx context.Context, email string, account *model.Account) error {
 	ret := _m.Called(ctx, email, account)
@@ -66,6 +350,76 @@ func (_m *AccountDB) Update(ctx context.Context, email string, account *model.Ac
 	return r0
 }
 
+// UpdateAPIKey provides a mock function with given fields: ctx, key
+func (_m *AccountDB) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.APIKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateEmail provides a mock function with given fields: ctx, id, email
+func (_m *AccountDB) UpdateEmail(ctx context.Context, id uint, email string) error {
+	ret := _m.Called(ctx, id, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, id, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateMFA provides a mock function with given fields: ctx, email, secret, enabled
+func (_m *AccountDB) UpdateMFA(ctx context.Context, email string, secret string, enabled bool) error {
+	ret := _m.Called(ctx, email, secret, enabled)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) error); ok {
+		r0 = rf(ctx, email, secret, enabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseMFAStep provides a mock function with given fields: ctx, accountID, step
+func (_m *AccountDB) UseMFAStep(ctx context.Context, accountID uint, step int64) error {
+	ret := _m.Called(ctx, accountID, step)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, int64) error); ok {
+		r0 = rf(ctx, accountID, step)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, accountID, codeHash
+func (_m *AccountDB) UseRecoveryCode(ctx context.Context, accountID uint, codeHash string) error {
+	ret := _m.Called(ctx, accountID, codeHash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, accountID, codeHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewAccountDB interface {
 	mock.TestingT
 	Cleanup(func())
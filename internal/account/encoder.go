@@ -1,9 +1,26 @@
 package account
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gin-rest-api-example/internal/config"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Password hash algorithms
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
 // EncodePassword encode a given password with bcrypt and default cost
 func EncodePassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -13,7 +30,119 @@ func EncodePassword(password string) (string, error) {
 	return string(bytes), nil
 }
 
-// MatchesPassword returns a nil if matched hashedPassword and raw password, otherwise returns a error
+// MatchesPassword returns a nil if matched hashedPassword and raw password, otherwise returns a error.
+// hashedPassword is a bcrypt hash or an argon2id hash in the PHC string format.
 func MatchesPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, "$"+AlgorithmArgon2id+"$") {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+	params, salt, hash, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(hash)))
+	if subtle.ConstantTimeCompare(hash, actual) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// PasswordEncoder encodes passwords with the algorithm of "account.password" configs.
+type PasswordEncoder struct {
+	algorithm  string
+	bcryptCost int
+	argon2     argon2Params
+	saltLength int
+}
+
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	keyLen  uint32
+}
+
+func NewPasswordEncoder(cfg *config.Config) (*PasswordEncoder, error) {
+	conf := cfg.AccountConfig.Password
+	switch conf.Algorithm {
+	case AlgorithmBcrypt:
+		if conf.BcryptCost < bcrypt.MinCost || conf.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost: %d", conf.BcryptCost)
+		}
+	case AlgorithmArgon2id:
+		if conf.Argon2.Time < 1 || conf.Argon2.Threads < 1 || conf.Argon2.KeyLength < 16 || conf.Argon2.SaltLength < 8 {
+			return nil, errors.New("invalid argon2 params")
+		}
+	default:
+		return nil, fmt.Errorf("unknown password algorithm: %s", conf.Algorithm)
+	}
+	return &PasswordEncoder{
+		algorithm:  conf.Algorithm,
+		bcryptCost: conf.BcryptCost,
+		argon2: argon2Params{
+			time:    uint32(conf.Argon2.Time),
+			memory:  uint32(conf.Argon2.Memory),
+			threads: uint8(conf.Argon2.Threads),
+			keyLen:  uint32(conf.Argon2.KeyLength),
+		},
+		saltLength: conf.Argon2.SaltLength,
+	}, nil
+}
+
+// Encode returns a hash of given password.
+func (e *PasswordEncoder) Encode(password string) (string, error) {
+	if e.algorithm == AlgorithmBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), e.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	}
+	salt := make([]byte, e.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := e.argon2
+	hash := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgorithmArgon2id, argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// NeedsRehash returns a true if given hash is encoded by an outdated algorithm or params.
+func (e *PasswordEncoder) NeedsRehash(hashedPassword string) bool {
+	if e.algorithm == AlgorithmBcrypt {
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return err != nil || cost != e.bcryptCost
+	}
+	params, _, hash, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+	params.keyLen = uint32(len(hash))
+	return params != e.argon2
+}
+
+// decodeArgon2id decodes a hash like "$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>".
+func decodeArgon2id(hashedPassword string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	return params, salt, hash, nil
 }
@@ -1,8 +1,10 @@
 package account
 
 import (
+	"gin-rest-api-example/internal/config"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"testing"
 )
 
@@ -31,3 +33,64 @@ func TestMatchesPassword(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, bcrypt.ErrMismatchedHashAndPassword, err)
 }
+
+func TestPasswordEncoder(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.AccountConfig.Password.Algorithm = AlgorithmArgon2id
+	cfg.AccountConfig.Password.Argon2.Memory = 1024
+	encoder, err := NewPasswordEncoder(cfg)
+	assert.NoError(t, err)
+	password := "password1234"
+
+	// when
+	encoded, err := encoder.Encode(password)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=4$"))
+	assert.NoError(t, MatchesPassword(encoded, password))
+	assert.Equal(t, bcrypt.ErrMismatchedHashAndPassword, MatchesPassword(encoded, password+"append"))
+	assert.Equal(t, ErrInvalidPasswordHash, MatchesPassword("$argon2id$v=19$invalid", password))
+	assert.False(t, encoder.NeedsRehash(encoded))
+}
+
+func TestPasswordEncoder_NeedsRehash(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	bcryptEncoder, err := NewPasswordEncoder(cfg)
+	assert.NoError(t, err)
+	cfg.AccountConfig.Password.Algorithm = AlgorithmArgon2id
+	cfg.AccountConfig.Password.Argon2.Memory = 1024
+	argon2Encoder, err := NewPasswordEncoder(cfg)
+	assert.NoError(t, err)
+	cfg.AccountConfig.Password.Argon2.Time = 2
+	upgradedArgon2Encoder, err := NewPasswordEncoder(cfg)
+	assert.NoError(t, err)
+
+	bcryptHash, _ := EncodePassword("password1234")
+	lowCostHash, _ := bcrypt.GenerateFromPassword([]byte("password1234"), bcrypt.MinCost)
+	argon2Hash, _ := argon2Encoder.Encode("password1234")
+
+	// when then
+	assert.False(t, bcryptEncoder.NeedsRehash(bcryptHash))
+	assert.True(t, bcryptEncoder.NeedsRehash(string(lowCostHash)))
+	assert.True(t, bcryptEncoder.NeedsRehash(argon2Hash))
+	assert.True(t, argon2Encoder.NeedsRehash(bcryptHash))
+	assert.False(t, argon2Encoder.NeedsRehash(argon2Hash))
+	assert.True(t, upgradedArgon2Encoder.NeedsRehash(argon2Hash))
+}
+
+func TestNewPasswordEncoder_FailIfInvalidConfig(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+
+	cfg.AccountConfig.Password.Algorithm = "md5"
+	_, err = NewPasswordEncoder(cfg)
+	assert.Error(t, err)
+
+	cfg.AccountConfig.Password.Algorithm = AlgorithmBcrypt
+	cfg.AccountConfig.Password.BcryptCost = 100
+	_, err = NewPasswordEncoder(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package account
+
+import (
+	"context"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware"
+	accountv1 "gin-rest-api-example/pkg/pb/account/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type grpcIdentityKey struct{}
+
+// grpcIdentity is the account and the api key of a gRPC request authenticated by UnaryServerInterceptor.
+type grpcIdentity struct {
+	account *model.Account
+	apiKey  *model.APIKey
+}
+
+// UnaryServerInterceptor returns the gRPC equivalent of MiddlewareFunc authenticating requests with "authorization"
+// metadata of a token or an api key, and stores the account to the context.
+// Requests without the metadata are passed as anonymous, so services require the account by RequireUser.
+func (m *AuthMiddleware) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+		authHeader := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) != 0 {
+				authHeader = values[0]
+			}
+		}
+		if authHeader == "" {
+			return h(ctx, req)
+		}
+
+		identity := grpcIdentity{}
+		if key, ok := apiKeyFromAuthorization(authHeader); ok {
+			acc, apiKey, err := m.apiKeyIdentity(ctx, key)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			identity = grpcIdentity{account: acc, apiKey: apiKey}
+		} else {
+			claims, err := m.claimsFromAuthorization(authHeader)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			if _, ok := claims["exp"].(float64); !ok {
+				return nil, status.Error(codes.Unauthenticated, ErrMissingExpField.Error())
+			}
+			acc := m.identity(ctx, claims)
+			if acc == nil {
+				return nil, status.Error(codes.PermissionDenied, ErrForbidden.Error())
+			}
+			identity = grpcIdentity{account: acc}
+		}
+		if !identity.account.MFAEnabled && m.requiresMFA(identity.account.Role) {
+			return nil, status.Error(codes.PermissionDenied, ErrMFAEnrollment.Error())
+		}
+		return h(context.WithValue(ctx, grpcIdentityKey{}, &identity), req)
+	}
+}
+
+// RequireUser returns the account of the gRPC request authenticated by UnaryServerInterceptor, or the status error
+// if not authenticated, the api key has no given scope or given action is restricted to unverified accounts.
+// The action is not restricted if empty.
+func RequireUser(ctx context.Context, cfg *config.Config, scope, action string) (*model.Account, error) {
+	identity, ok := ctx.Value(grpcIdentityKey{}).(*grpcIdentity)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, ErrEmptyAuthHeader.Error())
+	}
+	if identity.apiKey != nil && !identity.apiKey.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "api key requires "+scope+" scope")
+	}
+	if action != "" && isRestricted(cfg, action) && !identity.account.EmailVerified {
+		return nil, status.Error(codes.PermissionDenied, ErrUnverifiedAccount.Error())
+	}
+	return identity.account, nil
+}
+
+// GRPCServer serves accountv1.AccountService.
+type GRPCServer struct {
+	accountv1.UnimplementedAccountServiceServer
+	cfg *config.Config
+	h   *Handler
+}
+
+func NewGRPCServer(cfg *config.Config, h *Handler) *GRPCServer {
+	return &GRPCServer{cfg: cfg, h: h}
+}
+
+// Register registers the service to given server.
+func (s *GRPCServer) Register(server *grpc.Server) {
+	accountv1.RegisterAccountServiceServer(server, s)
+}
+
+// GetCurrentUser is the gRPC equivalent of GET /v1/api/user/me
+func (s *GRPCServer) GetCurrentUser(ctx context.Context, _ *emptypb.Empty) (*accountv1.User, error) {
+	currentUser, err := RequireUser(ctx, s.cfg, ScopeUserRead, "")
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.h.accountDB.FindByID(ctx, currentUser.ID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, status.Error(codes.NotFound, "not found current user")
+		}
+		return nil, middleware.InternalStatusError(ctx, "account.grpc.GetCurrentUser", err)
+	}
+	if acc.Disabled {
+		return nil, status.Error(codes.NotFound, "not found current user")
+	}
+	return &accountv1.User{
+		Username: acc.Username,
+		Email:    acc.Email,
+		Bio:      acc.Bio,
+		Image:    acc.Image,
+	}, nil
+}
+
+// GetProfile returns the public profile of an account by the username.
+func (s *GRPCServer) GetProfile(ctx context.Context, req *accountv1.GetProfileRequest) (*accountv1.Profile, error) {
+	if req.Username == "" {
+		return nil, status.Error(codes.InvalidArgument, "required username")
+	}
+	acc, err := s.h.accountDB.FindByUsername(ctx, req.Username)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, status.Error(codes.NotFound, "not found account")
+		}
+		return nil, middleware.InternalStatusError(ctx, "account.grpc.GetProfile", err)
+	}
+	if acc.Disabled {
+		return nil, status.Error(codes.NotFound, "not found account")
+	}
+	return &accountv1.Profile{
+		Username: acc.Username,
+		Bio:      acc.Bio,
+		Image:    acc.Image,
+	}, nil
+}
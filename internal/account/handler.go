@@ -3,45 +3,47 @@ package account
 import (
 	accountDB "gin-rest-api-example/internal/account/database"
 	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/audit"
 	"gin-rest-api-example/internal/config"
 	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/mailer"
 	"gin-rest-api-example/internal/middleware"
 	"gin-rest-api-example/internal/middleware/handler"
 	"gin-rest-api-example/pkg/logging"
-	"gin-rest-api-example/pkg/validate"
+	"gin-rest-api-example/pkg/oauth"
+	"gin-rest-api-example/pkg/openapi"
 	"net/http"
+	"strconv"
 
-	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
 type Handler struct {
+	cfg       *config.Config
 	accountDB accountDB.AccountDB
+	auth      *AuthMiddleware
+	sender    mailer.Sender
+	providers oauth.Providers
+	policy    *PasswordPolicy
+	usernames *UsernamePolicy
 }
 
 // signUp handles POST /v1/api/users
 func (h *Handler) signUp(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
 		logger := logging.FromContext(c)
-		type RequestBody struct {
-			User struct {
-				Username string `json:"username" binding:"required"`
-				Email    string `json:"email" binding:"required,email"`
-				Password string `json:"password" binding:"required,min=5"`
-			} `json:"user"`
-		}
-		var body RequestBody
-		if err := c.ShouldBindJSON(&body); err != nil {
-			logger.Errorw("account.handler.signUp failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&body.User, "json", vErrs)
-			}
+		var body SignUpRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		if details := h.usernames.Check("username", body.User.Username); len(details) != 0 {
 			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid user request in body", details)
 		}
+		if res := h.checkPassword(c, body.User.Password, "invalid user request in body"); res != nil {
+			return res
+		}
 
-		password, err := EncodePassword(body.User.Password)
+		password, err := h.auth.passwords.Encode(body.User.Password)
 		if err != nil {
 			logger.Errorw("account.handler.signUp failed to encode password", "err", err)
 			return handler.NewInternalErrorResponse(err)
@@ -53,11 +55,17 @@ func (h *Handler) signUp(c *gin.Context) {
 		}
 		err = h.accountDB.Save(c.Request.Context(), &acc)
 		if err != nil {
+			if err == accountDB.ErrUsernameConflict {
+				return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "duplicate username", nil)
+			}
 			if database.IsKeyConflictErr(err) {
 				return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "duplicate email address", nil)
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
+		if err := h.sendVerificationMail(c.Request.Context(), &acc); err != nil {
+			logger.Errorw("account.handler.signUp failed to send a verification mail", "err", err)
+		}
 		return handler.NewSuccessResponse(http.StatusCreated, NewUserResponse(&acc))
 	})
 }
@@ -66,7 +74,7 @@ func (h *Handler) signUp(c *gin.Context) {
 func (h *Handler) currentUser(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
 		currentUser := MustCurrentUser(c)
-		find, err := h.accountDB.FindByEmail(c.Request.Context(), currentUser.Email)
+		find, err := h.accountDB.FindByID(c.Request.Context(), currentUser.ID)
 		if err != nil {
 			if database.IsRecordNotFoundErr(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found current user", nil)
@@ -85,25 +93,17 @@ func (h *Handler) update(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
 		logger := logging.FromContext(c)
 		currentUser := MustCurrentUser(c)
-		type RequestBody struct {
-			User struct {
-				Username string `json:"username" binding:"omitempty"`
-				Password string `json:"password" binding:"omitempty,min=5"`
-				Bio      string `json:"bio"`
-				Image    string `json:"image"`
-			} `json:"user"`
-		}
-		var body RequestBody
-		if err := c.ShouldBindJSON(&body); err != nil {
-			logger.Errorw("account.handler.update failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&body.User, "json", vErrs)
+		var body UpdateUserRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		if body.User.Password != "" {
+			if res := h.checkPassword(c, body.User.Password, "invalid user request in body"); res != nil {
+				return res
 			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid user request in body", details)
 		}
 
-		acc, err := h.accountDB.FindByEmail(c.Request.Context(), currentUser.Email)
+		acc, err := h.accountDB.FindByID(c.Request.Context(), currentUser.ID)
 		if err != nil {
 			if database.IsRecordNotFoundErr(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found account", nil)
@@ -111,15 +111,19 @@ func (h *Handler) update(c *gin.Context) {
 			return handler.NewInternalErrorResponse(err)
 		}
 
+		before := auditFields(acc)
 		if body.User.Password != "" {
-			password, err := EncodePassword(body.User.Password)
+			password, err := h.auth.passwords.Encode(body.User.Password)
 			if err != nil {
 				logger.Errorw("account.handler.update failed to encode password", "err", err)
 				return &handler.Response{Err: err}
 			}
 			acc.Password = password
 		}
-		if body.User.Username != "" {
+		if body.User.Username != "" && body.User.Username != acc.Username {
+			if details := h.usernames.Check("username", body.User.Username); len(details) != 0 {
+				return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid user request in body", details)
+			}
 			acc.Username = body.User.Username
 		}
 		if body.User.Bio != "" {
@@ -130,35 +134,105 @@ func (h *Handler) update(c *gin.Context) {
 		}
 		err = h.accountDB.Update(c.Request.Context(), currentUser.Email, acc)
 		if err != nil {
+			if err == accountDB.ErrUsernameConflict {
+				return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "duplicate username", nil)
+			}
 			if database.IsRecordNotFoundErr(err) {
 				logger.Errorw("account.handler.update failed to update user because not found user", "err", err)
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
+		changes := audit.Diff(before, auditFields(acc))
+		if body.User.Password != "" {
+			changes["password"] = audit.Change{Before: audit.Redacted, After: audit.Redacted}
+		}
+		h.auth.auditor.Record(c, audit.Event{
+			Action:     audit.ActionAccountUpdate,
+			ActorID:    currentUser.ID,
+			TargetType: audit.TargetAccount,
+			TargetID:   strconv.FormatUint(uint64(acc.ID), 10),
+			Changes:    changes,
+		})
 		return handler.NewSuccessResponse(http.StatusOK, NewUserResponse(acc))
 	})
 }
 
+// auditFields returns fields of given account recorded in audit events when changed.
+func auditFields(acc *model.Account) map[string]interface{} {
+	return map[string]interface{}{
+		"username": acc.Username,
+		"bio":      acc.Bio,
+		"image":    acc.Image,
+	}
+}
+
+// checkPassword returns a bad request response with given message if the password violates the password policy.
+func (h *Handler) checkPassword(c *gin.Context, password, message string) *handler.Response {
+	details, err := h.policy.Check("password", password)
+	if err != nil {
+		logging.FromContext(c).Errorw("account.handler.checkPassword failed to check password", "err", err)
+		return handler.NewInternalErrorResponse(err)
+	}
+	if len(details) != 0 {
+		return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, message, details)
+	}
+	return nil
+}
+
 // RouteV1 routes user api given config and gin.Engine
-func RouteV1(cfg *config.Config, h *Handler, r *gin.Engine, auth *jwt.GinJWTMiddleware) {
+func RouteV1(cfg *config.Config, h *Handler, r *gin.Engine, auth *AuthMiddleware, limiter *middleware.RateLimiter, spec *openapi.Spec) {
+	r.GET(".well-known/jwks.json", auth.JWKSHandler)
+
 	v1 := r.Group("v1/api")
 	v1.Use(middleware.RequestIDMiddleware(), middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
 	// anonymous
-	v1.Use()
+	anonymous := v1.Group("", middleware.ValidateRequest(spec))
+	{
+		anonymous.POST("users/login", middleware.RateLimit(limiter, "login"), auth.LoginHandler)
+		anonymous.POST("users/login/mfa", middleware.RateLimit(limiter, "login"), auth.MFALoginHandler)
+		anonymous.POST("users", middleware.RateLimit(limiter, "signUp"), h.signUp)
+		anonymous.POST("users/verify", h.verifyEmail)
+		anonymous.POST("users/password/forgot", middleware.RateLimit(limiter, "forgotPassword"), h.forgotPassword)
+		anonymous.POST("users/password/reset", h.resetPassword)
+		anonymous.POST("users/email/confirm", h.confirmEmailChange)
+		anonymous.GET("auth/:provider/login", h.oauthLogin)
+		anonymous.GET("auth/:provider/callback", h.oauthCallback)
+	}
+	// auth required even if mfa must be enabled
+	mfa := v1.Group("user/mfa", auth.MFAEnrollmentMiddlewareFunc(), RejectAPIKey(), middleware.ValidateRequest(spec))
+	{
+		mfa.POST("enroll", h.enrollMFA)
+		mfa.POST("verify", h.verifyMFA)
+		mfa.POST("disable", h.disableMFA)
+	}
+	// auth required. requests are validated after the authentication so that anonymous requests get 401.
+	v1.Use(auth.MiddlewareFunc(), middleware.ValidateRequest(spec))
 	{
-		v1.POST("users/login", auth.LoginHandler)
-		v1.POST("users", h.signUp)
+		v1.GET("user/me", RequireScope(ScopeUserRead), h.currentUser)
+		v1.PUT("user", RequireScope(ScopeUserWrite), RestrictUnverified(cfg, RestrictUpdateUser), h.update)
 	}
-	// auth required
-	v1.Use(auth.MiddlewareFunc())
+	// api keys are managed only with access tokens
+	apiKeys := v1.Group("user/api-keys", RejectAPIKey())
 	{
-		v1.GET("user/me", h.currentUser)
-		v1.PUT("user", h.update)
+		apiKeys.POST("", h.saveAPIKey)
+		apiKeys.GET("", h.apiKeys)
+		apiKeys.GET(":id", h.apiKey)
+		apiKeys.PUT(":id", h.updateAPIKey)
+		apiKeys.DELETE(":id", h.deleteAPIKey)
 	}
+	// email addresses are changed only with access tokens
+	v1.POST("user/email", RejectAPIKey(), h.changeEmail)
 }
 
-func NewHandler(accountDB accountDB.AccountDB) *Handler {
+func NewHandler(cfg *config.Config, accountDB accountDB.AccountDB, auth *AuthMiddleware, sender mailer.Sender, providers oauth.Providers,
+	policy *PasswordPolicy) *Handler {
 	return &Handler{
+		cfg:       cfg,
 		accountDB: accountDB,
+		auth:      auth,
+		sender:    sender,
+		providers: providers,
+		policy:    policy,
+		usernames: NewUsernamePolicy(cfg),
 	}
 }
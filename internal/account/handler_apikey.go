@@ -0,0 +1,172 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// saveAPIKey handles POST /v1/api/user/api-keys
+func (h *Handler) saveAPIKey(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		currentUser := MustCurrentUser(c)
+		var body SaveAPIKeyRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		if res := validateScopes(body.APIKey.Scopes); res != nil {
+			return res
+		}
+
+		conf := h.cfg.AccountConfig.APIKey
+		now := h.auth.timeFunc()
+		expiresAt := now.Add(conf.DefaultTTL)
+		if body.APIKey.ExpiresAt != nil {
+			expiresAt = *body.APIKey.ExpiresAt
+		}
+		if !expiresAt.After(now) || (conf.MaxTTL > 0 && expiresAt.After(now.Add(conf.MaxTTL))) {
+			details := validate.NewValidationErrorDetails("expiresAt", "expiresAt must be in the future within the max ttl "+conf.MaxTTL.String(), expiresAt)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid api key request in body", details)
+		}
+
+		rawKey, prefix, hash, err := generateAPIKey()
+		if err != nil {
+			logger.Errorw("account.handler.saveAPIKey failed to generate an api key", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		key := model.APIKey{
+			AccountID: currentUser.ID,
+			Name:      body.APIKey.Name,
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    strings.Join(body.APIKey.Scopes, ","),
+			ExpiresAt: expiresAt,
+		}
+		if err := h.accountDB.SaveAPIKey(c.Request.Context(), &key); err != nil {
+			logger.Errorw("account.handler.saveAPIKey failed to save", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		// the raw key is shown only once
+		return handler.NewSuccessResponse(http.StatusCreated, NewAPIKeyResponse(&key, rawKey))
+	})
+}
+
+// apiKeys handles GET /v1/api/user/api-keys
+func (h *Handler) apiKeys(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		currentUser := MustCurrentUser(c)
+		keys, err := h.accountDB.FindAPIKeys(c.Request.Context(), currentUser.ID)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, NewAPIKeysResponse(keys))
+	})
+}
+
+// apiKey handles GET /v1/api/user/api-keys/:id
+func (h *Handler) apiKey(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		currentUser := MustCurrentUser(c)
+		id, res := bindAPIKeyURI(c)
+		if res != nil {
+			return res
+		}
+		key, err := h.accountDB.FindAPIKey(c.Request.Context(), currentUser.ID, id)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found api key", nil)
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, NewAPIKeyResponse(key, ""))
+	})
+}
+
+// updateAPIKey handles PUT /v1/api/user/api-keys/:id
+func (h *Handler) updateAPIKey(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		currentUser := MustCurrentUser(c)
+		id, res := bindAPIKeyURI(c)
+		if res != nil {
+			return res
+		}
+		var body UpdateAPIKeyRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		if body.APIKey.Scopes != nil {
+			if res := validateScopes(body.APIKey.Scopes); res != nil {
+				return res
+			}
+		}
+
+		key, err := h.accountDB.FindAPIKey(c.Request.Context(), currentUser.ID, id)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found api key", nil)
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+		if body.APIKey.Name != "" {
+			key.Name = body.APIKey.Name
+		}
+		if body.APIKey.Scopes != nil {
+			key.Scopes = strings.Join(body.APIKey.Scopes, ",")
+		}
+		if err := h.accountDB.UpdateAPIKey(c.Request.Context(), key); err != nil {
+			logger.Errorw("account.handler.updateAPIKey failed to update", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, NewAPIKeyResponse(key, ""))
+	})
+}
+
+// deleteAPIKey handles DELETE /v1/api/user/api-keys/:id
+func (h *Handler) deleteAPIKey(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		currentUser := MustCurrentUser(c)
+		id, res := bindAPIKeyURI(c)
+		if res != nil {
+			return res
+		}
+		if err := h.accountDB.DeleteAPIKey(c.Request.Context(), currentUser.ID, id); err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found api key", nil)
+			}
+			logger.Errorw("account.handler.deleteAPIKey failed to delete", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+func bindAPIKeyURI(c *gin.Context) (uint, *handler.Response) {
+	var uri APIKeyURI
+	if res := handler.Bind(c, &uri, nil, nil); res != nil {
+		return 0, res
+	}
+	return uri.ID, nil
+}
+
+func validateScopes(scopes []string) *handler.Response {
+	if len(scopes) == 0 {
+		details := validate.NewValidationErrorDetails("scopes", "required scopes", scopes)
+		return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid api key request in body", details)
+	}
+	for _, scope := range scopes {
+		if !isAPIKeyScope(scope) {
+			details := validate.NewValidationErrorDetails("scopes", "unknown scope "+scope, scope)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid api key request in body", details)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/database"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func (s *HandlerSuite) TestAPIKey_Save() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+	var saved model.APIKey
+	s.db.On("SaveAPIKey", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		key := args.Get(1).(*model.APIKey)
+		key.ID = 1
+		saved = *key
+	}).Return(nil)
+
+	// when
+	res := s.doAuthRequest("POST", "/v1/api/user/api-keys", token, map[string]interface{}{
+		"apiKey": map[string]interface{}{
+			"name":   "ci",
+			"scopes": []string{ScopeUserRead, ScopeArticleWrite},
+		},
+	})
+
+	// then
+	s.Equal(http.StatusCreated, res.Code)
+	key := gjson.Get(res.Body.String(), "apiKey.key").String()
+	s.True(strings.HasPrefix(key, gjson.Get(res.Body.String(), "apiKey.prefix").String()+"_"))
+	s.Equal("ci", gjson.Get(res.Body.String(), "apiKey.name").String())
+	s.Equal(`["user:read","article:write"]`, gjson.Get(res.Body.String(), "apiKey.scopes").Raw)
+	s.Equal(acc.ID, saved.AccountID)
+	s.Equal(hashAPIKey(key), saved.KeyHash)
+	s.Equal("user:read,article:write", saved.Scopes)
+	s.WithinDuration(time.Now().Add(s.cfg.AccountConfig.APIKey.DefaultTTL), saved.ExpiresAt, time.Minute)
+}
+
+func (s *HandlerSuite) TestAPIKey_SaveBadRequest() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+	cases := []map[string]interface{}{
+		{"scopes": []string{ScopeUserRead}},
+		{"name": "ci", "scopes": []string{}},
+		{"name": "ci", "scopes": []string{"admin"}},
+		{"name": "ci", "scopes": []string{ScopeUserRead}, "expiresAt": time.Now().Add(-time.Hour)},
+		{"name": "ci", "scopes": []string{ScopeUserRead}, "expiresAt": time.Now().Add(s.cfg.AccountConfig.APIKey.MaxTTL + time.Hour)},
+	}
+
+	for _, tc := range cases {
+		// when
+		res := s.doAuthRequest("POST", "/v1/api/user/api-keys", token, map[string]interface{}{"apiKey": tc})
+
+		// then
+		s.Equal(http.StatusBadRequest, res.Code)
+		s.Equal("InvalidBodyValue", gjson.Get(res.Body.String(), "code").String())
+	}
+	s.db.AssertNotCalled(s.T(), "SaveAPIKey", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestAPIKey_ListAndDelete() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+	keys := []*model.APIKey{
+		{ID: 1, AccountID: acc.ID, Name: "ci", Prefix: "ak_00000001", Scopes: "user:read", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: 2, AccountID: acc.ID, Name: "bot", Prefix: "ak_00000002", Scopes: "article:write,comment:write", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	s.db.On("FindAPIKeys", mock.Anything, acc.ID).Return(keys, nil)
+	s.db.On("DeleteAPIKey", mock.Anything, acc.ID, uint(1)).Return(nil)
+	s.db.On("DeleteAPIKey", mock.Anything, acc.ID, uint(3)).Return(database.ErrNotFound)
+
+	// when
+	res := s.doAuthRequest("GET", "/v1/api/user/api-keys", token, nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.EqualValues(2, gjson.Get(res.Body.String(), "apiKeysCount").Int())
+	s.Equal("bot", gjson.Get(res.Body.String(), "apiKeys.1.name").String())
+	s.Equal(`["article:write","comment:write"]`, gjson.Get(res.Body.String(), "apiKeys.1.scopes").Raw)
+	s.False(gjson.Get(res.Body.String(), "apiKeys.0.key").Exists())
+
+	// when then
+	res = s.doAuthRequest("DELETE", "/v1/api/user/api-keys/1", token, nil)
+	s.Equal(http.StatusOK, res.Code)
+	res = s.doAuthRequest("DELETE", "/v1/api/user/api-keys/3", token, nil)
+	s.Equal(http.StatusNotFound, res.Code)
+}
+
+func (s *HandlerSuite) TestAPIKey_Authenticate() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Bio: "user1 bio"}
+	rawKey, prefix, hash, _ := generateAPIKey()
+	apiKey := model.APIKey{ID: 1, AccountID: acc.ID, Name: "ci", Prefix: prefix, KeyHash: hash, Scopes: ScopeUserRead, ExpiresAt: time.Now().Add(time.Hour)}
+	s.db.On("FindAPIKeyByHash", mock.Anything, hash).Return(&apiKey, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.db.On("TouchAPIKey", mock.Anything, apiKey.ID, mock.Anything).Return(nil)
+
+	// when
+	res := s.doRequestWithAuthorization("GET", "/v1/api/user/me", "ApiKey "+rawKey, nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("user1", gjson.Get(res.Body.String(), "user.username").String())
+	s.db.AssertCalled(s.T(), "TouchAPIKey", mock.Anything, apiKey.ID, mock.Anything)
+
+	// when then: missing scope
+	res = s.doRequestWithAuthorization("PUT", "/v1/api/user", "ApiKey "+rawKey, map[string]interface{}{
+		"user": map[string]interface{}{"bio": "updated-bio"},
+	})
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal("InsufficientScope", gjson.Get(res.Body.String(), "code").String())
+	s.db.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	// when then: api keys can not manage api keys
+	res = s.doRequestWithAuthorization("GET", "/v1/api/user/api-keys", "ApiKey "+rawKey, nil)
+	s.Equal(http.StatusForbidden, res.Code)
+	s.db.AssertNotCalled(s.T(), "FindAPIKeys", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestAPIKey_AuthenticateFail() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	expiredKey, _, expiredHash, _ := generateAPIKey()
+	unknownKey, _, unknownHash, _ := generateAPIKey()
+	s.db.On("FindAPIKeyByHash", mock.Anything, expiredHash).Return(&model.APIKey{ID: 1, AccountID: acc.ID, Scopes: ScopeUserRead, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+	s.db.On("FindAPIKeyByHash", mock.Anything, unknownHash).Return(nil, database.ErrNotFound)
+
+	for _, key := range []string{expiredKey, unknownKey} {
+		// when
+		res := s.doRequestWithAuthorization("GET", "/v1/api/user/me", "ApiKey "+key, nil)
+
+		// then
+		s.Equal(http.StatusUnauthorized, res.Code)
+	}
+	s.db.AssertNotCalled(s.T(), "FindByID", mock.Anything, mock.Anything)
+}
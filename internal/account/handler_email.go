@@ -0,0 +1,112 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// changeEmail handles POST /v1/api/user/email
+func (h *Handler) changeEmail(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		var body ChangeEmailRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		acc, res := h.findCurrentAccount(c)
+		if res != nil {
+			return res
+		}
+		if err := MatchesPassword(acc.Password, body.User.Password); err != nil {
+			details := validate.NewValidationErrorDetails("password", "password does not match", "")
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid email request in body", details)
+		}
+		if body.User.Email == acc.Email {
+			details := validate.NewValidationErrorDetails("email", "email is the current email address", body.User.Email)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid email request in body", details)
+		}
+		ctx := cache.WithCacheSkip(c.Request.Context(), true)
+		if _, err := h.accountDB.FindByEmail(ctx, body.User.Email); err == nil {
+			return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "duplicate email address", nil)
+		} else if !database.IsRecordNotFoundErr(err) {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		// the email address is changed after the owner of the new email address confirms it.
+		conf := h.cfg.AccountConfig.EmailChange
+		token, err := h.auth.actionToken(purposeChangeEmail, acc.Email, conf.TokenTTL, jwt.MapClaims{
+			"email": body.User.Email,
+			"aid":   acc.ID,
+		})
+		if err != nil {
+			logger.Errorw("account.handler.changeEmail failed to create a token", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if err := h.sender.Send(c.Request.Context(), newEmailChangeNoticeMail(acc, body.User.Email)); err != nil {
+			logger.Warnw("account.handler.changeEmail failed to send a notice to the current email address", "err", err)
+		}
+		if err := h.sender.Send(c.Request.Context(), newEmailChangeMail(acc, body.User.Email, conf.URL, token)); err != nil {
+			logger.Errorw("account.handler.changeEmail failed to send an email change mail", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusAccepted, nil)
+	})
+}
+
+// confirmEmailChange handles POST /v1/api/users/email/confirm
+func (h *Handler) confirmEmailChange(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		var body ConfirmEmailChangeRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+
+		claims, err := h.auth.parseActionToken(purposeChangeEmail, body.Token)
+		if err != nil {
+			logger.Errorw("account.handler.confirmEmailChange failed to parse a token", "err", err)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+		}
+		newEmail, _ := claims["email"].(string)
+		accountID, _ := claims["aid"].(float64)
+		// the token is used already if the email address has been changed after issuing the token.
+		ctx := cache.WithCacheSkip(c.Request.Context(), true)
+		acc, err := h.accountDB.FindByEmail(ctx, claims["sub"].(string))
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+		if newEmail == "" || acc.Disabled || acc.ID != uint(accountID) {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+		}
+
+		if err := h.accountDB.UpdateEmail(c.Request.Context(), acc.ID, newEmail); err != nil {
+			if err == database.ErrKeyConflict {
+				return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "duplicate email address", nil)
+			}
+			logger.Errorw("account.handler.confirmEmailChange failed to update", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		h.auth.auditor.Record(c, audit.Event{
+			Action:     audit.ActionEmailChange,
+			ActorID:    acc.ID,
+			TargetType: audit.TargetAccount,
+			TargetID:   strconv.FormatUint(uint64(acc.ID), 10),
+			Changes:    audit.Diff(map[string]interface{}{"email": acc.Email}, map[string]interface{}{"email": newEmail}),
+		})
+		acc.Email = newEmail
+		acc.EmailVerified = true
+		return handler.NewSuccessResponse(http.StatusOK, NewUserResponse(acc))
+	})
+}
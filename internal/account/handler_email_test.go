@@ -0,0 +1,182 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func (s *HandlerSuite) TestChangeEmail() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	newEmail := "new-user1@gmail.com"
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByEmail", mock.Anything, newEmail).Return(nil, database.ErrNotFound)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.db.On("UpdateEmail", mock.Anything, acc.ID, newEmail).Return(nil)
+	token := s.getBearerToken(&acc, password)
+
+	// when
+	res := s.doAuthRequest("POST", "/v1/api/user/email", token, changeEmailBody(newEmail, password))
+
+	// then
+	s.Equal(http.StatusAccepted, res.Code)
+	s.db.AssertNotCalled(s.T(), "UpdateEmail", mock.Anything, mock.Anything, mock.Anything)
+	s.Contains(s.mails.String(), "Your email address is being changed")
+	confirmToken := s.lastMailToken(newEmail, "Confirm your new email address")
+
+	// when then: the access token is still valid until confirmed
+	res = s.doAuthRequest("GET", "/v1/api/user/me", token, nil)
+	s.Equal(http.StatusOK, res.Code)
+
+	// when
+	res = s.doRequest("POST", "/v1/api/users/email/confirm", map[string]interface{}{"token": confirmToken})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal(newEmail, gjson.Get(res.Body.String(), "user.email").String())
+	s.db.AssertCalled(s.T(), "UpdateEmail", mock.Anything, acc.ID, newEmail)
+}
+
+func (s *HandlerSuite) TestChangeEmail_BadRequest() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByEmail", mock.Anything, "user2@gmail.com").Return(&model.Account{ID: 2, Email: "user2@gmail.com"}, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	token := s.getBearerToken(&acc, password)
+
+	cases := []struct {
+		name string
+		body map[string]interface{}
+		code int
+	}{
+		{name: "invalid email", body: changeEmailBody("invalid", password), code: http.StatusBadRequest},
+		{name: "wrong password", body: changeEmailBody("new-user1@gmail.com", password+"wrong"), code: http.StatusBadRequest},
+		{name: "current email", body: changeEmailBody(acc.Email, password), code: http.StatusBadRequest},
+		{name: "duplicate email", body: changeEmailBody("user2@gmail.com", password), code: http.StatusConflict},
+	}
+	for _, tc := range cases {
+		// when
+		res := s.doAuthRequest("POST", "/v1/api/user/email", token, tc.body)
+
+		// then
+		s.Equal(tc.code, res.Code, tc.name)
+	}
+	s.Empty(s.mails.String())
+}
+
+func (s *HandlerSuite) TestConfirmEmailChange_InvalidToken() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	otherAccountToken, err := s.handler.auth.actionToken(purposeChangeEmail, acc.Email, time.Hour, jwt.MapClaims{
+		"email": "new-user1@gmail.com",
+		"aid":   acc.ID + 1,
+	})
+	s.NoError(err)
+	verifyToken, err := s.handler.auth.actionToken(purposeVerifyEmail, acc.Email, time.Hour, nil)
+	s.NoError(err)
+
+	for _, token := range []string{"invalid", otherAccountToken, verifyToken} {
+		// when
+		res := s.doRequest("POST", "/v1/api/users/email/confirm", map[string]interface{}{"token": token})
+
+		// then
+		s.Equal(http.StatusBadRequest, res.Code)
+		s.Equal("InvalidToken", gjson.Get(res.Body.String(), "code").String())
+	}
+	s.db.AssertNotCalled(s.T(), "UpdateEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestIdentity_EmailIdentityTokens() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	cutover := time.Now().Truncate(time.Second)
+	cfg, err := config.Load("")
+	s.NoError(err)
+	cfg.JwtConfig.AcceptEmailIdentity = true
+	cfg.JwtConfig.EmailIdentityCutover = cutover.Format(time.RFC3339)
+	s.setup(cfg)
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	legacyToken := func(origIat time.Time) string {
+		token, err := s.handler.auth.keys.Sign(jwt.MapClaims{
+			identityKey: acc.Email,
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"orig_iat":  origIat.Unix(),
+		})
+		s.NoError(err)
+		return token
+	}
+
+	// when then: accepted if the session started before the cutover
+	res := s.doAuthRequest("GET", "/v1/api/user/me", legacyToken(cutover.Add(-time.Minute)), nil)
+	s.Equal(http.StatusOK, res.Code)
+
+	// when then: rejected if the session started after the cutover
+	res = s.doAuthRequest("GET", "/v1/api/user/me", legacyToken(cutover.Add(time.Minute)), nil)
+	s.Equal(http.StatusForbidden, res.Code)
+
+	// when then: rejected after the session time since the cutover
+	s.handler.auth.timeFunc = func() time.Time { return cutover.Add(cfg.JwtConfig.SessionTime) }
+	res = s.doAuthRequest("GET", "/v1/api/user/me", legacyToken(cutover.Add(-time.Minute)), nil)
+	s.Equal(http.StatusForbidden, res.Code)
+
+	// when then: rejected by default
+	cfg, err = config.Load("")
+	s.NoError(err)
+	s.setup(cfg)
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	res = s.doAuthRequest("GET", "/v1/api/user/me", legacyToken(cutover.Add(-time.Minute)), nil)
+	s.Equal(http.StatusForbidden, res.Code)
+	s.db.AssertNotCalled(s.T(), "FindByEmail", mock.Anything, acc.Email)
+}
+
+func (s *HandlerSuite) TestNewAuthMiddleware_InvalidEmailIdentityCutover() {
+	cfg, err := config.Load("")
+	s.NoError(err)
+	cfg.JwtConfig.AcceptEmailIdentity = true
+
+	for _, cutover := range []string{"", "2021-01-01"} {
+		// when
+		cfg.JwtConfig.EmailIdentityCutover = cutover
+		_, err := NewAuthMiddleware(cfg, s.db, nil, s.mp, nil)
+
+		// then
+		s.Error(err, cutover)
+	}
+}
+
+func (s *HandlerSuite) TestIdentity_DisabledAccount() {
+	// given
+	acc := model.Account{ID: 1, Username: "deleted-user-1", Email: "deleted-user-1@deleted.invalid", Disabled: true}
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	token, _, err := s.handler.auth.TokenGenerator(&acc)
+	s.NoError(err)
+
+	// when
+	res := s.doAuthRequest("GET", "/v1/api/user/me", token, nil)
+
+	// then
+	s.Equal(http.StatusForbidden, res.Code)
+}
+
+func changeEmailBody(email, password string) map[string]interface{} {
+	return map[string]interface{}{
+		"user": map[string]interface{}{
+			"email":    email,
+			"password": password,
+		},
+	}
+}
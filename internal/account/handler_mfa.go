@@ -0,0 +1,154 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/totp"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// enrollMFA handles POST /v1/api/user/mfa/enroll
+func (h *Handler) enrollMFA(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		acc, res := h.findCurrentAccount(c)
+		if res != nil {
+			return res
+		}
+		if acc.MFAEnabled {
+			return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "two-factor authentication is already enabled", nil)
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			logger.Errorw("account.handler.enrollMFA failed to generate a secret", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		// the secret is pending until a code is verified by verifyMFA
+		if err := h.accountDB.UpdateMFA(c.Request.Context(), acc.Email, secret, false); err != nil {
+			logger.Errorw("account.handler.enrollMFA failed to update", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, &MFAEnrollResponse{
+			Secret: secret,
+			URI:    totp.ProvisioningURI(h.cfg.AccountConfig.MFA.Issuer, acc.Email, secret),
+		})
+	})
+}
+
+// verifyMFA handles POST /v1/api/user/mfa/verify
+func (h *Handler) verifyMFA(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		code, res := bindMFACode(c)
+		if res != nil {
+			return res
+		}
+		acc, res := h.findCurrentAccount(c)
+		if res != nil {
+			return res
+		}
+		if acc.MFAEnabled {
+			return handler.NewErrorResponse(http.StatusConflict, handler.DuplicateEntry, "two-factor authentication is already enabled", nil)
+		}
+		if acc.MFASecret == "" {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "two-factor authentication is not enrolled", nil)
+		}
+		ok, err := h.auth.verifyTOTPCode(c.Request.Context(), acc, code)
+		if err != nil {
+			logger.Errorw("account.handler.verifyMFA failed to verify a code", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if !ok {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidMFACode, ErrInvalidMFACode.Error(), nil)
+		}
+
+		codes, hashes, err := generateRecoveryCodes(h.cfg.AccountConfig.MFA.RecoveryCodes)
+		if err != nil {
+			logger.Errorw("account.handler.verifyMFA failed to generate recovery codes", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if err := h.accountDB.ReplaceRecoveryCodes(c.Request.Context(), acc.ID, hashes); err != nil {
+			logger.Errorw("account.handler.verifyMFA failed to save recovery codes", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if err := h.accountDB.UpdateMFA(c.Request.Context(), acc.Email, acc.MFASecret, true); err != nil {
+			logger.Errorw("account.handler.verifyMFA failed to update", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		// recovery codes are shown only once
+		return handler.NewSuccessResponse(http.StatusOK, &MFARecoveryCodesResponse{RecoveryCodes: codes})
+	})
+}
+
+// disableMFA handles POST /v1/api/user/mfa/disable
+func (h *Handler) disableMFA(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		code, res := bindMFACode(c)
+		if res != nil {
+			return res
+		}
+		acc, res := h.findCurrentAccount(c)
+		if res != nil {
+			return res
+		}
+		if !acc.MFAEnabled {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "two-factor authentication is not enabled", nil)
+		}
+		ok, err := h.auth.VerifyMFACode(c.Request.Context(), acc, code)
+		if err != nil {
+			logger.Errorw("account.handler.disableMFA failed to verify a code", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if !ok {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidMFACode, ErrInvalidMFACode.Error(), nil)
+		}
+
+		if err := h.accountDB.UpdateMFA(c.Request.Context(), acc.Email, "", false); err != nil {
+			logger.Errorw("account.handler.disableMFA failed to update", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if err := h.accountDB.ReplaceRecoveryCodes(c.Request.Context(), acc.ID, nil); err != nil {
+			logger.Errorw("account.handler.disableMFA failed to delete recovery codes", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, NewUserResponse(acc))
+	})
+}
+
+// findCurrentAccount returns the current account including the mfa secret from database.
+func (h *Handler) findCurrentAccount(c *gin.Context) (*model.Account, *handler.Response) {
+	currentUser := MustCurrentUser(c)
+	ctx := cache.WithCacheSkip(c.Request.Context(), true)
+	acc, err := h.accountDB.FindByEmail(ctx, currentUser.Email)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found current user", nil)
+		}
+		return nil, handler.NewInternalErrorResponse(err)
+	}
+	return acc, nil
+}
+
+func bindMFACode(c *gin.Context) (string, *handler.Response) {
+	var body MFACodeRequest
+	if res := handler.Bind(c, nil, nil, &body); res != nil {
+		return "", res
+	}
+	return body.Code, nil
+}
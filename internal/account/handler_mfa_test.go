@@ -0,0 +1,233 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/totp"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func (s *HandlerSuite) TestMFA_EnrollAndVerify() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+	s.db.On("UpdateMFA", mock.Anything, acc.Email, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		acc.MFASecret = args.String(2)
+		acc.MFAEnabled = args.Bool(3)
+	}).Return(nil)
+	s.db.On("ReplaceRecoveryCodes", mock.Anything, acc.ID, mock.Anything).Return(nil)
+	s.mockUseMFAStep(acc.ID)
+
+	// when
+	res := s.doAuthRequest("POST", "/v1/api/user/mfa/enroll", token, nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	secret := gjson.Get(res.Body.String(), "secret").String()
+	s.NotEmpty(secret)
+	s.Equal(secret, acc.MFASecret)
+	s.False(acc.MFAEnabled)
+	s.True(strings.HasPrefix(gjson.Get(res.Body.String(), "uri").String(), "otpauth://totp/article-server:user1@gmail.com?"))
+
+	// when then: invalid code
+	res = s.doAuthRequest("POST", "/v1/api/user/mfa/verify", token, map[string]interface{}{"code": "000000"})
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("InvalidMFACode", gjson.Get(res.Body.String(), "code").String())
+	s.False(acc.MFAEnabled)
+
+	// when
+	code, _ := totp.GenerateCode(secret, time.Now())
+	res = s.doAuthRequest("POST", "/v1/api/user/mfa/verify", token, map[string]interface{}{"code": code})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.True(acc.MFAEnabled)
+	recoveryCodes := gjson.Get(res.Body.String(), "recovery_codes").Array()
+	s.Len(recoveryCodes, s.cfg.AccountConfig.MFA.RecoveryCodes)
+	s.db.AssertCalled(s.T(), "ReplaceRecoveryCodes", mock.Anything, acc.ID, mock.MatchedBy(func(hashes []string) bool {
+		return len(hashes) == len(recoveryCodes) && hashes[0] == hashRecoveryCode(recoveryCodes[0].String())
+	}))
+
+	// when then: already enabled
+	res = s.doAuthRequest("POST", "/v1/api/user/mfa/enroll", token, nil)
+	s.Equal(http.StatusConflict, res.Code)
+}
+
+func (s *HandlerSuite) TestMFA_Login() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	secret, _ := totp.GenerateSecret()
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword, MFASecret: secret, MFAEnabled: true}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.mockUseMFAStep(acc.ID)
+	res := s.doRequest("POST", "/v1/api/users/login", map[string]interface{}{
+		"user": map[string]interface{}{"email": acc.Email, "password": password},
+	})
+	s.Equal(http.StatusOK, res.Code)
+	s.True(gjson.Get(res.Body.String(), "mfa_required").Bool())
+	s.False(gjson.Get(res.Body.String(), "token").Exists())
+	mfaToken := gjson.Get(res.Body.String(), "mfa_token").String()
+
+	// when then: the challenge token is not an access token
+	res = s.doAuthRequest("GET", "/v1/api/user/me", mfaToken, nil)
+	s.Equal(http.StatusUnauthorized, res.Code)
+
+	// when then: invalid code
+	res = s.doRequest("POST", "/v1/api/users/login/mfa", map[string]interface{}{"mfa_token": mfaToken, "code": "000000"})
+	s.Equal(http.StatusUnauthorized, res.Code)
+
+	// when
+	code, _ := totp.GenerateCode(secret, time.Now())
+	res = s.doRequest("POST", "/v1/api/users/login/mfa", map[string]interface{}{"mfa_token": mfaToken, "code": code})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	token := gjson.Get(res.Body.String(), "token").String()
+	s.NotEmpty(token)
+	res = s.doAuthRequest("GET", "/v1/api/user/me", token, nil)
+	s.Equal(http.StatusOK, res.Code)
+
+	// when then: the accepted code is not replayed
+	res = s.doRequest("POST", "/v1/api/users/login/mfa", map[string]interface{}{"mfa_token": mfaToken, "code": code})
+	s.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (s *HandlerSuite) TestMFA_LoginWithRecoveryCode() {
+	// given
+	secret, _ := totp.GenerateSecret()
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", MFASecret: secret, MFAEnabled: true}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.db.On("UseRecoveryCode", mock.Anything, acc.ID, hashRecoveryCode("abcde-fghij")).Return(nil).Once()
+	s.db.On("UseRecoveryCode", mock.Anything, acc.ID, mock.Anything).Return(database.ErrNotFound)
+	mfaToken, err := s.handler.auth.actionToken(purposeMFAChallenge, "1", time.Minute, nil)
+	s.NoError(err)
+
+	// when
+	res := s.doRequest("POST", "/v1/api/users/login/mfa", map[string]interface{}{"mfa_token": mfaToken, "code": "ABCDE FGHIJ"})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.NotEmpty(gjson.Get(res.Body.String(), "token").String())
+
+	// when then: the recovery code is used already
+	res = s.doRequest("POST", "/v1/api/users/login/mfa", map[string]interface{}{"mfa_token": mfaToken, "code": "abcde-fghij"})
+	s.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (s *HandlerSuite) TestMFA_LoginWithEmailChallenge() {
+	// given: challenge tokens are identified by account ids
+	mfaToken, err := s.handler.auth.actionToken(purposeMFAChallenge, "user1@gmail.com", time.Minute, nil)
+	s.NoError(err)
+
+	// when
+	res := s.doRequest("POST", "/v1/api/users/login/mfa", map[string]interface{}{"mfa_token": mfaToken, "code": "abcde-fghij"})
+
+	// then
+	s.Equal(http.StatusUnauthorized, res.Code)
+	s.db.AssertNotCalled(s.T(), "FindByEmail", mock.Anything, mock.Anything)
+	s.db.AssertNotCalled(s.T(), "UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestMFA_RequiredRoles() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "editor1", Email: "editor1@gmail.com", Password: encodedPassword, Role: model.RoleEditor}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.db.On("UpdateMFA", mock.Anything, acc.Email, mock.Anything, false).Return(nil)
+	res := s.doRequest("POST", "/v1/api/users/login", map[string]interface{}{
+		"user": map[string]interface{}{"email": acc.Email, "password": password},
+	})
+	s.Equal(http.StatusOK, res.Code)
+	s.True(gjson.Get(res.Body.String(), "mfa_enrollment_required").Bool())
+	token := gjson.Get(res.Body.String(), "token").String()
+
+	// when
+	res = s.doAuthRequest("GET", "/v1/api/user/me", token, nil)
+
+	// then
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal("MFAEnrollmentRequired", gjson.Get(res.Body.String(), "code").String())
+
+	// when then: can enroll mfa
+	res = s.doAuthRequest("POST", "/v1/api/user/mfa/enroll", token, nil)
+	s.Equal(http.StatusOK, res.Code)
+
+	// when then: not required roles
+	s.cfg.AccountConfig.MFA.RequiredRoles = []string{model.RoleAdmin}
+	s.setup(s.cfg)
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	res = s.doAuthRequest("GET", "/v1/api/user/me", token, nil)
+	s.Equal(http.StatusOK, res.Code)
+}
+
+func (s *HandlerSuite) TestMFA_Disable() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	secret, _ := totp.GenerateSecret()
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+	acc.MFASecret = secret
+	acc.MFAEnabled = true
+	s.db.On("UseRecoveryCode", mock.Anything, acc.ID, mock.Anything).Return(database.ErrNotFound)
+	s.db.On("UpdateMFA", mock.Anything, acc.Email, "", false).Return(nil)
+	s.db.On("ReplaceRecoveryCodes", mock.Anything, acc.ID, mock.Anything).Return(nil)
+	s.mockUseMFAStep(acc.ID)
+
+	// when then: invalid code
+	res := s.doAuthRequest("POST", "/v1/api/user/mfa/disable", token, map[string]interface{}{"code": "invalid-code"})
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("InvalidMFACode", gjson.Get(res.Body.String(), "code").String())
+	s.db.AssertNotCalled(s.T(), "UpdateMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// when
+	code, _ := totp.GenerateCode(secret, time.Now())
+	res = s.doAuthRequest("POST", "/v1/api/user/mfa/disable", token, map[string]interface{}{"code": code})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "UpdateMFA", mock.Anything, acc.Email, "", false)
+	s.db.AssertCalled(s.T(), "ReplaceRecoveryCodes", mock.Anything, acc.ID, []string(nil))
+}
+
+// mockUseMFAStep mocks UseMFAStep of given account to accept only steps after the last accepted step.
+func (s *HandlerSuite) mockUseMFAStep(accountID uint) {
+	var last int64
+	s.db.On("UseMFAStep", mock.Anything, accountID, mock.Anything).Return(func(_ context.Context, _ uint, step int64) error {
+		if step <= last {
+			return database.ErrNotFound
+		}
+		last = step
+		return nil
+	})
+}
+
+func (s *HandlerSuite) doAuthRequest(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	return s.doRequestWithAuthorization(method, path, "Bearer "+token, body)
+}
+
+func (s *HandlerSuite) doRequestWithAuthorization(method, path, authorization string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+	req.Header.Add("Authorization", authorization)
+	s.r.ServeHTTP(res, req)
+	return res
+}
@@ -0,0 +1,229 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/oauth"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const oauthStateCookie = "oauth_state"
+
+// NewOAuthProviders creates OAuth2 providers of "oauth.providers" configs
+func NewOAuthProviders(cfg *config.Config) (oauth.Providers, error) {
+	var confs []oauth.Config
+	for _, pc := range cfg.OAuthConfig.Providers {
+		confs = append(confs, oauth.Config{
+			Name:         pc.Name,
+			Type:         pc.Type,
+			ClientID:     pc.ClientID,
+			ClientSecret: pc.ClientSecret,
+			Scopes:       pc.Scopes,
+			RedirectURL:  fmt.Sprintf(cfg.OAuthConfig.RedirectURL, pc.Name),
+			Issuer:       pc.Issuer,
+			AuthURL:      pc.AuthURL,
+			TokenURL:     pc.TokenURL,
+			APIURL:       pc.APIURL,
+		})
+	}
+	return oauth.NewProviders(confs, nil)
+}
+
+// oauthLogin handles GET /v1/api/auth/:provider/login
+//
+// Redirects to the authorization endpoint of the provider. The state, nonce and PKCE code verifier
+// are kept in a signed cookie until the callback.
+func (h *Handler) oauthLogin(c *gin.Context) {
+	logger := logging.FromContext(c)
+	name := c.Param("provider")
+	provider, err := h.providers.Get(name)
+	if err != nil {
+		handler.AbortWithError(c, http.StatusNotFound, &handler.ErrorResponse{Code: handler.NotFoundEntity, Message: "not found provider"})
+		return
+	}
+
+	var values [3]string
+	for i := range values {
+		if values[i], err = oauth.RandomString(32); err != nil {
+			logger.Errorw("account.handler.oauthLogin failed to generate a random string", "err", err)
+			abortInternalError(c)
+			return
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+	ttl := h.cfg.OAuthConfig.StateTTL
+	stateToken, err := h.auth.actionToken(purposeOAuthState, name, ttl, jwt.MapClaims{
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	})
+	if err != nil {
+		logger.Errorw("account.handler.oauthLogin failed to create a state token", "err", err)
+		abortInternalError(c)
+		return
+	}
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, oauth.CodeChallenge(verifier))
+	if err != nil {
+		logger.Errorw("account.handler.oauthLogin failed to get an auth url", "provider", name, "err", err)
+		abortInternalError(c)
+		return
+	}
+	h.setOAuthStateCookie(c, name, stateToken, int(ttl.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// oauthCallback handles GET /v1/api/auth/:provider/callback
+//
+// Responds the same token response as LoginHandler.
+func (h *Handler) oauthCallback(c *gin.Context) {
+	logger := logging.FromContext(c)
+	name := c.Param("provider")
+	provider, err := h.providers.Get(name)
+	if err != nil {
+		handler.AbortWithError(c, http.StatusNotFound, &handler.ErrorResponse{Code: handler.NotFoundEntity, Message: "not found provider"})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		h.auth.unauthorized(c, http.StatusUnauthorized, "authorization is failed: "+e)
+		return
+	}
+
+	stateToken, _ := c.Cookie(oauthStateCookie)
+	// the state cookie can be used only once
+	h.setOAuthStateCookie(c, name, "", -1)
+	claims, err := h.auth.parseActionToken(purposeOAuthState, stateToken)
+	if err != nil || claims["sub"] != name || c.Query("state") == "" || claims["state"] != c.Query("state") {
+		h.auth.unauthorized(c, http.StatusUnauthorized, ErrInvalidToken.Error())
+		return
+	}
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["verifier"].(string)
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), verifier, nonce)
+	if err != nil {
+		logger.Errorw("account.handler.oauthCallback failed to exchange a code", "provider", name, "err", err)
+		h.auth.unauthorized(c, http.StatusUnauthorized, ErrFailedAuthentication.Error())
+		return
+	}
+
+	acc, err := h.linkAccount(c.Request.Context(), identity)
+	if err != nil {
+		switch err {
+		case ErrUnverifiedIdentity, ErrUnlinkableAccount:
+			h.auth.unauthorized(c, http.StatusForbidden, err.Error())
+		default:
+			logger.Errorw("account.handler.oauthCallback failed to link an account", "provider", name, "err", err)
+			h.auth.unauthorized(c, http.StatusUnauthorized, ErrFailedAuthentication.Error())
+		}
+		return
+	}
+	if acc.Disabled {
+		h.auth.unauthorized(c, http.StatusUnauthorized, ErrFailedAuthentication.Error())
+		return
+	}
+	h.auth.login(c, acc)
+}
+
+// linkAccount returns an account linked to given identity. If there is no linked account,
+// the identity is linked to an account with the same email address or a new account.
+// The email address must be verified by both of the provider and this server to prevent account takeovers.
+func (h *Handler) linkAccount(ctx context.Context, identity *oauth.Identity) (*model.Account, error) {
+	ctx = cache.WithCacheSkip(ctx, true)
+	acc, err := h.accountDB.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return acc, nil
+	}
+	if !database.IsRecordNotFoundErr(err) {
+		return nil, err
+	}
+	if !identity.EmailVerified {
+		return nil, ErrUnverifiedIdentity
+	}
+
+	err = h.accountDB.RunInTx(ctx, func(ctx context.Context) error {
+		acc, err = h.accountDB.FindByEmail(ctx, identity.Email)
+		if err != nil {
+			if !database.IsRecordNotFoundErr(err) {
+				return err
+			}
+			if acc, err = h.newOAuthAccount(ctx, identity); err != nil {
+				return err
+			}
+			if err := h.accountDB.Save(ctx, acc); err != nil {
+				return err
+			}
+		} else if !acc.EmailVerified {
+			return ErrUnlinkableAccount
+		}
+		return h.accountDB.SaveIdentity(ctx, &model.AccountIdentity{
+			AccountID: acc.ID,
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrUnlinkableAccount) {
+			return nil, ErrUnlinkableAccount
+		}
+		return nil, err
+	}
+	return acc, nil
+}
+
+// newOAuthAccount returns a new verified account of given identity with a random password,
+// so the password must be reset to sign in with the email address.
+// The username is derived from the name of the identity with a random suffix if taken already.
+func (h *Handler) newOAuthAccount(ctx context.Context, identity *oauth.Identity) (*model.Account, error) {
+	random, err := oauth.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	password, err := h.auth.passwords.Encode(random)
+	if err != nil {
+		return nil, err
+	}
+	name := identity.Name
+	if name == "" {
+		name = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	username := h.usernames.Sanitize(name)
+	if _, err := h.accountDB.FindByUsername(ctx, username); err == nil {
+		suffix, err := oauth.RandomString(4)
+		if err != nil {
+			return nil, err
+		}
+		username = h.usernames.WithSuffix(username, suffix)
+	} else if !database.IsRecordNotFoundErr(err) {
+		return nil, err
+	}
+	return &model.Account{
+		Username:      username,
+		Email:         identity.Email,
+		Password:      password,
+		EmailVerified: true,
+	}, nil
+}
+
+func (h *Handler) setOAuthStateCookie(c *gin.Context, provider, value string, maxAge int) {
+	secure := strings.HasPrefix(h.cfg.OAuthConfig.RedirectURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, value, maxAge, "/v1/api/auth/"+provider, "", secure, true)
+}
+
+func abortInternalError(c *gin.Context) {
+	handler.AbortWithError(c, http.StatusInternalServerError, &handler.ErrorResponse{
+		Code:    handler.InternalServerError,
+		Message: "An error has occurred, please try again later",
+	})
+}
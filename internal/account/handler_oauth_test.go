@@ -0,0 +1,194 @@
+package account
+
+import (
+	"context"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/oauth/oauthtest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func (s *HandlerSuite) TestOAuthLogin_NewAccount() {
+	// given
+	s.mockIdP.SetUser(oauthtest.User{Subject: "sub-1", Email: "user1@gmail.com", EmailVerified: true, Name: "user1"})
+	s.db.On("FindByIdentity", mock.Anything, "mock", "sub-1").Return(nil, database.ErrNotFound)
+	s.mockRunInTx()
+	s.db.On("FindByEmail", mock.Anything, "user1@gmail.com").Return(nil, database.ErrNotFound)
+	s.db.On("FindByUsername", mock.Anything, "user1").Return(nil, database.ErrNotFound)
+	s.db.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.Account).ID = 10
+	}).Return(nil)
+	s.db.On("SaveIdentity", mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	res := s.oauthLogin("mock")
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.NotEmpty(gjson.Get(res.Body.String(), "token").String())
+	s.NotEmpty(gjson.Get(res.Body.String(), "expire").String())
+	s.db.AssertCalled(s.T(), "Save", mock.Anything, mock.MatchedBy(func(acc *model.Account) bool {
+		return acc.Email == "user1@gmail.com" && acc.Username == "user1" && acc.EmailVerified && acc.Password != ""
+	}))
+	s.db.AssertCalled(s.T(), "SaveIdentity", mock.Anything, &model.AccountIdentity{
+		AccountID: 10,
+		Provider:  "mock",
+		Subject:   "sub-1",
+		Email:     "user1@gmail.com",
+	})
+}
+
+func (s *HandlerSuite) TestOAuthLogin_NewAccountWithTakenUsername() {
+	// given
+	s.mockIdP.SetUser(oauthtest.User{Subject: "sub-1", Email: "user1@gmail.com", EmailVerified: true, Name: "Admin User"})
+	s.db.On("FindByIdentity", mock.Anything, "mock", "sub-1").Return(nil, database.ErrNotFound)
+	s.mockRunInTx()
+	s.db.On("FindByEmail", mock.Anything, "user1@gmail.com").Return(nil, database.ErrNotFound)
+	s.db.On("FindByUsername", mock.Anything, "Admin-User").Return(&model.Account{ID: 1, Username: "Admin-User"}, nil)
+	s.db.On("Save", mock.Anything, mock.Anything).Return(nil)
+	s.db.On("SaveIdentity", mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	res := s.oauthLogin("mock")
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "Save", mock.Anything, mock.MatchedBy(func(acc *model.Account) bool {
+		return strings.HasPrefix(acc.Username, "Admin-User-") && len(acc.Username) == len("Admin-User-")+6
+	}))
+}
+
+func (s *HandlerSuite) TestOAuthLogin_LinkExistingAccount() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", EmailVerified: true}
+	s.mockIdP.SetUser(oauthtest.User{Subject: "sub-1", Email: acc.Email, EmailVerified: true})
+	s.db.On("FindByIdentity", mock.Anything, "mock", "sub-1").Return(nil, database.ErrNotFound)
+	s.mockRunInTx()
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("SaveIdentity", mock.Anything, mock.Anything).Return(nil)
+
+	// when
+	res := s.oauthLogin("mock")
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.NotEmpty(gjson.Get(res.Body.String(), "token").String())
+	s.db.AssertNotCalled(s.T(), "Save", mock.Anything, mock.Anything)
+	s.db.AssertCalled(s.T(), "SaveIdentity", mock.Anything, mock.MatchedBy(func(identity *model.AccountIdentity) bool {
+		return identity.AccountID == acc.ID && identity.Subject == "sub-1"
+	}))
+}
+
+func (s *HandlerSuite) TestOAuthLogin_Unverified() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	s.db.On("FindByIdentity", mock.Anything, "mock", mock.Anything).Return(nil, database.ErrNotFound)
+	s.mockRunInTx()
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+
+	// when then: not verified by the provider
+	s.mockIdP.SetUser(oauthtest.User{Subject: "sub-1", Email: acc.Email, EmailVerified: false})
+	res := s.oauthLogin("mock")
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal(ErrUnverifiedIdentity.Error(), gjson.Get(res.Body.String(), "detail").String())
+
+	// when then: the existing account is not verified
+	s.mockIdP.SetUser(oauthtest.User{Subject: "sub-1", Email: acc.Email, EmailVerified: true})
+	res = s.oauthLogin("mock")
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal(ErrUnlinkableAccount.Error(), gjson.Get(res.Body.String(), "detail").String())
+	s.db.AssertNotCalled(s.T(), "SaveIdentity", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestOAuthLogin_MFA() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", EmailVerified: true, MFAEnabled: true, MFASecret: "JBSWY3DPEHPK3PXP"}
+	s.mockIdP.SetUser(oauthtest.User{Subject: "sub-1", Email: acc.Email, EmailVerified: true})
+	s.db.On("FindByIdentity", mock.Anything, "mock", "sub-1").Return(&acc, nil)
+
+	// when
+	res := s.oauthLogin("mock")
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.True(gjson.Get(res.Body.String(), "mfa_required").Bool())
+	s.False(gjson.Get(res.Body.String(), "token").Exists())
+}
+
+func (s *HandlerSuite) TestOAuthLogin_InvalidState() {
+	// given
+	res := s.doRequest("GET", "/v1/api/auth/mock/login", nil)
+	s.Equal(http.StatusFound, res.Code)
+	callback := s.authorize(res.Header().Get("Location"))
+
+	// when then: no state cookie
+	res = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", callback.RequestURI(), nil)
+	s.r.ServeHTTP(res, req)
+	s.Equal(http.StatusUnauthorized, res.Code)
+
+	// when then: another state
+	cookie := s.loginCookie()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", callback.RequestURI(), nil)
+	req.AddCookie(cookie)
+	s.r.ServeHTTP(res, req)
+	s.Equal(http.StatusUnauthorized, res.Code)
+	s.db.AssertNotCalled(s.T(), "FindByIdentity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestOAuthLogin_UnknownProvider() {
+	res := s.doRequest("GET", "/v1/api/auth/unknown/login", nil)
+
+	s.Equal(http.StatusNotFound, res.Code)
+}
+
+// oauthLogin signs in with given provider through the mock provider and returns the response of the callback
+func (s *HandlerSuite) oauthLogin(provider string) *httptest.ResponseRecorder {
+	res := s.doRequest("GET", "/v1/api/auth/"+provider+"/login", nil)
+	s.Equal(http.StatusFound, res.Code)
+	cookies := (&http.Response{Header: res.Header()}).Cookies()
+	s.Len(cookies, 1)
+	callback := s.authorize(res.Header().Get("Location"))
+	s.Equal("/v1/api/auth/"+provider+"/callback", callback.Path)
+
+	res = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", callback.RequestURI(), nil)
+	req.AddCookie(cookies[0])
+	s.r.ServeHTTP(res, req)
+	return res
+}
+
+// loginCookie returns a state cookie of a new login request
+func (s *HandlerSuite) loginCookie() *http.Cookie {
+	res := s.doRequest("GET", "/v1/api/auth/mock/login", nil)
+	cookies := (&http.Response{Header: res.Header()}).Cookies()
+	s.Len(cookies, 1)
+	return cookies[0]
+}
+
+// authorize requests the authorization url to the mock provider and returns the redirected callback url
+func (s *HandlerSuite) authorize(authURL string) *url.URL {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	s.NoError(err)
+	defer res.Body.Close()
+	s.Equal(http.StatusFound, res.StatusCode)
+	callback, err := url.Parse(res.Header.Get("Location"))
+	s.NoError(err)
+	return callback
+}
+
+func (s *HandlerSuite) mockRunInTx() {
+	s.db.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, f func(context.Context) error) error {
+		return f(ctx)
+	})
+}
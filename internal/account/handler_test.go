@@ -3,11 +3,21 @@ package account
 import (
 	"bytes"
 	"encoding/json"
+	accountDB "gin-rest-api-example/internal/account/database"
 	"gin-rest-api-example/internal/account/database/mocks"
 	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/audit"
+	auditModel "gin-rest-api-example/internal/audit/model"
 	"gin-rest-api-example/internal/config"
 	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/mailer"
+	"gin-rest-api-example/internal/metric"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
 	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/oauth/oauthtest"
+	"gin-rest-api-example/pkg/openapi"
+	"gin-rest-api-example/pkg/validate"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -15,35 +25,82 @@ import (
 	"go.uber.org/zap/zapcore"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
 
+// testRequestID is the request id of test requests which is the instance of problem details.
+const testRequestID = "request-1"
+
 type HandlerSuite struct {
 	suite.Suite
+	cfg     *config.Config
 	r       *gin.Engine
 	handler *Handler
 	db      *mocks.AccountDB
+	mails   *bytes.Buffer
+	audits  *bytes.Buffer
+	oidc    *httptest.Server
+	mockIdP *oauthtest.Provider
+	mp      *metric.MetricsProvider
 }
 
 func (s *HandlerSuite) SetupSuite() {
 	logging.SetLevel(zapcore.FatalLevel)
+	s.NoError(validate.SetupBinding())
+	s.oidc, s.mockIdP = oauthtest.NewServer("client-id", "client-secret")
+	cfg, err := config.Load("")
+	s.NoError(err)
+	s.mp = metric.NewMetricsProvider(cfg)
+}
+
+func (s *HandlerSuite) TearDownSuite() {
+	s.oidc.Close()
 }
 
 func (s *HandlerSuite) SetupTest() {
 	cfg, err := config.Load("")
 	s.NoError(err)
+	// table tests request routes more than rate limits
+	cfg.RateLimitConfig.Enabled = false
+	s.setup(cfg)
+}
 
+func (s *HandlerSuite) setup(cfg *config.Config) {
+	s.cfg = cfg
 	s.db = &mocks.AccountDB{}
-	s.handler = NewHandler(s.db)
+	s.mails = &bytes.Buffer{}
+	s.audits = &bytes.Buffer{}
+	cfg.OAuthConfig.Providers = []config.OAuthProviderConfig{{
+		Name:         "mock",
+		Type:         "oidc",
+		Issuer:       s.oidc.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+	}}
+	providers, err := NewOAuthProviders(cfg)
+	s.NoError(err)
 
-	jwtMiddleware, err := NewAuthMiddleware(cfg, s.db)
+	jwtMiddleware, err := NewAuthMiddleware(cfg, s.db, nil, s.mp, audit.New(audit.NewWriterSink(s.audits)))
 	s.NoError(err)
+	policy, err := NewPasswordPolicy(cfg)
+	s.NoError(err)
+	s.handler = NewHandler(cfg, s.db, jwtMiddleware, mailer.NewWriterSender(cfg.MailConfig.From, s.mails), providers, policy)
+
+	limiter, err := middleware.NewRateLimiter(cfg, nil, s.mp)
+	s.NoError(err)
+
+	spec := openapi.NewSpec(openapi.Info{Title: "test", Version: "v1"}, handler.Problem{})
+	spec.Add(OpenAPIRoutes()...)
 
 	gin.SetMode(gin.TestMode)
 	s.r = gin.Default()
+	s.r.Use(middleware.ValidateResponse(spec, func(c *gin.Context, err error) {
+		s.Failf("response is not documented", "%s %s: %v", c.Request.Method, c.FullPath(), err)
+	}))
 
-	RouteV1(cfg, s.handler, s.r, jwtMiddleware)
+	RouteV1(cfg, s.handler, s.r, jwtMiddleware, limiter, spec)
 }
 
 func TestSuite(t *testing.T) {
@@ -72,6 +129,7 @@ func (s *HandlerSuite) TestRegister() {
 	b, _ := json.Marshal(body)
 	res := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/v1/api/users", bytes.NewBuffer(b))
+	req.Header.Set(middleware.XRequestIdKey, testRequestID)
 
 	s.r.ServeHTTP(res, req)
 
@@ -103,8 +161,12 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 		{
 			Expected: `
 			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid request in body",
+			  "instance": "request-1",
 			  "code": "InvalidBodyValue",
-			  "message": "[InvalidBodyValue] invalid user request in body",
 			  "errors": [
 				{
 				  "field": "username",
@@ -130,8 +192,12 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 			Password: password,
 			Expected: `
 			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid request in body",
+			  "instance": "request-1",
 			  "code": "InvalidBodyValue",
-			  "message": "[InvalidBodyValue] invalid user request in body",
 			  "errors": [
 				{
 				  "field": "username",
@@ -141,14 +207,79 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 			  ]
 			}`,
 		},
+		{
+			Username: "us",
+			Email:    email,
+			Password: password,
+			Expected: `
+			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid user request in body",
+			  "instance": "request-1",
+			  "code": "InvalidBodyValue",
+			  "errors": [
+				{
+				  "field": "username",
+				  "value": "us",
+				  "message": "username required at least 3 length"
+				}
+			  ]
+			}`,
+		}, {
+			Username: "user 1",
+			Email:    email,
+			Password: password,
+			Expected: `
+			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid request in body",
+			  "instance": "request-1",
+			  "code": "InvalidBodyValue",
+			  "errors": [
+				{
+				  "field": "username",
+				  "value": "user 1",
+				  "message": "username must start with a letter or digit and contain only letters, digits, '.', '_' and '-'"
+				}
+			  ]
+			}`,
+		}, {
+			Username: "Admin",
+			Email:    email,
+			Password: password,
+			Expected: `
+			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid user request in body",
+			  "instance": "request-1",
+			  "code": "InvalidBodyValue",
+			  "errors": [
+				{
+				  "field": "username",
+				  "value": "Admin",
+				  "message": "username is reserved"
+				}
+			  ]
+			}`,
+		},
 		// email
 		{
 			Username: username,
 			Password: password,
 			Expected: `
 			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid request in body",
+			  "instance": "request-1",
 			  "code": "InvalidBodyValue",
-			  "message": "[InvalidBodyValue] invalid user request in body",
 			  "errors": [
 				{
 				  "field": "email",
@@ -163,8 +294,12 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 			Password: password,
 			Expected: `
 			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid request in body",
+			  "instance": "request-1",
 			  "code": "InvalidBodyValue",
-			  "message": "[InvalidBodyValue] invalid user request in body",
 			  "errors": [
 				{
 				  "field": "email",
@@ -180,6 +315,11 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 			Email:    email,
 			Expected: `
 			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid request in body",
+			  "instance": "request-1",
 			  "code": "InvalidBodyValue",
 			  "errors": [
 				{
@@ -187,8 +327,7 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 				  "value": "",
 				  "message": "required password"
 				}
-			  ],
-			  "message": "[InvalidBodyValue] invalid user request in body"
+			  ]
 			}`,
 		}, {
 			Username: username,
@@ -196,6 +335,11 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 			Password: "1",
 			Expected: `
 			{
+			  "type": "about:blank",
+			  "title": "Invalid request body",
+			  "status": 400,
+			  "detail": "invalid user request in body",
+			  "instance": "request-1",
 			  "code": "InvalidBodyValue",
 			  "errors": [
 				{
@@ -203,8 +347,7 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 				  "value": "1",
 				  "message": "password required at least 5 length"
 				}
-			  ],
-			  "message": "[InvalidBodyValue] invalid user request in body"
+			  ]
 			}`,
 		},
 	}
@@ -226,6 +369,7 @@ func (s *HandlerSuite) TestRegister_BadRequest() {
 		})
 		res := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/v1/api/users", bytes.NewBuffer(b))
+		req.Header.Set(middleware.XRequestIdKey, testRequestID)
 
 		s.r.ServeHTTP(res, req)
 
@@ -257,6 +401,7 @@ func (s *HandlerSuite) TestRegister_FailIfDuplicateError() {
 	b, _ := json.Marshal(body)
 	res := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/v1/api/users", bytes.NewBuffer(b))
+	req.Header.Set(middleware.XRequestIdKey, testRequestID)
 
 	s.r.ServeHTTP(res, req)
 
@@ -265,8 +410,39 @@ func (s *HandlerSuite) TestRegister_FailIfDuplicateError() {
 	s.Equal(http.StatusConflict, res.Code)
 	expected := `
 	{
-	  "code": "DuplicateEntry",
-	  "message": "[DuplicateEntry] duplicate email address"
+	  "type": "about:blank",
+	  "title": "Resource already exists",
+	  "status": 409,
+	  "detail": "duplicate email address",
+	  "instance": "request-1",
+	  "code": "DuplicateEntry"
+	}`
+	s.JSONEq(expected, res.Body.String())
+}
+
+func (s *HandlerSuite) TestRegister_FailIfDuplicateUsername() {
+	// given
+	s.db.On("Save", mock.Anything, mock.Anything).Return(accountDB.ErrUsernameConflict)
+
+	// when
+	res := s.doRequest("POST", "/v1/api/users", map[string]interface{}{
+		"user": map[string]interface{}{
+			"username": "zaccoding",
+			"email":    "zaccoding@gmail.com",
+			"password": "password123",
+		},
+	})
+
+	// then
+	s.Equal(http.StatusConflict, res.Code)
+	expected := `
+	{
+	  "type": "about:blank",
+	  "title": "Resource already exists",
+	  "status": 409,
+	  "detail": "duplicate username",
+	  "instance": "request-1",
+	  "code": "DuplicateEntry"
 	}`
 	s.JSONEq(expected, res.Body.String())
 }
@@ -296,7 +472,7 @@ func (s *HandlerSuite) TestCurrentUser() {
 	s.r.ServeHTTP(res, req)
 
 	// then
-	s.db.AssertCalled(s.T(), "FindByEmail", mock.Anything, acc.Email)
+	s.db.AssertCalled(s.T(), "FindByID", mock.Anything, acc.ID)
 	s.Equal(http.StatusOK, res.Code)
 	expected := `
 	{
@@ -333,7 +509,7 @@ func (s *HandlerSuite) TestUpdate() {
 		"user": map[string]interface{}{
 			"username": "updated-user1",
 			"bio":      "updated-bio",
-			"image":    "updated-image",
+			"image":    "https://example.com/updated-image.png",
 		},
 	}
 	b, _ := json.Marshal(updateRequest)
@@ -345,7 +521,7 @@ func (s *HandlerSuite) TestUpdate() {
 
 	// then
 	s.db.AssertCalled(s.T(), "Update", mock.Anything, acc.Email, mock.MatchedBy(func(a *model.Account) bool {
-		return a.Email == acc.Email && a.Username == "updated-user1" && a.Bio == "updated-bio" && a.Image == "updated-image"
+		return a.Email == acc.Email && a.Username == "updated-user1" && a.Bio == "updated-bio" && a.Image == "https://example.com/updated-image.png"
 	}))
 	s.Equal(http.StatusOK, res.Code)
 	expected := `
@@ -354,14 +530,92 @@ func (s *HandlerSuite) TestUpdate() {
 		"username": "updated-user1",
 		"email": "user1@gmail.com",
 		"bio": "updated-bio",
-		"image": "updated-image"
+		"image": "https://example.com/updated-image.png"
 	  }
 	}`
 	s.JSONEq(expected, res.Body.String())
+	events := s.auditEvents(audit.ActionAccountUpdate)
+	s.Len(events, 1)
+	s.Equal(acc.ID, events[0].ActorID)
+	s.Equal("1", events[0].TargetID)
+	s.JSONEq(`{
+	  "username": {"before": "user1", "after": "updated-user1"},
+	  "bio": {"before": "user1 bio", "after": "updated-bio"},
+	  "image": {"before": "user1 image", "after": "https://example.com/updated-image.png"}
+	}`, string(events[0].Changes))
+}
+
+func (s *HandlerSuite) TestUpdate_Username() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+	s.db.On("Update", mock.Anything, acc.Email, mock.MatchedBy(func(a *model.Account) bool {
+		return a.Username == "user2"
+	})).Return(accountDB.ErrUsernameConflict)
+
+	cases := []struct {
+		Username string
+		Code     int
+	}{
+		{Username: "root", Code: http.StatusBadRequest},
+		{Username: "-user", Code: http.StatusBadRequest},
+		{Username: "deleted-user-1", Code: http.StatusBadRequest},
+		{Username: "user2", Code: http.StatusConflict},
+	}
+	for _, tc := range cases {
+		// when
+		res := s.doAuthRequest("PUT", "/v1/api/user", token, map[string]interface{}{
+			"user": map[string]interface{}{"username": tc.Username},
+		})
+
+		// then
+		s.Equal(tc.Code, res.Code, tc.Username)
+	}
+}
+
+func (s *HandlerSuite) TestLogin_AuditEvents() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+
+	// when
+	for _, pw := range []string{password + "wrong", password} {
+		s.doRequest("POST", "/v1/api/users/login", map[string]interface{}{
+			"user": map[string]interface{}{"email": acc.Email, "password": pw},
+		})
+	}
+
+	// then
+	failures := s.auditEvents(audit.ActionLoginFailed)
+	s.Len(failures, 1)
+	s.Zero(failures[0].ActorID)
+	s.Equal(acc.Email, failures[0].TargetID)
+	s.NotEmpty(failures[0].RequestID)
+	logins := s.auditEvents(audit.ActionLogin)
+	s.Len(logins, 1)
+	s.Equal(acc.ID, logins[0].ActorID)
+	s.Equal(audit.TargetAccount, logins[0].TargetType)
+}
+
+func (s *HandlerSuite) TestJWKS() {
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{"keys": []}`, res.Body.String())
 }
 
 func (s *HandlerSuite) getBearerToken(acc *model.Account, rawPassword string) string {
 	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(acc, nil)
+	s.db.On("FindByID", mock.Anything, acc.ID).Return(acc, nil)
 	body := map[string]interface{}{
 		"user": map[string]interface{}{
 			"email":    acc.Email,
@@ -375,3 +629,15 @@ func (s *HandlerSuite) getBearerToken(acc *model.Account, rawPassword string) st
 
 	return gjson.Get(res.Body.String(), "token").String()
 }
+
+// auditEvents returns recorded audit events of given action
+func (s *HandlerSuite) auditEvents(action string) []*auditModel.Event {
+	var events []*auditModel.Event
+	for _, line := range strings.Split(strings.TrimSpace(s.audits.String()), "\n") {
+		var event auditModel.Event
+		if err := json.Unmarshal([]byte(line), &event); err == nil && event.Action == action {
+			events = append(events, &event)
+		}
+	}
+	return events
+}
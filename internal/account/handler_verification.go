@@ -0,0 +1,143 @@
+package account
+
+import (
+	"context"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// verifyEmail handles POST /v1/api/users/verify
+func (h *Handler) verifyEmail(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		var body VerifyEmailRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+
+		claims, err := h.auth.parseActionToken(purposeVerifyEmail, body.Token)
+		if err != nil {
+			logger.Errorw("account.handler.verifyEmail failed to parse a token", "err", err)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+		}
+		email := claims["sub"].(string)
+		ctx := cache.WithCacheSkip(c.Request.Context(), true)
+		acc, err := h.accountDB.FindByEmail(ctx, email)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+		if !acc.EmailVerified {
+			if err := h.accountDB.Update(c.Request.Context(), email, &model.Account{EmailVerified: true}); err != nil {
+				logger.Errorw("account.handler.verifyEmail failed to update", "err", err)
+				return handler.NewInternalErrorResponse(err)
+			}
+			acc.EmailVerified = true
+		}
+		return handler.NewSuccessResponse(http.StatusOK, NewUserResponse(acc))
+	})
+}
+
+// forgotPassword handles POST /v1/api/users/password/forgot
+func (h *Handler) forgotPassword(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		var body ForgotPasswordRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+
+		// always returns 202 status code to not expose which email addresses are registered.
+		ctx := cache.WithCacheSkip(c.Request.Context(), true)
+		acc, err := h.accountDB.FindByEmail(ctx, body.Email)
+		if err != nil {
+			if !database.IsRecordNotFoundErr(err) {
+				return handler.NewInternalErrorResponse(err)
+			}
+			return handler.NewSuccessResponse(http.StatusAccepted, nil)
+		}
+		if acc.Disabled {
+			return handler.NewSuccessResponse(http.StatusAccepted, nil)
+		}
+		if err := h.sendPasswordResetMail(c.Request.Context(), acc); err != nil {
+			logger.Errorw("account.handler.forgotPassword failed to send a password reset mail", "err", err)
+		}
+		return handler.NewSuccessResponse(http.StatusAccepted, nil)
+	})
+}
+
+// resetPassword handles POST /v1/api/users/password/reset
+func (h *Handler) resetPassword(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		var body ResetPasswordRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		if res := h.checkPassword(c, body.Password, "invalid password request in body"); res != nil {
+			return res
+		}
+
+		claims, err := h.auth.parseActionToken(purposeResetPassword, body.Token)
+		if err != nil {
+			logger.Errorw("account.handler.resetPassword failed to parse a token", "err", err)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+		}
+		email := claims["sub"].(string)
+		ctx := cache.WithCacheSkip(c.Request.Context(), true)
+		acc, err := h.accountDB.FindByEmail(ctx, email)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+		// the token is used already if the password has been changed after issuing the token.
+		if fingerprint, _ := claims["pwd"].(string); acc.Disabled || fingerprint != passwordFingerprint(acc.Password) {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidToken, "invalid or expired token", nil)
+		}
+
+		password, err := h.auth.passwords.Encode(body.Password)
+		if err != nil {
+			logger.Errorw("account.handler.resetPassword failed to encode password", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		// a password reset proves the ownership of the email address.
+		updated := model.Account{Password: password, EmailVerified: true}
+		if err := h.accountDB.Update(c.Request.Context(), email, &updated); err != nil {
+			logger.Errorw("account.handler.resetPassword failed to update", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		acc.EmailVerified = true
+		return handler.NewSuccessResponse(http.StatusOK, NewUserResponse(acc))
+	})
+}
+
+func (h *Handler) sendVerificationMail(ctx context.Context, acc *model.Account) error {
+	conf := h.cfg.AccountConfig.Verification
+	token, err := h.auth.actionToken(purposeVerifyEmail, acc.Email, conf.TokenTTL, nil)
+	if err != nil {
+		return err
+	}
+	return h.sender.Send(ctx, newVerificationMail(acc, conf.URL, token))
+}
+
+func (h *Handler) sendPasswordResetMail(ctx context.Context, acc *model.Account) error {
+	conf := h.cfg.AccountConfig.PasswordReset
+	token, err := h.auth.actionToken(purposeResetPassword, acc.Email, conf.TokenTTL, jwt.MapClaims{
+		"pwd": passwordFingerprint(acc.Password),
+	})
+	if err != nil {
+		return err
+	}
+	return h.sender.Send(ctx, newPasswordResetMail(acc, conf.URL, token))
+}
@@ -0,0 +1,195 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func (s *HandlerSuite) TestVerifyEmail() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	s.db.On("Save", mock.Anything, mock.Anything).Return(nil)
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("Update", mock.Anything, acc.Email, mock.Anything).Return(nil)
+	res := s.doRequest("POST", "/v1/api/users", map[string]interface{}{
+		"user": map[string]interface{}{
+			"username": acc.Username,
+			"email":    acc.Email,
+			"password": "password1",
+		},
+	})
+	s.Equal(http.StatusCreated, res.Code)
+	token := s.lastMailToken(acc.Email, "Verify your email address")
+
+	// when
+	res = s.doRequest("POST", "/v1/api/users/verify", map[string]interface{}{
+		"token": token,
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "Update", mock.Anything, acc.Email, mock.MatchedBy(func(a *model.Account) bool {
+		return a.EmailVerified && a.Password == "" && a.Username == ""
+	}))
+}
+
+func (s *HandlerSuite) TestVerifyEmail_InvalidToken() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	resetToken, err := s.handler.auth.actionToken(purposeResetPassword, acc.Email, s.cfg.AccountConfig.PasswordReset.TokenTTL, nil)
+	s.NoError(err)
+	accessToken, _, err := s.handler.auth.TokenGenerator(&acc)
+	s.NoError(err)
+	expiredToken, err := s.handler.auth.actionToken(purposeVerifyEmail, acc.Email, -s.cfg.AccountConfig.Verification.TokenTTL, nil)
+	s.NoError(err)
+
+	for _, token := range []string{"invalid", resetToken, accessToken, expiredToken} {
+		// when
+		res := s.doRequest("POST", "/v1/api/users/verify", map[string]interface{}{
+			"token": token,
+		})
+
+		// then
+		s.Equal(http.StatusBadRequest, res.Code)
+		s.Equal("InvalidToken", gjson.Get(res.Body.String(), "code").String())
+	}
+	s.db.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestVerifyEmail_RejectedAsAccessToken() {
+	// given
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	token, err := s.handler.auth.actionToken(purposeVerifyEmail, acc.Email, s.cfg.AccountConfig.Verification.TokenTTL, nil)
+	s.NoError(err)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/api/user/me", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (s *HandlerSuite) TestResetPassword() {
+	// given
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.db.On("Update", mock.Anything, acc.Email, mock.Anything).Return(nil)
+
+	res := s.doRequest("POST", "/v1/api/users/password/forgot", map[string]interface{}{
+		"email": acc.Email,
+	})
+	s.Equal(http.StatusAccepted, res.Code)
+	token := s.lastMailToken(acc.Email, "Reset your password")
+
+	// when
+	res = s.doRequest("POST", "/v1/api/users/password/reset", map[string]interface{}{
+		"token":    token,
+		"password": "new-password",
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "Update", mock.Anything, acc.Email, mock.MatchedBy(func(a *model.Account) bool {
+		return a.EmailVerified && MatchesPassword(a.Password, "new-password") == nil
+	}))
+
+	// when then: the token can not be used after the password is changed
+	acc.Password, _ = EncodePassword("new-password")
+	res = s.doRequest("POST", "/v1/api/users/password/reset", map[string]interface{}{
+		"token":    token,
+		"password": "new-password2",
+	})
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("InvalidToken", gjson.Get(res.Body.String(), "code").String())
+}
+
+func (s *HandlerSuite) TestForgotPassword_NotExistEmail() {
+	// given
+	email := "unknown@gmail.com"
+	s.db.On("FindByEmail", mock.Anything, email).Return(nil, database.ErrNotFound)
+
+	// when
+	res := s.doRequest("POST", "/v1/api/users/password/forgot", map[string]interface{}{
+		"email": email,
+	})
+
+	// then
+	s.Equal(http.StatusAccepted, res.Code)
+	s.Empty(s.mails.String())
+}
+
+func (s *HandlerSuite) TestRestrictUnverified() {
+	// given
+	cfg, err := config.Load("")
+	s.NoError(err)
+	cfg.AccountConfig.Verification.Restrictions = []string{RestrictUpdateUser}
+	s.setup(cfg)
+
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	token := s.getBearerToken(&acc, password)
+
+	// when
+	b, _ := json.Marshal(map[string]interface{}{
+		"user": map[string]interface{}{"bio": "updated-bio"},
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/v1/api/user", bytes.NewBuffer(b))
+	req.Header.Add("Authorization", "Bearer "+token)
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal("UnverifiedAccount", gjson.Get(res.Body.String(), "code").String())
+	s.db.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	// when then: restrict login
+	cfg.AccountConfig.Verification.Restrictions = []string{RestrictLogin}
+	s.setup(cfg)
+	s.Empty(s.getBearerToken(&acc, password))
+	acc.EmailVerified = true
+	s.NotEmpty(s.getBearerToken(&acc, password))
+}
+
+func (s *HandlerSuite) doRequest(method, path string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+	req.Header.Set(middleware.XRequestIdKey, testRequestID)
+	s.r.ServeHTTP(res, req)
+	return res
+}
+
+// lastMailToken returns a token in the link of the last sent mail
+func (s *HandlerSuite) lastMailToken(to, subject string) string {
+	lines := strings.Split(strings.TrimSpace(s.mails.String()), "\n")
+	last := lines[len(lines)-1]
+	s.Equal(to, gjson.Get(last, "to.0").String())
+	s.Equal(subject, gjson.Get(last, "subject").String())
+
+	matches := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(gjson.Get(last, "body").String())
+	s.Len(matches, 2)
+	token, err := url.QueryUnescape(matches[1])
+	s.NoError(err)
+	return token
+}
@@ -0,0 +1,268 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/metric"
+	"gin-rest-api-example/pkg/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	lockoutScopeEmail = "email"
+	lockoutScopeIP    = "ip"
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginLockedError is returned if logins of an email address or a client ip are locked until RetryAfter elapsed.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s. retry after %s", ErrLoginLocked.Error(), e.RetryAfter.Round(time.Second))
+}
+
+// loginAttempts is failed logins of an email address or a client ip in the lockout window.
+type loginAttempts struct {
+	Failures    int
+	LockedUntil time.Time
+}
+
+// loginAttemptStore counts failed logins. Counters are updated atomically so that concurrent failures are not lost.
+type loginAttemptStore interface {
+	// get returns failed logins of given key. Missing keys have zero failures.
+	get(ctx context.Context, key string, now time.Time) (loginAttempts, error)
+
+	// incr increments failures of given key kept at least for given ttl and returns the failures.
+	incr(ctx context.Context, key string, ttl time.Duration, now time.Time) (int, error)
+
+	// lock locks given key until given time unless it is locked longer.
+	lock(ctx context.Context, key string, until time.Time, now time.Time) error
+
+	// delete removes failed logins of given key.
+	delete(ctx context.Context, key string) error
+}
+
+// loginLimiter tracks failed logins per email address and client ip in the redis cache or the memory.
+// Logins of an email address are delayed exponentially after free attempts and locked after max failures,
+// and logins from a client ip are locked after max ip failures.
+type loginLimiter struct {
+	store         loginAttemptStore
+	mp            *metric.MetricsProvider
+	freeAttempts  int
+	baseDelay     time.Duration
+	maxDelay      time.Duration
+	maxFailures   int
+	maxIPFailures int
+	duration      time.Duration
+	window        time.Duration
+}
+
+// newLoginLimiter returns a new loginLimiter or nil if the lockout is disabled.
+// Failed logins are tracked in the memory if the redis cache is disabled.
+func newLoginLimiter(cfg *config.Config, cacher cache.Cacher, mp *metric.MetricsProvider) *loginLimiter {
+	conf := cfg.AccountConfig.Lockout
+	if !conf.Enabled {
+		return nil
+	}
+	var store loginAttemptStore
+	if cli, prefix, ok := cache.RedisClient(cacher); ok {
+		store = newRedisLoginAttemptStore(cli, prefix)
+	} else {
+		logging.DefaultLogger().Warn("failed logins are tracked in the memory because the redis cache is disabled")
+		store = newMemoryLoginAttemptStore()
+	}
+	return &loginLimiter{
+		store:         store,
+		mp:            mp,
+		freeAttempts:  conf.FreeAttempts,
+		baseDelay:     conf.BaseDelay,
+		maxDelay:      conf.MaxDelay,
+		maxFailures:   conf.MaxFailures,
+		maxIPFailures: conf.MaxIPFailures,
+		duration:      conf.Duration,
+		window:        conf.Window,
+	}
+}
+
+// check returns a LoginLockedError if logins of given email address or client ip are locked.
+func (l *loginLimiter) check(ctx context.Context, email, ip string, now time.Time) error {
+	if l == nil {
+		return nil
+	}
+	var retryAfter time.Duration
+	for _, key := range []string{l.emailKey(email), l.ipKey(ip)} {
+		attempts, err := l.store.get(ctx, key, now)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("account.lockout failed to get failed logins", "key", key, "err", err)
+			continue
+		}
+		if d := attempts.LockedUntil.Sub(now); d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// fail records a failed login of given email address from given client ip.
+func (l *loginLimiter) fail(ctx context.Context, email, ip string, now time.Time) {
+	if l == nil {
+		return
+	}
+	key := l.emailKey(email)
+	switch failures := l.incr(ctx, key, now); {
+	case failures == 0:
+	case failures >= l.maxFailures:
+		l.lock(ctx, key, now.Add(l.duration), now)
+		l.recordLockout(ctx, lockoutScopeEmail, email)
+	case failures > l.freeAttempts:
+		l.lock(ctx, key, now.Add(l.delay(failures-l.freeAttempts)), now)
+	}
+	key = l.ipKey(ip)
+	if failures := l.incr(ctx, key, now); failures > 0 && failures >= l.maxIPFailures {
+		l.lock(ctx, key, now.Add(l.duration), now)
+		l.recordLockout(ctx, lockoutScopeIP, ip)
+	}
+}
+
+// reset clears failed logins of given email address after a successful login.
+// Failed logins of client ips are not cleared so that a valid account can not be used to reset them.
+func (l *loginLimiter) reset(ctx context.Context, email string) {
+	if l == nil {
+		return
+	}
+	if err := l.store.delete(ctx, l.emailKey(email)); err != nil {
+		logging.FromContext(ctx).Warnw("account.lockout failed to reset failed logins", "err", err)
+	}
+}
+
+// delay returns baseDelay * 2^(n-1) up to maxDelay.
+func (l *loginLimiter) delay(n int) time.Duration {
+	if n > 30 {
+		return l.maxDelay
+	}
+	d := l.baseDelay << uint(n-1)
+	if d > l.maxDelay || d <= 0 {
+		return l.maxDelay
+	}
+	return d
+}
+
+// incr increments failures of given key and returns the failures or zero if the store is unavailable.
+func (l *loginLimiter) incr(ctx context.Context, key string, now time.Time) int {
+	failures, err := l.store.incr(ctx, key, l.window, now)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("account.lockout failed to increment failed logins", "key", key, "err", err)
+		return 0
+	}
+	return failures
+}
+
+func (l *loginLimiter) lock(ctx context.Context, key string, until, now time.Time) {
+	if err := l.store.lock(ctx, key, until, now); err != nil {
+		logging.FromContext(ctx).Warnw("account.lockout failed to lock logins", "key", key, "err", err)
+	}
+}
+
+func (l *loginLimiter) recordLockout(ctx context.Context, scope, value string) {
+	logging.FromContext(ctx).Warnw("account.lockout locked logins", "scope", scope, scope, value)
+	if l.mp != nil {
+		l.mp.RecordLoginLockout(scope)
+	}
+}
+
+func (l *loginLimiter) emailKey(email string) string {
+	return "login-attempts:email:" + strings.ToLower(email)
+}
+
+func (l *loginLimiter) ipKey(ip string) string {
+	return "login-attempts:ip:" + ip
+}
+
+// memoryLoginAttemptStore counts failed logins in the memory of the process.
+type memoryLoginAttemptStore struct {
+	mu        sync.Mutex
+	attempts  map[string]*memoryLoginAttempts
+	lastSweep time.Time
+}
+
+type memoryLoginAttempts struct {
+	loginAttempts
+	expiresAt time.Time
+}
+
+func newMemoryLoginAttemptStore() *memoryLoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: make(map[string]*memoryLoginAttempts)}
+}
+
+func (m *memoryLoginAttemptStore) get(_ context.Context, key string, now time.Time) (loginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.attempts[key]; ok && now.Before(a.expiresAt) {
+		return a.loginAttempts, nil
+	}
+	return loginAttempts{}, nil
+}
+
+func (m *memoryLoginAttemptStore) incr(_ context.Context, key string, ttl time.Duration, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+	a, ok := m.attempts[key]
+	if !ok || !now.Before(a.expiresAt) {
+		a = &memoryLoginAttempts{}
+		m.attempts[key] = a
+	}
+	a.Failures++
+	if expiresAt := now.Add(ttl); expiresAt.After(a.expiresAt) {
+		a.expiresAt = expiresAt
+	}
+	return a.Failures, nil
+}
+
+func (m *memoryLoginAttemptStore) lock(_ context.Context, key string, until time.Time, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.attempts[key]
+	if !ok || !now.Before(a.expiresAt) {
+		a = &memoryLoginAttempts{}
+		m.attempts[key] = a
+	}
+	if until.After(a.LockedUntil) {
+		a.LockedUntil = until
+	}
+	if until.After(a.expiresAt) {
+		a.expiresAt = until
+	}
+	return nil
+}
+
+func (m *memoryLoginAttemptStore) delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
+
+// sweep removes expired failed logins at most once a minute. Must be called with the lock.
+func (m *memoryLoginAttemptStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, a := range m.attempts {
+		if !now.Before(a.expiresAt) {
+			delete(m.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// loginIncrScript increments failures of a key and extends the ttl of the key to at least ARGV[1] milliseconds.
+var loginIncrScript = redis.NewScript(`
+local failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return failures
+`)
+
+// loginLockScript sets the locked time of a key in unix milliseconds to ARGV[1] unless it is locked longer,
+// and extends the ttl of the key to at least ARGV[2] milliseconds.
+var loginLockScript = redis.NewScript(`
+local lockedUntil = tonumber(redis.call('HGET', KEYS[1], 'lockedUntil') or '0')
+if tonumber(ARGV[1]) > lockedUntil then
+	redis.call('HSET', KEYS[1], 'lockedUntil', ARGV[1])
+end
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// redisLoginAttemptStore counts failed logins in hashes of the redis shared between servers.
+type redisLoginAttemptStore struct {
+	cli    redis.UniversalClient
+	prefix string
+}
+
+func newRedisLoginAttemptStore(cli redis.UniversalClient, prefix string) *redisLoginAttemptStore {
+	return &redisLoginAttemptStore{cli: cli, prefix: prefix}
+}
+
+func (r *redisLoginAttemptStore) get(ctx context.Context, key string, _ time.Time) (loginAttempts, error) {
+	values, err := r.cli.HMGet(ctx, r.prefix+key, "failures", "lockedUntil").Result()
+	if err != nil {
+		return loginAttempts{}, err
+	}
+	var attempts loginAttempts
+	if v, ok := values[0].(string); ok {
+		if attempts.Failures, err = strconv.Atoi(v); err != nil {
+			return loginAttempts{}, fmt.Errorf("invalid failures of %s: %v", key, err)
+		}
+	}
+	if v, ok := values[1].(string); ok {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return loginAttempts{}, fmt.Errorf("invalid locked time of %s: %v", key, err)
+		}
+		attempts.LockedUntil = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	return attempts, nil
+}
+
+func (r *redisLoginAttemptStore) incr(ctx context.Context, key string, ttl time.Duration, _ time.Time) (int, error) {
+	failures, err := loginIncrScript.Run(ctx, r.cli, []string{r.prefix + key}, ttl.Milliseconds()).Int()
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (r *redisLoginAttemptStore) lock(ctx context.Context, key string, until time.Time, now time.Time) error {
+	ms := until.UnixNano() / int64(time.Millisecond)
+	return loginLockScript.Run(ctx, r.cli, []string{r.prefix + key}, ms, until.Sub(now).Milliseconds()).Err()
+}
+
+func (r *redisLoginAttemptStore) delete(ctx context.Context, key string) error {
+	return r.cli.Del(ctx, r.prefix+key).Err()
+}
@@ -0,0 +1,191 @@
+package account
+
+import (
+	"context"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoginLimiter_Memory(t *testing.T) {
+	testLoginLimiter(t, nil)
+}
+
+func TestLoginLimiter_Redis(t *testing.T) {
+	s := miniredis.RunT(t)
+	testLoginLimiter(t, newRedisCacher(t, s))
+	assert.NotEmpty(t, s.Keys())
+}
+
+func newRedisCacher(t *testing.T, s *miniredis.Miniredis) cache.Cacher {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.CacheConfig.Enabled = true
+	cfg.CacheConfig.RedisConfig.Endpoints = []string{s.Addr()}
+	cacher, err := cache.NewCacher(cfg)
+	assert.NoError(t, err)
+	t.Cleanup(func() { cacher.Close() })
+	return cacher
+}
+
+func testLoginLimiter(t *testing.T, cacher cache.Cacher) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.AccountConfig.Lockout.FreeAttempts = 2
+	cfg.AccountConfig.Lockout.BaseDelay = time.Second
+	cfg.AccountConfig.Lockout.MaxDelay = 3 * time.Second
+	cfg.AccountConfig.Lockout.MaxFailures = 5
+	cfg.AccountConfig.Lockout.MaxIPFailures = 7
+	cfg.AccountConfig.Lockout.Duration = 10 * time.Minute
+	ctx := context.Background()
+	// the redis stores locked times in milliseconds
+	now := time.Now().Truncate(time.Millisecond)
+
+	t.Run("Backoff and Lockout", func(t *testing.T) {
+		l := newLoginLimiter(cfg, cacher, nil)
+		expected := []time.Duration{0, 0, time.Second, 2 * time.Second, 10 * time.Minute}
+		for i, delay := range expected {
+			l.fail(ctx, "user1@email.com", "127.0.0.1", now)
+
+			err := l.check(ctx, "User1@email.com", "127.0.0.2", now)
+			if delay == 0 {
+				assert.NoError(t, err, "failures: %d", i+1)
+				continue
+			}
+			assert.Equal(t, &LoginLockedError{RetryAfter: delay}, err, "failures: %d", i+1)
+		}
+		assert.NoError(t, l.check(ctx, "user2@email.com", "127.0.0.1", now))
+		assert.NoError(t, l.check(ctx, "user1@email.com", "127.0.0.1", now.Add(10*time.Minute)))
+	})
+
+	t.Run("Lockout IP", func(t *testing.T) {
+		l := newLoginLimiter(cfg, cacher, nil)
+		for i := 0; i < 7; i++ {
+			l.fail(ctx, "user3@email.com", "127.0.0.3", now)
+			l.reset(ctx, "user3@email.com")
+		}
+
+		assert.Equal(t, &LoginLockedError{RetryAfter: 10 * time.Minute}, l.check(ctx, "user4@email.com", "127.0.0.3", now))
+		assert.NoError(t, l.check(ctx, "user4@email.com", "127.0.0.4", now))
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		l := newLoginLimiter(cfg, cacher, nil)
+		for i := 0; i < 3; i++ {
+			l.fail(ctx, "user5@email.com", "127.0.0.5", now)
+		}
+		assert.Error(t, l.check(ctx, "user5@email.com", "127.0.0.6", now))
+
+		l.reset(ctx, "user5@email.com")
+
+		assert.NoError(t, l.check(ctx, "user5@email.com", "127.0.0.6", now))
+	})
+
+	t.Run("Concurrent failures", func(t *testing.T) {
+		l := newLoginLimiter(cfg, cacher, nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l.fail(ctx, "user6@email.com", "127.0.0.7", now)
+			}()
+		}
+		wg.Wait()
+
+		for _, key := range []string{l.emailKey("user6@email.com"), l.ipKey("127.0.0.7")} {
+			attempts, err := l.store.get(ctx, key, now)
+			assert.NoError(t, err)
+			assert.Equal(t, 20, attempts.Failures)
+			assert.Equal(t, now.Add(10*time.Minute).UnixNano(), attempts.LockedUntil.UnixNano())
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		cfg.AccountConfig.Lockout.Enabled = false
+		defer func() { cfg.AccountConfig.Lockout.Enabled = true }()
+		l := newLoginLimiter(cfg, cacher, nil)
+		for i := 0; i < 10; i++ {
+			l.fail(ctx, "user1@email.com", "127.0.0.1", now)
+		}
+		assert.NoError(t, l.check(ctx, "user1@email.com", "127.0.0.1", now))
+	})
+}
+
+func (s *HandlerSuite) TestLogin_Lockout() {
+	// given
+	s.cfg.AccountConfig.Lockout.FreeAttempts = 0
+	s.cfg.AccountConfig.Lockout.MaxFailures = 2
+	s.setup(s.cfg)
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	login := func(password string) (int, string) {
+		res := s.doRequest("POST", "/v1/api/users/login", map[string]interface{}{
+			"user": map[string]interface{}{"email": acc.Email, "password": password},
+		})
+		return res.Code, res.Header().Get("Retry-After")
+	}
+
+	// when then: delayed after the first failure
+	code, _ := login("invalid-password")
+	s.Equal(http.StatusUnauthorized, code)
+	code, retryAfter := login(password)
+	s.Equal(http.StatusTooManyRequests, code)
+	s.Equal("1", retryAfter)
+
+	// when then: locked after max failures
+	s.handler.auth.timeFunc = func() time.Time { return time.Now().Add(time.Minute) }
+	code, _ = login("invalid-password")
+	s.Equal(http.StatusUnauthorized, code)
+	code, retryAfter = login(password)
+	s.Equal(http.StatusTooManyRequests, code)
+	s.Equal("900", retryAfter)
+
+	// when then: unlocked after the lockout duration
+	s.handler.auth.timeFunc = func() time.Time { return time.Now().Add(17 * time.Minute) }
+	code, _ = login(password)
+	s.Equal(http.StatusOK, code)
+}
+
+func (s *HandlerSuite) TestLogin_RateLimit() {
+	// given
+	s.cfg.AccountConfig.Lockout.Enabled = false
+	s.cfg.RateLimitConfig.Enabled = true
+	s.cfg.RateLimitConfig.Routes["login"] = config.RateLimitRule{Limit: 2, Window: time.Minute, Key: middleware.RateLimitKeyIP}
+	s.setup(s.cfg)
+	s.db.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, database.ErrNotFound)
+	login := func() *httptest.ResponseRecorder {
+		return s.doRequest("POST", "/v1/api/users/login", map[string]interface{}{
+			"user": map[string]interface{}{"email": "user1@gmail.com", "password": "password1"},
+		})
+	}
+
+	// when then: failed logins are counted
+	for i := 1; i >= 0; i-- {
+		res := login()
+		s.Equal(http.StatusUnauthorized, res.Code)
+		s.Equal("2", res.Header().Get(middleware.RateLimitLimitHeader))
+		s.Equal(strconv.Itoa(i), res.Header().Get(middleware.RateLimitRemainingHeader))
+	}
+
+	// when then: rejected over the limit before checking credentials
+	res := login()
+	s.Equal(http.StatusTooManyRequests, res.Code)
+	s.NotEmpty(res.Header().Get("Retry-After"))
+	s.JSONEq(`{"type":"about:blank","title":"Too many requests","status":429,"detail":"too many requests, please try again later","instance":"request-1","code":"TooManyRequests"}`, res.Body.String())
+	s.db.AssertNumberOfCalls(s.T(), "FindByEmail", 2)
+}
@@ -0,0 +1,52 @@
+package account
+
+import (
+	"fmt"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/mailer"
+	"net/url"
+)
+
+func newVerificationMail(acc *model.Account, linkFormat, token string) *mailer.Message {
+	link := fmt.Sprintf(linkFormat, url.QueryEscape(token))
+	return &mailer.Message{
+		To:      []string{acc.Email},
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please verify your email address by visiting the link below.\n\n%s\n\n"+
+			"If you did not sign up, please ignore this email.", acc.Username, link),
+	}
+}
+
+func newPasswordResetMail(acc *model.Account, linkFormat, token string) *mailer.Message {
+	link := fmt.Sprintf(linkFormat, url.QueryEscape(token))
+	return &mailer.Message{
+		To:      []string{acc.Email},
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset your password. You can set a new password by visiting the link below.\n\n%s\n\n"+
+			"If you did not request a password reset, please ignore this email.", acc.Username, link),
+	}
+}
+
+func newEmailChangeMail(acc *model.Account, newEmail, linkFormat, token string) *mailer.Message {
+	link := fmt.Sprintf(linkFormat, url.QueryEscape(token))
+	return &mailer.Message{
+		To:      []string{newEmail},
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm your new email address by visiting the link below.\n\n%s\n\n"+
+			"If you did not request to change your email address, please ignore this email.", acc.Username, link),
+	}
+}
+
+func newEmailChangeNoticeMail(acc *model.Account, newEmail string) *mailer.Message {
+	return &mailer.Message{
+		To:      []string{acc.Email},
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to change the email address of your account to %s. "+
+			"The change takes effect once the new email address is confirmed.\n\n"+
+			"If you did not request this change, please reset your password.", acc.Username, newEmail),
+	}
+}
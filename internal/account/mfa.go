@@ -0,0 +1,88 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/totp"
+	"strings"
+)
+
+const recoveryCodeLength = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns n random recovery codes formatted like "abcde-fghij" and their hashes
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, n)
+	hashes := make([]string, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b)[:recoveryCodeLength])
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode returns a sha256 digest of given code ignoring case, spaces and dashes.
+// Recovery codes have enough entropy, so a fast hash is used to look up codes by the hash.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyMFACode returns true if given code is a valid TOTP code of the account's secret
+// or an unused recovery code. A matched code is consumed.
+func (m *AuthMiddleware) VerifyMFACode(ctx context.Context, acc *model.Account, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return m.verifyTOTPCode(ctx, acc, code)
+	}
+	err := m.accountDB.UseRecoveryCode(ctx, acc.ID, hashRecoveryCode(code))
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// verifyTOTPCode returns true if given code is a valid TOTP code of the account's secret after the last accepted
+// time step of the account. The time step of a matched code is saved so that the code is not replayed.
+func (m *AuthMiddleware) verifyTOTPCode(ctx context.Context, acc *model.Account, code string) (bool, error) {
+	step, ok := totp.Validate(code, acc.MFASecret, m.timeFunc())
+	if !ok {
+		return false, nil
+	}
+	err := m.accountDB.UseMFAStep(ctx, acc.ID, step)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// requiresMFA returns true if accounts of given role must enable mfa.
+func (m *AuthMiddleware) requiresMFA(role string) bool {
+	if role == "" {
+		role = model.RoleUser
+	}
+	for _, r := range m.mfaRequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
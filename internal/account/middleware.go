@@ -1,21 +1,71 @@
 package account
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	accountDB "gin-rest-api-example/internal/account/database"
 	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/audit"
 	"gin-rest-api-example/internal/cache"
 	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/metric"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/jwks"
 	"gin-rest-api-example/pkg/logging"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var identityKey = "id"
 
+// authTimeKey is the key of the time when the current user logged in with the access token.
+const authTimeKey = "authTime"
+
+const (
+	realm         = "test zone"
+	tokenHeadName = "Bearer"
+)
+
+var (
+	ErrMissingLoginValues   = errors.New("missing Username or Password")
+	ErrFailedAuthentication = errors.New("incorrect Username or Password")
+	ErrFailedTokenCreation  = errors.New("failed to create JWT Token")
+	ErrEmptyAuthHeader      = errors.New("auth header is empty")
+	ErrInvalidAuthHeader    = errors.New("auth header is invalid")
+	ErrMissingExpField      = errors.New("missing exp field")
+	ErrForbidden            = errors.New("you don't have permission to access this resource")
+	ErrUnverifiedAccount    = errors.New("email address is not verified")
+	ErrInvalidMFACode       = errors.New("invalid mfa code")
+	ErrMFAEnrollment        = errors.New("two-factor authentication must be enabled")
+	ErrUnverifiedIdentity   = errors.New("email address is not verified by the provider")
+	ErrUnlinkableAccount    = errors.New("verify the email address of the existing account to link")
+	ErrInvalidAPIKey        = errors.New("api key is invalid")
+	ErrExpiredAPIKey        = errors.New("api key is expired")
+)
+
+// Actions which can be restricted to unverified accounts by "account.verification.restrictions" config.
+const (
+	RestrictLogin        = "login"
+	RestrictUpdateUser   = "user.update"
+	RestrictWriteArticle = "article.write"
+	RestrictWriteComment = "comment.write"
+)
+
+type signInMFA struct {
+	MFAToken string `form:"mfa_token" json:"mfa_token" binding:"required"`
+	Code     string `form:"code" json:"code" binding:"required"`
+}
+
 type signIn struct {
 	User struct {
 		Email    string `form:"email" json:"email" binding:"email"`
@@ -40,86 +90,527 @@ func MustCurrentUser(c *gin.Context) *model.Account {
 	panic("no account in gin.Context")
 }
 
-func NewAuthMiddleware(cfg *config.Config, accountDB accountDB.AccountDB) (*jwt.GinJWTMiddleware, error) {
-	return jwt.New(&jwt.GinJWTMiddleware{
-		Realm:       "test zone",
-		Key:         []byte(cfg.JwtConfig.Secret),
-		Timeout:     cfg.JwtConfig.SessionTime,
-		MaxRefresh:  time.Hour,
-		IdentityKey: identityKey,
-		PayloadFunc: func(data interface{}) jwt.MapClaims {
-			if v, ok := data.(*model.Account); ok {
-				return jwt.MapClaims{
-					identityKey: v.Email,
+// AuthTime returns the time when the current user logged in with the access token.
+// False is returned for anonymous requests and requests of api keys.
+func AuthTime(c *gin.Context) (time.Time, bool) {
+	data, ok := c.Get(authTimeKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := data.(time.Time)
+	return t, ok
+}
+
+// RestrictUnverified returns a gin.HandlerFunc that rejects the current user with 403 status code
+// if the email address is not verified and given action is restricted in configs.
+// Must be used after AuthMiddleware.MiddlewareFunc.
+func RestrictUnverified(cfg *config.Config, action string) gin.HandlerFunc {
+	restricted := isRestricted(cfg, action)
+	return func(c *gin.Context) {
+		if !restricted {
+			return
+		}
+		if acc, ok := CurrentUser(c); ok && !acc.EmailVerified {
+			handler.AbortWithError(c, http.StatusForbidden, &handler.ErrorResponse{
+				Code:    handler.UnverifiedAccount,
+				Message: ErrUnverifiedAccount.Error(),
+			})
+		}
+	}
+}
+
+// RequireRole returns a gin.HandlerFunc that rejects the current user with 403 status code
+// if the role of the account is not one of given roles.
+// Must be used after AuthMiddleware.MiddlewareFunc.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		acc, ok := CurrentUser(c)
+		if ok {
+			for _, role := range roles {
+				if acc.Role == role {
+					return
 				}
 			}
-			return jwt.MapClaims{}
-		},
-		IdentityHandler: func(c *gin.Context) interface{} {
-			claims := jwt.ExtractClaims(c)
-			email, ok := claims[identityKey].(string)
-			if !ok {
-				return nil
-			}
-			acc, err := accountDB.FindByEmail(c.Request.Context(), email)
-			if err != nil {
-				return nil
-			}
-			return acc
-		},
-		Authenticator: func(c *gin.Context) (interface{}, error) {
-			var req signIn
-			if err := c.ShouldBindJSON(&req); err != nil {
-				return "", jwt.ErrMissingLoginValues
-			}
+		}
+		handler.AbortWithError(c, http.StatusForbidden, &handler.ErrorResponse{
+			Code:    handler.InsufficientRole,
+			Message: ErrForbidden.Error(),
+		})
+	}
+}
 
-			ctx := cache.WithCacheSkip(c.Request.Context(), true)
-			acc, err := accountDB.FindByEmail(ctx, req.User.Email)
-			if err != nil || acc.Disabled {
-				return nil, jwt.ErrFailedAuthentication
-			}
-			err = MatchesPassword(acc.Password, req.User.Password)
+func isRestricted(cfg *config.Config, action string) bool {
+	for _, restriction := range cfg.AccountConfig.Verification.Restrictions {
+		if restriction == action {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthMiddleware issues jwt tokens to accounts and authenticates requests with the tokens.
+// Tokens are signed and verified by jwks.KeySet, so HS256 with a shared secret or
+// asymmetric keys with rotation are used depending on configs.
+//
+// Accounts with mfa enabled get a short-lived challenge token from LoginHandler instead of an access token,
+// and exchange the challenge token with a valid code at MFALoginHandler.
+//
+// Failed logins are tracked per email address and client ip, and logins are delayed or locked
+// by "account.lockout" configs. Logins and failed logins are recorded as audit events.
+type AuthMiddleware struct {
+	keys             *jwks.KeySet
+	timeout          time.Duration
+	restrictLogin    bool
+	mfaChallengeTTL  time.Duration
+	mfaRequiredRoles []string
+	emailIDCutover   time.Time
+	accountDB        accountDB.AccountDB
+	limiter          *loginLimiter
+	passwords        *PasswordEncoder
+	auditor          *audit.Auditor
+	timeFunc         func() time.Time
+}
+
+func NewAuthMiddleware(cfg *config.Config, accountDB accountDB.AccountDB, cacher cache.Cacher, mp *metric.MetricsProvider,
+	auditor *audit.Auditor) (*AuthMiddleware, error) {
+	var keys []*jwks.Key
+	for _, kc := range cfg.JwtConfig.Keys {
+		key, err := jwks.LoadKey(jwks.KeyConfig{
+			ID:             kc.ID,
+			Algorithm:      kc.Algorithm,
+			PrivateKeyFile: kc.PrivateKeyFile,
+			PublicKeyFile:  kc.PublicKeyFile,
+			Retired:        kc.Retired,
+		})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	keySet, err := jwks.NewKeySet(cfg.JwtConfig.Secret, cfg.JwtConfig.SigningKeyID, keys)
+	if err != nil {
+		return nil, err
+	}
+	passwords, err := NewPasswordEncoder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	timeout := cfg.JwtConfig.SessionTime
+	if timeout == 0 {
+		timeout = time.Hour
+	}
+	var emailIDCutover time.Time
+	if cfg.JwtConfig.AcceptEmailIdentity {
+		emailIDCutover, err = time.Parse(time.RFC3339, cfg.JwtConfig.EmailIdentityCutover)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt.emailIdentityCutover %q: %v", cfg.JwtConfig.EmailIdentityCutover, err)
+		}
+	}
+	return &AuthMiddleware{
+		keys:             keySet,
+		timeout:          timeout,
+		restrictLogin:    isRestricted(cfg, RestrictLogin),
+		mfaChallengeTTL:  cfg.AccountConfig.MFA.ChallengeTTL,
+		mfaRequiredRoles: cfg.AccountConfig.MFA.RequiredRoles,
+		emailIDCutover:   emailIDCutover,
+		accountDB:        accountDB,
+		limiter:          newLoginLimiter(cfg, cacher, mp),
+		passwords:        passwords,
+		auditor:          auditor,
+		timeFunc:         time.Now,
+	}, nil
+}
+
+// LoginHandler handles POST /v1/api/users/login
+func (m *AuthMiddleware) LoginHandler(c *gin.Context) {
+	acc, err := m.authenticate(c)
+	if err != nil {
+		if lockedErr, ok := err.(*LoginLockedError); ok {
+			m.tooManyAttempts(c, lockedErr)
+			return
+		}
+		code := http.StatusUnauthorized
+		if err == ErrUnverifiedAccount {
+			code = http.StatusForbidden
+		}
+		m.unauthorized(c, code, err.Error())
+		return
+	}
+	m.login(c, acc)
+}
+
+// login responds a token of given authenticated account, or a mfa challenge token if mfa is enabled.
+func (m *AuthMiddleware) login(c *gin.Context, acc *model.Account) {
+	if acc.MFAEnabled {
+		expire := m.timeFunc().Add(m.mfaChallengeTTL)
+		// the challenge is identified by the account id since the email address can be changed
+		token, err := m.actionToken(purposeMFAChallenge, strconv.FormatUint(uint64(acc.ID), 10), m.mfaChallengeTTL, nil)
+		if err != nil {
+			logging.FromContext(c).Errorw("middleware.jwt.LoginHandler failed to create a mfa challenge token", "err", err)
+			m.unauthorized(c, http.StatusUnauthorized, ErrFailedTokenCreation.Error())
+			return
+		}
+		c.JSON(http.StatusOK, &TokenResponse{
+			Code:        http.StatusOK,
+			MFARequired: true,
+			MFAToken:    token,
+			Expire:      expire,
+		})
+		return
+	}
+	m.limiter.reset(c.Request.Context(), acc.Email)
+	m.loginResponse(c, acc)
+}
+
+// MFALoginHandler handles POST /v1/api/users/login/mfa
+func (m *AuthMiddleware) MFALoginHandler(c *gin.Context) {
+	var req signInMFA
+	if err := c.ShouldBindJSON(&req); err != nil {
+		m.unauthorized(c, http.StatusUnauthorized, ErrMissingLoginValues.Error())
+		return
+	}
+	claims, err := m.parseActionToken(purposeMFAChallenge, req.MFAToken)
+	if err != nil {
+		m.unauthorized(c, http.StatusUnauthorized, ErrInvalidToken.Error())
+		return
+	}
+	sub, _ := claims["sub"].(string)
+	accountID, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil {
+		m.unauthorized(c, http.StatusUnauthorized, ErrInvalidToken.Error())
+		return
+	}
+	ctx := cache.WithCacheSkip(c.Request.Context(), true)
+	acc, err := m.accountDB.FindByID(ctx, uint(accountID))
+	if err != nil || acc.Disabled || !acc.MFAEnabled {
+		m.unauthorized(c, http.StatusUnauthorized, ErrFailedAuthentication.Error())
+		return
+	}
+	email := acc.Email
+	if err := m.limiter.check(c.Request.Context(), email, c.ClientIP(), m.timeFunc()); err != nil {
+		m.tooManyAttempts(c, err.(*LoginLockedError))
+		return
+	}
+	ok, err := m.VerifyMFACode(c.Request.Context(), acc, req.Code)
+	if err != nil {
+		logging.FromContext(c).Errorw("middleware.jwt.MFALoginHandler failed to verify a mfa code", "err", err)
+	}
+	if !ok {
+		m.limiter.fail(c.Request.Context(), email, c.ClientIP(), m.timeFunc())
+		m.auditLoginFailure(c, email)
+		m.unauthorized(c, http.StatusUnauthorized, ErrInvalidMFACode.Error())
+		return
+	}
+	m.limiter.reset(c.Request.Context(), email)
+	m.loginResponse(c, acc)
+}
+
+// MiddlewareFunc returns a gin.HandlerFunc that requires a valid token in the Authorization header
+// and stores the account of the token to the gin.Context.
+// Accounts of roles in "account.mfa.requiredRoles" config are rejected until mfa is enabled.
+func (m *AuthMiddleware) MiddlewareFunc() gin.HandlerFunc {
+	return m.middlewareFunc(true)
+}
+
+// MFAEnrollmentMiddlewareFunc is same as MiddlewareFunc except allowing accounts which must enable mfa
+// so that they can enroll mfa.
+func (m *AuthMiddleware) MFAEnrollmentMiddlewareFunc() gin.HandlerFunc {
+	return m.middlewareFunc(false)
+}
+
+// OptionalMiddlewareFunc is same as MiddlewareFunc except passing requests without the Authorization header
+// as anonymous, so that handlers serve both of anonymous and authenticated requests by CurrentUser.
+func (m *AuthMiddleware) OptionalMiddlewareFunc() gin.HandlerFunc {
+	authenticate := m.middlewareFunc(true)
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("Authorization") == "" {
+			return
+		}
+		authenticate(c)
+	}
+}
+
+func (m *AuthMiddleware) middlewareFunc(requireMFA bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.Request.Header.Get("Authorization")
+		if key, ok := apiKeyFromAuthorization(authHeader); ok {
+			acc, apiKey, err := m.apiKeyIdentity(c.Request.Context(), key)
 			if err != nil {
-				if err != bcrypt.ErrMismatchedHashAndPassword {
-					logging.FromContext(c).Warnw("middleware.jwt.Authenticator found unknown error when matches password", "err", err)
-				}
-				return nil, jwt.ErrFailedAuthentication
-			}
-			return &model.Account{
-				ID:        acc.ID,
-				Username:  acc.Username,
-				Email:     acc.Email,
-				Password:  "",
-				Bio:       acc.Bio,
-				Image:     acc.Image,
-				CreatedAt: acc.CreatedAt,
-				UpdatedAt: acc.UpdatedAt,
-				Disabled:  acc.Disabled,
-			}, nil
-		},
-		Authorizator: func(data interface{}, c *gin.Context) bool {
-			logging.FromContext(c).Info("middleware.jwt.Authorizator", "data", data)
-			if _, ok := data.(*model.Account); ok {
-				return true
+				m.unauthorized(c, http.StatusUnauthorized, err.Error())
+				return
 			}
-			return false
-		},
-		Unauthorized: func(c *gin.Context, code int, message string) {
-			logging.FromContext(c).Info("middleware.jwt.Unauthorized", "code", code, "message", message)
-			c.JSON(code, gin.H{
-				"code":    code,
-				"message": message,
-			})
-		},
-		LoginResponse: func(c *gin.Context, code int, token string, expire time.Time) {
-			c.JSON(http.StatusOK, gin.H{
-				"code":   code,
-				"token":  token,
-				"expire": expire,
-			})
-		},
-		TokenLookup:   "header: Authorization",
-		TokenHeadName: "Bearer",
-		TimeFunc:      time.Now,
+			m.setIdentity(c, acc, requireMFA)
+			c.Set(apiKeyCtxKey, apiKey)
+			middleware.SetClientIdentity(c, acc.ID, apiKey.ID)
+			return
+		}
+
+		claims, err := m.claimsFromAuthorization(authHeader)
+		if err != nil {
+			m.unauthorized(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if _, ok := claims["exp"].(float64); !ok {
+			m.unauthorized(c, http.StatusBadRequest, ErrMissingExpField.Error())
+			return
+		}
+		acc := m.identity(c.Request.Context(), claims)
+		if acc == nil {
+			m.unauthorized(c, http.StatusForbidden, ErrForbidden.Error())
+			return
+		}
+		if origIat, ok := claims["orig_iat"].(float64); ok {
+			c.Set(authTimeKey, time.Unix(int64(origIat), 0))
+		}
+		m.setIdentity(c, acc, requireMFA)
+	}
+}
+
+// setIdentity stores given account to the gin.Context or aborts if the account must enable mfa.
+func (m *AuthMiddleware) setIdentity(c *gin.Context, acc *model.Account, requireMFA bool) {
+	if requireMFA && !acc.MFAEnabled && m.requiresMFA(acc.Role) {
+		handler.AbortWithError(c, http.StatusForbidden, &handler.ErrorResponse{
+			Code:    handler.MFAEnrollmentRequired,
+			Message: ErrMFAEnrollment.Error(),
+		})
+		return
+	}
+	c.Set(identityKey, acc)
+	middleware.SetClientIdentity(c, acc.ID, 0)
+}
+
+// JWKSHandler handles GET /.well-known/jwks.json
+func (m *AuthMiddleware) JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, m.keys.JWKS())
+}
+
+// TokenGenerator returns a new signed token of given account and its expire time.
+// The account is identified by the id since the email address can be changed.
+func (m *AuthMiddleware) TokenGenerator(acc *model.Account) (string, time.Time, error) {
+	now := m.timeFunc()
+	expire := now.Add(m.timeout)
+	token, err := m.keys.Sign(jwt.MapClaims{
+		identityKey: acc.ID,
+		"exp":       expire.Unix(),
+		"orig_iat":  now.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expire, nil
+}
+
+func (m *AuthMiddleware) loginResponse(c *gin.Context, acc *model.Account) {
+	token, expire, err := m.TokenGenerator(acc)
+	if err != nil {
+		logging.FromContext(c).Errorw("middleware.jwt.LoginHandler failed to create a token", "err", err)
+		m.unauthorized(c, http.StatusUnauthorized, ErrFailedTokenCreation.Error())
+		return
+	}
+	res := TokenResponse{
+		Code:                  http.StatusOK,
+		Token:                 token,
+		Expire:                expire,
+		MFAEnrollmentRequired: !acc.MFAEnabled && m.requiresMFA(acc.Role),
+	}
+	m.auditor.Record(c, audit.Event{
+		Action:     audit.ActionLogin,
+		ActorID:    acc.ID,
+		TargetType: audit.TargetAccount,
+		TargetID:   strconv.FormatUint(uint64(acc.ID), 10),
+	})
+	c.JSON(http.StatusOK, &res)
+}
+
+// auditLoginFailure records a failed login of given email address by an anonymous actor.
+func (m *AuthMiddleware) auditLoginFailure(c *gin.Context, email string) {
+	m.auditor.Record(c, audit.Event{
+		Action:     audit.ActionLoginFailed,
+		TargetType: audit.TargetAccount,
+		TargetID:   email,
+	})
+}
+
+func (m *AuthMiddleware) authenticate(c *gin.Context) (*model.Account, error) {
+	var req signIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, ErrMissingLoginValues
+	}
+	if err := m.limiter.check(c.Request.Context(), req.User.Email, c.ClientIP(), m.timeFunc()); err != nil {
+		return nil, err
+	}
+
+	ctx := cache.WithCacheSkip(c.Request.Context(), true)
+	acc, err := m.accountDB.FindByEmail(ctx, req.User.Email)
+	if err != nil || acc.Disabled {
+		m.limiter.fail(c.Request.Context(), req.User.Email, c.ClientIP(), m.timeFunc())
+		m.auditLoginFailure(c, req.User.Email)
+		return nil, ErrFailedAuthentication
+	}
+	err = MatchesPassword(acc.Password, req.User.Password)
+	if err != nil {
+		if err != bcrypt.ErrMismatchedHashAndPassword {
+			logging.FromContext(c).Warnw("middleware.jwt.Authenticator found unknown error when matches password", "err", err)
+		}
+		m.limiter.fail(c.Request.Context(), req.User.Email, c.ClientIP(), m.timeFunc())
+		m.auditLoginFailure(c, req.User.Email)
+		return nil, ErrFailedAuthentication
+	}
+	if m.passwords.NeedsRehash(acc.Password) {
+		m.rehashPassword(c, acc.Email, req.User.Password)
+	}
+	if m.restrictLogin && !acc.EmailVerified {
+		return nil, ErrUnverifiedAccount
+	}
+	return &model.Account{
+		ID:            acc.ID,
+		Username:      acc.Username,
+		Email:         acc.Email,
+		Password:      "",
+		Bio:           acc.Bio,
+		Image:         acc.Image,
+		CreatedAt:     acc.CreatedAt,
+		UpdatedAt:     acc.UpdatedAt,
+		Disabled:      acc.Disabled,
+		EmailVerified: acc.EmailVerified,
+		Role:          acc.Role,
+		MFAEnabled:    acc.MFAEnabled,
+	}, nil
+}
+
+// rehashPassword upgrades the password hash of given account encoded by an outdated algorithm or params.
+// Failures are ignored since the login is succeeded already.
+func (m *AuthMiddleware) rehashPassword(c *gin.Context, email, password string) {
+	logger := logging.FromContext(c)
+	hash, err := m.passwords.Encode(password)
+	if err != nil {
+		logger.Warnw("middleware.jwt.Authenticator failed to rehash password", "err", err)
+		return
+	}
+	if err := m.accountDB.Update(c.Request.Context(), email, &model.Account{Password: hash}); err != nil {
+		logger.Warnw("middleware.jwt.Authenticator failed to update the rehashed password", "err", err)
+	}
+}
+
+// claimsFromAuthorization returns claims of the access token of given "Bearer <token>" authorization.
+func (m *AuthMiddleware) claimsFromAuthorization(authHeader string) (jwt.MapClaims, error) {
+	if authHeader == "" {
+		return nil, ErrEmptyAuthHeader
+	}
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == tokenHeadName) {
+		return nil, ErrInvalidAuthHeader
+	}
+	token, err := m.keys.Parse(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	// action tokens e.g. email verification are not access tokens
+	if _, ok := claims[purposeClaim]; ok {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// identity returns the account of given claims identified by the account id,
+// or the email address of tokens issued before if "jwt.acceptEmailIdentity" config is enabled.
+// Tokens identified by email addresses are rejected if the session started after "jwt.emailIdentityCutover",
+// and all of them are rejected after the session time since the cutover.
+func (m *AuthMiddleware) identity(ctx context.Context, claims jwt.MapClaims) *model.Account {
+	var (
+		acc *model.Account
+		err error
+	)
+	switch id := claims[identityKey].(type) {
+	case float64:
+		acc, err = m.accountDB.FindByID(ctx, uint(id))
+	case string:
+		if !m.acceptEmailIdentity(claims) {
+			return nil
+		}
+		acc, err = m.accountDB.FindByEmail(ctx, id)
+	default:
+		return nil
+	}
+	if err != nil || acc.Disabled {
+		return nil
+	}
+	return acc
+}
+
+// acceptEmailIdentity returns true if given claims identifying an account by the email address were
+// issued by older versions before the cutover, and the cutover is within the session time.
+func (m *AuthMiddleware) acceptEmailIdentity(claims jwt.MapClaims) bool {
+	if m.emailIDCutover.IsZero() || !m.timeFunc().Before(m.emailIDCutover.Add(m.timeout)) {
+		return false
+	}
+	origIat, ok := claims["orig_iat"].(float64)
+	return ok && time.Unix(int64(origIat), 0).Before(m.emailIDCutover)
+}
+
+// apiKeyFromAuthorization returns the raw api key of given "ApiKey <key>" authorization.
+func apiKeyFromAuthorization(authHeader string) (string, bool) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != apiKeyHeadName {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// apiKeyIdentity returns the account and the api key of given raw api key.
+func (m *AuthMiddleware) apiKeyIdentity(ctx context.Context, key string) (*model.Account, *model.APIKey, error) {
+	logger := logging.FromContext(ctx)
+	apiKey, err := m.accountDB.FindAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if !database.IsRecordNotFoundErr(err) {
+			logger.Errorw("middleware.jwt.apiKeyIdentity failed to find an api key", "err", err)
+		}
+		return nil, nil, ErrInvalidAPIKey
+	}
+	now := m.timeFunc()
+	if !now.Before(apiKey.ExpiresAt) {
+		return nil, nil, ErrExpiredAPIKey
+	}
+	acc, err := m.accountDB.FindByID(ctx, apiKey.AccountID)
+	if err != nil || acc.Disabled {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	// the last used time is updated at most once a minute
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > time.Minute {
+		if err := m.accountDB.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+			logger.Warnw("middleware.jwt.apiKeyIdentity failed to update last used time", "err", err)
+		}
+	}
+	acc.Password = ""
+	acc.MFASecret = ""
+	return acc, apiKey, nil
+}
+
+// tooManyAttempts responds 429 status code with Retry-After header if logins are locked.
+func (m *AuthMiddleware) tooManyAttempts(c *gin.Context, err *LoginLockedError) {
+	logging.FromContext(c).Infow("middleware.jwt.TooManyAttempts", "retryAfter", err.RetryAfter)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	handler.AbortWithError(c, http.StatusTooManyRequests, &handler.ErrorResponse{
+		Code:    handler.TooManyRequests,
+		Message: err.Error(),
+	})
+}
+
+// unauthorized responds given status code with WWW-Authenticate header if the authentication is failed.
+func (m *AuthMiddleware) unauthorized(c *gin.Context, code int, message string) {
+	logging.FromContext(c).Infow("middleware.jwt.Unauthorized", "code", code, "message", message)
+	c.Header("WWW-Authenticate", "JWT realm="+realm)
+	errorCode := handler.Unauthorized
+	switch code {
+	case http.StatusBadRequest:
+		errorCode = handler.InvalidToken
+	case http.StatusForbidden:
+		errorCode = handler.Forbidden
+	}
+	handler.AbortWithError(c, code, &handler.ErrorResponse{
+		Code:    errorCode,
+		Message: message,
 	})
 }
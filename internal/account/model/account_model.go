@@ -3,24 +3,102 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type Account struct {
+	ID            uint       `gorm:"column:id"`
+	Username      string     `gorm:"column:username"`
+	Email         string     `gorm:"column:email"`
+	Password      string     `gorm:"column:password"`
+	Bio           string     `gorm:"column:bio"`
+	Image         string     `gorm:"column:image"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at"`
+	Disabled      bool       `gorm:"column:disabled"`
+	EmailVerified bool       `gorm:"column:email_verified"`
+	Role          string     `gorm:"column:role;default:user"`
+	MFASecret     string     `gorm:"column:mfa_secret"`
+	MFAEnabled    bool       `gorm:"column:mfa_enabled"`
+	DeletedAt     *time.Time `gorm:"column:deleted_at"`
+}
+
+// Roles of accounts
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// RecoveryCode is a hashed one-time code to sign in an account when the authenticator app is unavailable
+type RecoveryCode struct {
+	ID        uint       `gorm:"column:id"`
+	AccountID uint       `gorm:"column:account_id"`
+	CodeHash  string     `gorm:"column:code_hash"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "account_recovery_codes"
+}
+
+// AccountIdentity links an account to a user of an external identity provider e.g. GitHub
+type AccountIdentity struct {
 	ID        uint      `gorm:"column:id"`
-	Username  string    `gorm:"column:username"`
+	AccountID uint      `gorm:"column:account_id"`
+	Provider  string    `gorm:"column:provider"`
+	Subject   string    `gorm:"column:subject"`
 	Email     string    `gorm:"column:email"`
-	Password  string    `gorm:"column:password"`
-	Bio       string    `gorm:"column:bio"`
-	Image     string    `gorm:"column:image"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
-	Disabled  bool      `gorm:"column:disabled"`
+}
+
+func (AccountIdentity) TableName() string {
+	return "account_identities"
+}
+
+// APIKey is a hashed personal api key of an account for machine clients.
+// Scopes are stored as a comma separated string.
+type APIKey struct {
+	ID         uint       `gorm:"column:id"`
+	AccountID  uint       `gorm:"column:account_id"`
+	Name       string     `gorm:"column:name"`
+	Prefix     string     `gorm:"column:prefix"`
+	KeyHash    string     `gorm:"column:key_hash"`
+	Scopes     string     `gorm:"column:scopes"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns scopes of the key
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope returns true if the key has given scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 func (a Account) String() string {
-	return fmt.Sprintf("Account{id:%d, username:%s, password:%s, bio:%s, image:%s, createdAt:%v, updatedAt:%v, disabled:%v",
-		a.ID, a.Username, "[PROTECTED]", a.Bio, a.Image, a.CreatedAt, a.UpdatedAt, a.Disabled)
+	return fmt.Sprintf("Account{id:%d, username:%s, password:%s, bio:%s, image:%s, createdAt:%v, updatedAt:%v, disabled:%v, emailVerified:%v, role:%s, mfaEnabled:%v",
+		a.ID, a.Username, "[PROTECTED]", a.Bio, a.Image, a.CreatedAt, a.UpdatedAt, a.Disabled, a.EmailVerified, a.Role, a.MFAEnabled)
 }
 
 func (a *Account) UnmarshalJSON(b []byte) error {
@@ -0,0 +1,74 @@
+package account
+
+import (
+	"net/http"
+
+	"gin-rest-api-example/pkg/jwks"
+	"gin-rest-api-example/pkg/openapi"
+)
+
+// oauthCallbackQuery is query parameters of GET /v1/api/auth/:provider/callback
+type oauthCallbackQuery struct {
+	Code  string `form:"code"`
+	State string `form:"state" binding:"required"`
+	Error string `form:"error"`
+}
+
+// OpenAPIRoutes returns the documentation of routes registered by RouteV1.
+func OpenAPIRoutes() []openapi.Route {
+	var (
+		tags       = []string{"account"}
+		tokenAuth  = []string{openapi.BearerAuth}
+		anyAuth    = []string{openapi.BearerAuth, openapi.APIKeyAuth}
+		ok         = func(body interface{}) map[int]interface{} { return map[int]interface{}{http.StatusOK: body} }
+		accepted   = map[int]interface{}{http.StatusAccepted: nil}
+		userResult = ok(&UserResponse{})
+	)
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/.well-known/jwks.json", Summary: "Get public keys verifying access tokens", Tags: tags,
+			Responses: ok(&jwks.JSONWebKeySet{})},
+		// anonymous
+		{Method: http.MethodPost, Path: "/v1/api/users/login", Summary: "Sign in", Tags: tags,
+			Request: &signIn{}, Responses: ok(&TokenResponse{})},
+		{Method: http.MethodPost, Path: "/v1/api/users/login/mfa", Summary: "Sign in with a MFA code", Tags: tags,
+			Request: &signInMFA{}, Responses: ok(&TokenResponse{})},
+		{Method: http.MethodPost, Path: "/v1/api/users", Summary: "Sign up", Tags: tags,
+			Request: &SignUpRequest{}, Responses: map[int]interface{}{http.StatusCreated: &UserResponse{}}},
+		{Method: http.MethodPost, Path: "/v1/api/users/verify", Summary: "Verify an email address", Tags: tags,
+			Request: &VerifyEmailRequest{}, Responses: userResult},
+		{Method: http.MethodPost, Path: "/v1/api/users/password/forgot", Summary: "Send a password reset mail", Tags: tags,
+			Request: &ForgotPasswordRequest{}, Responses: accepted},
+		{Method: http.MethodPost, Path: "/v1/api/users/password/reset", Summary: "Reset a password", Tags: tags,
+			Request: &ResetPasswordRequest{}, Responses: userResult},
+		{Method: http.MethodPost, Path: "/v1/api/users/email/confirm", Summary: "Confirm an email address change", Tags: tags,
+			Request: &ConfirmEmailChangeRequest{}, Responses: userResult},
+		{Method: http.MethodGet, Path: "/v1/api/auth/:provider/login", Summary: "Redirect to an OAuth provider", Tags: tags,
+			Responses: map[int]interface{}{http.StatusFound: nil}},
+		{Method: http.MethodGet, Path: "/v1/api/auth/:provider/callback", Summary: "Sign in with an OAuth provider", Tags: tags,
+			Query: &oauthCallbackQuery{}, Responses: ok(&TokenResponse{})},
+		// mfa
+		{Method: http.MethodPost, Path: "/v1/api/user/mfa/enroll", Summary: "Enroll a MFA secret", Tags: tags, Security: tokenAuth,
+			Responses: ok(&MFAEnrollResponse{})},
+		{Method: http.MethodPost, Path: "/v1/api/user/mfa/verify", Summary: "Enable MFA", Tags: tags, Security: tokenAuth,
+			Request: &MFACodeRequest{}, Responses: ok(&MFARecoveryCodesResponse{})},
+		{Method: http.MethodPost, Path: "/v1/api/user/mfa/disable", Summary: "Disable MFA", Tags: tags, Security: tokenAuth,
+			Request: &MFACodeRequest{}, Responses: userResult},
+		// auth required
+		{Method: http.MethodGet, Path: "/v1/api/user/me", Summary: "Get the current user", Tags: tags, Security: anyAuth,
+			Responses: userResult},
+		{Method: http.MethodPut, Path: "/v1/api/user", Summary: "Update the current user", Tags: tags, Security: anyAuth,
+			Request: &UpdateUserRequest{}, Responses: userResult},
+		{Method: http.MethodPost, Path: "/v1/api/user/api-keys", Summary: "Create an api key", Tags: tags, Security: tokenAuth,
+			Request: &SaveAPIKeyRequest{}, Responses: map[int]interface{}{http.StatusCreated: &APIKeyResponse{}}},
+		{Method: http.MethodGet, Path: "/v1/api/user/api-keys", Summary: "List api keys", Tags: tags, Security: tokenAuth,
+			Responses: ok(&APIKeysResponse{})},
+		{Method: http.MethodGet, Path: "/v1/api/user/api-keys/:id", Summary: "Get an api key", Tags: tags, Security: tokenAuth,
+			Responses: ok(&APIKeyResponse{})},
+		{Method: http.MethodPut, Path: "/v1/api/user/api-keys/:id", Summary: "Update an api key", Tags: tags, Security: tokenAuth,
+			Request: &UpdateAPIKeyRequest{}, Responses: ok(&APIKeyResponse{})},
+		{Method: http.MethodDelete, Path: "/v1/api/user/api-keys/:id", Summary: "Revoke an api key", Tags: tags, Security: tokenAuth,
+			Responses: ok(nil)},
+		{Method: http.MethodPost, Path: "/v1/api/user/email", Summary: "Request an email address change", Tags: tags, Security: tokenAuth,
+			Request: &ChangeEmailRequest{}, Responses: accepted},
+	}
+}
@@ -0,0 +1,19 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/openapi"
+)
+
+func (s *HandlerSuite) TestOpenAPIRoutes() {
+	spec := openapi.NewSpec(openapi.Info{Title: "test", Version: "v1"}, handler.Problem{})
+	spec.Add(OpenAPIRoutes()...)
+
+	// then registered routes are documented
+	routes := s.r.Routes()
+	for _, r := range routes {
+		s.Truef(spec.Has(r.Method, r.Path), "route %s %s is missing in the OpenAPI document", r.Method, r.Path)
+	}
+	// then documented routes are registered
+	s.Len(OpenAPIRoutes(), len(routes))
+}
@@ -0,0 +1,75 @@
+package account
+
+import (
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/breached"
+	"gin-rest-api-example/pkg/validate"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy checks new passwords with the minimum length, character classes and
+// breached passwords by "account.password" configs.
+type PasswordPolicy struct {
+	minLength      int
+	minCharClasses int
+	breached       *breached.List
+}
+
+func NewPasswordPolicy(cfg *config.Config) (*PasswordPolicy, error) {
+	conf := cfg.AccountConfig.Password
+	policy := PasswordPolicy{
+		minLength:      conf.MinLength,
+		minCharClasses: conf.MinCharClasses,
+	}
+	if conf.BreachedListFile != "" {
+		list, err := breached.Open(conf.BreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = list
+	}
+	return &policy, nil
+}
+
+// Check returns validation error details of given field if the password violates the policy.
+// Messages of the details are localized by validate.Localize.
+func (p *PasswordPolicy) Check(field, password string) ([]*validate.ValidationErrDetail, error) {
+	var details []*validate.ValidationErrDetail
+	if utf8.RuneCountInString(password) < p.minLength {
+		details = append(details, validate.NewTagErrorDetail(field, "min", strconv.Itoa(p.minLength), password))
+	}
+	if charClasses(password) < p.minCharClasses {
+		details = append(details, validate.NewTagErrorDetail(field, "charclasses", strconv.Itoa(p.minCharClasses), password))
+	}
+	if len(details) != 0 || p.breached == nil {
+		return details, nil
+	}
+	found, err := p.breached.Contains(password)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		details = []*validate.ValidationErrDetail{validate.NewTagErrorDetail(field, "breached", "", password)}
+	}
+	return details, nil
+}
+
+// charClasses returns the number of lowercase, uppercase, digit and symbol classes in given password.
+func charClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
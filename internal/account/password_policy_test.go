@@ -0,0 +1,152 @@
+package account
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/i18n"
+	"gin-rest-api-example/pkg/validate"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	sum := sha1.Sum([]byte("Password1!"))
+	file, err := ioutil.TempFile(os.TempDir(), "breached-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(strings.ToUpper(hex.EncodeToString(sum[:])) + ":100\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.AccountConfig.Password.MinLength = 8
+	cfg.AccountConfig.Password.MinCharClasses = 3
+	cfg.AccountConfig.Password.BreachedListFile = file.Name()
+	policy, err := NewPasswordPolicy(cfg)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		Password string
+		Messages []string
+	}{
+		{Password: "Pass1!", Messages: []string{"password required at least 8 length"}},
+		{Password: "password", Messages: []string{"password required at least 3 of lowercase, uppercase, digit and symbol characters"}},
+		{Password: "pass", Messages: []string{"password required at least 8 length", "password required at least 3 of lowercase, uppercase, digit and symbol characters"}},
+		{Password: "Password1!", Messages: []string{"password is found in breached passwords"}},
+		{Password: "Password12"},
+		{Password: "비밀번호abc12"},
+	}
+
+	for _, tc := range cases {
+		// when
+		details, err := policy.Check("password", tc.Password)
+
+		// then
+		assert.NoError(t, err)
+		var messages []string
+		for _, detail := range details {
+			messages = append(messages, detail.Message)
+		}
+		assert.Equal(t, tc.Messages, messages, tc.Password)
+	}
+}
+
+func TestPolicies_Localize(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.AccountConfig.Password.MinLength = 8
+	cfg.AccountConfig.Password.MinCharClasses = 3
+	passwordPolicy, err := NewPasswordPolicy(cfg)
+	assert.NoError(t, err)
+	usernamePolicy := NewUsernamePolicy(cfg)
+	l := i18n.DefaultCatalog().Localizer("ko")
+
+	// when
+	passwordDetails, err := passwordPolicy.Check("password", "pass")
+	assert.NoError(t, err)
+	usernameDetails := usernamePolicy.Check("username", "admin")
+
+	// then
+	passwordDetails = validate.Localize(l, passwordDetails)
+	assert.Len(t, passwordDetails, 2)
+	assert.Equal(t, "password은(는) 최소 8자 이상이어야 합니다", passwordDetails[0].Message)
+	assert.Equal(t, "password은(는) 영문 소문자, 대문자, 숫자, 기호 중 최소 3종류를 포함해야 합니다", passwordDetails[1].Message)
+	usernameDetails = validate.Localize(l, usernameDetails)
+	assert.Len(t, usernameDetails, 1)
+	assert.Equal(t, "username은(는) 예약되어 있습니다", usernameDetails[0].Message)
+}
+
+func TestNewPasswordPolicy_FailIfNotExistBreachedList(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.AccountConfig.Password.BreachedListFile = "not-exist-file"
+
+	_, err = NewPasswordPolicy(cfg)
+
+	assert.Error(t, err)
+}
+
+func (s *HandlerSuite) TestLogin_RehashPassword() {
+	// given
+	s.cfg.AccountConfig.Password.Algorithm = AlgorithmArgon2id
+	s.cfg.AccountConfig.Password.Argon2.Memory = 1024
+	s.setup(s.cfg)
+	password := "password1"
+	encodedPassword, _ := EncodePassword(password)
+	acc := &model.Account{ID: 1, Username: "user1", Email: "user1@gmail.com", Password: encodedPassword}
+	s.db.On("FindByEmail", mock.Anything, acc.Email).Return(acc, nil)
+	s.db.On("Update", mock.Anything, acc.Email, mock.Anything).Return(nil)
+
+	// when
+	res := s.doRequest("POST", "/v1/api/users/login", map[string]interface{}{
+		"user": map[string]interface{}{"email": acc.Email, "password": password},
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.NotEmpty(gjson.Get(res.Body.String(), "token").String())
+	s.db.AssertCalled(s.T(), "Update", mock.Anything, acc.Email, mock.MatchedBy(func(a *model.Account) bool {
+		return strings.HasPrefix(a.Password, "$argon2id$") && MatchesPassword(a.Password, password) == nil
+	}))
+}
+
+func (s *HandlerSuite) TestRegister_PasswordPolicy() {
+	// given
+	s.cfg.AccountConfig.Password.MinCharClasses = 2
+	s.setup(s.cfg)
+
+	// when
+	res := s.doRequest("POST", "/v1/api/users", map[string]interface{}{
+		"user": map[string]interface{}{"username": "user1", "email": "user1@gmail.com", "password": "password"},
+	})
+
+	// then
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.JSONEq(`
+	{
+	  "type": "about:blank",
+	  "title": "Invalid request body",
+	  "status": 400,
+	  "detail": "invalid user request in body",
+	  "instance": "request-1",
+	  "code": "InvalidBodyValue",
+	  "errors": [
+		{
+		  "field": "password",
+		  "value": "password",
+		  "message": "password required at least 2 of lowercase, uppercase, digit and symbol characters"
+		}
+	  ]
+	}`, res.Body.String())
+	s.db.AssertNotCalled(s.T(), "Save", mock.Anything, mock.Anything)
+}
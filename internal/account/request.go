@@ -0,0 +1,80 @@
+package account
+
+import (
+	"time"
+)
+
+// SignUpRequest is a request body of POST /v1/api/users
+type SignUpRequest struct {
+	User struct {
+		Username string `json:"username" binding:"required,username"`
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	} `json:"user"`
+}
+
+// UpdateUserRequest is a request body of PUT /v1/api/user
+type UpdateUserRequest struct {
+	User struct {
+		Username string `json:"username" binding:"omitempty,username"`
+		Password string `json:"password" binding:"omitempty"`
+		Bio      string `json:"bio"`
+		Image    string `json:"image" binding:"omitempty,httpurl"`
+	} `json:"user"`
+}
+
+// VerifyEmailRequest is a request body of POST /v1/api/users/verify
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest is a request body of POST /v1/api/users/password/forgot
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest is a request body of POST /v1/api/users/password/reset
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangeEmailRequest is a request body of POST /v1/api/user/email
+type ChangeEmailRequest struct {
+	User struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	} `json:"user"`
+}
+
+// ConfirmEmailChangeRequest is a request body of POST /v1/api/users/email/confirm
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// MFACodeRequest is a request body of two-factor codes of POST /v1/api/user/mfa/verify and disable
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// APIKeyURI is path parameters of /v1/api/user/api-keys/:id
+type APIKeyURI struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+// SaveAPIKeyRequest is a request body of POST /v1/api/user/api-keys
+type SaveAPIKeyRequest struct {
+	APIKey struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	} `json:"apiKey"`
+}
+
+// UpdateAPIKeyRequest is a request body of PUT /v1/api/user/api-keys/:id
+type UpdateAPIKeyRequest struct {
+	APIKey struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	} `json:"apiKey"`
+}
@@ -1,6 +1,9 @@
 package account
 
-import "gin-rest-api-example/internal/account/model"
+import (
+	"gin-rest-api-example/internal/account/model"
+	"time"
+)
 
 type UserResponse struct {
 	User User `json:"user"`
@@ -23,3 +26,58 @@ func NewUserResponse(acc *model.Account) *UserResponse {
 		},
 	}
 }
+
+// TokenResponse is a response of logins with an access token, or a challenge token of two-factor login
+// if MFARequired is true.
+type TokenResponse struct {
+	Code                  int       `json:"code"`
+	Token                 string    `json:"token,omitempty"`
+	Expire                time.Time `json:"expire"`
+	MFARequired           bool      `json:"mfa_required,omitempty"`
+	MFAToken              string    `json:"mfa_token,omitempty"`
+	MFAEnrollmentRequired bool      `json:"mfa_enrollment_required,omitempty"`
+}
+
+type APIKeyResponse struct {
+	APIKey APIKey `json:"apiKey"`
+}
+
+type APIKeysResponse struct {
+	APIKeys      []APIKey `json:"apiKeys"`
+	APIKeysCount int      `json:"apiKeysCount"`
+}
+
+// APIKey is an api key. Key is the raw api key which is returned only once at creation.
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func NewAPIKeyResponse(key *model.APIKey, rawKey string) *APIKeyResponse {
+	return &APIKeyResponse{
+		APIKey: APIKey{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.ScopeList(),
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			CreatedAt:  key.CreatedAt,
+			Key:        rawKey,
+		},
+	}
+}
+
+func NewAPIKeysResponse(keys []*model.APIKey) *APIKeysResponse {
+	res := APIKeysResponse{APIKeys: []APIKey{}, APIKeysCount: len(keys)}
+	for _, key := range keys {
+		res.APIKeys = append(res.APIKeys, NewAPIKeyResponse(key, "").APIKey)
+	}
+	return &res
+}
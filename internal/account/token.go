@@ -0,0 +1,65 @@
+package account
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	purposeClaim = "purpose"
+
+	purposeVerifyEmail   = "verify_email"
+	purposeResetPassword = "reset_password"
+	purposeMFAChallenge  = "mfa_challenge"
+	purposeOAuthState    = "oauth_state"
+	purposeChangeEmail   = "change_email"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+)
+
+// actionToken returns a signed token to confirm given purpose of the subject e.g. email verification of the email owner.
+// Action tokens are signed with the same keys of access tokens but rejected by MiddlewareFunc.
+func (m *AuthMiddleware) actionToken(purpose, subject string, ttl time.Duration, extra jwt.MapClaims) (string, error) {
+	now := m.timeFunc()
+	claims := jwt.MapClaims{
+		purposeClaim: purpose,
+		"sub":        subject,
+		"iat":        now.Unix(),
+		"exp":        now.Add(ttl).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return m.keys.Sign(claims)
+}
+
+// parseActionToken returns claims of given token if the token is valid and has given purpose.
+func (m *AuthMiddleware) parseActionToken(purpose, token string) (jwt.MapClaims, error) {
+	parsed, err := m.keys.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	if p, _ := claims[purposeClaim].(string); p != purpose {
+		return nil, ErrInvalidToken
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return nil, ErrMissingExpField
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// passwordFingerprint returns a short digest of a hashed password to make password reset tokens single use.
+func passwordFingerprint(hashedPassword string) string {
+	sum := sha256.Sum256([]byte(hashedPassword))
+	return hex.EncodeToString(sum[:8])
+}
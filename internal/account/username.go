@@ -0,0 +1,85 @@
+package account
+
+import (
+	accountDB "gin-rest-api-example/internal/account/database"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/validate"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// UsernamePolicy checks new usernames with the length, allowed characters and reserved names
+// by "account.username" configs.
+type UsernamePolicy struct {
+	minLength int
+	maxLength int
+	reserved  map[string]struct{}
+}
+
+func NewUsernamePolicy(cfg *config.Config) *UsernamePolicy {
+	conf := cfg.AccountConfig.Username
+	policy := UsernamePolicy{
+		minLength: conf.MinLength,
+		maxLength: conf.MaxLength,
+		reserved:  make(map[string]struct{}, len(conf.Reserved)),
+	}
+	for _, name := range conf.Reserved {
+		policy.reserved[strings.ToLower(name)] = struct{}{}
+	}
+	return &policy
+}
+
+// Check returns validation error details of given field if the username violates the policy.
+// Messages of the details are localized by validate.Localize.
+func (p *UsernamePolicy) Check(field, username string) []*validate.ValidationErrDetail {
+	var detail *validate.ValidationErrDetail
+	switch {
+	case len(username) < p.minLength:
+		detail = validate.NewTagErrorDetail(field, "min", strconv.Itoa(p.minLength), username)
+	case len(username) > p.maxLength:
+		detail = validate.NewTagErrorDetail(field, "max", strconv.Itoa(p.maxLength), username)
+	case !validate.IsUsername(username):
+		detail = validate.NewTagErrorDetail(field, "username", "", username)
+	case p.isReserved(username):
+		detail = validate.NewTagErrorDetail(field, "reserved", "", username)
+	default:
+		return nil
+	}
+	return []*validate.ValidationErrDetail{detail}
+}
+
+// Sanitize returns a username satisfying the policy derived from given name.
+// The returned username can be used by another account already.
+func (p *UsernamePolicy) Sanitize(name string) string {
+	username := usernameInvalidChars.ReplaceAllString(name, "-")
+	username = strings.TrimLeft(username, "._-")
+	if len(username) > p.maxLength {
+		username = username[:p.maxLength]
+	}
+	if len(username) < p.minLength || p.isReserved(username) {
+		username = "user-" + username
+		if len(username) > p.maxLength {
+			username = username[:p.maxLength]
+		}
+	}
+	return username
+}
+
+// WithSuffix returns given username followed by '-' and the suffix within the maximum length.
+func (p *UsernamePolicy) WithSuffix(username, suffix string) string {
+	if max := p.maxLength - len(suffix) - 1; len(username) > max && max > 0 {
+		username = username[:max]
+	}
+	return username + "-" + suffix
+}
+
+func (p *UsernamePolicy) isReserved(username string) bool {
+	lower := strings.ToLower(username)
+	if _, ok := p.reserved[lower]; ok {
+		return true
+	}
+	return strings.HasPrefix(lower, accountDB.AnonymizedUsernamePrefix)
+}
@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"gin-rest-api-example/internal/account"
+	accountModel "gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/article/bulk"
+	"gin-rest-api-example/internal/audit"
+	auditDB "gin-rest-api-example/internal/audit/database"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxEventsLimit is the maximum number of audit events in a page
+const maxEventsLimit = 100
+
+func init() {
+	validate.DefaultRegistry().RegisterStructValidation(validateAuditEventsQuery, auditEventsQuery{})
+}
+
+type auditEventsQuery struct {
+	ActorID    string `form:"actorId" binding:"omitempty,numeric"`
+	Action     string `form:"action"`
+	TargetType string `form:"targetType"`
+	TargetID   string `form:"targetId"`
+	From       string `form:"from" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `form:"to" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Limit      string `form:"limit,default=20" binding:"numeric"`
+	Offset     string `form:"offset,default=0" binding:"numeric"`
+}
+
+// validateAuditEventsQuery reports "to" which is not after "from" if both are valid.
+func validateAuditEventsQuery(sl validator.StructLevel) {
+	query := sl.Current().Interface().(auditEventsQuery)
+	from, err := time.Parse(time.RFC3339, query.From)
+	if err != nil {
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.To)
+	if err != nil {
+		return
+	}
+	if !to.After(from) {
+		sl.ReportError(query.To, "To", "To", "after", "from")
+	}
+}
+
+type Handler struct {
+	auditDB  auditDB.AuditDB
+	auditor  *audit.Auditor
+	importer *bulk.Importer
+	exporter *bulk.Exporter
+}
+
+func NewHandler(auditDB auditDB.AuditDB, auditor *audit.Auditor, importer *bulk.Importer, exporter *bulk.Exporter) *Handler {
+	return &Handler{
+		auditDB:  auditDB,
+		auditor:  auditor,
+		importer: importer,
+		exporter: exporter,
+	}
+}
+
+// auditEvents handles GET /v1/api/admin/audit-events
+func (h *Handler) auditEvents(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		var query auditEventsQuery
+		if res := handler.Bind(c, nil, &query, nil); res != nil {
+			return res
+		}
+
+		criteria := auditDB.FindEventsCriteria{
+			Action:     query.Action,
+			TargetType: query.TargetType,
+			TargetID:   query.TargetID,
+		}
+		if actorID, err := strconv.ParseUint(query.ActorID, 10, 64); err == nil {
+			criteria.ActorID = uint(actorID)
+		}
+		if from, err := time.Parse(time.RFC3339, query.From); err == nil {
+			criteria.From = from.UTC()
+		}
+		if to, err := time.Parse(time.RFC3339, query.To); err == nil {
+			criteria.To = to.UTC()
+		}
+		limit, err := strconv.ParseUint(query.Limit, 10, 64)
+		if err != nil || limit > maxEventsLimit {
+			limit = maxEventsLimit
+		}
+		criteria.Limit = uint(limit)
+		if offset, err := strconv.ParseUint(query.Offset, 10, 64); err == nil {
+			criteria.Offset = uint(offset)
+		}
+
+		events, total, err := h.auditDB.FindEvents(c.Request.Context(), criteria)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+		// queries of audit events are audited as well
+		rawQuery := c.Request.URL.RawQuery
+		if len(rawQuery) > 255 {
+			rawQuery = rawQuery[:255]
+		}
+		h.auditor.Record(c, audit.Event{
+			Action:     audit.ActionAuditQuery,
+			ActorID:    account.MustCurrentUser(c).ID,
+			TargetType: audit.TargetAudit,
+			TargetID:   rawQuery,
+		})
+		return handler.NewSuccessResponse(http.StatusOK, NewAuditEventsResponse(events, total))
+	})
+}
+
+// RouteV1 routes admin api given config and gin.Engine.
+// Admin apis require an access token of an admin account.
+// Imports and exports of articles are timed out after the bulk timeout instead of the write timeout.
+func RouteV1(cfg *config.Config, h *Handler, r *gin.Engine, auth *account.AuthMiddleware,
+	customMethods *middleware.CustomMethodRegistry) {
+	admin := []gin.HandlerFunc{auth.MiddlewareFunc(), account.RejectAPIKey(), account.RequireRole(accountModel.RoleAdmin)}
+	v1 := r.Group("v1/api/admin")
+	v1.Use(middleware.RequestIDMiddleware())
+
+	api := v1.Group("", middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
+	api.Use(admin...)
+	{
+		api.GET("audit-events", h.auditEvents)
+	}
+
+	bulk := v1.Group("", middleware.TimeoutMiddleware(cfg.ServerConfig.BulkTimeout))
+	bulk.Use(admin...)
+	{
+		bulk.POST(middleware.CustomMethodRoute(customMethods, "articles", "import"), h.importArticles)
+		bulk.GET(middleware.CustomMethodRoute(customMethods, "articles", "export"), h.exportArticles)
+	}
+}
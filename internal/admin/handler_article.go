@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"gin-rest-api-example/internal/account"
+	"gin-rest-api-example/internal/article/bulk"
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize is the maximum size of bodies of article imports.
+// Bodies are read before importing, so no articles are imported if the body is too large.
+const maxImportSize = 32 << 20
+
+// Content types of imported and exported articles
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeZip    = "application/zip"
+)
+
+// ExportCountTrailer is the trailer of the number of exported articles.
+// The trailer is missing if the export failed after the response started, so clients detect truncated exports.
+const ExportCountTrailer = "X-Export-Count"
+
+type importArticlesQuery struct {
+	DryRun    bool `form:"dryRun"`
+	BatchSize int  `form:"batchSize,default=100" binding:"min=1,max=1000"`
+}
+
+type exportArticlesQuery struct {
+	Format string `form:"format,default=ndjson" binding:"oneof=ndjson markdown"`
+}
+
+// importArticles handles POST /v1/api/admin/articles:import
+func (h *Handler) importArticles(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		var query importArticlesQuery
+		if res := handler.Bind(c, nil, &query, nil); res != nil {
+			return res
+		}
+		contentType := c.ContentType()
+		if contentType != contentTypeNDJSON && contentType != contentTypeZip {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidHeaderValue,
+				fmt.Sprintf("unsupported content type %q, %s or %s is required", contentType, contentTypeNDJSON, contentTypeZip), nil)
+		}
+		b, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+		if err != nil {
+			logger.Errorw("admin.handler.importArticles failed to read the body", "err", err)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue,
+				fmt.Sprintf("failed to read the body of at most %d bytes", maxImportSize), nil)
+		}
+
+		reader, format := bulk.NewNDJSONReader(bytes.NewReader(b)), bulk.FormatNDJSON
+		if contentType == contentTypeZip {
+			zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+			if err != nil {
+				logger.Errorw("admin.handler.importArticles failed to read the zip archive", "err", err)
+				return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid zip archive of Markdown files", nil)
+			}
+			reader, format = bulk.NewMarkdownReader(zr), bulk.FormatMarkdown
+		}
+
+		// records without an author are written by the current admin
+		currentUser := account.MustCurrentUser(c)
+		report, err := h.importer.Import(c.Request.Context(), reader, bulk.Options{
+			BatchSize:     query.BatchSize,
+			DryRun:        query.DryRun,
+			DefaultAuthor: currentUser.Username,
+		})
+		if err != nil {
+			logger.Errorw("admin.handler.importArticles failed to read articles", "imported", report.Imported, "err", err)
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue,
+				fmt.Sprintf("failed to read articles after importing %d articles: %v", report.Imported, err), nil)
+		}
+		if !report.DryRun {
+			h.auditor.Record(c, audit.Event{
+				Action:     audit.ActionArticleImport,
+				ActorID:    currentUser.ID,
+				TargetType: audit.TargetArticle,
+				TargetID:   format,
+				Changes:    audit.Changes{"imported": {After: report.Imported}},
+			})
+		}
+		return handler.NewSuccessResponse(http.StatusOK, report)
+	})
+}
+
+// exportArticles handles GET /v1/api/admin/articles:export
+func (h *Handler) exportArticles(c *gin.Context) {
+	logger := logging.FromContext(c)
+	var query exportArticlesQuery
+	if res := handler.Bind(c, nil, &query, nil); res != nil {
+		handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+			return res
+		})
+		return
+	}
+
+	// the status code is written with the first bytes, so errors while streaming only abort the response
+	var (
+		w         bulk.Writer
+		zw        *zip.Writer
+		extension = query.Format
+	)
+	if query.Format == bulk.FormatNDJSON {
+		c.Header("Content-Type", contentTypeNDJSON)
+		w = bulk.NewNDJSONWriter(c.Writer)
+	} else {
+		c.Header("Content-Type", contentTypeZip)
+		extension = "zip"
+		zw = zip.NewWriter(c.Writer)
+		w = bulk.NewMarkdownWriter(func(name string, data []byte) error {
+			fw, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+			_, err = fw.Write(data)
+			return err
+		})
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="articles.%s"`, extension))
+	c.Header("Trailer", ExportCountTrailer)
+	c.Status(http.StatusOK)
+	written, err := h.exporter.Export(c.Request.Context(), w)
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		logger.Errorw("admin.handler.exportArticles failed to write articles", "written", written, "err", err)
+		c.Abort()
+		return
+	}
+	c.Header(ExportCountTrailer, strconv.Itoa(written))
+	h.auditor.Record(c, audit.Event{
+		Action:     audit.ActionArticleExport,
+		ActorID:    account.MustCurrentUser(c).ID,
+		TargetType: audit.TargetArticle,
+		TargetID:   query.Format,
+	})
+}
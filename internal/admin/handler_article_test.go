@@ -0,0 +1,202 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	accountModel "gin-rest-api-example/internal/account/model"
+	articleDB "gin-rest-api-example/internal/article/database"
+	articleModel "gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/middleware"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+)
+
+func (s *HandlerSuite) TestImportArticles() {
+	// given
+	s.accountDB.On("FindByUsernames", mock.Anything, []string{dAdmin.Username, dAdmin.Username}).
+		Return([]*accountModel.Account{&dAdmin}, nil)
+	s.articleDB.On("FindArticlesBySlugs", mock.Anything, []string{"title-1", "title-2"}).
+		Return([]*articleModel.Article{{Slug: "title-2"}}, nil)
+	s.articleDB.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, f func(context.Context) error) error {
+		return f(ctx)
+	})
+	s.articleDB.On("SaveArticle", mock.Anything, mock.Anything).Return(nil)
+	body := `{"title": "title 1", "body": "body 1"}` + "\n" + `{"title": "title 2", "body": "body 2"}`
+
+	// when
+	res := s.doArticlesRequest("POST", "/v1/api/admin/articles:import", "application/x-ndjson", strings.NewReader(body), &dAdmin)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{
+		"dryRun": false,
+		"total": 2,
+		"imported": 1,
+		"failed": 1,
+		"errors": [{"source": "line 2", "slug": "title-2", "message": "article already exists"}]
+	}`, res.Body.String())
+	s.articleDB.AssertCalled(s.T(), "SaveArticle", mock.Anything, mock.MatchedBy(func(a *articleModel.Article) bool {
+		return a.Slug == "title-1" && a.AuthorID == dAdmin.ID
+	}))
+	s.Equal(audit.ActionArticleImport, gjson.Get(s.audits.String(), "action").String())
+	s.Equal("ndjson", gjson.Get(s.audits.String(), "targetId").String())
+	s.EqualValues(1, gjson.Get(s.audits.String(), "changes.imported.after").Int())
+}
+
+func (s *HandlerSuite) TestImportArticles_MarkdownDryRun() {
+	// given
+	s.accountDB.On("FindByUsernames", mock.Anything, []string{"user1"}).Return([]*accountModel.Account{&dUser}, nil)
+	s.articleDB.On("FindArticlesBySlugs", mock.Anything, []string{"title-1"}).Return([]*articleModel.Article{}, nil)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.Create("posts/title-1.md")
+	s.NoError(err)
+	_, err = io.WriteString(fw, "---\ntitle: title 1\nauthor: user1\n---\n\nbody 1\n")
+	s.NoError(err)
+	s.NoError(zw.Close())
+
+	// when
+	res := s.doArticlesRequest("POST", "/v1/api/admin/articles:import?dryRun=true", "application/zip", &buf, &dAdmin)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{"dryRun": true, "total": 1, "imported": 1, "failed": 0, "errors": []}`, res.Body.String())
+	s.articleDB.AssertNotCalled(s.T(), "SaveArticle", mock.Anything, mock.Anything)
+	s.Empty(s.audits.String())
+}
+
+func (s *HandlerSuite) TestImportArticles_BadRequest() {
+	cases := []struct {
+		Name        string
+		Path        string
+		ContentType string
+		Body        string
+		Code        string
+	}{
+		{Name: "unsupported content type", Path: "/v1/api/admin/articles:import", ContentType: "application/json",
+			Body: `{}`, Code: "InvalidHeaderValue"},
+		{Name: "invalid zip", Path: "/v1/api/admin/articles:import", ContentType: "application/zip",
+			Body: "not a zip", Code: "InvalidBodyValue"},
+		{Name: "invalid batch size", Path: "/v1/api/admin/articles:import?batchSize=0", ContentType: "application/x-ndjson",
+			Body: "", Code: "InvalidQueryValue"},
+	}
+
+	for _, tc := range cases {
+		s.Run(tc.Name, func() {
+			// when
+			res := s.doArticlesRequest("POST", tc.Path, tc.ContentType, strings.NewReader(tc.Body), &dAdmin)
+
+			// then
+			s.Equal(http.StatusBadRequest, res.Code)
+			s.Equal(tc.Code, gjson.Get(res.Body.String(), "code").String())
+		})
+	}
+	s.articleDB.AssertNotCalled(s.T(), "SaveArticle", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestImportArticles_Forbidden() {
+	// when
+	res := s.doArticlesRequest("POST", "/v1/api/admin/articles:import", "application/x-ndjson", strings.NewReader(""), &dUser)
+
+	// then
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal("InsufficientRole", gjson.Get(res.Body.String(), "code").String())
+}
+
+func (s *HandlerSuite) TestExportArticles() {
+	// given
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	articles := []*articleModel.Article{
+		{ID: 2, Slug: "title-2", Title: "title 2", Body: "body 2", Author: dUser, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: 1, Slug: "title-1", Title: "title 1", Body: "body 1", Author: dAdmin, CreatedAt: createdAt, UpdatedAt: createdAt,
+			Tags: []*articleModel.Tag{{Name: "tag1"}}},
+	}
+	s.articleDB.On("FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Limit: 1}).Return(articles[:1], int64(2), nil)
+	s.articleDB.On("FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Limit: 2}).Return(articles, int64(2), nil)
+
+	s.Run("ndjson", func() {
+		// when
+		res := s.doArticlesRequest("GET", "/v1/api/admin/articles:export", "", nil, &dAdmin)
+
+		// then
+		s.Equal(http.StatusOK, res.Code)
+		s.Equal("application/x-ndjson", res.Header().Get("Content-Type"))
+		s.Equal(`attachment; filename="articles.ndjson"`, res.Header().Get("Content-Disposition"))
+		s.Equal(`{"slug":"title-1","title":"title 1","body":"body 1","tagList":["tag1"],"author":"admin1",`+
+			`"createdAt":"2021-01-01T00:00:00Z","updatedAt":"2021-01-01T00:00:00Z"}`+"\n"+
+			`{"slug":"title-2","title":"title 2","body":"body 2","tagList":[],"author":"user1",`+
+			`"createdAt":"2021-01-01T00:00:00Z","updatedAt":"2021-01-01T00:00:00Z"}`+"\n", res.Body.String())
+		s.Equal("2", res.Result().Trailer.Get(ExportCountTrailer))
+		s.Equal(audit.ActionArticleExport, gjson.Get(s.audits.String(), "action").String())
+	})
+
+	s.Run("markdown", func() {
+		// when
+		res := s.doArticlesRequest("GET", "/v1/api/admin/articles:export?format=markdown", "", nil, &dAdmin)
+
+		// then
+		s.Equal(http.StatusOK, res.Code)
+		s.Equal("application/zip", res.Header().Get("Content-Type"))
+		zr, err := zip.NewReader(bytes.NewReader(res.Body.Bytes()), int64(res.Body.Len()))
+		s.NoError(err)
+		s.Equal(2, len(zr.File))
+		s.Equal("title-1.md", zr.File[0].Name)
+		f, err := zr.File[1].Open()
+		s.NoError(err)
+		b, err := ioutil.ReadAll(f)
+		s.NoError(err)
+		s.True(strings.HasSuffix(string(b), "---\n\nbody 2"))
+	})
+}
+
+func (s *HandlerSuite) TestExportArticles_Truncated() {
+	// given
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	articles := []*articleModel.Article{
+		{ID: 1, Slug: "title-1", Title: "title 1", Body: "body 1", Author: dAdmin, CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+	s.articleDB.On("FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Limit: 1}).Return(articles, int64(101), nil).Once()
+	s.articleDB.On("FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Offset: 1, Limit: 100}).Return(articles, int64(101), nil)
+	s.articleDB.On("FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Limit: 1}).Return(nil, int64(0), errors.New("failed"))
+
+	// when
+	res := s.doArticlesRequest("GET", "/v1/api/admin/articles:export", "", nil, &dAdmin)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Empty(res.Result().Trailer.Get(ExportCountTrailer))
+	s.Empty(s.audits.String())
+}
+
+func (s *HandlerSuite) TestExportArticles_BadRequest() {
+	// when
+	res := s.doArticlesRequest("GET", "/v1/api/admin/articles:export?format=csv", "", nil, &dAdmin)
+
+	// then
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("format", gjson.Get(res.Body.String(), "errors.0.field").String())
+	s.articleDB.AssertNotCalled(s.T(), "FindArticles", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) doArticlesRequest(method, path, contentType string, body io.Reader, acc *accountModel.Account) *httptest.ResponseRecorder {
+	token, _, err := s.auth.TokenGenerator(acc)
+	s.NoError(err)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, body)
+	req.Header.Add("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	middleware.CustomMethods(s.customMethods, s.r).ServeHTTP(res, req)
+	return res
+}
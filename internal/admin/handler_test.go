@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"bytes"
+	"gin-rest-api-example/internal/account"
+	accountDBMock "gin-rest-api-example/internal/account/database/mocks"
+	accountModel "gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/article/bulk"
+	articleDBMock "gin-rest-api-example/internal/article/database/mocks"
+	"gin-rest-api-example/internal/audit"
+	auditDB "gin-rest-api-example/internal/audit/database"
+	auditDBMock "gin-rest-api-example/internal/audit/database/mocks"
+	"gin-rest-api-example/internal/audit/model"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	dAdmin = accountModel.Account{ID: 1, Username: "admin1", Email: "admin1@gmail.com", Role: accountModel.RoleAdmin, MFAEnabled: true}
+	dUser  = accountModel.Account{ID: 2, Username: "user1", Email: "user1@gmail.com", Role: accountModel.RoleUser}
+)
+
+type HandlerSuite struct {
+	suite.Suite
+	r             *gin.Engine
+	auth          *account.AuthMiddleware
+	db            *auditDBMock.AuditDB
+	accountDB     *accountDBMock.AccountDB
+	articleDB     *articleDBMock.ArticleDB
+	audits        *bytes.Buffer
+	customMethods *middleware.CustomMethodRegistry
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSuite))
+}
+
+func (s *HandlerSuite) SetupSuite() {
+	logging.SetLevel(zapcore.FatalLevel)
+	s.NoError(validate.SetupBinding())
+}
+
+func (s *HandlerSuite) SetupTest() {
+	cfg, err := config.Load("")
+	s.NoError(err)
+
+	s.db = &auditDBMock.AuditDB{}
+	s.articleDB = &articleDBMock.ArticleDB{}
+	s.accountDB = &accountDBMock.AccountDB{}
+	s.accountDB.On("FindByID", mock.Anything, dAdmin.ID).Return(&dAdmin, nil)
+	s.accountDB.On("FindByID", mock.Anything, dUser.ID).Return(&dUser, nil)
+	s.audits = &bytes.Buffer{}
+	auditor := audit.New(audit.NewWriterSink(s.audits))
+
+	s.auth, err = account.NewAuthMiddleware(cfg, s.accountDB, nil, nil, auditor)
+	s.NoError(err)
+
+	gin.SetMode(gin.TestMode)
+	s.r = gin.New()
+	h := NewHandler(s.db, auditor, bulk.NewImporter(s.articleDB, s.accountDB), bulk.NewExporter(s.articleDB))
+	s.customMethods = middleware.NewCustomMethodRegistry()
+	RouteV1(cfg, h, s.r, s.auth, s.customMethods)
+}
+
+func (s *HandlerSuite) TestAuditEvents() {
+	// given
+	from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []*model.Event{
+		{ID: 2, Action: audit.ActionArticleDelete, ActorID: 2, TargetType: audit.TargetArticle, TargetID: "slug-1", CreatedAt: from},
+	}
+	criteria := auditDB.FindEventsCriteria{ActorID: 2, Action: audit.ActionArticleDelete, From: from, Limit: 10}
+	s.db.On("FindEvents", mock.Anything, criteria).Return(events, int64(1), nil)
+
+	// when
+	res := s.doRequest("/v1/api/admin/audit-events?actorId=2&action=article.delete&from=2021-01-01T00:00:00Z&limit=10", &dAdmin)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.EqualValues(1, gjson.Get(res.Body.String(), "eventsCount").Int())
+	s.Equal("slug-1", gjson.Get(res.Body.String(), "events.0.targetId").String())
+	s.Equal(audit.ActionAuditQuery, gjson.Get(s.audits.String(), "action").String())
+	s.EqualValues(dAdmin.ID, gjson.Get(s.audits.String(), "actorId").Int())
+}
+
+func (s *HandlerSuite) TestAuditEvents_BadRequest() {
+	for _, query := range []string{"actorId=a", "from=2021-01-01", "limit=a", "from=2021-01-02T00:00:00Z&to=2021-01-01T00:00:00Z"} {
+		// when
+		res := s.doRequest("/v1/api/admin/audit-events?"+query, &dAdmin)
+
+		// then
+		s.Equal(http.StatusBadRequest, res.Code, query)
+	}
+	s.db.AssertNotCalled(s.T(), "FindEvents", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestAuditEvents_ToBeforeFrom() {
+	// when
+	res := s.doRequest("/v1/api/admin/audit-events?from=2021-01-02T00:00:00Z&to=2021-01-02T00:00:00Z", &dAdmin)
+
+	// then
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("to", gjson.Get(res.Body.String(), "errors.0.field").String())
+	s.Equal("to must be after from", gjson.Get(res.Body.String(), "errors.0.message").String())
+	s.db.AssertNotCalled(s.T(), "FindEvents", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestAuditEvents_Forbidden() {
+	// when
+	res := s.doRequest("/v1/api/admin/audit-events", &dUser)
+
+	// then
+	s.Equal(http.StatusForbidden, res.Code)
+	s.Equal("InsufficientRole", gjson.Get(res.Body.String(), "code").String())
+	s.db.AssertNotCalled(s.T(), "FindEvents", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) doRequest(path string, acc *accountModel.Account) *httptest.ResponseRecorder {
+	token, _, err := s.auth.TokenGenerator(acc)
+	s.NoError(err)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	s.r.ServeHTTP(res, req)
+	return res
+}
@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"gin-rest-api-example/internal/audit/model"
+)
+
+type AuditEventsResponse struct {
+	Events      []*model.Event `json:"events"`
+	EventsCount int64          `json:"eventsCount"`
+}
+
+// NewAuditEventsResponse converts audit event models and total count to AuditEventsResponse
+func NewAuditEventsResponse(events []*model.Event, total int64) *AuditEventsResponse {
+	if events == nil {
+		events = []*model.Event{}
+	}
+	return &AuditEventsResponse{
+		Events:      events,
+		EventsCount: total,
+	}
+}
@@ -0,0 +1,69 @@
+package bulk
+
+import (
+	"context"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/article/model"
+)
+
+const exportPageSize = 100
+
+// Exporter writes all articles as records which are imported by Importer.
+type Exporter struct {
+	articleDB articleDB.ArticleDB
+}
+
+func NewExporter(articleDB articleDB.ArticleDB) *Exporter {
+	return &Exporter{articleDB: articleDB}
+}
+
+// Export writes all articles to given writer in the order of creation, closes the writer
+// and returns the number of written articles.
+// Articles are read page by page from the last page, so articles are streamed without loading all of them
+// and imported in the same order.
+func (e *Exporter) Export(ctx context.Context, w Writer) (int, error) {
+	_, total, err := e.articleDB.FindArticles(ctx, articleDB.IterateArticleCriteria{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	written := 0
+	// articles are ordered by id descending
+	for end := uint(total); end > 0; {
+		start := uint(0)
+		if end > exportPageSize {
+			start = end - exportPageSize
+		}
+		articles, _, err := e.articleDB.FindArticles(ctx, articleDB.IterateArticleCriteria{
+			Offset: start,
+			Limit:  end - start,
+		})
+		if err != nil {
+			return written, err
+		}
+		for i := len(articles) - 1; i >= 0; i-- {
+			if err := w.Write(newRecord(articles[i])); err != nil {
+				return written, err
+			}
+			written++
+		}
+		end = start
+	}
+	return written, w.Close()
+}
+
+// newRecord returns a record of given article with timestamps in UTC.
+func newRecord(article *model.Article) *Record {
+	tags := []string{}
+	for _, tag := range article.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return &Record{
+		Slug:      article.Slug,
+		Title:     article.Title,
+		Body:      article.Body,
+		Tags:      tags,
+		Author:    article.Author.Username,
+		CreatedAt: article.CreatedAt.UTC(),
+		UpdatedAt: article.UpdatedAt.UTC(),
+	}
+}
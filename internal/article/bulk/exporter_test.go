@@ -0,0 +1,86 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	articleDB "gin-rest-api-example/internal/article/database"
+	articleDBMock "gin-rest-api-example/internal/article/database/mocks"
+	"gin-rest-api-example/internal/article/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExporter(t *testing.T) {
+	// given 150 articles ordered by id descending
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var articles []*model.Article
+	for id := 150; id > 0; id-- {
+		articles = append(articles, &model.Article{
+			ID:        uint(id),
+			Slug:      fmt.Sprintf("title-%d", id),
+			Title:     fmt.Sprintf("title %d", id),
+			Body:      "body",
+			Author:    dUser,
+			Tags:      []*model.Tag{{Name: "tag1"}},
+			CreatedAt: createdAt.Add(time.Duration(id) * time.Minute),
+			UpdatedAt: createdAt.Add(time.Duration(id) * time.Minute),
+		})
+	}
+	db := &articleDBMock.ArticleDB{}
+	db.On("FindArticles", mock.Anything, mock.Anything).Return(func(_ context.Context, criteria articleDB.IterateArticleCriteria) []*model.Article {
+		end := criteria.Offset + criteria.Limit
+		if end > uint(len(articles)) {
+			end = uint(len(articles))
+		}
+		return articles[criteria.Offset:end]
+	}, int64(len(articles)), nil)
+
+	// when
+	var buf bytes.Buffer
+	written, err := NewExporter(db).Export(context.Background(), NewNDJSONWriter(&buf))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 150, written)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 150, len(lines))
+	r := NewNDJSONReader(&buf)
+	for id := 1; id <= 150; id++ {
+		_, record, err := r.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("title-%d", id), record.Slug)
+	}
+	db.AssertCalled(t, "FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Offset: 50, Limit: 100})
+	db.AssertCalled(t, "FindArticles", mock.Anything, articleDB.IterateArticleCriteria{Offset: 0, Limit: 50})
+}
+
+func TestNewRecord(t *testing.T) {
+	createdAt := time.Date(2021, 1, 1, 9, 0, 0, 0, time.FixedZone("KST", 9*60*60))
+	article := model.Article{
+		Slug:      "title-1",
+		Title:     "title 1",
+		Body:      "body 1",
+		Author:    dUser,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	// when
+	record := newRecord(&article)
+
+	// then
+	assert.Equal(t, &Record{
+		Slug:      "title-1",
+		Title:     "title 1",
+		Body:      "body 1",
+		Tags:      []string{},
+		Author:    dUser.Username,
+		CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, record)
+}
@@ -0,0 +1,250 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	accountDB "gin-rest-api-example/internal/account/database"
+	accountModel "gin-rest-api-example/internal/account/model"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/gosimple/slug"
+)
+
+// DefaultBatchSize is the number of records saved in a transaction by default
+const DefaultBatchSize = 100
+
+// Options are options of Importer.Import
+type Options struct {
+	// BatchSize is the number of records saved in a transaction. DefaultBatchSize is used if zero.
+	BatchSize int
+	// DryRun checks records without saving them.
+	DryRun bool
+	// DefaultAuthor is the username of the author of records without an author.
+	DefaultAuthor string
+}
+
+// Report is the result of an import. Imported is the number of records to be imported if dry run.
+type Report struct {
+	DryRun   bool           `json:"dryRun"`
+	Total    int            `json:"total"`
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Errors   []*RecordError `json:"errors"`
+}
+
+func (r *Report) fail(err *RecordError) {
+	r.Failed++
+	r.Errors = append(r.Errors, err)
+}
+
+// item is a record to import with its source and the article to save.
+type item struct {
+	source  string
+	record  *Record
+	article *model.Article
+}
+
+// Importer saves articles of records in batched transactions.
+// Records are validated by binding tags, so validate.SetupBinding must be called before importing.
+type Importer struct {
+	articleDB articleDB.ArticleDB
+	accountDB accountDB.AccountDB
+}
+
+func NewImporter(articleDB articleDB.ArticleDB, accountDB accountDB.AccountDB) *Importer {
+	return &Importer{
+		articleDB: articleDB,
+		accountDB: accountDB,
+	}
+}
+
+// Import imports records of given reader in transactions of the batch size.
+// Malformed and invalid records, records of unknown authors and records of existing or duplicate slugs
+// are reported without failing the other records. If a transaction fails, all records of the batch are reported.
+// The report of records read so far is returned with an error if the reader fails.
+func (im *Importer) Import(ctx context.Context, r Reader, opts Options) (*Report, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	report := Report{DryRun: opts.DryRun, Errors: []*RecordError{}}
+	// sources of slugs read so far to report duplicate slugs
+	sources := make(map[string]string)
+	var batch []*item
+	for {
+		source, record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var recordErr *RecordError
+			if !errors.As(err, &recordErr) {
+				return &report, err
+			}
+			report.Total++
+			report.fail(recordErr)
+			continue
+		}
+		report.Total++
+		it := item{source: source, record: record}
+		if recordErr := im.check(&it, opts, sources); recordErr != nil {
+			report.fail(recordErr)
+			continue
+		}
+		batch = append(batch, &it)
+		if len(batch) == opts.BatchSize {
+			im.importBatch(ctx, batch, opts, &report)
+			batch = nil
+		}
+	}
+	if len(batch) != 0 {
+		im.importBatch(ctx, batch, opts, &report)
+	}
+	return &report, nil
+}
+
+// check validates the record of given item and fills the default slug and author.
+func (im *Importer) check(it *item, opts Options, sources map[string]string) *RecordError {
+	record := it.record
+	if err := binding.Validator.ValidateStruct(record); err != nil {
+		recordErr := RecordError{Source: it.source, Slug: record.Slug, Message: "invalid record"}
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			recordErr.Details = validate.ValidationErrorDetails(record, "json", vErrs)
+		}
+		return &recordErr
+	}
+	if record.Slug == "" {
+		record.Slug = slug.Make(record.Title)
+	}
+	if record.Author == "" {
+		record.Author = opts.DefaultAuthor
+	}
+	if record.Author == "" {
+		return &RecordError{Source: it.source, Slug: record.Slug, Message: "invalid record",
+			Details: []*validate.ValidationErrDetail{validate.NewTagErrorDetail("author", "required", "", "")}}
+	}
+	if source, ok := sources[record.Slug]; ok {
+		return &RecordError{Source: it.source, Slug: record.Slug, Message: fmt.Sprintf("duplicate slug of %s", source)}
+	}
+	sources[record.Slug] = it.source
+	return nil
+}
+
+// importBatch saves articles of given items in a transaction unless dry run.
+// Items of unknown authors and existing slugs are reported before the transaction.
+func (im *Importer) importBatch(ctx context.Context, batch []*item, opts Options, report *Report) {
+	logger := logging.FromContext(ctx)
+	// articles are not cached until the transaction is committed
+	ctx = cache.WithCacheSkip(ctx, true)
+
+	var usernames, slugs []string
+	for _, it := range batch {
+		usernames = append(usernames, it.record.Author)
+		slugs = append(slugs, it.record.Slug)
+	}
+	accounts, err := im.accountDB.FindByUsernames(ctx, usernames)
+	if err != nil {
+		logger.Errorw("bulk.importer failed to find authors", "err", err)
+		failBatch(batch, report, err.Error())
+		return
+	}
+	authors := make(map[string]*accountModel.Account)
+	for _, acc := range accounts {
+		if !acc.Disabled {
+			authors[acc.Username] = acc
+		}
+	}
+	existing, err := im.articleDB.FindArticlesBySlugs(ctx, slugs)
+	if err != nil {
+		logger.Errorw("bulk.importer failed to find existing articles", "err", err)
+		failBatch(batch, report, err.Error())
+		return
+	}
+	exists := make(map[string]bool)
+	for _, article := range existing {
+		exists[article.Slug] = true
+	}
+
+	var valid []*item
+	for _, it := range batch {
+		author, ok := authors[it.record.Author]
+		if !ok {
+			report.fail(&RecordError{Source: it.source, Slug: it.record.Slug, Message: fmt.Sprintf("author %s is not found", it.record.Author)})
+			continue
+		}
+		if exists[it.record.Slug] {
+			report.fail(&RecordError{Source: it.source, Slug: it.record.Slug, Message: "article already exists"})
+			continue
+		}
+		it.article = newArticle(it.record, author)
+		valid = append(valid, it)
+	}
+	if opts.DryRun || len(valid) == 0 {
+		report.Imported += len(valid)
+		return
+	}
+
+	var failed *item
+	err = im.articleDB.RunInTx(ctx, func(ctx context.Context) error {
+		for _, it := range valid {
+			if err := im.articleDB.SaveArticle(ctx, it.article); err != nil {
+				failed = it
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorw("bulk.importer failed to save a batch of articles", "err", err)
+		for _, it := range valid {
+			// all records of the batch fail with the error if the commit fails
+			message := err.Error()
+			if failed != nil && it != failed {
+				message = fmt.Sprintf("rolled back by the failure of %s", failed.source)
+			} else if database.IsKeyConflictErr(err) {
+				message = "article already exists"
+			}
+			report.fail(&RecordError{Source: it.source, Slug: it.record.Slug, Message: message})
+		}
+		return
+	}
+	report.Imported += len(valid)
+}
+
+func failBatch(batch []*item, report *Report, message string) {
+	for _, it := range batch {
+		report.fail(&RecordError{Source: it.source, Slug: it.record.Slug, Message: message})
+	}
+}
+
+// newArticle returns an article of given record and author.
+// Timestamps of the record are kept, and the updated time is the created time if empty.
+func newArticle(record *Record, author *accountModel.Account) *model.Article {
+	var tags []*model.Tag
+	for _, tag := range record.Tags {
+		tags = append(tags, &model.Tag{Name: tag})
+	}
+	article := model.Article{
+		Slug:      record.Slug,
+		Title:     record.Title,
+		Body:      record.Body,
+		Author:    *author,
+		AuthorID:  author.ID,
+		Tags:      tags,
+		CreatedAt: record.CreatedAt.UTC().Truncate(time.Second),
+		UpdatedAt: record.UpdatedAt.UTC().Truncate(time.Second),
+	}
+	if article.UpdatedAt.IsZero() {
+		article.UpdatedAt = article.CreatedAt
+	}
+	return &article
+}
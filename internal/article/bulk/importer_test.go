@@ -0,0 +1,164 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	accountDBMock "gin-rest-api-example/internal/account/database/mocks"
+	accountModel "gin-rest-api-example/internal/account/model"
+	articleDBMock "gin-rest-api-example/internal/article/database/mocks"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	dUser     = accountModel.Account{ID: 1, Username: "user1", Email: "user1@gmail.com"}
+	dDisabled = accountModel.Account{ID: 2, Username: "user2", Email: "user2@gmail.com", Disabled: true}
+)
+
+type ImporterSuite struct {
+	suite.Suite
+	articleDB *articleDBMock.ArticleDB
+	accountDB *accountDBMock.AccountDB
+	importer  *Importer
+}
+
+func TestImporterSuite(t *testing.T) {
+	suite.Run(t, new(ImporterSuite))
+}
+
+func (s *ImporterSuite) SetupSuite() {
+	logging.SetLevel(zapcore.FatalLevel)
+	s.NoError(validate.SetupBinding())
+}
+
+func (s *ImporterSuite) SetupTest() {
+	s.articleDB = &articleDBMock.ArticleDB{}
+	s.accountDB = &accountDBMock.AccountDB{}
+	s.accountDB.On("FindByUsernames", mock.Anything, mock.Anything).Return([]*accountModel.Account{&dUser, &dDisabled}, nil)
+	s.articleDB.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, f func(context.Context) error) error {
+		return f(ctx)
+	})
+	s.importer = NewImporter(s.articleDB, s.accountDB)
+}
+
+func (s *ImporterSuite) TestImport() {
+	// given
+	s.articleDB.On("FindArticlesBySlugs", mock.Anything, mock.Anything).Return([]*model.Article{{Slug: "existing-title"}}, nil)
+	var saved []*model.Article
+	s.articleDB.On("SaveArticle", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(*model.Article))
+	})
+	input := strings.Join([]string{
+		`{"title": "title 1", "body": "body 1", "tagList": ["tag1"], "createdAt": "2021-01-01T09:00:00.5+09:00"}`,
+		`{"slug": "custom-slug", "title": "title 2", "body": "body 2", "author": "user1"}`,
+		`{"title": "title 3", "body": "body 3", "tagList": ["Not A Slug"]}`,
+		`{"title": "title 1", "body": "body 4"}`,
+		`{"title": "title 5", "body": "body 5", "author": "user2"}`,
+		`{"title": "title 6", "body": "body 6", "author": "unknown"}`,
+		`{"title": "existing title", "body": "body 7"}`,
+		`{"title": }`,
+		`{"title": "title 9", "body": "body 9"}`,
+	}, "\n")
+
+	// when
+	report, err := s.importer.Import(context.Background(), NewNDJSONReader(strings.NewReader(input)),
+		Options{BatchSize: 2, DefaultAuthor: dUser.Username})
+
+	// then
+	s.NoError(err)
+	s.Equal(9, report.Total)
+	s.Equal(3, report.Imported)
+	s.Equal(6, report.Failed)
+	s.False(report.DryRun)
+	messages := make(map[string]string)
+	for _, e := range report.Errors {
+		messages[e.Source] = e.Message
+	}
+	s.Equal("invalid record", messages["line 3"])
+	s.Equal("duplicate slug of line 1", messages["line 4"])
+	s.Equal("author user2 is not found", messages["line 5"])
+	s.Equal("author unknown is not found", messages["line 6"])
+	s.Equal("article already exists", messages["line 7"])
+	s.Contains(messages["line 8"], "invalid json")
+	s.Equal("tagList[0]", report.Errors[0].Details[0].Field)
+
+	s.Equal(3, len(saved))
+	s.Equal("title-1", saved[0].Slug)
+	s.Equal(dUser.ID, saved[0].AuthorID)
+	s.Equal("tag1", saved[0].Tags[0].Name)
+	s.Equal(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), saved[0].CreatedAt)
+	s.Equal(saved[0].CreatedAt, saved[0].UpdatedAt)
+	s.Equal("custom-slug", saved[1].Slug)
+	s.Equal("title-9", saved[2].Slug)
+	// batches of valid records are saved in transactions
+	s.articleDB.AssertNumberOfCalls(s.T(), "RunInTx", 2)
+}
+
+func (s *ImporterSuite) TestImport_DryRun() {
+	// given
+	s.articleDB.On("FindArticlesBySlugs", mock.Anything, mock.Anything).Return([]*model.Article{}, nil)
+	input := `{"title": "title 1", "body": "body 1", "author": "user1"}` + "\n" + `{"title": "title 2", "body": ""}`
+
+	// when
+	report, err := s.importer.Import(context.Background(), NewNDJSONReader(strings.NewReader(input)), Options{DryRun: true})
+
+	// then
+	s.NoError(err)
+	s.True(report.DryRun)
+	s.Equal(2, report.Total)
+	s.Equal(1, report.Imported)
+	s.Equal(1, report.Failed)
+	s.Equal("line 2", report.Errors[0].Source)
+	s.Equal("body", report.Errors[0].Details[0].Field)
+	s.articleDB.AssertNotCalled(s.T(), "RunInTx", mock.Anything, mock.Anything)
+	s.articleDB.AssertNotCalled(s.T(), "SaveArticle", mock.Anything, mock.Anything)
+}
+
+func (s *ImporterSuite) TestImport_RollbackBatch() {
+	// given
+	s.articleDB.On("FindArticlesBySlugs", mock.Anything, mock.Anything).Return([]*model.Article{}, nil)
+	s.articleDB.On("SaveArticle", mock.Anything, mock.MatchedBy(func(a *model.Article) bool {
+		return a.Slug == "title-2"
+	})).Return(database.ErrKeyConflict)
+	s.articleDB.On("SaveArticle", mock.Anything, mock.Anything).Return(nil)
+	input := strings.Join([]string{
+		`{"title": "title 1", "body": "body 1"}`,
+		`{"title": "title 2", "body": "body 2"}`,
+		`{"title": "title 3", "body": "body 3"}`,
+	}, "\n")
+
+	// when
+	report, err := s.importer.Import(context.Background(), NewNDJSONReader(strings.NewReader(input)),
+		Options{BatchSize: 2, DefaultAuthor: dUser.Username})
+
+	// then
+	s.NoError(err)
+	s.Equal(1, report.Imported)
+	s.Equal(2, report.Failed)
+	s.Equal(&RecordError{Source: "line 1", Slug: "title-1", Message: "rolled back by the failure of line 2"}, report.Errors[0])
+	s.Equal(&RecordError{Source: "line 2", Slug: "title-2", Message: "article already exists"}, report.Errors[1])
+}
+
+func (s *ImporterSuite) TestImport_ReaderFailure() {
+	// when
+	report, err := s.importer.Import(context.Background(), NewNDJSONReader(&failingReader{}), Options{})
+
+	// then
+	s.Error(err)
+	s.Equal(0, report.Total)
+}
+
+type failingReader struct{}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failure")
+}
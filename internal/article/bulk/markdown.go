@@ -0,0 +1,108 @@
+package bulk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---\n"
+
+// NewMarkdownReader returns a Reader of records in Markdown files of given file system in lexical order.
+// Each "*.md" file is a record with a YAML front matter between "---" lines and the body after it.
+// Unknown fields of the front matter are ignored.
+func NewMarkdownReader(fsys fs.FS) Reader {
+	return &markdownReader{fsys: fsys}
+}
+
+type markdownReader struct {
+	fsys  fs.FS
+	names []string
+	// walked is true after names of files are read
+	walked bool
+}
+
+func (m *markdownReader) Read() (string, *Record, error) {
+	if !m.walked {
+		err := fs.WalkDir(m.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && path.Ext(name) == ".md" {
+				m.names = append(m.names, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		m.walked = true
+	}
+	if len(m.names) == 0 {
+		return "", nil, io.EOF
+	}
+	name := m.names[0]
+	m.names = m.names[1:]
+	b, err := fs.ReadFile(m.fsys, name)
+	if err != nil {
+		return "", nil, err
+	}
+	record, err := parseMarkdown(b)
+	if err != nil {
+		return name, nil, &RecordError{Source: name, Message: err.Error()}
+	}
+	return name, record, nil
+}
+
+// parseMarkdown returns a record of the front matter and the body of given Markdown.
+// A blank line after the front matter is not a part of the body.
+func parseMarkdown(b []byte) (*Record, error) {
+	content := strings.ReplaceAll(string(b), "\r\n", "\n")
+	if !strings.HasPrefix(content, frontMatterDelimiter) {
+		return nil, fmt.Errorf("front matter is missing")
+	}
+	content = content[len(frontMatterDelimiter):]
+	end := strings.Index(content, "\n"+frontMatterDelimiter)
+	if end < 0 {
+		return nil, fmt.Errorf("front matter is not closed")
+	}
+	var record Record
+	if err := yaml.Unmarshal([]byte(content[:end+1]), &record); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %v", err)
+	}
+	record.Body = strings.TrimPrefix(content[end+1+len(frontMatterDelimiter):], "\n")
+	return &record, nil
+}
+
+// NewMarkdownWriter returns a Writer of records in "<slug>.md" files written by given writeFile
+// e.g. files of a directory or a zip archive.
+func NewMarkdownWriter(writeFile func(name string, data []byte) error) Writer {
+	return &markdownWriter{writeFile: writeFile}
+}
+
+type markdownWriter struct {
+	writeFile func(name string, data []byte) error
+}
+
+func (m *markdownWriter) Write(record *Record) error {
+	frontMatter, err := yaml.Marshal(record)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter)
+	buf.Write(frontMatter)
+	buf.WriteString(frontMatterDelimiter)
+	buf.WriteString("\n")
+	buf.WriteString(record.Body)
+	return m.writeFile(record.Slug+".md", buf.Bytes())
+}
+
+func (m *markdownWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package bulk
+
+import (
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []*Record{
+		{Slug: "title-1", Title: "title 1", Body: "# body 1\n\n---\n", Tags: []string{"tag1", "tag2"}, Author: "user1", CreatedAt: createdAt, UpdatedAt: createdAt},
+		{Slug: "title-2", Title: "title: 2", Body: "body 2", Tags: []string{}, Author: "user2", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)},
+	}
+
+	// when
+	fsys := fstest.MapFS{}
+	w := NewMarkdownWriter(func(name string, data []byte) error {
+		fsys[name] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	for _, record := range records {
+		assert.NoError(t, w.Write(record))
+	}
+	assert.NoError(t, w.Close())
+
+	// then
+	assert.Equal(t, "---\nslug: title-1\ntitle: title 1\ntagList:\n    - tag1\n    - tag2\nauthor: user1\n"+
+		"createdAt: 2021-01-01T00:00:00Z\nupdatedAt: 2021-01-01T00:00:00Z\n---\n\n# body 1\n\n---\n", string(fsys["title-1.md"].Data))
+	r := NewMarkdownReader(fsys)
+	for _, record := range records {
+		source, read, err := r.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, record.Slug+".md", source)
+		assert.Equal(t, record, read)
+	}
+	_, _, err := r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMarkdownReader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md":      {Data: []byte("---\r\ntitle: title a\r\ndraft: false\r\n---\r\nbody a\r\n")},
+		"posts/b.md":      {Data: []byte("# no front matter")},
+		"posts/c.md":      {Data: []byte("---\ntitle: title c\n")},
+		"posts/d.md":      {Data: []byte("---\ntitle: [\n---\nbody d")},
+		"posts/image.png": {Data: []byte("png")},
+	}
+	r := NewMarkdownReader(fsys)
+
+	// when, then
+	source, record, err := r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "posts/a.md", source)
+	assert.Equal(t, &Record{Title: "title a", Body: "body a\n"}, record)
+
+	for _, expected := range []struct {
+		Source  string
+		Message string
+	}{
+		{Source: "posts/b.md", Message: "front matter is missing"},
+		{Source: "posts/c.md", Message: "front matter is not closed"},
+		{Source: "posts/d.md", Message: "invalid front matter"},
+	} {
+		source, _, err := r.Read()
+		assert.Equal(t, expected.Source, source)
+		if assert.IsType(t, &RecordError{}, err) {
+			assert.Contains(t, err.(*RecordError).Message, expected.Message)
+		}
+	}
+	_, _, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
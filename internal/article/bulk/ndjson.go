@@ -0,0 +1,64 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NewNDJSONReader returns a Reader of records in JSON lines. Blank lines are skipped.
+func NewNDJSONReader(r io.Reader) Reader {
+	return &ndjsonReader{r: bufio.NewReader(r)}
+}
+
+type ndjsonReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (n *ndjsonReader) Read() (string, *Record, error) {
+	for {
+		// lines are read without a limit of the size, unlike bufio.Scanner, because bodies may be long.
+		b, err := n.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return "", nil, err
+		}
+		if len(b) == 0 && err == io.EOF {
+			return "", nil, io.EOF
+		}
+		n.line++
+		source := fmt.Sprintf("line %d", n.line)
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(b, &record); err != nil {
+			return source, nil, &RecordError{Source: source, Message: fmt.Sprintf("invalid json: %v", err)}
+		}
+		return source, &record, nil
+	}
+}
+
+// NewNDJSONWriter returns a Writer of records in JSON lines to w.
+func NewNDJSONWriter(w io.Writer) Writer {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &ndjsonWriter{w: bw, enc: enc}
+}
+
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(record *Record) error {
+	return n.enc.Encode(record)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
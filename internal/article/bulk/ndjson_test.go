@@ -0,0 +1,63 @@
+package bulk
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSON(t *testing.T) {
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []*Record{
+		{Slug: "title-1", Title: "title 1", Body: "body <1>\nline 2", Tags: []string{"tag1"}, Author: "user1", CreatedAt: createdAt, UpdatedAt: createdAt},
+		{Slug: "title-2", Title: "title 2", Body: "body 2", Tags: []string{}, Author: "user2", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)},
+	}
+
+	// when
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+	for _, record := range records {
+		assert.NoError(t, w.Write(record))
+	}
+	assert.NoError(t, w.Close())
+
+	// then
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `"body":"body <1>\nline 2"`)
+	r := NewNDJSONReader(&buf)
+	for i, record := range records {
+		source, read, err := r.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, record, read)
+		assert.Equal(t, []string{"line 1", "line 2"}[i], source)
+	}
+	_, _, err := r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNDJSONReader_Malformed(t *testing.T) {
+	r := NewNDJSONReader(strings.NewReader("{\"title\": \"title 1\"}\n\n{\"title\": \n{\"title\": \"title 4\"}"))
+
+	// when, then
+	source, record, err := r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1", source)
+	assert.Equal(t, "title 1", record.Title)
+
+	source, _, err = r.Read()
+	assert.Equal(t, "line 3", source)
+	assert.IsType(t, &RecordError{}, err)
+	assert.Contains(t, err.Error(), "line 3: invalid json")
+
+	source, record, err = r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "line 4", source)
+	assert.Equal(t, "title 4", record.Title)
+
+	_, _, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,53 @@
+package bulk
+
+import (
+	"fmt"
+	"gin-rest-api-example/pkg/validate"
+	"time"
+)
+
+// Formats of imported and exported articles
+const (
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "markdown"
+)
+
+// Record is an article in imported and exported files.
+// The slug is made from the title if empty, and the author is the username of the author.
+type Record struct {
+	Slug      string    `json:"slug" yaml:"slug" binding:"omitempty,slug"`
+	Title     string    `json:"title" yaml:"title" binding:"required,min=5"`
+	Body      string    `json:"body" yaml:"-" binding:"required"`
+	Tags      []string  `json:"tagList" yaml:"tagList" binding:"omitempty,dive,max=10,slug"`
+	Author    string    `json:"author" yaml:"author"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" yaml:"updatedAt"`
+}
+
+// RecordError is an error of a record which is not imported.
+// The source is the location of the record e.g. "line 3" of NDJSON or "hello.md" of Markdown files.
+type RecordError struct {
+	Source  string                          `json:"source"`
+	Slug    string                          `json:"slug,omitempty"`
+	Message string                          `json:"message"`
+	Details []*validate.ValidationErrDetail `json:"details,omitempty"`
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Source, e.Message)
+}
+
+// Reader reads records one by one.
+type Reader interface {
+	// Read returns the source and the next record, and io.EOF after the last record.
+	// A *RecordError is returned if the record is malformed and the next record can be read.
+	Read() (string, *Record, error)
+}
+
+// Writer writes records one by one.
+type Writer interface {
+	Write(record *Record) error
+
+	// Close flushes written records.
+	Close() error
+}
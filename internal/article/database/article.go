@@ -15,12 +15,16 @@ import (
 )
 
 type IterateArticleCriteria struct {
-	Tags   []string
-	Author string
-	Offset uint
-	Limit  uint
+	Tags     []string
+	Author   string
+	AuthorID uint
+	Offset   uint
+	Limit    uint
 }
 
+// ErrVersionConflict is returned if an article is updated or deleted with a version which is not the current version.
+var ErrVersionConflict = errors.New("article version conflict")
+
 //go:generate mockery --name ArticleDB --filename article_mock.go
 type ArticleDB interface {
 	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
@@ -29,6 +33,11 @@ type ArticleDB interface {
 	// if not exist tags, then save a new tag
 	SaveArticle(ctx context.Context, article *model.Article) error
 
+	// UpdateArticle updates title, body and tags of given article and increments the version
+	// if the current version of the article is given version.
+	// ErrVersionConflict is returned if the version is changed, database.ErrNotFound if not exist
+	UpdateArticle(ctx context.Context, article *model.Article, version uint) error
+
 	// FindArticleBySlug returns a article with given slug
 	// database.ErrNotFound error is returned if not exist
 	FindArticleBySlug(ctx context.Context, slug string) (*model.Article, error)
@@ -36,9 +45,13 @@ type ArticleDB interface {
 	// FindArticles returns article list with given criteria and total count
 	FindArticles(ctx context.Context, criteria IterateArticleCriteria) ([]*model.Article, int64, error)
 
-	// DeleteArticleBySlug deletes a article with given slug
-	// and returns nil if success to delete, otherwise returns an error
-	DeleteArticleBySlug(ctx context.Context, authorId uint, slug string) error
+	// FindArticlesBySlugs returns articles of given slugs which exist in no particular order
+	FindArticlesBySlugs(ctx context.Context, slugs []string) ([]*model.Article, error)
+
+	// DeleteArticleBySlug deletes a article with given slug if the current version of the article is given version
+	// and returns nil if success to delete, otherwise returns an error.
+	// ErrVersionConflict is returned if the version is changed, database.ErrNotFound if not exist
+	DeleteArticleBySlug(ctx context.Context, authorId uint, slug string, version uint) error
 
 	// SaveComment saves a comment with given article slug and comment
 	SaveComment(ctx context.Context, slug string, comment *model.Comment) error
@@ -46,6 +59,10 @@ type ArticleDB interface {
 	// FindComments returns all comments with given article slug
 	FindComments(ctx context.Context, slug string) ([]*model.Comment, error)
 
+	// FindCommentsBySlugs returns all comments of given article slugs by the slugs.
+	// Comments of each slug are ordered like FindComments and slugs without comments are not in the map.
+	FindCommentsBySlugs(ctx context.Context, slugs []string) (map[string][]*model.Comment, error)
+
 	// DeleteCommentById deletes a comment with given article slug and comment id
 	// database.ErrNotFound error is returned if not exist
 	DeleteCommentById(ctx context.Context, authorId uint, slug string, id uint) error
@@ -53,6 +70,20 @@ type ArticleDB interface {
 	// DeleteComments deletes all comment with given author id and slug
 	// and returns deleted records count
 	DeleteComments(ctx context.Context, authorId uint, slug string) (int64, error)
+
+	// FindCommentsByAuthor returns all comments of given author id ordered by id
+	FindCommentsByAuthor(ctx context.Context, authorId uint) ([]*model.Comment, error)
+
+	// DeleteArticlesByAuthor deletes all articles of given author id
+	// and returns deleted records count
+	DeleteArticlesByAuthor(ctx context.Context, authorId uint) (int64, error)
+
+	// DeleteCommentsByAuthor deletes all comments of given author id
+	// and returns deleted records count
+	DeleteCommentsByAuthor(ctx context.Context, authorId uint) (int64, error)
+
+	// ReassignAuthor changes the author of all articles and comments of given author id to the other author
+	ReassignAuthor(ctx context.Context, authorId, newAuthorId uint) error
 }
 
 // NewArticleDB creates a new article db with given db
@@ -99,6 +130,14 @@ func (a *articleDB) SaveArticle(ctx context.Context, article *model.Article) err
 		}
 	}
 
+	if article.Version == 0 {
+		article.Version = 1
+	}
+	// the database stores seconds, so cached articles have the same timestamps as stored articles.
+	if article.CreatedAt.IsZero() {
+		article.CreatedAt = time.Now().Truncate(time.Second)
+		article.UpdatedAt = article.CreatedAt
+	}
 	if err := db.WithContext(ctx).Create(article).Error; err != nil {
 		logger.Errorw("article.db.SaveArticle failed to save article", "err", err)
 		if database.IsKeyConflictErr(err) {
@@ -109,6 +148,65 @@ func (a *articleDB) SaveArticle(ctx context.Context, article *model.Article) err
 	return nil
 }
 
+func (a *articleDB) UpdateArticle(ctx context.Context, article *model.Article, version uint) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.UpdateArticle", "article", article, "version", version)
+
+	now := time.Now().Truncate(time.Second)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chain := tx.Model(&model.Article{}).
+			Where("id = ? AND version = ? AND deleted_at_unix = 0", article.ID, version).
+			Updates(map[string]interface{}{
+				"title":      article.Title,
+				"body":       article.Body,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": now,
+			})
+		if chain.Error != nil {
+			return chain.Error
+		}
+		if chain.RowsAffected == 0 {
+			return a.versionConflictOrNotFound(tx.Where("id = ?", article.ID))
+		}
+		// replace article tag relation
+		if err := tx.Exec("DELETE FROM article_tags WHERE article_id = ?", article.ID).Error; err != nil {
+			return err
+		}
+		for _, tag := range article.Tags {
+			if err := tx.FirstOrCreate(tag, "name = ?", tag.Name).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("INSERT INTO article_tags(article_id, tag_id) VALUES (?, ?)", article.ID, tag.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err != ErrVersionConflict && err != database.ErrNotFound {
+			logger.Errorw("article.db.UpdateArticle failed to update article", "err", err)
+		}
+		return err
+	}
+	article.Version = version + 1
+	article.UpdatedAt = now
+	return nil
+}
+
+// versionConflictOrNotFound returns ErrVersionConflict if an article of given query exists
+// after a conditional update with the version affected no rows, otherwise database.ErrNotFound.
+func (a *articleDB) versionConflictOrNotFound(query *gorm.DB) error {
+	var count int64
+	if err := query.Model(&model.Article{}).Where("deleted_at_unix = 0").Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return database.ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
 func (a *articleDB) FindArticleBySlug(ctx context.Context, slug string) (*model.Article, error) {
 	logger := logging.FromContext(ctx)
 	db := database.FromContext(ctx, a.db)
@@ -149,6 +247,9 @@ func (a *articleDB) FindArticles(ctx context.Context, criteria IterateArticleCri
 	if criteria.Author != "" {
 		chain = chain.Where("au.username = ?", criteria.Author)
 	}
+	if criteria.AuthorID != 0 {
+		chain = chain.Where("a.author_id = ?", criteria.AuthorID)
+	}
 	if len(criteria.Tags) != 0 {
 		chain = chain.Joins("LEFT JOIN article_tags ats on ats.article_id = a.id").
 			Joins("LEFT JOIN tags t on t.id = ats.tag_id")
@@ -200,57 +301,91 @@ func (a *articleDB) FindArticles(ctx context.Context, criteria IterateArticleCri
 	}
 
 	// get tags by article ids
+	if err := a.loadTags(ctx, db, ret); err != nil {
+		return nil, 0, err
+	}
+	return ret, totalCount, nil
+}
+
+func (a *articleDB) FindArticlesBySlugs(ctx context.Context, slugs []string) ([]*model.Article, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.FindArticlesBySlugs", "slugs", slugs)
+
+	ret := []*model.Article{}
+	err := inBatches(len(slugs), func(from, to int) error {
+		var articles []*model.Article
+		err := db.WithContext(ctx).Joins("Author").
+			Where("slug IN ? AND deleted_at_unix = 0", slugs[from:to]).
+			Find(&articles).Error
+		if err != nil {
+			logger.Errorw("failed to find articles by slugs", "slugs", slugs[from:to], "err", err)
+			return err
+		}
+		ret = append(ret, articles...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.loadTags(ctx, db, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// loadTags loads tags of given articles with queries of article ids in batches.
+func (a *articleDB) loadTags(ctx context.Context, db *gorm.DB, articles []*model.Article) error {
+	logger := logging.FromContext(ctx)
+	ids := make([]uint, len(articles))
 	ma := make(map[uint]*model.Article)
-	for _, r := range ret {
-		ma[r.ID] = r
+	for i, article := range articles {
+		ids[i] = article.ID
+		ma[article.ID] = article
 	}
 	type ArticleTag struct {
 		model.Tag
 		ArticleId uint
 	}
-	batchSize := 100 // TODO : config
-	for i := 0; i < len(ret); i += batchSize {
+	return inBatches(len(ids), func(from, to int) error {
 		var at []*ArticleTag
-		last := i + batchSize
-		if last > len(ret) {
-			last = len(ret)
-		}
-
-		err = db.WithContext(ctx).Table("tags").
-			Where("article_tags.article_id IN (?)", ids[i:last]).
+		err := db.WithContext(ctx).Table("tags").
+			Where("article_tags.article_id IN (?)", ids[from:to]).
 			Joins("LEFT JOIN article_tags ON article_tags.tag_id = tags.id").
 			Select("tags.*, article_tags.article_id article_id").
 			Find(&at).Error
 
 		if err != nil {
-			logger.Error("failed to load tags by article ids", "articleIds", ids[i:last], "err", err)
-			return nil, 0, err
+			logger.Error("failed to load tags by article ids", "articleIds", ids[from:to], "err", err)
+			return err
 		}
 		for _, tag := range at {
 			a := ma[tag.ArticleId]
 			a.Tags = append(a.Tags, &tag.Tag)
 		}
-	}
-	return ret, totalCount, nil
+		return nil
+	})
 }
 
-func (a *articleDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug string) error {
+func (a *articleDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug string, version uint) error {
 	logger := logging.FromContext(ctx)
 	db := database.FromContext(ctx, a.db)
-	logger.Debugw("article.db.DeleteArticleBySlug", "slug", slug)
+	logger.Debugw("article.db.DeleteArticleBySlug", "slug", slug, "version", version)
 
 	// delete article
 	chain := db.WithContext(ctx).Model(&model.Article{}).
 		Where("slug = ? AND deleted_at_unix = 0", slug).
 		Where("author_id = ?", authorId).
+		Where("version = ?", version).
 		Update("deleted_at_unix", time.Now().Unix())
 	if chain.Error != nil {
 		logger.Errorw("failed to delete an article", "err", chain.Error)
 		return chain.Error
 	}
 	if chain.RowsAffected == 0 {
-		logger.Error("failed to delete an article because not found")
-		return database.ErrNotFound
+		err := a.versionConflictOrNotFound(db.WithContext(ctx).Where("slug = ? AND author_id = ?", slug, authorId))
+		logger.Errorw("failed to delete an article because not found or the version is changed", "err", err)
+		return err
 	}
 	// delete article tag relation
 	query := `DELETE ats FROM article_tags ats
@@ -262,3 +397,66 @@ func (a *articleDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug
 	}
 	return nil
 }
+
+func (a *articleDB) DeleteArticlesByAuthor(ctx context.Context, authorId uint) (int64, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.DeleteArticlesByAuthor", "authorId", authorId)
+
+	var deleted int64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// delete article tag relation
+		query := `DELETE ats FROM article_tags ats
+			JOIN articles a on a.id = ats.article_id
+			WHERE a.author_id = ? AND a.deleted_at_unix = 0;`
+		if err := tx.Exec(query, authorId).Error; err != nil {
+			return err
+		}
+		chain := tx.Model(&model.Article{}).
+			Where("author_id = ? AND deleted_at_unix = 0", authorId).
+			Update("deleted_at_unix", time.Now().Unix())
+		deleted = chain.RowsAffected
+		return chain.Error
+	})
+	if err != nil {
+		logger.Errorw("article.db.DeleteArticlesByAuthor failed to delete articles", "err", err)
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func (a *articleDB) ReassignAuthor(ctx context.Context, authorId, newAuthorId uint) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.ReassignAuthor", "authorId", authorId, "newAuthorId", newAuthorId)
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Article{}).Where("author_id = ?", authorId).Update("author_id", newAuthorId).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Comment{}).Where("author_id = ?", authorId).Update("author_id", newAuthorId).Error
+	})
+	if err != nil {
+		logger.Errorw("article.db.ReassignAuthor failed to reassign", "err", err)
+		return err
+	}
+	return nil
+}
+
+// batchSize is the max number of ids or slugs in a query of IN condition.
+const batchSize = 100 // TODO : config
+
+// inBatches calls given function with ranges [from, to) of n elements split by batchSize
+// and stops at the first error.
+func inBatches(n int, f func(from, to int) error) error {
+	for i := 0; i < n; i += batchSize {
+		last := i + batchSize
+		if last > n {
+			last = n
+		}
+		if err := f(i, last); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"gin-rest-api-example/internal/article/model"
 	"gin-rest-api-example/internal/cache"
 	"gin-rest-api-example/internal/metric"
+	"gin-rest-api-example/pkg/logging"
 )
 
 var _ ArticleDB = (*articleCacheDB)(nil)
@@ -40,7 +41,15 @@ func (ac *articleCacheDB) SaveArticle(ctx context.Context, article *model.Articl
 		return nil
 	}
 	key := ac.articleBySlugCacheKey(article.Slug)
-	ac.cacher.Set(ctx, key, article)
+	ac.cacher.Set(ctx, key, withoutAuthorSecrets(article))
+	return nil
+}
+
+func (ac *articleCacheDB) UpdateArticle(ctx context.Context, article *model.Article, version uint) error {
+	if err := ac.delegate.UpdateArticle(ctx, article, version); err != nil {
+		return err
+	}
+	ac.cacher.Delete(ctx, ac.articleBySlugCacheKey(article.Slug))
 	return nil
 }
 
@@ -56,7 +65,11 @@ func (ac *articleCacheDB) FindArticleBySlug(ctx context.Context, slug string) (*
 	)
 	err := ac.cacher.Fetch(ctx, key, &item, func() (interface{}, error) {
 		cacheHit = false
-		return ac.delegate.FindArticleBySlug(ctx, slug)
+		article, err := ac.delegate.FindArticleBySlug(ctx, slug)
+		if err != nil {
+			return nil, err
+		}
+		return withoutAuthorSecrets(article), nil
 	})
 	if err != nil {
 		return nil, err
@@ -69,14 +82,58 @@ func (ac *articleCacheDB) FindArticles(ctx context.Context, criteria IterateArti
 	return ac.delegate.FindArticles(ctx, criteria)
 }
 
-func (ac *articleCacheDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug string) error {
-	if err := ac.delegate.DeleteArticleBySlug(ctx, authorId, slug); err != nil {
+// FindArticlesBySlugs gets cached articles of given slugs at once and finds only missing articles from the delegate.
+// Found articles are cached one by one like FindArticleBySlug.
+func (ac *articleCacheDB) FindArticlesBySlugs(ctx context.Context, slugs []string) ([]*model.Article, error) {
+	if cache.IsCacheSkip(ctx) || len(slugs) == 0 {
+		return ac.delegate.FindArticlesBySlugs(ctx, slugs)
+	}
+
+	keys := make([]string, len(slugs))
+	for i, slug := range slugs {
+		keys[i] = ac.articleBySlugCacheKey(slug)
+	}
+	cached, err := ac.cacher.MGet(ctx, keys, func() interface{} { return &model.Article{} })
+	if err != nil {
+		// find all articles from the delegate if the cache is not available
+		logging.FromContext(ctx).Warnw("article.cache.FindArticlesBySlugs failed to get cached articles", "err", err)
+		cached = map[string]interface{}{}
+	}
+
+	var (
+		ret     []*model.Article
+		missing []string
+	)
+	for i, slug := range slugs {
+		if item, ok := cached[keys[i]]; ok {
+			ret = append(ret, item.(*model.Article))
+			ac.mp.RecordCache(cacheKeyArticleBySlug, true)
+			continue
+		}
+		missing = append(missing, slug)
+		ac.mp.RecordCache(cacheKeyArticleBySlug, false)
+	}
+	if len(missing) == 0 {
+		return ret, nil
+	}
+	found, err := ac.delegate.FindArticlesBySlugs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, article := range found {
+		ac.cacher.Set(ctx, ac.articleBySlugCacheKey(article.Slug), withoutAuthorSecrets(article))
+	}
+	return append(ret, found...), nil
+}
+
+func (ac *articleCacheDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug string, version uint) error {
+	if err := ac.delegate.DeleteArticleBySlug(ctx, authorId, slug, version); err != nil {
 		return err
 	}
 	// TODO: require tx?
 	key := ac.articleBySlugCacheKey(slug)
 	if exists, _ := ac.cacher.Exists(ctx, key); exists {
-		ac.cacher.Delete(ctx, slug)
+		ac.cacher.Delete(ctx, key)
 	}
 	return nil
 }
@@ -89,6 +146,10 @@ func (ac *articleCacheDB) FindComments(ctx context.Context, slug string) ([]*mod
 	return ac.delegate.FindComments(ctx, slug)
 }
 
+func (ac *articleCacheDB) FindCommentsBySlugs(ctx context.Context, slugs []string) (map[string][]*model.Comment, error) {
+	return ac.delegate.FindCommentsBySlugs(ctx, slugs)
+}
+
 func (ac *articleCacheDB) DeleteCommentById(ctx context.Context, authorId uint, slug string, id uint) error {
 	return ac.delegate.DeleteCommentById(ctx, authorId, slug, id)
 }
@@ -97,6 +158,72 @@ func (ac *articleCacheDB) DeleteComments(ctx context.Context, authorId uint, slu
 	return ac.delegate.DeleteComments(ctx, authorId, slug)
 }
 
+func (ac *articleCacheDB) FindCommentsByAuthor(ctx context.Context, authorId uint) ([]*model.Comment, error) {
+	return ac.delegate.FindCommentsByAuthor(ctx, authorId)
+}
+
+func (ac *articleCacheDB) DeleteArticlesByAuthor(ctx context.Context, authorId uint) (int64, error) {
+	slugs, err := ac.articleSlugsByAuthor(ctx, authorId)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := ac.delegate.DeleteArticlesByAuthor(ctx, authorId)
+	if err != nil {
+		return 0, err
+	}
+	ac.deleteArticlesBySlug(ctx, slugs)
+	return deleted, nil
+}
+
+func (ac *articleCacheDB) DeleteCommentsByAuthor(ctx context.Context, authorId uint) (int64, error) {
+	return ac.delegate.DeleteCommentsByAuthor(ctx, authorId)
+}
+
+func (ac *articleCacheDB) ReassignAuthor(ctx context.Context, authorId, newAuthorId uint) error {
+	slugs, err := ac.articleSlugsByAuthor(ctx, authorId)
+	if err != nil {
+		return err
+	}
+	if err := ac.delegate.ReassignAuthor(ctx, authorId, newAuthorId); err != nil {
+		return err
+	}
+	// cached articles have the previous author
+	ac.deleteArticlesBySlug(ctx, slugs)
+	return nil
+}
+
+// articleSlugsByAuthor returns slugs of all articles of given author id to evict cached articles
+func (ac *articleCacheDB) articleSlugsByAuthor(ctx context.Context, authorId uint) ([]string, error) {
+	const limit = 100
+	var slugs []string
+	for offset := uint(0); ; offset += limit {
+		articles, _, err := ac.delegate.FindArticles(ctx, IterateArticleCriteria{AuthorID: authorId, Offset: offset, Limit: limit})
+		if err != nil {
+			return nil, err
+		}
+		for _, article := range articles {
+			slugs = append(slugs, article.Slug)
+		}
+		if len(articles) < limit {
+			return slugs, nil
+		}
+	}
+}
+
+func (ac *articleCacheDB) deleteArticlesBySlug(ctx context.Context, slugs []string) {
+	for _, slug := range slugs {
+		ac.cacher.Delete(ctx, ac.articleBySlugCacheKey(slug))
+	}
+}
+
+// withoutAuthorSecrets returns a copy of given article without the password and the mfa secret of the author to cache.
+func withoutAuthorSecrets(article *model.Article) *model.Article {
+	a := *article
+	a.Author.Password = ""
+	a.Author.MFASecret = ""
+	return &a
+}
+
 func (ac *articleCacheDB) articleBySlugCacheKey(slug string) string {
 	return fmt.Sprintf("%s.%s", cacheKeyArticleBySlug, slug)
 }
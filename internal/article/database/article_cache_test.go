@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	accountModel "gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/metric"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubArticleDB finds articles of given slugs and fails other calls.
+type stubArticleDB struct {
+	ArticleDB
+	articles map[string]*model.Article
+}
+
+func (s *stubArticleDB) SaveArticle(_ context.Context, _ *model.Article) error {
+	return nil
+}
+
+func (s *stubArticleDB) FindArticleBySlug(_ context.Context, slug string) (*model.Article, error) {
+	if a, ok := s.articles[slug]; ok {
+		return a, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (s *stubArticleDB) FindArticlesBySlugs(_ context.Context, slugs []string) ([]*model.Article, error) {
+	var ret []*model.Article
+	for _, slug := range slugs {
+		if a, ok := s.articles[slug]; ok {
+			ret = append(ret, a)
+		}
+	}
+	return ret, nil
+}
+
+func TestArticleCacheDB_WithoutAuthorSecrets(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	author := accountModel.Account{ID: 1, Username: "user1", Password: "hashed", MFASecret: "secret"}
+	delegate := &stubArticleDB{articles: map[string]*model.Article{
+		"title-1": {Slug: "title-1", Title: "title 1", Author: author},
+		"title-2": {Slug: "title-2", Title: "title 2", Author: author},
+		"title-3": {Slug: "title-3", Title: "title 3", Author: author},
+	}}
+	cacher := cache.NewMemoryCacher(time.Minute)
+	db := newarticleCacheDB(cacher, metric.NewMetricsProvider(cfg), delegate)
+	ctx := context.Background()
+
+	// when
+	assert.NoError(t, db.SaveArticle(ctx, delegate.articles["title-1"]))
+	_, err = db.FindArticleBySlug(ctx, "title-2")
+	assert.NoError(t, err)
+	_, err = db.FindArticlesBySlugs(ctx, []string{"title-3"})
+	assert.NoError(t, err)
+
+	// then
+	for _, slug := range []string{"title-1", "title-2", "title-3"} {
+		var cached model.Article
+		assert.NoError(t, cacher.Get(ctx, "article-by-slug."+slug, &cached))
+		assert.Equal(t, "user1", cached.Author.Username)
+		assert.Empty(t, cached.Author.Password)
+		assert.Empty(t, cached.Author.MFASecret)
+	}
+	// articles of the delegate are not modified
+	assert.Equal(t, "hashed", author.Password)
+	assert.Equal(t, "hashed", delegate.articles["title-1"].Author.Password)
+}
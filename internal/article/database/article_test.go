@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	accountDB "gin-rest-api-example/internal/account/database"
 	accountModel "gin-rest-api-example/internal/account/model"
 	"gin-rest-api-example/internal/article/model"
@@ -69,6 +70,7 @@ func (s *DBSuite) TestSaveArticle() {
 	s.WithinDuration(now, find.CreatedAt, time.Second)
 	s.WithinDuration(now, find.UpdatedAt, time.Second)
 	s.Equal(int64(0), find.DeletedAtUnix)
+	s.Equal(uint(1), find.Version)
 	s.Equal(article.Author, dUser)
 	s.assertArticleTag(find, []string{"tag1", "tag2"})
 }
@@ -77,7 +79,7 @@ func (s *DBSuite) TestSaveArticle_WithSameSlugAfterDeleted() {
 	// given
 	article := newArticle("title1", "title1", "body", dUser, []string{"tag1", "tag2"})
 	s.NoError(s.db.SaveArticle(nil, article))
-	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug))
+	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug, article.Version))
 
 	article2 := newArticle(article.Slug, article.Title, article.Body, dUser, []string{})
 
@@ -132,7 +134,7 @@ func (s *DBSuite) TestFindArticleBySlug_FailIfDeleted() {
 	s.NoError(s.db.SaveArticle(nil, article))
 	_, err := s.db.FindArticleBySlug(nil, article.Slug)
 	s.NoError(err)
-	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug))
+	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug, article.Version))
 
 	// when
 	find, err := s.db.FindArticleBySlug(nil, article.Slug)
@@ -168,7 +170,7 @@ func (s *DBSuite) TestFindArticles() {
 	s.NoError(s.db.SaveArticle(nil, article5))
 	article6 := newArticle("article6", "article6", "body6", user1, []string{"tag1"})
 	s.NoError(s.db.SaveArticle(nil, article6))
-	s.NoError(s.db.DeleteArticleBySlug(nil, user1.ID, article6.Slug))
+	s.NoError(s.db.DeleteArticleBySlug(nil, user1.ID, article6.Slug, article6.Version))
 
 	user2 := accountModel.Account{Username: "test-user2", Email: "test-user2@gmail.com", Password: "password"}
 	s.NoError(s.accountDB.Save(nil, &user2))
@@ -203,13 +205,46 @@ func (s *DBSuite) TestFindArticles() {
 	s.assertArticle(article1, results[0])
 }
 
+func (s *DBSuite) TestFindArticlesBySlugs() {
+	// given
+	article1 := newArticle("article1", "article1", "body1", dUser, []string{"tag1", "tag2"})
+	s.NoError(s.db.SaveArticle(nil, article1))
+	article2 := newArticle("article2", "article2", "body2", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, article2))
+	article3 := newArticle("article3", "article3", "body3", dUser, []string{"tag3"})
+	s.NoError(s.db.SaveArticle(nil, article3))
+	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article3.Slug, article3.Version))
+
+	// when
+	results, err := s.db.FindArticlesBySlugs(nil, []string{article2.Slug, "not-exist-slug", article1.Slug, article3.Slug})
+
+	// then
+	s.NoError(err)
+	s.Equal(2, len(results))
+	found := make(map[string]*model.Article)
+	for _, result := range results {
+		found[result.Slug] = result
+	}
+	s.assertArticle(article1, found[article1.Slug])
+	s.assertArticle(article2, found[article2.Slug])
+}
+
+func (s *DBSuite) TestFindArticlesBySlugs_Empty() {
+	// when
+	results, err := s.db.FindArticlesBySlugs(nil, nil)
+
+	// then
+	s.NoError(err)
+	s.Empty(results)
+}
+
 func (s *DBSuite) TestDeleteArticleBySlug() {
 	// given
 	article := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
 	s.NoError(s.db.SaveArticle(nil, article))
 
 	// when
-	err := s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug)
+	err := s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug, article.Version)
 
 	// then
 	s.NoError(err)
@@ -218,13 +253,67 @@ func (s *DBSuite) TestDeleteArticleBySlug() {
 	s.Equal(database.ErrNotFound, err)
 }
 
+func (s *DBSuite) TestDeleteArticleBySlug_FailIfVersionChanged() {
+	// given
+	article := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, article))
+
+	// when
+	err := s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug, article.Version+1)
+
+	// then
+	s.Equal(ErrVersionConflict, err)
+	_, err = s.db.FindArticleBySlug(nil, article.Slug)
+	s.NoError(err)
+}
+
+func (s *DBSuite) TestUpdateArticle() {
+	// given
+	article := newArticle("title1", "title1", "body", dUser, []string{"tag1", "tag2"})
+	s.NoError(s.db.SaveArticle(nil, article))
+	update := *article
+	update.Title = "title2"
+	update.Body = "body2"
+	update.Tags = []*model.Tag{{Name: "tag2"}, {Name: "tag3"}}
+
+	// when
+	err := s.db.UpdateArticle(nil, &update, article.Version)
+
+	// then
+	s.NoError(err)
+	s.Equal(article.Version+1, update.Version)
+	find, err := s.db.FindArticleBySlug(nil, article.Slug)
+	s.NoError(err)
+	s.Equal("title2", find.Title)
+	s.Equal("body2", find.Body)
+	s.Equal(update.Version, find.Version)
+	s.Equal(update.UpdatedAt.Unix(), find.UpdatedAt.Unix())
+	s.assertArticleTag(find, []string{"tag2", "tag3"})
+}
+
+func (s *DBSuite) TestUpdateArticle_Fail() {
+	// given
+	article := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, article))
+	deleted := newArticle("title2", "title2", "body", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, deleted))
+	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, deleted.Slug, deleted.Version))
+
+	// when then
+	s.Equal(ErrVersionConflict, s.db.UpdateArticle(nil, article, article.Version+1))
+	s.Equal(database.ErrNotFound, s.db.UpdateArticle(nil, deleted, deleted.Version))
+	find, err := s.db.FindArticleBySlug(nil, article.Slug)
+	s.NoError(err)
+	s.Equal(uint(1), find.Version)
+}
+
 func (s *DBSuite) TestDeleteArticleBySlug_FailIfNotExist() {
 	// given
 	article := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
 	s.NoError(s.db.SaveArticle(nil, article))
 	article2 := newArticle("title2", "title2", "body", dUser, []string{"tag1"})
 	s.NoError(s.db.SaveArticle(nil, article2))
-	s.NoError(s.db.DeleteArticleBySlug(nil, article2.Author.ID, article2.Slug))
+	s.NoError(s.db.DeleteArticleBySlug(nil, article2.Author.ID, article2.Slug, article2.Version))
 
 	cases := []struct {
 		AuthorID uint
@@ -244,7 +333,7 @@ func (s *DBSuite) TestDeleteArticleBySlug_FailIfNotExist() {
 
 	for _, tc := range cases {
 		// when
-		err := s.db.DeleteArticleBySlug(nil, tc.AuthorID, tc.Slug)
+		err := s.db.DeleteArticleBySlug(nil, tc.AuthorID, tc.Slug, 1)
 
 		// then
 		s.Error(err)
@@ -297,3 +386,47 @@ func newArticle(slug, title, body string, author accountModel.Account, tags []st
 		Tags:   tagArr,
 	}
 }
+
+func (s *DBSuite) TestDeleteArticlesByAuthor() {
+	// given
+	for i := 0; i < 3; i++ {
+		slug := fmt.Sprintf("title%d", i)
+		s.NoError(s.db.SaveArticle(nil, newArticle(slug, slug, "body", dUser, []string{"tag1"})))
+	}
+
+	// when
+	deleted, err := s.db.DeleteArticlesByAuthor(nil, dUser.ID)
+
+	// then
+	s.NoError(err)
+	s.Equal(int64(3), deleted)
+	articles, total, err := s.db.FindArticles(nil, IterateArticleCriteria{AuthorID: dUser.ID, Limit: 10})
+	s.NoError(err)
+	s.Empty(articles)
+	s.Equal(int64(0), total)
+}
+
+func (s *DBSuite) TestReassignAuthor() {
+	// given
+	other := accountModel.Account{Username: "ghost", Email: "ghost@gmail.com", Password: "password"}
+	s.NoError(s.accountDB.Save(nil, &other))
+	article := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, article))
+	comment := model.Comment{Body: "comment1", Author: dUser}
+	s.NoError(s.db.SaveComment(nil, article.Slug, &comment))
+
+	// when
+	err := s.db.ReassignAuthor(nil, dUser.ID, other.ID)
+
+	// then
+	s.NoError(err)
+	find, err := s.db.FindArticleBySlug(nil, article.Slug)
+	s.NoError(err)
+	s.Equal(other.ID, find.AuthorID)
+	comments, err := s.db.FindCommentsByAuthor(nil, other.ID)
+	s.NoError(err)
+	s.Len(comments, 1)
+	comments, err = s.db.FindCommentsByAuthor(nil, dUser.ID)
+	s.NoError(err)
+	s.Empty(comments)
+}
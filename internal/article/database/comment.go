@@ -5,6 +5,8 @@ import (
 	"gin-rest-api-example/internal/article/model"
 	"gin-rest-api-example/internal/database"
 	"gin-rest-api-example/pkg/logging"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -51,6 +53,33 @@ func (a *articleDB) FindComments(ctx context.Context, slug string) ([]*model.Com
 	return ret, nil
 }
 
+func (a *articleDB) FindCommentsBySlugs(ctx context.Context, slugs []string) (map[string][]*model.Comment, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.FindCommentsBySlugs", "slugs", slugs)
+
+	ret := make(map[string][]*model.Comment)
+	err := inBatches(len(slugs), func(from, to int) error {
+		var comments []*model.Comment
+		err := db.Joins("Author").
+			Where("slug IN ? AND deleted_at IS NULL", slugs[from:to]).
+			Order("id DESC").
+			Find(&comments).Error
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			ret[comment.Slug] = append(ret[comment.Slug], comment)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorw("article.db.FindCommentsBySlugs failed to find comments", "err", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
 func (a *articleDB) DeleteCommentById(ctx context.Context, authorId uint, slug string, id uint) error {
 	logger := logging.FromContext(ctx)
 	db := database.FromContext(ctx, a.db)
@@ -86,3 +115,35 @@ func (a *articleDB) DeleteComments(ctx context.Context, authorId uint, slug stri
 	}
 	return chain.RowsAffected, nil
 }
+
+func (a *articleDB) FindCommentsByAuthor(ctx context.Context, authorId uint) ([]*model.Comment, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.FindCommentsByAuthor", "authorId", authorId)
+
+	var ret []*model.Comment
+	err := db.WithContext(ctx).
+		Where("author_id = ? AND deleted_at IS NULL", authorId).
+		Order("id ASC").
+		Find(&ret).Error
+	if err != nil {
+		logger.Errorw("article.db.FindCommentsByAuthor failed to find comments", "err", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (a *articleDB) DeleteCommentsByAuthor(ctx context.Context, authorId uint) (int64, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("article.db.DeleteCommentsByAuthor", "authorId", authorId)
+
+	chain := db.WithContext(ctx).Model(&model.Comment{}).
+		Where("author_id = ? AND deleted_at IS NULL", authorId).
+		Update("deleted_at", time.Now())
+	if chain.Error != nil {
+		logger.Errorw("article.db.DeleteCommentsByAuthor failed to delete comments", "err", chain.Error)
+		return 0, chain.Error
+	}
+	return chain.RowsAffected, nil
+}
@@ -23,7 +23,7 @@ func (s *DBSuite) TestSaveComment() {
 func (s *DBSuite) TestSaveComment_FailIfNotExistOrDeleted() {
 	article := newArticle("title1", "title1", "body", dUser, []string{"tag1", "tag2"})
 	s.NoError(s.db.SaveArticle(nil, article))
-	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug))
+	s.NoError(s.db.DeleteArticleBySlug(nil, dUser.ID, article.Slug, article.Version))
 
 	cases := []struct {
 		Slug string
@@ -64,6 +64,32 @@ func (s *DBSuite) TestFindComments() {
 	s.assertArticleComment(&c1, comments[1])
 }
 
+func (s *DBSuite) TestFindCommentsBySlugs() {
+	// given
+	article1 := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, article1))
+	article2 := newArticle("title2", "title2", "body", dUser, []string{"tag2"})
+	s.NoError(s.db.SaveArticle(nil, article2))
+	c1 := model.Comment{Body: "comment1", Author: dUser}
+	s.NoError(s.db.SaveComment(nil, article1.Slug, &c1))
+	c2 := model.Comment{Body: "comment2", Author: dUser}
+	s.NoError(s.db.SaveComment(nil, article1.Slug, &c2))
+	c3 := model.Comment{Body: "comment3", Author: dUser}
+	s.NoError(s.db.SaveComment(nil, article2.Slug, &c3))
+
+	// when
+	comments, err := s.db.FindCommentsBySlugs(nil, []string{article1.Slug, article2.Slug, "unknown"})
+
+	// then
+	s.NoError(err)
+	s.Len(comments, 2)
+	s.Len(comments[article1.Slug], 2)
+	s.assertArticleComment(&c2, comments[article1.Slug][0])
+	s.assertArticleComment(&c1, comments[article1.Slug][1])
+	s.Len(comments[article2.Slug], 1)
+	s.assertArticleComment(&c3, comments[article2.Slug][0])
+}
+
 func (s *DBSuite) TestDeleteCommentById() {
 	// given
 	article := newArticle("title1", "title1", "body", dUser, []string{"tag1", "tag2"})
@@ -164,3 +190,23 @@ func (s *DBSuite) assertArticleComment(expected, actual *model.Comment) {
 	s.WithinDuration(expected.UpdatedAt, actual.UpdatedAt, time.Second)
 	s.Equal(expected.DeletedAt, actual.DeletedAt)
 }
+
+func (s *DBSuite) TestDeleteCommentsByAuthor() {
+	// given
+	article := newArticle("title1", "title1", "body", dUser, []string{"tag1"})
+	s.NoError(s.db.SaveArticle(nil, article))
+	for i := 0; i < 2; i++ {
+		c := model.Comment{Body: "comment", Author: dUser}
+		s.NoError(s.db.SaveComment(nil, article.Slug, &c))
+	}
+
+	// when
+	deleted, err := s.db.DeleteCommentsByAuthor(nil, dUser.ID)
+
+	// then
+	s.NoError(err)
+	s.Equal(int64(2), deleted)
+	find, err := s.db.FindCommentsByAuthor(nil, dUser.ID)
+	s.NoError(err)
+	s.Empty(find)
+}
@@ -16,13 +16,13 @@ type ArticleDB struct {
 	mock.Mock
 }
 
-// DeleteArticleBySlug provides a mock function with given fields: ctx, authorId, slug
-func (_m *ArticleDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug string) error {
-	ret := _m.Called(ctx, authorId, slug)
+// DeleteArticleBySlug provides a mock function with given fields: ctx, authorId, slug, version
+func (_m *ArticleDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slug string, version uint) error {
+	ret := _m.Called(ctx, authorId, slug, version)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
-		r0 = rf(ctx, authorId, slug)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, uint) error); ok {
+		r0 = rf(ctx, authorId, slug, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -30,6 +30,27 @@ func (_m *ArticleDB) DeleteArticleBySlug(ctx context.Context, authorId uint, slu
 	return r0
 }
 
+// DeleteArticlesByAuthor provides a mock function with given fields: ctx, authorId
+func (_m *ArticleDB) DeleteArticlesByAuthor(ctx context.Context, authorId uint) (int64, error) {
+	ret := _m.Called(ctx, authorId)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, uint) int64); ok {
+		r0 = rf(ctx, authorId)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, authorId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteCommentById provides a mock function with given fields: ctx, authorId, slug, id
 func (_m *ArticleDB) DeleteCommentById(ctx context.Context, authorId uint, slug string, id uint) error {
 	ret := _m.Called(ctx, authorId, slug, id)
@@ -65,6 +86,27 @@ func (_m *ArticleDB) DeleteComments(ctx context.Context, authorId uint, slug str
 	return r0, r1
 }
 
+// DeleteCommentsByAuthor provides a mock function with given fields: ctx, authorId
+func (_m *ArticleDB) DeleteCommentsByAuthor(ctx context.Context, authorId uint) (int64, error) {
+	ret := _m.Called(ctx, authorId)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, uint) int64); ok {
+		r0 = rf(ctx, authorId)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, authorId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindArticleBySlug provides a mock function with given fields: ctx, slug
 func (_m *ArticleDB) FindArticleBySlug(ctx context.Context, slug string) (*model.Article, error) {
 	ret := _m.Called(ctx, slug)
@@ -118,6 +160,29 @@ func (_m *ArticleDB) FindArticles(ctx context.Context, criteria database.Iterate
 	return r0, r1, r2
 }
 
+// FindArticlesBySlugs provides a mock function with given fields: ctx, slugs
+func (_m *ArticleDB) FindArticlesBySlugs(ctx context.Context, slugs []string) ([]*model.Article, error) {
+	ret := _m.Called(ctx, slugs)
+
+	var r0 []*model.Article
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*model.Article); ok {
+		r0 = rf(ctx, slugs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Article)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, slugs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindComments provides a mock function with given fields: ctx, slug
 func (_m *ArticleDB) FindComments(ctx context.Context, slug string) ([]*model.Comment, error) {
 	ret := _m.Called(ctx, slug)
@@ -141,6 +206,66 @@ func (_m *ArticleDB) FindComments(ctx context.Context, slug string) ([]*model.Co
 	return r0, r1
 }
 
+// FindCommentsByAuthor provides a mock function with given fields: ctx, authorId
+func (_m *ArticleDB) FindCommentsByAuthor(ctx context.Context, authorId uint) ([]*model.Comment, error) {
+	ret := _m.Called(ctx, authorId)
+
+	var r0 []*model.Comment
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []*model.Comment); ok {
+		r0 = rf(ctx, authorId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, authorId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindCommentsBySlugs provides a mock function with given fields: ctx, slugs
+func (_m *ArticleDB) FindCommentsBySlugs(ctx context.Context, slugs []string) (map[string][]*model.Comment, error) {
+	ret := _m.Called(ctx, slugs)
+
+	var r0 map[string][]*model.Comment
+	if rf, ok := ret.Get(0).(func(context.Context, []string) map[string][]*model.Comment); ok {
+		r0 = rf(ctx, slugs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]*model.Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, slugs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReassignAuthor provides a mock function with given fields: ctx, authorId, newAuthorId
+func (_m *ArticleDB) ReassignAuthor(ctx context.Context, authorId uint, newAuthorId uint) error {
+	ret := _m.Called(ctx, authorId, newAuthorId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, authorId, newAuthorId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RunInTx provides a mock function with given fields: ctx, f
 func (_m *ArticleDB) RunInTx(ctx context.Context, f func(context.Context) error) error {
 	ret := _m.Called(ctx, f)
@@ -183,6 +308,20 @@ func (_m *ArticleDB) SaveComment(ctx context.Context, slug string, comment *mode
 	return r0
 }
 
+// UpdateArticle provides a mock function with given fields: ctx, article, version
+func (_m *ArticleDB) UpdateArticle(ctx context.Context, article *model.Article, version uint) error {
+	ret := _m.Called(ctx, article, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Article, uint) error); ok {
+		r0 = rf(ctx, article, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewArticleDB interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,64 @@
+package article
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// articleETag returns a strong entity tag of given article in the representation from the version, the update time
+// and a hash of contents. The name of the representation is hashed too, since representations of api versions
+// are not byte-for-byte identical.
+func articleETag(a *model.Article, rep representation) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%d\n%s\n%s\n", rep.name(), a.UpdatedAt.Unix(), a.Title, a.Body)
+	for _, tag := range a.Tags {
+		fmt.Fprintf(h, "%s\n", tag.Name)
+	}
+	fmt.Fprintf(h, "%s\n%s\n%s\n", a.Author.Username, a.Author.Bio, a.Author.Image)
+	return fmt.Sprintf(`"%d-%s"`, a.Version, hex.EncodeToString(h.Sum(nil))[:16])
+}
+
+// matchETag returns true if given If-Match or If-None-Match header contains the etag or "*".
+// Weak entity tags match only if weak is true i.e. the weak comparison of If-None-Match.
+func matchETag(header, etag string, weak bool) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" {
+			return true
+		}
+		if strings.HasPrefix(value, "W/") {
+			if !weak {
+				continue
+			}
+			value = strings.TrimPrefix(value, "W/")
+		}
+		if value == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch returns an error response if If-Match header is missing or does not match the etag of given article
+// in the representation of the request.
+func checkIfMatch(c *gin.Context, a *model.Article) *handler.Response {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return handler.NewErrorResponse(http.StatusPreconditionRequired, handler.PreconditionRequired, "If-Match header required", nil)
+	}
+	if !matchETag(ifMatch, articleETag(a, representationOf(c)), false) {
+		return newArticleModifiedResponse()
+	}
+	return nil
+}
+
+func newArticleModifiedResponse() *handler.Response {
+	return handler.NewErrorResponse(http.StatusPreconditionFailed, handler.PreconditionFailed, "article has been modified", nil)
+}
@@ -0,0 +1,333 @@
+package article
+
+import (
+	"context"
+	"gin-rest-api-example/internal/account"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware"
+	articlev1 "gin-rest-api-example/pkg/pb/article/v1"
+	"strconv"
+
+	"github.com/gosimple/slug"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCServer serves articlev1.ArticleService by the ArticleDB of the Handler.
+// Requests are validated by binding tags of REST request types.
+type GRPCServer struct {
+	articlev1.UnimplementedArticleServiceServer
+	cfg *config.Config
+	h   *Handler
+}
+
+func NewGRPCServer(cfg *config.Config, h *Handler) *GRPCServer {
+	return &GRPCServer{cfg: cfg, h: h}
+}
+
+// Register registers the service to given server.
+func (s *GRPCServer) Register(server *grpc.Server) {
+	articlev1.RegisterArticleServiceServer(server, s)
+}
+
+// GetArticle is the gRPC equivalent of GET /v1/api/articles/:slug
+func (s *GRPCServer) GetArticle(ctx context.Context, req *articlev1.GetArticleRequest) (*articlev1.Article, error) {
+	if err := middleware.ValidateMessage(&ArticleURI{Slug: req.Slug}, "uri"); err != nil {
+		return nil, err
+	}
+	article, err := s.h.articleDB.FindArticleBySlug(ctx, req.Slug)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, status.Error(codes.NotFound, "not found article")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.GetArticle", err)
+	}
+	return newArticleMessage(article), nil
+}
+
+// ListArticles is the gRPC equivalent of GET /v1/api/articles
+func (s *GRPCServer) ListArticles(ctx context.Context, req *articlev1.ListArticlesRequest) (*articlev1.ListArticlesResponse, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = 5
+	}
+	query := ListArticlesQuery{
+		Tag:    req.Tags,
+		Author: req.Author,
+		Limit:  strconv.FormatUint(uint64(limit), 10),
+		Offset: strconv.FormatUint(uint64(req.Offset), 10),
+	}
+	if err := middleware.ValidateMessage(&query, "form"); err != nil {
+		return nil, err
+	}
+	articles, total, err := s.h.articleDB.FindArticles(ctx, articleDB.IterateArticleCriteria{
+		Tags:   req.Tags,
+		Author: req.Author,
+		Offset: uint(req.Offset),
+		Limit:  uint(limit),
+	})
+	if err != nil {
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.ListArticles", err)
+	}
+	res := articlev1.ListArticlesResponse{Total: total}
+	for _, article := range articles {
+		res.Articles = append(res.Articles, newArticleMessage(article))
+	}
+	return &res, nil
+}
+
+// CreateArticle is the gRPC equivalent of POST /v1/api/articles
+func (s *GRPCServer) CreateArticle(ctx context.Context, req *articlev1.CreateArticleRequest) (*articlev1.Article, error) {
+	currentUser, err := account.RequireUser(ctx, s.cfg, account.ScopeArticleWrite, account.RestrictWriteArticle)
+	if err != nil {
+		return nil, err
+	}
+	var body SaveArticleRequest
+	body.Article.Title = req.Title
+	body.Article.Body = req.Body
+	body.Article.Tags = req.Tags
+	if err := middleware.ValidateMessage(&body, "json"); err != nil {
+		return nil, err
+	}
+
+	var tags []*model.Tag
+	for _, tag := range req.Tags {
+		tags = append(tags, &model.Tag{Name: tag})
+	}
+	article := model.Article{
+		Slug:     slug.Make(req.Title),
+		Title:    req.Title,
+		Body:     req.Body,
+		Author:   *currentUser,
+		AuthorID: currentUser.ID,
+		Tags:     tags,
+	}
+	if err := s.h.articleDB.SaveArticle(ctx, &article); err != nil {
+		if database.IsKeyConflictErr(err) {
+			return nil, status.Error(codes.AlreadyExists, "duplicate article title")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.CreateArticle", err)
+	}
+	return newArticleMessage(&article), nil
+}
+
+// UpdateArticle is the gRPC equivalent of PUT /v1/api/articles/:slug without the precondition of an etag.
+func (s *GRPCServer) UpdateArticle(ctx context.Context, req *articlev1.UpdateArticleRequest) (*articlev1.Article, error) {
+	currentUser, err := account.RequireUser(ctx, s.cfg, account.ScopeArticleWrite, account.RestrictWriteArticle)
+	if err != nil {
+		return nil, err
+	}
+	var body UpdateArticleRequest
+	body.Article.Title = req.Title
+	body.Article.Body = req.Body
+	if req.Tags != nil {
+		tags := req.Tags.GetTags()
+		body.Article.Tags = &tags
+	}
+	if err := middleware.ValidateMessage(&ArticleURI{Slug: req.Slug}, "uri"); err != nil {
+		return nil, err
+	}
+	if err := middleware.ValidateMessage(&body, "json"); err != nil {
+		return nil, err
+	}
+
+	// find the article of the current user
+	article, err := s.h.articleDB.FindArticleBySlug(ctx, req.Slug)
+	if err != nil && !database.IsRecordNotFoundErr(err) {
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.UpdateArticle", err)
+	}
+	if err != nil || article.AuthorID != currentUser.ID {
+		return nil, status.Error(codes.NotFound, "not found article")
+	}
+
+	// update the article if not modified since found
+	before := articleAuditFields(article)
+	if body.Article.Title != nil {
+		article.Title = *body.Article.Title
+	}
+	if body.Article.Body != nil {
+		article.Body = *body.Article.Body
+	}
+	if body.Article.Tags != nil {
+		article.Tags = nil
+		for _, tag := range *body.Article.Tags {
+			article.Tags = append(article.Tags, &model.Tag{Name: tag})
+		}
+	}
+	if err := s.h.articleDB.UpdateArticle(ctx, article, article.Version); err != nil {
+		switch {
+		case err == articleDB.ErrVersionConflict:
+			return nil, status.Error(codes.Aborted, "article has been modified")
+		case database.IsRecordNotFoundErr(err):
+			return nil, status.Error(codes.NotFound, "not found article")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.UpdateArticle", err)
+	}
+	s.h.auditor.RecordContext(ctx, middleware.PeerIP(ctx), audit.Event{
+		Action:     audit.ActionArticleUpdate,
+		ActorID:    currentUser.ID,
+		TargetType: audit.TargetArticle,
+		TargetID:   article.Slug,
+		Changes:    audit.Diff(before, articleAuditFields(article)),
+	})
+	return newArticleMessage(article), nil
+}
+
+// DeleteArticle is the gRPC equivalent of DELETE /v1/api/articles/:slug without the precondition of an etag.
+func (s *GRPCServer) DeleteArticle(ctx context.Context, req *articlev1.DeleteArticleRequest) (*emptypb.Empty, error) {
+	currentUser, err := account.RequireUser(ctx, s.cfg, account.ScopeArticleWrite, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := middleware.ValidateMessage(&ArticleURI{Slug: req.Slug}, "uri"); err != nil {
+		return nil, err
+	}
+
+	// find the article of the current user
+	article, err := s.h.articleDB.FindArticleBySlug(ctx, req.Slug)
+	if err != nil && !database.IsRecordNotFoundErr(err) {
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.DeleteArticle", err)
+	}
+	if err != nil || article.AuthorID != currentUser.ID {
+		return nil, status.Error(codes.NotFound, "not found article")
+	}
+
+	// delete article and comments with in transaction
+	err = s.h.articleDB.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.h.articleDB.DeleteArticleBySlug(ctx, currentUser.ID, req.Slug, article.Version); err != nil {
+			return err
+		}
+		_, err := s.h.articleDB.DeleteComments(ctx, currentUser.ID, req.Slug)
+		return err
+	})
+	if err != nil {
+		if errors.Cause(err) == articleDB.ErrVersionConflict {
+			return nil, status.Error(codes.Aborted, "article has been modified")
+		}
+		if database.IsRecordNotFoundErr(errors.Cause(err)) {
+			return nil, status.Error(codes.NotFound, "not found article")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.DeleteArticle", err)
+	}
+	s.h.auditor.RecordContext(ctx, middleware.PeerIP(ctx), audit.Event{
+		Action:     audit.ActionArticleDelete,
+		ActorID:    currentUser.ID,
+		TargetType: audit.TargetArticle,
+		TargetID:   req.Slug,
+	})
+	return &emptypb.Empty{}, nil
+}
+
+// ListComments is the gRPC equivalent of GET /v1/api/articles/:slug/comments
+func (s *GRPCServer) ListComments(ctx context.Context, req *articlev1.ListCommentsRequest) (*articlev1.ListCommentsResponse, error) {
+	if err := middleware.ValidateMessage(&ArticleURI{Slug: req.Slug}, "uri"); err != nil {
+		return nil, err
+	}
+	comments, err := s.h.articleDB.FindComments(ctx, req.Slug)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, status.Error(codes.NotFound, "not found article")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.ListComments", err)
+	}
+	var res articlev1.ListCommentsResponse
+	for _, comment := range comments {
+		res.Comments = append(res.Comments, newCommentMessage(comment))
+	}
+	return &res, nil
+}
+
+// CreateComment is the gRPC equivalent of POST /v1/api/articles/:slug/comments
+func (s *GRPCServer) CreateComment(ctx context.Context, req *articlev1.CreateCommentRequest) (*articlev1.Comment, error) {
+	currentUser, err := account.RequireUser(ctx, s.cfg, account.ScopeCommentWrite, account.RestrictWriteComment)
+	if err != nil {
+		return nil, err
+	}
+	var body SaveCommentRequest
+	body.Comment.Body = req.Body
+	if err := middleware.ValidateMessage(&ArticleURI{Slug: req.Slug}, "uri"); err != nil {
+		return nil, err
+	}
+	if err := middleware.ValidateMessage(&body, "json"); err != nil {
+		return nil, err
+	}
+
+	comment := model.Comment{
+		Body:   req.Body,
+		Author: *currentUser,
+	}
+	if err := s.h.articleDB.SaveComment(ctx, req.Slug, &comment); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, status.Error(codes.NotFound, "not found article")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.CreateComment", err)
+	}
+	return newCommentMessage(&comment), nil
+}
+
+// DeleteComment is the gRPC equivalent of DELETE /v1/api/articles/:slug/comments/:id
+func (s *GRPCServer) DeleteComment(ctx context.Context, req *articlev1.DeleteCommentRequest) (*emptypb.Empty, error) {
+	currentUser, err := account.RequireUser(ctx, s.cfg, account.ScopeCommentWrite, "")
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.FormatUint(req.Id, 10)
+	if err := middleware.ValidateMessage(&CommentURI{Slug: req.Slug, ID: req.Id}, "uri"); err != nil {
+		return nil, err
+	}
+	if err := s.h.articleDB.DeleteCommentById(ctx, currentUser.ID, req.Slug, uint(req.Id)); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, status.Error(codes.NotFound, "not found article comment")
+		}
+		return nil, middleware.InternalStatusError(ctx, "article.grpc.DeleteComment", err)
+	}
+	s.h.auditor.RecordContext(ctx, middleware.PeerIP(ctx), audit.Event{
+		Action:     audit.ActionCommentDelete,
+		ActorID:    currentUser.ID,
+		TargetType: audit.TargetComment,
+		TargetID:   id,
+	})
+	return &emptypb.Empty{}, nil
+}
+
+func newArticleMessage(a *model.Article) *articlev1.Article {
+	var tags []string
+	for _, tag := range a.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return &articlev1.Article{
+		Slug:       a.Slug,
+		Title:      a.Title,
+		Body:       a.Body,
+		Tags:       tags,
+		CreateTime: timestamppb.New(a.CreatedAt),
+		UpdateTime: timestamppb.New(a.UpdatedAt),
+		Author: &articlev1.Author{
+			Username: a.Author.Username,
+			Bio:      a.Author.Bio,
+			Image:    a.Author.Image,
+		},
+	}
+}
+
+func newCommentMessage(comment *model.Comment) *articlev1.Comment {
+	return &articlev1.Comment{
+		Id:         uint64(comment.ID),
+		Body:       comment.Body,
+		CreateTime: timestamppb.New(comment.CreatedAt),
+		UpdateTime: timestamppb.New(comment.UpdatedAt),
+		Author: &articlev1.Author{
+			Username: comment.Author.Username,
+			Bio:      comment.Author.Bio,
+			Image:    comment.Author.Image,
+		},
+	}
+}
@@ -0,0 +1,214 @@
+package article
+
+import (
+	"context"
+	"gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/article/model"
+	db "gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware"
+	articlev1 "gin-rest-api-example/pkg/pb/article/v1"
+	"net"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// newGRPCClient serves the ArticleService of the suite on a bufconn listener and returns the client of it.
+func (s *HandlerSuite) newGRPCClient() articlev1.ArticleServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		middleware.RequestIDUnaryInterceptor(),
+		middleware.RecoveryUnaryInterceptor(),
+		s.auth.UnaryServerInterceptor(),
+	))
+	NewGRPCServer(s.cfg, s.handler).Register(srv)
+	go srv.Serve(lis)
+	s.T().Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	s.NoError(err)
+	s.T().Cleanup(func() { conn.Close() })
+	return articlev1.NewArticleServiceClient(conn)
+}
+
+func (s *HandlerSuite) authorizedContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+s.getBearerToken())
+}
+
+func (s *HandlerSuite) TestGRPCGetArticle() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+	client := s.newGRPCClient()
+
+	// when
+	var header metadata.MD
+	res, err := client.GetArticle(context.Background(), &articlev1.GetArticleRequest{Slug: dArticle.Slug}, grpc.Header(&header))
+
+	// then
+	s.NoError(err)
+	s.NotEmpty(header.Get("x-request-id"))
+	s.Equal(dArticle.Slug, res.Slug)
+	s.Equal(dArticle.Title, res.Title)
+	s.Equal(dArticle.Body, res.Body)
+	s.Equal(dArticleTags, res.Tags)
+	s.Equal(dArticle.CreatedAt.Unix(), res.CreateTime.AsTime().Unix())
+	s.Equal(dUser.Username, res.Author.Username)
+}
+
+func (s *HandlerSuite) TestGRPCGetArticle_NotFound() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, "unknown").Return(nil, db.ErrNotFound)
+	client := s.newGRPCClient()
+
+	// when
+	_, err := client.GetArticle(context.Background(), &articlev1.GetArticleRequest{Slug: "unknown"})
+
+	// then
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *HandlerSuite) TestGRPCListArticles() {
+	// given
+	s.db.On("FindArticles", mock.Anything, database.IterateArticleCriteria{
+		Tags:   []string{"dragons"},
+		Offset: 0,
+		Limit:  5,
+	}).Return([]*model.Article{&dArticle}, int64(1), nil)
+	client := s.newGRPCClient()
+
+	// when
+	res, err := client.ListArticles(context.Background(), &articlev1.ListArticlesRequest{Tags: []string{"dragons"}})
+
+	// then
+	s.NoError(err)
+	s.Equal(int64(1), res.Total)
+	s.Len(res.Articles, 1)
+	s.Equal(dArticle.Slug, res.Articles[0].Slug)
+}
+
+func (s *HandlerSuite) TestGRPCCreateArticle() {
+	// given
+	s.db.On("SaveArticle", mock.Anything, mock.Anything).Return(nil)
+	client := s.newGRPCClient()
+
+	// when
+	res, err := client.CreateArticle(s.authorizedContext(), &articlev1.CreateArticleRequest{
+		Title: dArticle.Title,
+		Body:  dArticle.Body,
+		Tags:  dArticleTags,
+	})
+
+	// then
+	s.NoError(err)
+	s.db.AssertCalled(s.T(), "SaveArticle", mock.Anything,
+		mock.MatchedBy(articleMatcher(dArticle.Title, dArticle.Body, dArticleTags, &dUser)))
+	s.Equal(dArticle.Slug, res.Slug)
+	s.Equal(dUser.Username, res.Author.Username)
+}
+
+func (s *HandlerSuite) TestGRPCCreateArticle_Fail() {
+	client := s.newGRPCClient()
+
+	s.Run("unauthenticated", func() {
+		_, err := client.CreateArticle(context.Background(), &articlev1.CreateArticleRequest{
+			Title: dArticle.Title,
+			Body:  dArticle.Body,
+		})
+		s.Equal(codes.Unauthenticated, status.Code(err))
+	})
+
+	s.Run("invalid token", func() {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+		_, err := client.CreateArticle(ctx, &articlev1.CreateArticleRequest{
+			Title: dArticle.Title,
+			Body:  dArticle.Body,
+		})
+		s.Equal(codes.Unauthenticated, status.Code(err))
+	})
+
+	s.Run("invalid argument", func() {
+		_, err := client.CreateArticle(s.authorizedContext(), &articlev1.CreateArticleRequest{
+			Title: "abc",
+			Body:  dArticle.Body,
+		})
+		st := status.Convert(err)
+		s.Equal(codes.InvalidArgument, st.Code())
+		s.Len(st.Details(), 1)
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		s.True(ok)
+		s.Equal("title", badRequest.FieldViolations[0].Field)
+	})
+
+	s.db.AssertNotCalled(s.T(), "SaveArticle", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestGRPCUpdateArticle() {
+	// given
+	article := dArticle
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&article, nil)
+	s.db.On("UpdateArticle", mock.Anything, mock.Anything, dArticle.Version).Return(nil)
+	client := s.newGRPCClient()
+
+	// when
+	res, err := client.UpdateArticle(s.authorizedContext(), &articlev1.UpdateArticleRequest{
+		Slug:  dArticle.Slug,
+		Title: proto.String("How to train your dragon 2"),
+		Tags:  &articlev1.TagList{},
+	})
+
+	// then
+	s.NoError(err)
+	s.Equal("How to train your dragon 2", res.Title)
+	s.Equal(dArticle.Body, res.Body)
+	s.Empty(res.Tags)
+	event := gjson.Get(s.audits.String(), `..#(action=="article.update")`)
+	s.Equal(dArticle.Slug, event.Get("targetId").String())
+	s.Equal(int64(dUser.ID), event.Get("actorId").Int())
+}
+
+func (s *HandlerSuite) TestGRPCUpdateArticle_VersionConflict() {
+	// given
+	article := dArticle
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&article, nil)
+	s.db.On("UpdateArticle", mock.Anything, mock.Anything, dArticle.Version).Return(database.ErrVersionConflict)
+	client := s.newGRPCClient()
+
+	// when
+	_, err := client.UpdateArticle(s.authorizedContext(), &articlev1.UpdateArticleRequest{
+		Slug: dArticle.Slug,
+		Body: proto.String("updated"),
+	})
+
+	// then
+	s.Equal(codes.Aborted, status.Code(err))
+}
+
+func (s *HandlerSuite) TestGRPCDeleteArticle() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+	s.db.On("RunInTx", mock.Anything, mock.Anything).Return(nil)
+	client := s.newGRPCClient()
+
+	// when
+	res, err := client.DeleteArticle(s.authorizedContext(), &articlev1.DeleteArticleRequest{Slug: dArticle.Slug})
+
+	// then
+	s.NoError(err)
+	s.True(proto.Equal(&emptypb.Empty{}, res))
+	s.db.AssertCalled(s.T(), "RunInTx", mock.Anything, mock.Anything)
+	event := gjson.Get(s.audits.String(), `..#(action=="article.delete")`)
+	s.Equal(dArticle.Slug, event.Get("targetId").String())
+}
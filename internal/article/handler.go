@@ -5,52 +5,40 @@ import (
 	"gin-rest-api-example/internal/account"
 	articleDB "gin-rest-api-example/internal/article/database"
 	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
 	"gin-rest-api-example/internal/config"
 	"gin-rest-api-example/internal/database"
 	"gin-rest-api-example/internal/middleware"
 	"gin-rest-api-example/internal/middleware/handler"
 	"gin-rest-api-example/pkg/logging"
-	"gin-rest-api-example/pkg/validate"
+	"gin-rest-api-example/pkg/openapi"
 	"net/http"
 	"strconv"
 
-	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"github.com/gosimple/slug"
 	"github.com/pkg/errors"
 )
 
-func NewHandler(articleDB articleDB.ArticleDB) *Handler {
+func NewHandler(articleDB articleDB.ArticleDB, auditor *audit.Auditor) *Handler {
 	return &Handler{
 		articleDB: articleDB,
+		auditor:   auditor,
 	}
 }
 
 type Handler struct {
 	articleDB articleDB.ArticleDB
+	auditor   *audit.Auditor
 }
 
-// saveArticle handles POST /v1/api/articles
+// saveArticle handles POST /v1/api/articles and /v2/api/articles
 func (h *Handler) saveArticle(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		logger := logging.FromContext(c)
 		// bind
-		type RequestBody struct {
-			Article struct {
-				Title string   `json:"title" binding:"required,min=5"`
-				Body  string   `json:"body" binding:"required"`
-				Tags  []string `json:"tagList" binding:"omitempty,dive,max=10"`
-			} `json:"article"`
-		}
-		var body RequestBody
-		if err := c.ShouldBindJSON(&body); err != nil {
-			logger.Errorw("article.handler.register failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&body.Article, "json", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid article request in body", details)
+		var body SaveArticleRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
 		}
 
 		// save article
@@ -74,26 +62,17 @@ func (h *Handler) saveArticle(c *gin.Context) {
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
-		return handler.NewSuccessResponse(http.StatusCreated, NewArticleResponse(&article))
+		return handler.NewSuccessResponse(http.StatusCreated, representationOf(c).article(&article))
 	})
 }
 
-// articleBySlug handles GET /v1/api/articles/:slug
+// articleBySlug handles GET /v1/api/articles/:slug and /v2/api/articles/:slug
 func (h *Handler) articleBySlug(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		logger := logging.FromContext(c)
 		// bind
-		type RequestUri struct {
-			Slug string `uri:"slug"`
-		}
-		var uri RequestUri
-		if err := c.ShouldBindUri(&uri); err != nil {
-			logger.Errorw("article.handler.articleBySlug failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&uri, "uri", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article request in uri", details)
+		var uri ArticleURI
+		if res := handler.Bind(c, &uri, nil, nil); res != nil {
+			return res
 		}
 
 		// find
@@ -104,28 +83,94 @@ func (h *Handler) articleBySlug(c *gin.Context) {
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
-		return handler.NewSuccessResponse(http.StatusOK, NewArticleResponse(article))
+		etag := articleETag(article, representationOf(c))
+		c.Header("ETag", etag)
+		if matchETag(c.GetHeader("If-None-Match"), etag, true) {
+			return handler.NewSuccessResponse(http.StatusNotModified, nil)
+		}
+		return handler.NewSuccessResponse(http.StatusOK, representationOf(c).article(article))
 	})
 }
 
-// articles handles GET /v1/api/articles
-func (h *Handler) articles(c *gin.Context) {
+// updateArticle handles PUT /v1/api/articles/:slug and /v2/api/articles/:slug
+func (h *Handler) updateArticle(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		logger := logging.FromContext(c)
-		type QueryParameter struct {
-			Tag    []string `form:"tag" binding:"omitempty,dive,max=10"`
-			Author string   `form:"author" binding:"omitempty"`
-			Limit  string   `form:"limit,default=5" binding:"numeric"`
-			Offset string   `form:"offset,default=0" binding:"numeric"`
-		}
-		var query QueryParameter
-		if err := c.ShouldBindQuery(&query); err != nil {
-			logger.Errorw("article.handler.articles failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&query, "form", vErrs)
+		// bind
+		var (
+			uri  ArticleURI
+			body UpdateArticleRequest
+		)
+		if res := handler.Bind(c, &uri, nil, &body); res != nil {
+			return res
+		}
+
+		// find the article of the current user
+		currentUser := account.MustCurrentUser(c)
+		article, err := h.articleDB.FindArticleBySlug(c.Request.Context(), uri.Slug)
+		if err != nil && !database.IsRecordNotFoundErr(err) {
+			return handler.NewInternalErrorResponse(err)
+		}
+		if err != nil || article.AuthorID != currentUser.ID {
+			return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found article", nil)
+		}
+		if res := checkIfMatch(c, article); res != nil {
+			return res
+		}
+
+		// update the article if not modified since the etag
+		before := articleAuditFields(article)
+		if body.Article.Title != nil {
+			article.Title = *body.Article.Title
+		}
+		if body.Article.Body != nil {
+			article.Body = *body.Article.Body
+		}
+		if body.Article.Tags != nil {
+			article.Tags = nil
+			for _, tag := range *body.Article.Tags {
+				article.Tags = append(article.Tags, &model.Tag{Name: tag})
+			}
+		}
+		if err := h.articleDB.UpdateArticle(c.Request.Context(), article, article.Version); err != nil {
+			switch {
+			case err == articleDB.ErrVersionConflict:
+				return newArticleModifiedResponse()
+			case database.IsRecordNotFoundErr(err):
+				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found article", nil)
 			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article request in query", details)
+			return handler.NewInternalErrorResponse(err)
+		}
+		h.auditor.Record(c, audit.Event{
+			Action:     audit.ActionArticleUpdate,
+			ActorID:    currentUser.ID,
+			TargetType: audit.TargetArticle,
+			TargetID:   article.Slug,
+			Changes:    audit.Diff(before, articleAuditFields(article)),
+		})
+		c.Header("ETag", articleETag(article, representationOf(c)))
+		return handler.NewSuccessResponse(http.StatusOK, representationOf(c).article(article))
+	})
+}
+
+// articleAuditFields returns fields of given article recorded in audit events when changed.
+func articleAuditFields(a *model.Article) map[string]interface{} {
+	tags := []string{}
+	for _, tag := range a.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return map[string]interface{}{
+		"title":   a.Title,
+		"body":    a.Body,
+		"tagList": tags,
+	}
+}
+
+// articles handles GET /v1/api/articles and /v2/api/articles
+func (h *Handler) articles(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		var query ListArticlesQuery
+		if res := handler.Bind(c, nil, &query, nil); res != nil {
+			return res
 		}
 
 		limit, err := strconv.ParseUint(query.Limit, 10, 64)
@@ -146,33 +191,77 @@ func (h *Handler) articles(c *gin.Context) {
 		if err != nil {
 			return handler.NewInternalErrorResponse(err)
 		}
-		return handler.NewSuccessResponse(http.StatusOK, NewArticlesResponse(articles, total))
+		meta := handler.PageMeta{Total: total, Limit: criteria.Limit, Offset: criteria.Offset}
+		return handler.NewSuccessResponse(http.StatusOK, representationOf(c).articles(c.Request.URL, articles, meta))
+	})
+}
+
+// batchGetArticles handles POST /v1/api/articles:batchGet and /v2/api/articles:batchGet
+func (h *Handler) batchGetArticles(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		// bind
+		var body BatchGetArticlesRequest
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+
+		// find articles of distinct slugs at once
+		var slugs []string
+		requested := make(map[string]bool)
+		for _, slug := range body.Slugs {
+			if !requested[slug] {
+				requested[slug] = true
+				slugs = append(slugs, slug)
+			}
+		}
+		found, err := h.articleDB.FindArticlesBySlugs(c.Request.Context(), slugs)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+		bySlug := make(map[string]*model.Article)
+		for _, article := range found {
+			bySlug[article.Slug] = article
+		}
+		articles := []*model.Article{}
+		missing := []string{}
+		for _, slug := range slugs {
+			if article, ok := bySlug[slug]; ok {
+				articles = append(articles, article)
+			} else {
+				missing = append(missing, slug)
+			}
+		}
+		return handler.NewSuccessResponse(http.StatusOK, representationOf(c).batchArticles(c.Request.URL, articles, missing))
 	})
 }
 
-// deleteArticle handles DELETE /v1/api/articles/:slug
+// deleteArticle handles DELETE /v1/api/articles/:slug and /v2/api/articles/:slug
 func (h *Handler) deleteArticle(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
 		logger := logging.FromContext(c)
 		// bind
-		type RequestUri struct {
-			Slug string `uri:"slug" binding:"required"`
-		}
-		var uri RequestUri
-		if err := c.ShouldBindUri(&uri); err != nil {
-			logger.Errorw("article.handler.deleteArticle failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&uri, "uri", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article request in uri", details)
+		var uri ArticleURI
+		if res := handler.Bind(c, &uri, nil, nil); res != nil {
+			return res
 		}
 
-		// delete article and comments with in transaction
+		// find the article of the current user
 		currentUser := account.MustCurrentUser(c)
-		err := h.articleDB.RunInTx(c.Request.Context(), func(ctx context.Context) error {
-			// delete a article
-			if err := h.articleDB.DeleteArticleBySlug(ctx, currentUser.ID, uri.Slug); err != nil {
+		article, err := h.articleDB.FindArticleBySlug(c.Request.Context(), uri.Slug)
+		if err != nil && !database.IsRecordNotFoundErr(err) {
+			return handler.NewInternalErrorResponse(err)
+		}
+		if err != nil || article.AuthorID != currentUser.ID {
+			return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found article", nil)
+		}
+		if res := checkIfMatch(c, article); res != nil {
+			return res
+		}
+
+		// delete article and comments with in transaction
+		err = h.articleDB.RunInTx(c.Request.Context(), func(ctx context.Context) error {
+			// delete a article if not modified since the etag
+			if err := h.articleDB.DeleteArticleBySlug(ctx, currentUser.ID, uri.Slug, article.Version); err != nil {
 				return err
 			}
 
@@ -186,34 +275,70 @@ func (h *Handler) deleteArticle(c *gin.Context) {
 		})
 		if err != nil {
 			logger.Errorw("article.handler.deleteArticle failed to delete a article", "err", err)
+			if errors.Cause(err) == articleDB.ErrVersionConflict {
+				return newArticleModifiedResponse()
+			}
 			if database.IsRecordNotFoundErr(errors.Cause(err)) {
 				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found article", nil)
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
+		h.auditor.Record(c, audit.Event{
+			Action:     audit.ActionArticleDelete,
+			ActorID:    currentUser.ID,
+			TargetType: audit.TargetArticle,
+			TargetID:   uri.Slug,
+		})
 		return handler.NewSuccessResponse(http.StatusOK, nil)
 	})
 }
 
-func RouteV1(cfg *config.Config, h *Handler, r *gin.Engine, auth *jwt.GinJWTMiddleware) {
+func RouteV1(cfg *config.Config, h *Handler, r *gin.Engine, auth *account.AuthMiddleware, limiter *middleware.RateLimiter,
+	idempotency *middleware.Idempotency, spec *openapi.Spec, customMethods *middleware.CustomMethodRegistry) {
 	v1 := r.Group("v1/api")
 	v1.Use(middleware.RequestIDMiddleware(), middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
 
-	articleV1 := v1.Group("articles")
+	// v1 apis are deprecated by v2 apis
+	articleV1 := v1.Group("articles", middleware.Deprecated(cfg), withRepresentation(representationV1{}))
+	routeArticles(cfg, h, articleV1, auth, limiter, idempotency, spec)
+	v1.POST(middleware.CustomMethodRoute(customMethods, "articles", "batchGet"), middleware.ValidateRequest(spec), middleware.Deprecated(cfg),
+		withRepresentation(representationV1{}), h.batchGetArticles)
+}
+
+// RouteV2 registers v2 apis of articles responding {data, meta, links} envelopes. Requests of v1 apis accepting
+// middleware.MediaTypeV2 are served by them too if the server handler is middleware.NegotiateVersion.
+func RouteV2(cfg *config.Config, h *Handler, r *gin.Engine, auth *account.AuthMiddleware, limiter *middleware.RateLimiter,
+	idempotency *middleware.Idempotency, spec *openapi.Spec, customMethods *middleware.CustomMethodRegistry) {
+	v2 := r.Group("v2/api")
+	v2.Use(middleware.RequestIDMiddleware(), middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
+
+	articleV2 := v2.Group("articles", withRepresentation(representationV2{}))
+	routeArticles(cfg, h, articleV2, auth, limiter, idempotency, spec)
+	v2.POST(middleware.CustomMethodRoute(customMethods, "articles", "batchGet"), middleware.ValidateRequest(spec), withRepresentation(representationV2{}),
+		h.batchGetArticles)
+}
+
+// routeArticles registers routes of articles and comments to given group of an api version.
+// Requests are validated by the spec after the authentication so that anonymous requests get 401.
+func routeArticles(cfg *config.Config, h *Handler, articles *gin.RouterGroup, auth *account.AuthMiddleware, limiter *middleware.RateLimiter,
+	idempotency *middleware.Idempotency, spec *openapi.Spec) {
 	// anonymous
-	articleV1.Use()
+	anonymous := articles.Group("", middleware.ValidateRequest(spec))
 	{
-		articleV1.GET(":slug", h.articleBySlug)
-		articleV1.GET("", h.articles)
-		articleV1.GET(":slug/comments", h.articleComments)
+		anonymous.GET(":slug", h.articleBySlug)
+		anonymous.GET("", h.articles)
+		anonymous.GET(":slug/comments", h.articleComments)
 	}
 
 	// auth required
-	articleV1.Use(auth.MiddlewareFunc())
+	articles.Use(auth.MiddlewareFunc(), middleware.ValidateRequest(spec))
 	{
-		articleV1.POST("", h.saveArticle)
-		articleV1.DELETE(":slug", h.deleteArticle)
-		articleV1.POST(":slug/comments", h.saveComment)
-		articleV1.DELETE(":slug/comments/:id", h.deleteComment)
+		articles.POST("", account.RequireScope(account.ScopeArticleWrite), account.RestrictUnverified(cfg, account.RestrictWriteArticle),
+			middleware.Idempotent(idempotency), middleware.RateLimit(limiter, "writeArticle"), h.saveArticle)
+		articles.PUT(":slug", account.RequireScope(account.ScopeArticleWrite), account.RestrictUnverified(cfg, account.RestrictWriteArticle), h.updateArticle)
+		articles.DELETE(":slug", account.RequireScope(account.ScopeArticleWrite), h.deleteArticle)
+		articles.POST(":slug/comments", account.RequireScope(account.ScopeCommentWrite), account.RestrictUnverified(cfg, account.RestrictWriteComment),
+			middleware.Idempotent(idempotency), middleware.RateLimit(limiter, "writeComment"), h.saveComment)
+		articles.DELETE(":slug/comments/:id", account.RequireScope(account.ScopeCommentWrite), h.deleteComment)
 	}
 }
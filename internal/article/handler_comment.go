@@ -3,48 +3,24 @@ package article
 import (
 	"gin-rest-api-example/internal/account"
 	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
 	"gin-rest-api-example/internal/database"
 	"gin-rest-api-example/internal/middleware/handler"
-	"gin-rest-api-example/pkg/logging"
-	"gin-rest-api-example/pkg/validate"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"net/http"
 	"strconv"
 )
 
-// saveComment handles POST /v1/api/articles/:slug/comments
+// saveComment handles POST /v1/api/articles/:slug/comments and /v2/api/articles/:slug/comments
 func (h *Handler) saveComment(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		logger := logging.FromContext(c)
 		// bind
-		type RequestUri struct {
-			Slug string `uri:"slug" binding:"required"`
-		}
-		type RequestBody struct {
-			Comment struct {
-				Body string `json:"body" binding:"required"`
-			} `json:"comment" binding:"required"`
-		}
 		var (
-			uri  RequestUri
-			body RequestBody
+			uri  ArticleURI
+			body SaveCommentRequest
 		)
-		if err := c.ShouldBindUri(&uri); err != nil {
-			logger.Errorw("article.handler.saveComment failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&uri, "uri", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article comment request in uri", details)
-		}
-		if err := c.ShouldBindJSON(&body); err != nil {
-			logger.Errorw("article.handler.saveComment failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&uri, "json", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article comment request in body", details)
+		if res := handler.Bind(c, &uri, nil, &body); res != nil {
+			return res
 		}
 
 		currentUser := account.MustCurrentUser(c)
@@ -58,26 +34,17 @@ func (h *Handler) saveComment(c *gin.Context) {
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
-		return handler.NewSuccessResponse(http.StatusCreated, NewCommentResponse(&comment))
+		return handler.NewSuccessResponse(http.StatusCreated, representationOf(c).comment(uri.Slug, &comment))
 	})
 }
 
-// articleComment handles GET /v1/api/articles/:slug/comments
+// articleComment handles GET /v1/api/articles/:slug/comments and /v2/api/articles/:slug/comments
 func (h *Handler) articleComments(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		logger := logging.FromContext(c)
 		// bind
-		type RequestUri struct {
-			Slug string `uri:"slug"`
-		}
-		var uri RequestUri
-		if err := c.ShouldBindUri(&uri); err != nil {
-			logger.Errorw("article.handler.articleComments failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&uri, "uri", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article comment request in uri", details)
+		var uri ArticleURI
+		if res := handler.Bind(c, &uri, nil, nil); res != nil {
+			return res
 		}
 
 		comments, err := h.articleDB.FindComments(c.Request.Context(), uri.Slug)
@@ -87,41 +54,32 @@ func (h *Handler) articleComments(c *gin.Context) {
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
-		return handler.NewSuccessResponse(http.StatusOK, NewCommentsResponse(comments))
+		return handler.NewSuccessResponse(http.StatusOK, representationOf(c).comments(uri.Slug, comments))
 	})
 }
 
 func (h *Handler) deleteComment(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		logger := logging.FromContext(c)
 		// bind
-		type RequestUri struct {
-			Slug string `uri:"slug"`
-			ID   string `uri:"id" binding:"numeric"`
-		}
-		var uri RequestUri
-		if err := c.ShouldBindUri(&uri); err != nil {
-			logger.Errorw("article.handler.deleteComment failed to bind", "err", err)
-			var details []*validate.ValidationErrDetail
-			if vErrs, ok := err.(validator.ValidationErrors); ok {
-				details = validate.ValidationErrorDetails(&uri, "uri", vErrs)
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article comment request in uri", details)
-		}
-		id, err := strconv.ParseUint(uri.ID, 10, 64)
-		if err != nil {
-			details := validate.NewValidationErrorDetails("id", "id must be greater than or equals to 0", uri.ID)
-			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidUriValue, "invalid article comment request in uri", details)
+		var uri CommentURI
+		if res := handler.Bind(c, &uri, nil, nil); res != nil {
+			return res
 		}
 
 		// delete
 		currentUser := account.MustCurrentUser(c)
-		if err := h.articleDB.DeleteCommentById(c.Request.Context(), currentUser.ID, uri.Slug, uint(id)); err != nil {
+		if err := h.articleDB.DeleteCommentById(c.Request.Context(), currentUser.ID, uri.Slug, uint(uri.ID)); err != nil {
 			if database.IsRecordNotFoundErr(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found article comment", nil)
 			}
 			return handler.NewInternalErrorResponse(err)
 		}
+		h.auditor.Record(c, audit.Event{
+			Action:     audit.ActionCommentDelete,
+			ActorID:    currentUser.ID,
+			TargetType: audit.TargetComment,
+			TargetID:   strconv.FormatUint(uri.ID, 10),
+		})
 		return handler.NewSuccessResponse(http.StatusOK, nil)
 	})
 }
@@ -97,6 +97,26 @@ func (s *HandlerSuite) TestDeleteComment() {
 	s.Equal(http.StatusOK, res.Code)
 	// 3) response
 	s.Empty(res.Body.Bytes())
+	// 4) audit event
+	s.Equal(fmt.Sprint(dComment.ID), gjson.Get(s.audits.String(), `..#(action=="comment.delete").targetId`).String())
+}
+
+func (s *HandlerSuite) TestDeleteComment_InvalidID() {
+	for _, id := range []string{"abc", "-1", "18446744073709551616"} {
+		s.Run(id, func() {
+			// when
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/v1/api/articles/%s/comments/%s", dComment.Slug, id), nil)
+			req.Header.Add("Authorization", "Bearer "+s.getBearerToken())
+
+			s.r.ServeHTTP(res, req)
+
+			// then
+			s.Equal(http.StatusBadRequest, res.Code)
+			s.Equal("InvalidUriValue", gjson.Get(res.Body.String(), "code").String())
+			s.db.AssertNotCalled(s.T(), "DeleteCommentById", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func (s *HandlerSuite) assertCommentResponse(comment *model.Comment, result gjson.Result) {
@@ -10,14 +10,21 @@ import (
 	"gin-rest-api-example/internal/article/database"
 	articleDBMock "gin-rest-api-example/internal/article/database/mocks"
 	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
 	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/mailer"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
 	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/openapi"
+	"gin-rest-api-example/pkg/validate"
 	"github.com/gin-gonic/gin"
 	"github.com/gosimple/slug"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"github.com/tidwall/gjson"
 	"go.uber.org/zap/zapcore"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -40,6 +47,7 @@ var (
 		Slug:      "how-to-train-your-dragon",
 		Title:     "How to train your dragon",
 		Body:      "You have to believe",
+		Version:   1,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Author:    dUser,
@@ -65,14 +73,19 @@ var (
 
 type HandlerSuite struct {
 	suite.Suite
-	r         *gin.Engine
-	handler   *Handler
-	db        *articleDBMock.ArticleDB
-	accountDB *accountDBMock.AccountDB
+	r             *gin.Engine
+	cfg           *config.Config
+	auth          *account.AuthMiddleware
+	handler       *Handler
+	db            *articleDBMock.ArticleDB
+	accountDB     *accountDBMock.AccountDB
+	audits        *bytes.Buffer
+	customMethods *middleware.CustomMethodRegistry
 }
 
 func (s *HandlerSuite) SetupSuite() {
 	logging.SetLevel(zapcore.FatalLevel)
+	s.NoError(validate.SetupBinding())
 }
 
 func (s *HandlerSuite) SetupTest() {
@@ -80,22 +93,38 @@ func (s *HandlerSuite) SetupTest() {
 	s.NoError(err)
 
 	s.db = &articleDBMock.ArticleDB{}
-	s.handler = NewHandler(s.db)
+	s.audits = &bytes.Buffer{}
+	auditor := audit.New(audit.NewWriterSink(s.audits))
+	s.handler = NewHandler(s.db, auditor)
 	s.accountDB = &accountDBMock.AccountDB{}
 	s.accountDB.On("FindByEmail", mock.Anything, mock.MatchedBy(func(email string) bool {
 		return email == dUser.Email
 	})).Return(&dUser, nil)
+	s.accountDB.On("FindByID", mock.Anything, dUser.ID).Return(&dUser, nil)
 
-	jwtMiddleware, err := account.NewAuthMiddleware(cfg, s.accountDB)
+	jwtMiddleware, err := account.NewAuthMiddleware(cfg, s.accountDB, nil, nil, auditor)
 	s.NoError(err)
+	s.cfg = cfg
+	s.auth = jwtMiddleware
+
+	spec := openapi.NewSpec(openapi.Info{Title: "test", Version: "v1"}, handler.Problem{})
+	spec.Add(OpenAPIRoutes()...)
+	spec.Add(account.OpenAPIRoutes()...)
 
 	gin.SetMode(gin.TestMode)
 	s.r = gin.Default()
+	s.r.Use(middleware.ValidateResponse(spec, func(c *gin.Context, err error) {
+		s.Failf("response is not documented", "%s %s: %v", c.Request.Method, c.FullPath(), err)
+	}))
 
-	RouteV1(cfg, s.handler, s.r, jwtMiddleware)
+	s.customMethods = middleware.NewCustomMethodRegistry()
+	RouteV1(cfg, s.handler, s.r, jwtMiddleware, nil, middleware.NewIdempotency(cfg, nil), spec, s.customMethods)
+	RouteV2(cfg, s.handler, s.r, jwtMiddleware, nil, middleware.NewIdempotency(cfg, nil), spec, s.customMethods)
 
-	accountHandler := account.NewHandler(s.accountDB)
-	account.RouteV1(cfg, accountHandler, s.r, jwtMiddleware)
+	policy, err := account.NewPasswordPolicy(cfg)
+	s.NoError(err)
+	accountHandler := account.NewHandler(cfg, s.accountDB, jwtMiddleware, mailer.NewWriterSender(cfg.MailConfig.From, ioutil.Discard), nil, policy)
+	account.RouteV1(cfg, accountHandler, s.r, jwtMiddleware, nil, spec)
 }
 
 func TestSuite(t *testing.T) {
@@ -134,6 +163,66 @@ func (s *HandlerSuite) TestSaveArticle() {
 	s.assertArticleResponse(&dArticle, gjson.Parse(jsonVal).Get("article"))
 }
 
+func (s *HandlerSuite) TestSaveArticle_IdempotencyKey() {
+	// given
+	s.db.On("SaveArticle", mock.Anything, mock.Anything).Return(nil)
+	save := func(title string) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(map[string]interface{}{
+			"article": map[string]interface{}{"title": title, "body": dArticle.Body},
+		})
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/api/articles", bytes.NewBuffer(b))
+		req.Header.Add("Authorization", "Bearer "+s.getBearerToken())
+		req.Header.Add(middleware.IdempotencyKeyHeader, "save-article-1")
+		s.r.ServeHTTP(res, req)
+		return res
+	}
+
+	// when then: the retry is replayed
+	res := save(dArticle.Title)
+	s.Equal(http.StatusCreated, res.Code)
+	retry := save(dArticle.Title)
+	s.Equal(http.StatusCreated, retry.Code)
+	s.Equal("true", retry.Header().Get(middleware.IdempotencyReplayedHeader))
+	s.JSONEq(res.Body.String(), retry.Body.String())
+	s.db.AssertNumberOfCalls(s.T(), "SaveArticle", 1)
+
+	// when then: the key is reused with a different article
+	res = save("other title")
+	s.Equal(http.StatusConflict, res.Code)
+	s.db.AssertNumberOfCalls(s.T(), "SaveArticle", 1)
+}
+
+func (s *HandlerSuite) TestSaveArticle_InvalidTags() {
+	for _, tag := range []string{"Golang", "go lang", "-golang"} {
+		// when
+		b, _ := json.Marshal(map[string]interface{}{
+			"article": map[string]interface{}{"title": dArticle.Title, "body": dArticle.Body, "tagList": []string{"tag1", tag}},
+		})
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/api/articles", bytes.NewBuffer(b))
+		req.Header.Add("Authorization", "Bearer "+s.getBearerToken())
+		s.r.ServeHTTP(res, req)
+
+		// then
+		s.Equal(http.StatusBadRequest, res.Code, tag)
+		s.Equal("tagList[1]", gjson.Get(res.Body.String(), "errors.0.field").String())
+		s.Equal("tagList[1] must be a lowercase slug of letters, digits and '-'", gjson.Get(res.Body.String(), "errors.0.message").String())
+	}
+	s.db.AssertNotCalled(s.T(), "SaveArticle", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestSaveArticle_UnauthorizedBeforeValidation() {
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/api/articles", bytes.NewBufferString(`{"article": {}}`))
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusUnauthorized, res.Code)
+	s.db.AssertNotCalled(s.T(), "SaveArticle", mock.Anything, mock.Anything)
+}
+
 func (s *HandlerSuite) TestArticleBySlug() {
 	// given
 	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
@@ -149,11 +238,129 @@ func (s *HandlerSuite) TestArticleBySlug() {
 	s.db.AssertCalled(s.T(), "FindArticleBySlug", mock.Anything, dArticle.Slug)
 	// 2) status code
 	s.Equal(http.StatusOK, res.Code)
+	s.Equal(articleETag(&dArticle, representationV1{}), res.Header().Get("ETag"))
 	// 3) body
 	jsonVal := res.Body.String()
 	s.assertArticleResponse(&dArticle, gjson.Parse(jsonVal).Get("article"))
 }
 
+func (s *HandlerSuite) TestArticleBySlug_IfNoneMatch() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+	etag := articleETag(&dArticle, representationV1{})
+
+	cases := []struct {
+		Name        string
+		IfNoneMatch string
+		Code        int
+	}{
+		{Name: "not modified", IfNoneMatch: etag, Code: http.StatusNotModified},
+		{Name: "not modified with weak etag", IfNoneMatch: `"other", W/` + etag, Code: http.StatusNotModified},
+		{Name: "modified", IfNoneMatch: `"1-0000000000000000"`, Code: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		s.Run(tc.Name, func() {
+			// when
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/api/articles/"+dArticle.Slug, nil)
+			req.Header.Add("If-None-Match", tc.IfNoneMatch)
+			s.r.ServeHTTP(res, req)
+
+			// then
+			s.Equal(tc.Code, res.Code)
+			s.Equal(etag, res.Header().Get("ETag"))
+			if tc.Code == http.StatusNotModified {
+				s.Empty(res.Body.Bytes())
+			}
+		})
+	}
+}
+
+func (s *HandlerSuite) TestUpdateArticle() {
+	// given
+	article := dArticle
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&article, nil)
+	s.db.On("UpdateArticle", mock.Anything, mock.Anything, uint(1)).Run(func(args mock.Arguments) {
+		updated := args.Get(1).(*model.Article)
+		updated.Version = 2
+		updated.UpdatedAt = time.Now().Add(time.Second)
+	}).Return(nil)
+	etag := articleETag(&dArticle, representationV1{})
+
+	// when
+	res := s.doUpdateArticle(etag, map[string]interface{}{"title": "How to train your dog", "tagList": []string{"dogs"}})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "UpdateArticle", mock.Anything, mock.MatchedBy(func(a *model.Article) bool {
+		return a.Slug == dArticle.Slug && a.Title == "How to train your dog" && a.Body == dArticle.Body &&
+			len(a.Tags) == 1 && a.Tags[0].Name == "dogs"
+	}), uint(1))
+	s.NotEqual(etag, res.Header().Get("ETag"))
+	s.Equal(articleETag(&article, representationV1{}), res.Header().Get("ETag"))
+	result := gjson.Get(res.Body.String(), "article")
+	s.Equal(dArticle.Slug, result.Get("slug").String())
+	s.Equal("How to train your dog", result.Get("title").String())
+	event := gjson.Get(s.audits.String(), `..#(action=="article.update")`)
+	s.Equal(dArticle.Slug, event.Get("targetId").String())
+	s.Equal("How to train your dog", event.Get("changes.title.after").String())
+	s.False(event.Get("changes.body").Exists())
+}
+
+func (s *HandlerSuite) TestUpdateArticle_Fail() {
+	other := dArticle
+	other.AuthorID = dUser.ID + 1
+	etag := articleETag(&dArticle, representationV1{})
+
+	cases := []struct {
+		Name      string
+		Article   *model.Article
+		IfMatch   string
+		UpdateErr error
+		Code      int
+		ErrCode   string
+	}{
+		{Name: "if-match required", Article: &dArticle, Code: http.StatusPreconditionRequired, ErrCode: "PreconditionRequired"},
+		{Name: "modified", Article: &dArticle, IfMatch: `"1-0000000000000000"`, Code: http.StatusPreconditionFailed, ErrCode: "PreconditionFailed"},
+		{Name: "weak etag", Article: &dArticle, IfMatch: "W/" + etag, Code: http.StatusPreconditionFailed, ErrCode: "PreconditionFailed"},
+		{Name: "version conflict", Article: &dArticle, IfMatch: etag, UpdateErr: database.ErrVersionConflict, Code: http.StatusPreconditionFailed, ErrCode: "PreconditionFailed"},
+		{Name: "other author", Article: &other, IfMatch: "*", Code: http.StatusNotFound, ErrCode: "NotFoundEntity"},
+	}
+
+	for _, tc := range cases {
+		s.Run(tc.Name, func() {
+			// given
+			s.SetupTest()
+			article := *tc.Article
+			s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&article, nil)
+			s.db.On("UpdateArticle", mock.Anything, mock.Anything, uint(1)).Return(tc.UpdateErr)
+
+			// when
+			res := s.doUpdateArticle(tc.IfMatch, map[string]interface{}{"body": "updated body"})
+
+			// then
+			s.Equal(tc.Code, res.Code)
+			s.Equal(tc.ErrCode, gjson.Get(res.Body.String(), "code").String())
+			if tc.UpdateErr == nil {
+				s.db.AssertNotCalled(s.T(), "UpdateArticle", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func (s *HandlerSuite) doUpdateArticle(ifMatch string, article map[string]interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(map[string]interface{}{"article": article})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/v1/api/articles/"+dArticle.Slug, bytes.NewBuffer(b))
+	req.Header.Add("Authorization", "Bearer "+s.getBearerToken())
+	if ifMatch != "" {
+		req.Header.Add("If-Match", ifMatch)
+	}
+	s.r.ServeHTTP(res, req)
+	return res
+}
+
 func (s *HandlerSuite) TestArticles() {
 	criteria := database.IterateArticleCriteria{
 		Tags:   []string{dArticleTags[0]},
@@ -187,14 +394,68 @@ func (s *HandlerSuite) TestArticles() {
 	s.assertArticleResponse(&dArticle, articlesResult[0])
 }
 
+func (s *HandlerSuite) TestBatchGetArticles() {
+	slugs := []string{dArticle.Slug, "unknown"}
+	s.db.On("FindArticlesBySlugs", mock.Anything, slugs).Return([]*model.Article{&dArticle}, nil)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/api/articles:batchGet",
+		strings.NewReader(`{"slugs": ["how-to-train-your-dragon", "unknown", "how-to-train-your-dragon"]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	middleware.CustomMethods(s.customMethods, s.r).ServeHTTP(res, req)
+
+	// then
+	// 1) method called with distinct slugs
+	s.db.AssertCalled(s.T(), "FindArticlesBySlugs", mock.Anything, slugs)
+	// 2) status code
+	s.Equal(http.StatusOK, res.Code)
+	// 3) body
+	result := gjson.Parse(res.Body.String())
+	articlesResult := result.Get("articles").Array()
+	s.Equal(1, len(articlesResult))
+	s.assertArticleResponse(&dArticle, articlesResult[0])
+	s.JSONEq(`["unknown"]`, result.Get("missing").Raw)
+}
+
+func (s *HandlerSuite) TestBatchGetArticles_Fail() {
+	cases := []struct {
+		Name string
+		Body string
+	}{
+		{Name: "slugs required", Body: `{}`},
+		{Name: "empty slugs", Body: `{"slugs": []}`},
+		{Name: "empty slug", Body: `{"slugs": [""]}`},
+		{Name: "too many slugs", Body: fmt.Sprintf(`{"slugs": ["%s"]}`, strings.Repeat(`slug", "`, 100)+"slug")},
+	}
+
+	for _, tc := range cases {
+		s.Run(tc.Name, func() {
+			// when
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/api/articles:batchGet", strings.NewReader(tc.Body))
+			req.Header.Set("Content-Type", "application/json")
+
+			middleware.CustomMethods(s.customMethods, s.r).ServeHTTP(res, req)
+
+			// then
+			s.Equal(http.StatusBadRequest, res.Code)
+			s.db.AssertNotCalled(s.T(), "FindArticlesBySlugs", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func (s *HandlerSuite) TestDeleteArticle() {
 	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
 	s.db.On("RunInTx", mock.Anything, mock.Anything).Return(nil)
 
 	// when
 	res := httptest.NewRecorder()
 	req, _ := http.NewRequest("DELETE", "/v1/api/articles/"+dArticle.Slug, nil)
 	req.Header.Add("Authorization", "Bearer "+s.getBearerToken())
+	req.Header.Add("If-Match", articleETag(&dArticle, representationV1{}))
 
 	s.r.ServeHTTP(res, req)
 
@@ -205,6 +466,42 @@ func (s *HandlerSuite) TestDeleteArticle() {
 	s.Equal(http.StatusOK, res.Code)
 	// 3) body
 	s.Empty(res.Body.Bytes())
+	// 4) audit event
+	event := gjson.Get(s.audits.String(), `..#(action=="article.delete")`)
+	s.Equal(dArticle.Slug, event.Get("targetId").String())
+	s.Equal(int64(dUser.ID), event.Get("actorId").Int())
+}
+
+func (s *HandlerSuite) TestDeleteArticle_Precondition() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+	s.db.On("RunInTx", mock.Anything, mock.Anything).Return(database.ErrVersionConflict)
+
+	cases := []struct {
+		Name    string
+		IfMatch string
+		Code    int
+	}{
+		{Name: "if-match required", Code: http.StatusPreconditionRequired},
+		{Name: "modified", IfMatch: `"2-0000000000000000"`, Code: http.StatusPreconditionFailed},
+		{Name: "version conflict", IfMatch: articleETag(&dArticle, representationV1{}), Code: http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range cases {
+		s.Run(tc.Name, func() {
+			// when
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/v1/api/articles/"+dArticle.Slug, nil)
+			req.Header.Add("Authorization", "Bearer "+s.getBearerToken())
+			if tc.IfMatch != "" {
+				req.Header.Add("If-Match", tc.IfMatch)
+			}
+			s.r.ServeHTTP(res, req)
+
+			// then
+			s.Equal(tc.Code, res.Code)
+		})
+	}
 }
 
 func (s *HandlerSuite) assertArticleResponse(article *model.Article, result gjson.Result) {
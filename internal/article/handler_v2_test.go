@@ -0,0 +1,155 @@
+package article
+
+import (
+	"fmt"
+	"gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/middleware"
+	"github.com/stretchr/testify/mock"
+	"github.com/tidwall/gjson"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+func (s *HandlerSuite) TestArticleBySlug_V1Deprecated() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/api/articles/"+dArticle.Slug, nil)
+
+	middleware.NegotiateVersion(s.r).ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("@1793491200", res.Header().Get("Deprecation"))
+	s.Equal("Sat, 01 May 2027 00:00:00 GMT", res.Header().Get("Sunset"))
+	s.Equal(fmt.Sprintf(`</v2/api/articles/%s>; rel="successor-version"`, dArticle.Slug), res.Header().Get("Link"))
+	s.Equal("Accept", res.Header().Get("Vary"))
+	s.True(gjson.Get(res.Body.String(), "article").Exists())
+}
+
+func (s *HandlerSuite) TestArticleBySlugV2() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+
+	for _, target := range []string{"/v2/api/articles/", "/v1/api/articles/"} {
+		// when
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target+dArticle.Slug, nil)
+		req.Header.Set("Accept", middleware.MediaTypeV2)
+
+		middleware.NegotiateVersion(s.r).ServeHTTP(res, req)
+
+		// then
+		s.Equal(http.StatusOK, res.Code, target)
+		s.Equal(articleETag(&dArticle, representationV2{}), res.Header().Get("ETag"))
+		s.NotEqual(articleETag(&dArticle, representationV1{}), res.Header().Get("ETag"))
+		s.Empty(res.Header().Get("Deprecation"))
+		result := gjson.Parse(res.Body.String())
+		s.assertArticleV2Response(&dArticle, result.Get("data"))
+		s.Equal("/v2/api/articles/"+dArticle.Slug, result.Get("links.self").String())
+	}
+}
+
+func (s *HandlerSuite) TestArticlesV2() {
+	criteria := database.IterateArticleCriteria{
+		Tags:   []string{dArticleTags[0]},
+		Offset: 1,
+		Limit:  1,
+	}
+	s.db.On("FindArticles", mock.Anything, criteria).Return([]*model.Article{&dArticle}, int64(3), nil)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/v2/api/articles?tag=%s&offset=1&limit=1", criteria.Tags[0]), nil)
+
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.db.AssertCalled(s.T(), "FindArticles", mock.Anything, criteria)
+	s.Equal(http.StatusOK, res.Code)
+	result := gjson.Parse(res.Body.String())
+	data := result.Get("data").Array()
+	s.Equal(1, len(data))
+	s.assertArticleV2Response(&dArticle, data[0])
+	s.JSONEq(`{"total": 3, "limit": 1, "offset": 1}`, result.Get("meta").Raw)
+	s.JSONEq(`{
+		"self": "/v2/api/articles?limit=1&offset=1&tag=reactjs",
+		"first": "/v2/api/articles?limit=1&offset=0&tag=reactjs",
+		"prev": "/v2/api/articles?limit=1&offset=0&tag=reactjs",
+		"next": "/v2/api/articles?limit=1&offset=2&tag=reactjs",
+		"last": "/v2/api/articles?limit=1&offset=2&tag=reactjs"
+	}`, result.Get("links").Raw)
+}
+
+func (s *HandlerSuite) TestArticlesV2_Empty() {
+	s.db.On("FindArticles", mock.Anything, mock.Anything).Return([]*model.Article{}, int64(0), nil)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/api/articles", nil)
+
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	result := gjson.Parse(res.Body.String())
+	s.Equal("[]", result.Get("data").Raw)
+	s.Equal(int64(0), result.Get("meta.total").Int())
+	s.False(result.Get("links.next").Exists())
+}
+
+func (s *HandlerSuite) TestBatchGetArticlesV2() {
+	s.db.On("FindArticlesBySlugs", mock.Anything, []string{"unknown"}).Return([]*model.Article{}, nil)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v2/api/articles:batchGet", strings.NewReader(`{"slugs": ["unknown"]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	middleware.CustomMethods(s.customMethods, s.r).ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	result := gjson.Parse(res.Body.String())
+	s.Equal("[]", result.Get("data").Raw)
+	s.JSONEq(`{"missing": ["unknown"]}`, result.Get("meta").Raw)
+	s.JSONEq(`{"self": "/v2/api/articles:batchGet"}`, result.Get("links").Raw)
+}
+
+func (s *HandlerSuite) TestArticleCommentsV2() {
+	// given
+	s.db.On("FindComments", mock.Anything, dComment.Slug).Return([]*model.Comment{&dComment}, nil)
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/v2/api/articles/%s/comments", dComment.Slug), nil)
+
+	s.r.ServeHTTP(res, req)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	result := gjson.Parse(res.Body.String())
+	data := result.Get("data").Array()
+	s.Equal(1, len(data))
+	s.Equal(int64(dComment.ID), data[0].Get("id").Int())
+	s.Equal(dComment.Body, data[0].Get("body").String())
+	s.Equal(timestampV2(dComment.CreatedAt).Format(time.RFC3339), data[0].Get("createdAt").String())
+	s.Equal(int64(1), result.Get("meta.total").Int())
+	s.Equal(fmt.Sprintf("/v2/api/articles/%s/comments", dComment.Slug), result.Get("links.self").String())
+}
+
+func (s *HandlerSuite) assertArticleV2Response(article *model.Article, result gjson.Result) {
+	s.Equal(article.Slug, result.Get("slug").String())
+	s.Equal(article.Title, result.Get("title").String())
+	s.Equal(article.Body, result.Get("body").String())
+	s.Equal(len(article.Tags), len(result.Get("tagList").Array()))
+	// RFC 3339 in UTC without fractional seconds
+	s.Equal(article.CreatedAt.UTC().Format(time.RFC3339), result.Get("createdAt").String())
+	s.Equal(article.UpdatedAt.UTC().Format(time.RFC3339), result.Get("updatedAt").String())
+	s.Equal(article.Author.Username, result.Get("author.username").String())
+}
@@ -10,6 +10,7 @@ type Article struct {
 	Slug          string    `gorm:"column:slug"`
 	Title         string    `gorm:"column:title"`
 	Body          string    `gorm:"column:body"`
+	Version       uint      `gorm:"column:version"`
 	CreatedAt     time.Time `gorm:"column:created_at"`
 	UpdatedAt     time.Time `gorm:"column:updated_at"`
 	DeletedAtUnix int64     `gorm:"column:deleted_at_unix"`
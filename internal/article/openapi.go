@@ -0,0 +1,59 @@
+package article
+
+import (
+	"net/http"
+
+	"gin-rest-api-example/pkg/openapi"
+)
+
+// OpenAPIRoutes returns the documentation of routes registered by RouteV1 and RouteV2.
+func OpenAPIRoutes() []openapi.Route {
+	var (
+		tags   = []string{"article"}
+		tagsV2 = []string{"article v2"}
+		auth   = []string{openapi.BearerAuth, openapi.APIKeyAuth}
+	)
+	return []openapi.Route{
+		// anonymous
+		{Method: http.MethodGet, Path: "/v1/api/articles/:slug", Summary: "Get an article", Tags: tags,
+			Responses: map[int]interface{}{http.StatusOK: &ArticleResponse{}, http.StatusNotModified: nil}},
+		{Method: http.MethodGet, Path: "/v1/api/articles", Summary: "List articles", Tags: tags,
+			Query: &ListArticlesQuery{}, Responses: map[int]interface{}{http.StatusOK: &ArticlesResponse{}}},
+		{Method: http.MethodGet, Path: "/v1/api/articles/:slug/comments", Summary: "List comments of an article", Tags: tags,
+			Responses: map[int]interface{}{http.StatusOK: &CommentsResponse{}}},
+		{Method: http.MethodPost, Path: "/v1/api/articles:batchGet", Summary: "Get articles by slugs", Tags: tags,
+			Request: &BatchGetArticlesRequest{}, Responses: map[int]interface{}{http.StatusOK: &BatchGetArticlesResponse{}}},
+		// auth required
+		{Method: http.MethodPost, Path: "/v1/api/articles", Summary: "Create an article", Tags: tags, Security: auth,
+			Request: &SaveArticleRequest{}, Responses: map[int]interface{}{http.StatusCreated: &ArticleResponse{}}},
+		{Method: http.MethodPut, Path: "/v1/api/articles/:slug", Summary: "Update an article", Tags: tags, Security: auth,
+			Request: &UpdateArticleRequest{}, Responses: map[int]interface{}{http.StatusOK: &ArticleResponse{}}},
+		{Method: http.MethodDelete, Path: "/v1/api/articles/:slug", Summary: "Delete an article", Tags: tags, Security: auth,
+			Responses: map[int]interface{}{http.StatusOK: nil}},
+		{Method: http.MethodPost, Path: "/v1/api/articles/:slug/comments", Summary: "Create a comment", Tags: tags, Security: auth,
+			Request: &SaveCommentRequest{}, Responses: map[int]interface{}{http.StatusCreated: &CommentResponse{}}},
+		{Method: http.MethodDelete, Path: "/v1/api/articles/:slug/comments/:id", Summary: "Delete a comment", Tags: tags, Security: auth,
+			Responses: map[int]interface{}{http.StatusOK: nil}},
+
+		// v2 anonymous
+		{Method: http.MethodGet, Path: "/v2/api/articles/:slug", Summary: "Get an article", Tags: tagsV2,
+			Responses: map[int]interface{}{http.StatusOK: &ArticleV2Response{}, http.StatusNotModified: nil}},
+		{Method: http.MethodGet, Path: "/v2/api/articles", Summary: "List articles", Tags: tagsV2,
+			Query: &ListArticlesQuery{}, Responses: map[int]interface{}{http.StatusOK: &ArticlesV2Response{}}},
+		{Method: http.MethodGet, Path: "/v2/api/articles/:slug/comments", Summary: "List comments of an article", Tags: tagsV2,
+			Responses: map[int]interface{}{http.StatusOK: &CommentsV2Response{}}},
+		{Method: http.MethodPost, Path: "/v2/api/articles:batchGet", Summary: "Get articles by slugs", Tags: tagsV2,
+			Request: &BatchGetArticlesRequest{}, Responses: map[int]interface{}{http.StatusOK: &BatchGetArticlesV2Response{}}},
+		// v2 auth required
+		{Method: http.MethodPost, Path: "/v2/api/articles", Summary: "Create an article", Tags: tagsV2, Security: auth,
+			Request: &SaveArticleRequest{}, Responses: map[int]interface{}{http.StatusCreated: &ArticleV2Response{}}},
+		{Method: http.MethodPut, Path: "/v2/api/articles/:slug", Summary: "Update an article", Tags: tagsV2, Security: auth,
+			Request: &UpdateArticleRequest{}, Responses: map[int]interface{}{http.StatusOK: &ArticleV2Response{}}},
+		{Method: http.MethodDelete, Path: "/v2/api/articles/:slug", Summary: "Delete an article", Tags: tagsV2, Security: auth,
+			Responses: map[int]interface{}{http.StatusOK: nil}},
+		{Method: http.MethodPost, Path: "/v2/api/articles/:slug/comments", Summary: "Create a comment", Tags: tagsV2, Security: auth,
+			Request: &SaveCommentRequest{}, Responses: map[int]interface{}{http.StatusCreated: &CommentV2Response{}}},
+		{Method: http.MethodDelete, Path: "/v2/api/articles/:slug/comments/:id", Summary: "Delete a comment", Tags: tagsV2, Security: auth,
+			Responses: map[int]interface{}{http.StatusOK: nil}},
+	}
+}
@@ -0,0 +1,26 @@
+package article
+
+import (
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/openapi"
+	"strings"
+)
+
+func (s *HandlerSuite) TestOpenAPIRoutes() {
+	spec := openapi.NewSpec(openapi.Info{Title: "test", Version: "v1"}, handler.Problem{})
+	spec.Add(OpenAPIRoutes()...)
+
+	// then registered routes are documented
+	count := 0
+	for _, r := range s.r.Routes() {
+		// routes of the account package are registered to the router too
+		if !strings.HasPrefix(r.Path, "/v1/api/articles") && !strings.HasPrefix(r.Path, "/v2/api/articles") {
+			continue
+		}
+		count++
+		s.Truef(spec.Has(r.Method, middleware.CustomMethodPath(r.Path)), "route %s %s is missing in the OpenAPI document", r.Method, r.Path)
+	}
+	// then documented routes are registered
+	s.Equal(len(OpenAPIRoutes()), count)
+}
@@ -0,0 +1,50 @@
+package article
+
+// ArticleURI is path parameters of /v1/api/articles/:slug
+type ArticleURI struct {
+	Slug string `uri:"slug" binding:"required"`
+}
+
+// CommentURI is path parameters of /v1/api/articles/:slug/comments/:id
+type CommentURI struct {
+	Slug string `uri:"slug" binding:"required"`
+	ID   uint64 `uri:"id"`
+}
+
+// SaveArticleRequest is a request body of POST /v1/api/articles
+type SaveArticleRequest struct {
+	Article struct {
+		Title string   `json:"title" binding:"required,min=5"`
+		Body  string   `json:"body" binding:"required"`
+		Tags  []string `json:"tagList" binding:"omitempty,dive,max=10,slug"`
+	} `json:"article"`
+}
+
+// UpdateArticleRequest is a request body of PUT /v1/api/articles/:slug
+type UpdateArticleRequest struct {
+	Article struct {
+		Title *string   `json:"title" binding:"omitempty,min=5"`
+		Body  *string   `json:"body" binding:"omitempty,min=1"`
+		Tags  *[]string `json:"tagList" binding:"omitempty,dive,max=10,slug"`
+	} `json:"article"`
+}
+
+// BatchGetArticlesRequest is a request body of POST /v1/api/articles:batchGet
+type BatchGetArticlesRequest struct {
+	Slugs []string `json:"slugs" binding:"required,min=1,max=100,dive,required"`
+}
+
+// ListArticlesQuery is query parameters of GET /v1/api/articles
+type ListArticlesQuery struct {
+	Tag    []string `form:"tag" binding:"omitempty,dive,max=10"`
+	Author string   `form:"author" binding:"omitempty"`
+	Limit  string   `form:"limit,default=5" binding:"numeric"`
+	Offset string   `form:"offset,default=0" binding:"numeric"`
+}
+
+// SaveCommentRequest is a request body of POST /v1/api/articles/:slug/comments
+type SaveCommentRequest struct {
+	Comment struct {
+		Body string `json:"body" binding:"required"`
+	} `json:"comment" binding:"required"`
+}
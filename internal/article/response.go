@@ -2,7 +2,11 @@ package article
 
 import (
 	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/url"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 type ArticleResponse struct {
@@ -14,6 +18,13 @@ type ArticlesResponse struct {
 	ArticlesCount int64     `json:"articlesCount"`
 }
 
+// BatchGetArticlesResponse is a response body of POST /v1/api/articles:batchGet
+// with found articles and slugs of missing articles in the requested order.
+type BatchGetArticlesResponse struct {
+	Articles []Article `json:"articles"`
+	Missing  []string  `json:"missing"`
+}
+
 type Article struct {
 	Slug      string    `json:"slug"`
 	Title     string    `json:"title"`
@@ -110,3 +121,62 @@ func NewCommentResponse(comment *model.Comment) *CommentResponse {
 		},
 	}
 }
+
+const representationKey = "article.representation"
+
+// withRepresentation returns a middleware storing the representation of an api version to the gin.Context.
+func withRepresentation(rep representation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(representationKey, rep)
+	}
+}
+
+// representationOf returns the representation stored by withRepresentation or representationV1 if not exist.
+func representationOf(c *gin.Context) representation {
+	if v, ok := c.Get(representationKey); ok {
+		return v.(representation)
+	}
+	return representationV1{}
+}
+
+// representation converts models to response bodies of an api version.
+type representation interface {
+	// name is the name of the api version e.g. "v1".
+	name() string
+	article(a *model.Article) interface{}
+	articles(u *url.URL, articles []*model.Article, meta handler.PageMeta) interface{}
+	batchArticles(u *url.URL, articles []*model.Article, missing []string) interface{}
+	comment(slug string, comment *model.Comment) interface{}
+	comments(slug string, comments []*model.Comment) interface{}
+}
+
+// representationV1 represents models of v1 apis by RealWorld shapes.
+type representationV1 struct{}
+
+func (representationV1) name() string {
+	return "v1"
+}
+
+func (representationV1) article(a *model.Article) interface{} {
+	return NewArticleResponse(a)
+}
+
+func (representationV1) articles(_ *url.URL, articles []*model.Article, meta handler.PageMeta) interface{} {
+	return NewArticlesResponse(articles, meta.Total)
+}
+
+func (representationV1) batchArticles(_ *url.URL, articles []*model.Article, missing []string) interface{} {
+	res := BatchGetArticlesResponse{Articles: []Article{}, Missing: missing}
+	for _, a := range articles {
+		res.Articles = append(res.Articles, NewArticleResponse(a).Article)
+	}
+	return &res
+}
+
+func (representationV1) comment(_ string, comment *model.Comment) interface{} {
+	return NewCommentResponse(comment)
+}
+
+func (representationV1) comments(_ string, comments []*model.Comment) interface{} {
+	return NewCommentsResponse(comments)
+}
@@ -0,0 +1,165 @@
+package article
+
+import (
+	"fmt"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/url"
+	"time"
+)
+
+type ArticleV2Response struct {
+	Data  ArticleV2     `json:"data"`
+	Links handler.Links `json:"links"`
+}
+
+type ArticlesV2Response struct {
+	Data  []ArticleV2      `json:"data"`
+	Meta  handler.PageMeta `json:"meta"`
+	Links handler.Links    `json:"links"`
+}
+
+type BatchGetArticlesV2Response struct {
+	Data  []ArticleV2          `json:"data"`
+	Meta  BatchGetArticlesMeta `json:"meta"`
+	Links handler.Links        `json:"links"`
+}
+
+// BatchGetArticlesMeta is slugs of missing articles in the requested order.
+type BatchGetArticlesMeta struct {
+	Missing []string `json:"missing"`
+}
+
+type ArticleV2 struct {
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tagList"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Author    Author    `json:"author"`
+}
+
+type CommentV2Response struct {
+	Data  CommentV2     `json:"data"`
+	Links handler.Links `json:"links"`
+}
+
+type CommentsV2Response struct {
+	Data  []CommentV2   `json:"data"`
+	Meta  CommentsMeta  `json:"meta"`
+	Links handler.Links `json:"links"`
+}
+
+type CommentsMeta struct {
+	Total int `json:"total"`
+}
+
+type CommentV2 struct {
+	ID        uint      `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Author    Author    `json:"author"`
+}
+
+// representationV2 represents models of v2 apis by {data, meta, links} envelopes.
+type representationV2 struct{}
+
+func (representationV2) name() string {
+	return "v2"
+}
+
+func (representationV2) article(a *model.Article) interface{} {
+	return &ArticleV2Response{
+		Data:  newArticleV2(a),
+		Links: handler.NewSelfLinks(articlePathV2(a.Slug)),
+	}
+}
+
+func (representationV2) articles(u *url.URL, articles []*model.Article, meta handler.PageMeta) interface{} {
+	data := []ArticleV2{}
+	for _, a := range articles {
+		data = append(data, newArticleV2(a))
+	}
+	return &ArticlesV2Response{
+		Data:  data,
+		Meta:  meta,
+		Links: handler.NewPageLinks(u, meta),
+	}
+}
+
+func (representationV2) batchArticles(u *url.URL, articles []*model.Article, missing []string) interface{} {
+	data := []ArticleV2{}
+	for _, a := range articles {
+		data = append(data, newArticleV2(a))
+	}
+	return &BatchGetArticlesV2Response{
+		Data:  data,
+		Meta:  BatchGetArticlesMeta{Missing: missing},
+		Links: handler.NewSelfLinks(middleware.CustomMethodPath(u.Path)),
+	}
+}
+
+func (representationV2) comment(slug string, comment *model.Comment) interface{} {
+	return &CommentV2Response{
+		Data:  newCommentV2(comment),
+		Links: handler.NewSelfLinks(fmt.Sprintf("%s/comments/%d", articlePathV2(slug), comment.ID)),
+	}
+}
+
+func (representationV2) comments(slug string, comments []*model.Comment) interface{} {
+	data := []CommentV2{}
+	for _, comment := range comments {
+		data = append(data, newCommentV2(comment))
+	}
+	return &CommentsV2Response{
+		Data:  data,
+		Meta:  CommentsMeta{Total: len(data)},
+		Links: handler.NewSelfLinks(articlePathV2(slug) + "/comments"),
+	}
+}
+
+func newArticleV2(a *model.Article) ArticleV2 {
+	tags := []string{}
+	for _, tag := range a.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return ArticleV2{
+		Slug:      a.Slug,
+		Title:     a.Title,
+		Body:      a.Body,
+		Tags:      tags,
+		CreatedAt: timestampV2(a.CreatedAt),
+		UpdatedAt: timestampV2(a.UpdatedAt),
+		Author: Author{
+			Username: a.Author.Username,
+			Bio:      a.Author.Bio,
+			Image:    a.Author.Image,
+		},
+	}
+}
+
+func newCommentV2(comment *model.Comment) CommentV2 {
+	return CommentV2{
+		ID:        comment.ID,
+		Body:      comment.Body,
+		CreatedAt: timestampV2(comment.CreatedAt),
+		UpdatedAt: timestampV2(comment.UpdatedAt),
+		Author: Author{
+			Username: comment.Author.Username,
+			Bio:      comment.Author.Bio,
+			Image:    comment.Author.Image,
+		},
+	}
+}
+
+// timestampV2 returns given time in UTC without fractional seconds which is encoded as RFC 3339 e.g. 2021-01-02T15:04:05Z.
+func timestampV2(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+func articlePathV2(slug string) string {
+	return "/v2/api/articles/" + url.PathEscape(slug)
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/internal/audit/database"
+	"gin-rest-api-example/internal/audit/model"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/trace"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Actions of audit events
+const (
+	ActionLogin         = "account.login"
+	ActionLoginFailed   = "account.login_failed"
+	ActionAccountUpdate = "account.update"
+	ActionEmailChange   = "account.email_change"
+	ActionAccountDelete = "account.delete"
+	ActionArticleUpdate = "article.update"
+	ActionArticleDelete = "article.delete"
+	ActionCommentDelete = "comment.delete"
+	ActionAuditQuery    = "admin.audit_query"
+	ActionArticleImport = "admin.article_import"
+	ActionArticleExport = "admin.article_export"
+)
+
+// Types of audit event targets
+const (
+	TargetAccount = "account"
+	TargetArticle = "article"
+	TargetComment = "comment"
+	TargetAudit   = "audit_event"
+)
+
+// Redacted is recorded instead of secret values e.g. passwords.
+const Redacted = "[PROTECTED]"
+
+// Event is an action to record. The client ip and the request id are taken from the request.
+type Event struct {
+	Action     string
+	ActorID    uint
+	TargetType string
+	TargetID   string
+	Changes    Changes
+}
+
+// Change is the before and after values of a field.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Changes is changed fields to the before and after values.
+type Changes map[string]Change
+
+// Diff returns changes of fields which have different values in before and after.
+// Missing fields are recorded as nil e.g. all fields of before are changed to nil if after is nil.
+func Diff(before, after map[string]interface{}) Changes {
+	changes := Changes{}
+	for field, value := range before {
+		if afterValue, ok := after[field]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[field] = Change{Before: value, After: afterValue}
+		}
+	}
+	for field, value := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = Change{After: value}
+		}
+	}
+	return changes
+}
+
+// Auditor records audit events to the audit_events table and optional sinks e.g. a json lines file.
+// Failures to record are logged and never fail the request.
+type Auditor struct {
+	sinks []Sink
+}
+
+// NewAuditor creates a new auditor which records events to given db and
+// appends events to a file if "audit.file.path" config is not empty.
+func NewAuditor(cfg *config.Config, auditDB database.AuditDB) (*Auditor, error) {
+	sinks := []Sink{NewDBSink(auditDB)}
+	if cfg.AuditConfig.File.Path != "" {
+		sink, err := newFileSink(cfg.AuditConfig.File.Path)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return New(sinks...), nil
+}
+
+// New creates a new auditor which records events to given sinks.
+func New(sinks ...Sink) *Auditor {
+	return &Auditor{sinks: sinks}
+}
+
+// Record records given event of the request to all sinks.
+func (a *Auditor) Record(c *gin.Context, e Event) {
+	a.RecordContext(c.Request.Context(), c.ClientIP(), e)
+}
+
+// RecordContext records given event of the request of given context and client ip to all sinks
+// e.g. requests of gRPC.
+func (a *Auditor) RecordContext(ctx context.Context, ip string, e Event) {
+	logger := logging.FromContext(ctx)
+	event := model.Event{
+		Action:     e.Action,
+		ActorID:    e.ActorID,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         ip,
+		RequestID:  trace.RequestIDFromContext(ctx),
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	if len(e.Changes) != 0 {
+		b, err := json.Marshal(e.Changes)
+		if err != nil {
+			logger.Errorw("audit.Record failed to marshal changes", "action", e.Action, "err", err)
+		}
+		event.Changes = b
+	}
+	for _, sink := range a.sinks {
+		if err := sink.Write(ctx, &event); err != nil {
+			logger.Errorw("audit.Record failed to record an event", "action", e.Action, "err", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gin-rest-api-example/internal/audit/database/mocks"
+	"gin-rest-api-example/internal/audit/model"
+	"gin-rest-api-example/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{"username": "user1", "bio": "bio", "image": "image1"}
+	after := map[string]interface{}{"username": "user2", "bio": "bio", "role": "admin"}
+
+	// when
+	changes := Diff(before, after)
+
+	// then
+	assert.Equal(t, Changes{
+		"username": {Before: "user1", After: "user2"},
+		"image":    {Before: "image1", After: nil},
+		"role":     {Before: nil, After: "admin"},
+	}, changes)
+	assert.Empty(t, Diff(before, before))
+}
+
+func TestAuditor_Record(t *testing.T) {
+	var buf bytes.Buffer
+	db := &mocks.AuditDB{}
+	db.On("SaveEvent", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.Event).ID = 10
+	}).Return(nil)
+	failing := &mocks.AuditDB{}
+	failing.On("SaveEvent", mock.Anything, mock.Anything).Return(errors.New("failed"))
+	auditor := New(NewDBSink(failing), NewDBSink(db), NewWriterSink(&buf))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestIDMiddleware())
+	r.PUT("/user", func(c *gin.Context) {
+		auditor.Record(c, Event{
+			Action:     ActionAccountUpdate,
+			ActorID:    1,
+			TargetType: TargetAccount,
+			TargetID:   "1",
+			Changes:    Changes{"bio": {Before: "bio1", After: "bio2"}},
+		})
+	})
+
+	// when
+	req, _ := http.NewRequest("PUT", "/user", nil)
+	req.Header.Set(middleware.XRequestIdKey, "request-1")
+	req.RemoteAddr = "10.0.0.1:1234"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// then
+	db.AssertCalled(t, "SaveEvent", mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+		return e.Action == ActionAccountUpdate && e.RequestID == "request-1" && e.IP == "10.0.0.1"
+	}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 1)
+	var event model.Event
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.EqualValues(t, 10, event.ID)
+	assert.Equal(t, ActionAccountUpdate, event.Action)
+	assert.EqualValues(t, 1, event.ActorID)
+	assert.Equal(t, "request-1", event.RequestID)
+	assert.Equal(t, "10.0.0.1", event.IP)
+	assert.JSONEq(t, `{"bio": {"before": "bio1", "after": "bio2"}}`, string(event.Changes))
+	assert.False(t, event.CreatedAt.IsZero())
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"gin-rest-api-example/internal/audit/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/logging"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FindEventsCriteria filters audit events by non empty fields.
+// From is inclusive and To is exclusive.
+type FindEventsCriteria struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	Offset     uint
+	Limit      uint
+}
+
+//go:generate mockery --name AuditDB --filename audit_mock.go
+type AuditDB interface {
+	// SaveEvent appends a given event. Events are never updated or deleted.
+	SaveEvent(ctx context.Context, event *model.Event) error
+
+	// FindEvents returns events with given criteria ordered by id desc and total count
+	FindEvents(ctx context.Context, criteria FindEventsCriteria) ([]*model.Event, int64, error)
+}
+
+// NewAuditDB creates a new audit db with given db
+func NewAuditDB(db *gorm.DB) AuditDB {
+	return &auditDB{db: db}
+}
+
+type auditDB struct {
+	db *gorm.DB
+}
+
+func (a *auditDB) SaveEvent(ctx context.Context, event *model.Event) error {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("audit.db.SaveEvent", "action", event.Action, "actorID", event.ActorID)
+
+	if err := db.WithContext(ctx).Create(event).Error; err != nil {
+		logger.Errorw("audit.db.SaveEvent failed to save", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (a *auditDB) FindEvents(ctx context.Context, criteria FindEventsCriteria) ([]*model.Event, int64, error) {
+	logger := logging.FromContext(ctx)
+	db := database.FromContext(ctx, a.db)
+	logger.Debugw("audit.db.FindEvents", "criteria", criteria)
+
+	chain := db.WithContext(ctx).Model(&model.Event{})
+	if criteria.ActorID != 0 {
+		chain = chain.Where("actor_id = ?", criteria.ActorID)
+	}
+	if criteria.Action != "" {
+		chain = chain.Where("action = ?", criteria.Action)
+	}
+	if criteria.TargetType != "" {
+		chain = chain.Where("target_type = ?", criteria.TargetType)
+	}
+	if criteria.TargetID != "" {
+		chain = chain.Where("target_id = ?", criteria.TargetID)
+	}
+	if !criteria.From.IsZero() {
+		chain = chain.Where("created_at >= ?", criteria.From)
+	}
+	if !criteria.To.IsZero() {
+		chain = chain.Where("created_at < ?", criteria.To)
+	}
+
+	var total int64
+	if err := chain.Count(&total).Error; err != nil {
+		logger.Errorw("audit.db.FindEvents failed to get total count", "err", err)
+		return nil, 0, err
+	}
+	var events []*model.Event
+	err := chain.Order("id DESC").
+		Offset(int(criteria.Offset)).
+		Limit(int(criteria.Limit)).
+		Find(&events).Error
+	if err != nil {
+		logger.Errorw("audit.db.FindEvents failed to find events", "err", err)
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"gin-rest-api-example/internal/audit/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+)
+
+type DBSuite struct {
+	suite.Suite
+	db       AuditDB
+	originDB *gorm.DB
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(DBSuite))
+}
+
+func (s *DBSuite) SetupSuite() {
+	logging.SetLevel(zapcore.FatalLevel)
+	s.originDB = database.NewTestDatabase(s.T(), true)
+	s.db = NewAuditDB(s.originDB)
+}
+
+func (s *DBSuite) TestSaveAndFindEvents() {
+	// given
+	now := time.Now().UTC().Truncate(time.Second)
+	events := []*model.Event{
+		{Action: "account.login", ActorID: 1, TargetType: "account", TargetID: "1", CreatedAt: now.Add(-time.Hour)},
+		{Action: "account.update", ActorID: 1, TargetType: "account", TargetID: "1", Changes: []byte(`{"bio":{"before":"a","after":"b"}}`), CreatedAt: now},
+		{Action: "account.login", ActorID: 2, TargetType: "account", TargetID: "2", CreatedAt: now},
+	}
+	for _, e := range events {
+		s.NoError(s.db.SaveEvent(nil, e))
+	}
+
+	// when
+	find, total, err := s.db.FindEvents(nil, FindEventsCriteria{ActorID: 1, Limit: 10})
+
+	// then
+	s.NoError(err)
+	s.EqualValues(2, total)
+	s.Len(find, 2)
+	s.Equal(events[1].ID, find[0].ID)
+	s.JSONEq(string(events[1].Changes), string(find[0].Changes))
+	s.Equal(events[0].ID, find[1].ID)
+
+	// when then: filter by action and created time
+	find, total, err = s.db.FindEvents(nil, FindEventsCriteria{Action: "account.login", From: now, Limit: 10})
+	s.NoError(err)
+	s.EqualValues(1, total)
+	s.Equal(events[2].ID, find[0].ID)
+}
+
+func (s *DBSuite) TestEventsAreAppendOnly() {
+	// given
+	event := model.Event{Action: "test.append_only", ActorID: 3, CreatedAt: time.Now()}
+	s.NoError(s.db.SaveEvent(nil, &event))
+
+	// when
+	updateErr := s.originDB.Model(&event).Update("action", "account.update").Error
+	deleteErr := s.originDB.Delete(&event).Error
+
+	// then
+	s.Error(updateErr)
+	s.Error(deleteErr)
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is an append-only record of a security-relevant action.
+// ActorID is 0 if the actor is anonymous e.g. failed logins.
+// Changes is a json object of changed fields to the before and after values.
+type Event struct {
+	ID         uint            `gorm:"column:id" json:"id"`
+	Action     string          `gorm:"column:action" json:"action"`
+	ActorID    uint            `gorm:"column:actor_id" json:"actorId"`
+	TargetType string          `gorm:"column:target_type" json:"targetType"`
+	TargetID   string          `gorm:"column:target_id" json:"targetId"`
+	IP         string          `gorm:"column:ip" json:"ip"`
+	RequestID  string          `gorm:"column:request_id" json:"requestId"`
+	Changes    json.RawMessage `gorm:"column:changes" json:"changes,omitempty"`
+	CreatedAt  time.Time       `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
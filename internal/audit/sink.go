@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/internal/audit/database"
+	"gin-rest-api-example/internal/audit/model"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink is a destination of audit events.
+type Sink interface {
+	// Write records a given event
+	Write(ctx context.Context, event *model.Event) error
+}
+
+var (
+	_ Sink = (*dbSink)(nil)
+	_ Sink = (*writerSink)(nil)
+)
+
+// NewDBSink creates a new sink that appends events to the audit_events table.
+func NewDBSink(auditDB database.AuditDB) Sink {
+	return &dbSink{auditDB: auditDB}
+}
+
+type dbSink struct {
+	auditDB database.AuditDB
+}
+
+func (s *dbSink) Write(ctx context.Context, event *model.Event) error {
+	return s.auditDB.SaveEvent(ctx, event)
+}
+
+// NewWriterSink creates a new sink that writes events to given writer as json lines.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+func newFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSink(f), nil
+}
+
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *writerSink) Write(_ context.Context, event *model.Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
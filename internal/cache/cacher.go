@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"gin-rest-api-example/internal/config"
 	"io"
+	"time"
 )
 
 var (
@@ -26,9 +27,16 @@ type Cacher interface {
 	// Get gets an item for the given computeKey.
 	Get(ctx context.Context, key string, value interface{}) error
 
+	// MGet gets items of given keys at once. Each item is decoded to a new value of newValue
+	// and returned by the key. Missing items and items failed to decode are not in returned values.
+	MGet(ctx context.Context, keys []string, newValue func() interface{}) (map[string]interface{}, error)
+
 	// Set adds an item to the cache.
 	Set(ctx context.Context, key string, value interface{}) error
 
+	// SetWithTTL adds an item to the cache with given ttl instead of the default ttl.
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+
 	// Exists returns a true if the given computeKey is exists, otherwise false.
 	Exists(ctx context.Context, key string) (bool, error)
 
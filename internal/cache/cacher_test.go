@@ -101,6 +101,53 @@ func testGet(t *testing.T, cacher Cacher) {
 	})
 }
 
+func testMGet(t *testing.T, cacher Cacher) {
+	existKey1, existKey2 := uuid.NewString(), uuid.NewString()
+	assert.NoError(t, cacher.Set(context.TODO(), existKey1, "value1"))
+	assert.NoError(t, cacher.Set(context.TODO(), existKey2, "value2"))
+
+	t.Run("Exist And NotExist Items", func(t *testing.T) {
+		missingKey := uuid.NewString()
+
+		values, err := cacher.MGet(context.TODO(), []string{existKey1, missingKey, existKey2}, func() interface{} {
+			return new(string)
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, values, 2)
+		assert.Equal(t, "value1", *values[existKey1].(*string))
+		assert.Equal(t, "value2", *values[existKey2].(*string))
+		assert.NotContains(t, values, missingKey)
+	})
+
+	t.Run("Invalid Value", func(t *testing.T) {
+		values, err := cacher.MGet(context.TODO(), []string{existKey1}, func() interface{} {
+			return new(int)
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, values)
+	})
+
+	t.Run("Empty Keys", func(t *testing.T) {
+		values, err := cacher.MGet(context.TODO(), nil, func() interface{} {
+			return new(string)
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, values)
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := cacher.MGet(context.TODO(), []string{existKey1, ""}, func() interface{} {
+			return new(string)
+		})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrInvalidKey.Error())
+	})
+}
+
 func testExists(t *testing.T, cacher Cacher) {
 	existKey := uuid.NewString()
 	existValue := "value1"
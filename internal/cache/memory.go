@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var _ Cacher = (*memoryCacher)(nil)
+
+// NewMemoryCacher returns a Cacher which stores items in the memory of the process.
+// Items are encoded like redis cacher, so values are copied on Set and Get.
+// It is used for features which require a cache even if the cache is disabled in configs
+// and is not shared between servers.
+func NewMemoryCacher(ttl time.Duration) Cacher {
+	return &memoryCacher{
+		items: make(map[string]memoryItem),
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+type memoryItem struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+type memoryCacher struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	ttl       time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func (m *memoryCacher) Fetch(ctx context.Context, key string, value interface{}, fetchFunc FetchFunc) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	err := m.Get(ctx, key, value)
+	if err != ErrCacheMiss || fetchFunc == nil {
+		return err
+	}
+	fetched, err := fetchFunc()
+	if err != nil {
+		return err
+	}
+	if err := m.Set(ctx, key, fetched); err != nil {
+		return err
+	}
+	return m.Get(ctx, key, value)
+}
+
+func (m *memoryCacher) Get(_ context.Context, key string, value interface{}) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrInvalidValue
+	}
+	m.mu.Lock()
+	item, ok := m.items[key]
+	if ok && !m.now().Before(item.expiresAt) {
+		delete(m.items, key)
+		ok = false
+	}
+	m.mu.Unlock()
+	if !ok {
+		return ErrCacheMiss
+	}
+	if err := msgpack.Unmarshal(item.data, value); err != nil {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+func (m *memoryCacher) MGet(ctx context.Context, keys []string, newValue func() interface{}) (map[string]interface{}, error) {
+	for _, key := range keys {
+		if key == "" {
+			return nil, ErrInvalidKey
+		}
+	}
+	ret := make(map[string]interface{})
+	for _, key := range keys {
+		value := newValue()
+		if err := m.Get(ctx, key, value); err == nil {
+			ret[key] = value
+		}
+	}
+	return ret, nil
+}
+
+func (m *memoryCacher) Set(ctx context.Context, key string, value interface{}) error {
+	return m.SetWithTTL(ctx, key, value, m.ttl)
+}
+
+func (m *memoryCacher) SetWithTTL(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	data, err := msgpack.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+	m.items[key] = memoryItem{data: data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *memoryCacher) Exists(_ context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, ErrInvalidKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	return ok && m.now().Before(item.expiresAt), nil
+}
+
+func (m *memoryCacher) Delete(_ context.Context, key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *memoryCacher) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = make(map[string]memoryItem)
+	return nil
+}
+
+// sweep removes expired items at most once per the default ttl. Must be called with the lock.
+func (m *memoryCacher) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.ttl {
+		return
+	}
+	for key, item := range m.items {
+		if !now.Before(item.expiresAt) {
+			delete(m.items, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MemoryCacheSuite struct {
+	suite.Suite
+	cacher Cacher
+}
+
+func TestMemoryCache(t *testing.T) {
+	suite.Run(t, new(MemoryCacheSuite))
+}
+
+func (s *MemoryCacheSuite) SetupTest() {
+	s.cacher = NewMemoryCacher(time.Minute)
+}
+
+func (s *MemoryCacheSuite) TestFetch() {
+	testFetch(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestGet() {
+	testGet(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestMGet() {
+	testMGet(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestSet() {
+	testSet(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestExists() {
+	testExists(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestDelete() {
+	testDelete(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestSetWithTTL() {
+	// given
+	now := time.Now()
+	s.cacher.(*memoryCacher).now = func() time.Time { return now }
+	s.NoError(s.cacher.SetWithTTL(context.TODO(), "key1", "value1", time.Hour))
+
+	// when
+	now = now.Add(time.Hour)
+
+	// then
+	ok, err := s.cacher.Exists(context.TODO(), "key1")
+	s.NoError(err)
+	s.False(ok)
+	var find string
+	assert.Equal(s.T(), ErrCacheMiss, s.cacher.Get(context.TODO(), "key1", &find))
+}
@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 	cache "gin-rest-api-example/internal/cache"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -91,6 +92,29 @@ func (_m *Cacher) Get(ctx context.Context, key string, value interface{}) error
 	return r0
 }
 
+// MGet provides a mock function with given fields: ctx, keys, newValue
+func (_m *Cacher) MGet(ctx context.Context, keys []string, newValue func() interface{}) (map[string]interface{}, error) {
+	ret := _m.Called(ctx, keys, newValue)
+
+	var r0 map[string]interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, func() interface{}) map[string]interface{}); ok {
+		r0 = rf(ctx, keys, newValue)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, func() interface{}) error); ok {
+		r1 = rf(ctx, keys, newValue)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Set provides a mock function with given fields: ctx, key, value
 func (_m *Cacher) Set(ctx context.Context, key string, value interface{}) error {
 	ret := _m.Called(ctx, key, value)
@@ -105,6 +129,20 @@ func (_m *Cacher) Set(ctx context.Context, key string, value interface{}) error
 	return r0
 }
 
+// SetWithTTL provides a mock function with given fields: ctx, key, value, ttl
+func (_m *Cacher) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	ret := _m.Called(ctx, key, value, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, time.Duration) error); ok {
+		r0 = rf(ctx, key, value, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewCacher interface {
 	mock.TestingT
 	Cleanup(func())
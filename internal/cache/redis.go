@@ -73,7 +73,67 @@ func (r *redisCacher) Get(ctx context.Context, key string, value interface{}) er
 	return nil
 }
 
+func (r *redisCacher) MGet(ctx context.Context, keys []string, newValue func() interface{}) (map[string]interface{}, error) {
+	computed := make([]string, len(keys))
+	for i, key := range keys {
+		if key == "" {
+			return nil, ErrInvalidKey
+		}
+		computed[i] = r.computeKey(key)
+	}
+	ret := make(map[string]interface{})
+	if len(keys) == 0 {
+		return ret, nil
+	}
+	items, err := r.mget(ctx, computed)
+	if err != nil {
+		return nil, r.wrapError(err)
+	}
+	for i, item := range items {
+		b, ok := item.(string)
+		if !ok {
+			continue
+		}
+		value := newValue()
+		if err := r.cache.Unmarshal([]byte(b), value); err != nil {
+			logging.FromContext(ctx).Warnw("cache.redis.MGet failed to decode an item", "key", keys[i], "err", err)
+			continue
+		}
+		ret[keys[i]] = value
+	}
+	return ret, nil
+}
+
+// mget returns raw items of given keys which are nil if missing by a MGET command,
+// or pipelined GET commands in clusters since keys of a MGET command must be in the same slot.
+func (r *redisCacher) mget(ctx context.Context, keys []string) ([]interface{}, error) {
+	if _, ok := r.cli.(*redis.ClusterClient); !ok {
+		return r.cli.MGet(ctx, keys...).Result()
+	}
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	ret := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil {
+			ret[i] = v
+		}
+	}
+	return ret, nil
+}
+
 func (r *redisCacher) Set(ctx context.Context, key string, value interface{}) error {
+	return r.SetWithTTL(ctx, key, value, r.ttl)
+}
+
+func (r *redisCacher) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	if key == "" {
 		return ErrInvalidKey
 	}
@@ -81,7 +141,7 @@ func (r *redisCacher) Set(ctx context.Context, key string, value interface{}) er
 		Ctx:            ctx,
 		Key:            r.computeKey(key),
 		Value:          value,
-		TTL:            r.ttl,
+		TTL:            ttl,
 		SkipLocalCache: true,
 	})
 	if err != nil {
@@ -114,6 +174,16 @@ func (r *redisCacher) Close() error {
 	return nil
 }
 
+// RedisClient returns the redis client of given Cacher and the key prefix if the Cacher is backed by the redis.
+// It is used for features which require commands other than the Cacher e.g. atomic counters.
+func RedisClient(c Cacher) (redis.UniversalClient, string, bool) {
+	r, ok := c.(*redisCacher)
+	if !ok || r == nil {
+		return nil, "", false
+	}
+	return r.cli, r.prefix, true
+}
+
 func (r *redisCacher) computeKey(k string) string {
 	return r.prefix + k
 }
@@ -50,6 +50,10 @@ func (s *RedisCacheSuite) TestGet() {
 	testGet(s.T(), s.cacher)
 }
 
+func (s *RedisCacheSuite) TestMGet() {
+	testMGet(s.T(), s.cacher)
+}
+
 func (s *RedisCacheSuite) TestSet() {
 	testSet(s.T(), s.cacher)
 }
@@ -17,12 +17,19 @@ import (
 )
 
 type Config struct {
-	ServerConfig  ServerConfig  `json:"server"`
-	LoggingConfig LoggingConfig `json:"logging" yaml:"logging"`
-	JwtConfig     JWTConfig     `json:"jwt"`
-	DBConfig      DBConfig      `json:"db"`
-	CacheConfig   CacheConfig   `json:"cache"`
-	MetricsConfig MetricsConfig `json:"metrics"`
+	ServerConfig      ServerConfig      `json:"server"`
+	LoggingConfig     LoggingConfig     `json:"logging" yaml:"logging"`
+	JwtConfig         JWTConfig         `json:"jwt"`
+	DBConfig          DBConfig          `json:"db"`
+	CacheConfig       CacheConfig       `json:"cache"`
+	MetricsConfig     MetricsConfig     `json:"metrics"`
+	MailConfig        MailConfig        `json:"mail"`
+	AccountConfig     AccountConfig     `json:"account"`
+	OAuthConfig       OAuthConfig       `json:"oauth"`
+	AuditConfig       AuditConfig       `json:"audit"`
+	RateLimitConfig   RateLimitConfig   `json:"rateLimit"`
+	IdempotencyConfig IdempotencyConfig `json:"idempotency"`
+	I18nConfig        I18nConfig        `json:"i18n"`
 }
 
 type ServerConfig struct {
@@ -30,6 +37,30 @@ type ServerConfig struct {
 	ReadTimeout      time.Duration `json:"readTimeout"`
 	WriteTimeout     time.Duration `json:"writeTimeout"`
 	GracefulShutdown time.Duration `json:"gracefulShutdown"`
+	// BulkTimeout is the timeout of bulk apis such as imports and exports of articles instead of WriteTimeout.
+	BulkTimeout time.Duration `json:"bulkTimeout"`
+	// Errors is the format of error responses which is "problem" for RFC 7807 problem details
+	// or "legacy" for {code, message, errors}. Problem types are TypeBaseURL + error code
+	// or "about:blank" if TypeBaseURL is empty.
+	Errors struct {
+		Format      string `json:"format"`
+		TypeBaseURL string `json:"typeBaseURL"`
+	} `json:"errors"`
+	// V1 is the deprecation of v1 apis succeeded by v2 apis. Deprecation and Sunset headers are added
+	// to responses of them with the RFC 3339 dates unless empty.
+	V1 struct {
+		Deprecation string `json:"deprecation"`
+		Sunset      string `json:"sunset"`
+	} `json:"v1"`
+	// Grpc is the gRPC server serving articles and accounts. It is disabled if the port is zero.
+	Grpc struct {
+		Port int `json:"port"`
+	} `json:"grpc"`
+	// Graphql is limits of queries of /graphql. Queries are not limited if zero.
+	Graphql struct {
+		MaxDepth      int `json:"maxDepth"`
+		MaxComplexity int `json:"maxComplexity"`
+	} `json:"graphql"`
 }
 
 type LoggingConfig struct {
@@ -39,8 +70,83 @@ type LoggingConfig struct {
 }
 
 type JWTConfig struct {
-	Secret      string        `json:"secret"`
-	SessionTime time.Duration `json:"sessionTime"`
+	Secret       string         `json:"secret"`
+	SessionTime  time.Duration  `json:"sessionTime"`
+	SigningKeyID string         `json:"signingKeyId"`
+	Keys         []JWTKeyConfig `json:"keys"`
+	// AcceptEmailIdentity accepts tokens identifying accounts by email addresses which were issued
+	// before tokens identify accounts by ids. Disable it after the session time since the upgrade.
+	AcceptEmailIdentity bool `json:"acceptEmailIdentity"`
+	// EmailIdentityCutover is the time of the upgrade in RFC 3339 e.g. "2021-01-01T00:00:00Z" which is required
+	// if AcceptEmailIdentity is enabled. Tokens identifying accounts by email addresses are accepted only if
+	// sessions started before it, and until the session time passes since it.
+	EmailIdentityCutover string `json:"emailIdentityCutover"`
+}
+
+// JWTKeyConfig is an asymmetric key to sign or verify jwt tokens.
+// Retired keys are not used to sign but still verify tokens until they expire.
+type JWTKeyConfig struct {
+	ID             string `json:"id"`
+	Algorithm      string `json:"algorithm"`
+	PrivateKeyFile string `json:"privateKeyFile"`
+	PublicKeyFile  string `json:"publicKeyFile"`
+	Retired        bool   `json:"retired"`
+}
+
+type OAuthConfig struct {
+	// RedirectURL is a format of callback urls with a provider name
+	RedirectURL string                `json:"redirectURL"`
+	StateTTL    time.Duration         `json:"stateTTL"`
+	Providers   []OAuthProviderConfig `json:"providers"`
+}
+
+// OAuthProviderConfig is an OAuth2 provider to sign in with.
+// Type is "oidc" for OpenID Connect providers e.g. Google or "github".
+type OAuthProviderConfig struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+	AuthURL      string   `json:"authURL"`
+	TokenURL     string   `json:"tokenURL"`
+	APIURL       string   `json:"apiURL"`
+}
+
+// RateLimitConfig limits requests of routes by the rule of the route name e.g. "login".
+// Requests are counted in the redis if the cache is redis, otherwise in the memory of the process.
+type RateLimitConfig struct {
+	Enabled bool                     `json:"enabled"`
+	Routes  map[string]RateLimitRule `json:"routes"`
+}
+
+// RateLimitRule allows Limit requests in a sliding Window per Key which is "ip", "account" or "apiKey".
+// "account" and "apiKey" keys fall back to the client ip for anonymous requests.
+type RateLimitRule struct {
+	Limit  int           `json:"limit"`
+	Window time.Duration `json:"window"`
+	Key    string        `json:"key"`
+}
+
+// IdempotencyConfig is how long responses of requests with Idempotency-Key header are replayed.
+// Responses are stored in the cache, or in the memory of the process if the cache is disabled.
+type IdempotencyConfig struct {
+	Enabled bool          `json:"enabled"`
+	TTL     time.Duration `json:"ttl"`
+}
+
+// I18nConfig is the locale of messages used if no locales of Accept-Language header are supported.
+type I18nConfig struct {
+	FallbackLocale string `json:"fallbackLocale"`
+}
+
+// AuditConfig is where audit events are recorded in addition to the audit_events table.
+// Events are appended to File.Path as json lines if not empty.
+type AuditConfig struct {
+	File struct {
+		Path string `json:"path"`
+	} `json:"file"`
 }
 
 type DBConfig struct {
@@ -82,6 +188,89 @@ type MetricsConfig struct {
 	Subsystem string `json:"subsystem"`
 }
 
+type MailConfig struct {
+	Type string `json:"type"`
+	From string `json:"from"`
+	SMTP struct {
+		Host     string `json:"host"`
+		Port     int    `json:"port"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"smtp"`
+	File struct {
+		Path string `json:"path"`
+	} `json:"file"`
+}
+
+type AccountConfig struct {
+	Verification struct {
+		URL          string        `json:"url"`
+		TokenTTL     time.Duration `json:"tokenTTL"`
+		Restrictions []string      `json:"restrictions"`
+	} `json:"verification"`
+	PasswordReset struct {
+		URL      string        `json:"url"`
+		TokenTTL time.Duration `json:"tokenTTL"`
+	} `json:"passwordReset"`
+	EmailChange struct {
+		URL      string        `json:"url"`
+		TokenTTL time.Duration `json:"tokenTTL"`
+	} `json:"emailChange"`
+	MFA struct {
+		Issuer        string        `json:"issuer"`
+		ChallengeTTL  time.Duration `json:"challengeTTL"`
+		RequiredRoles []string      `json:"requiredRoles"`
+		RecoveryCodes int           `json:"recoveryCodes"`
+	} `json:"mfa"`
+	APIKey struct {
+		DefaultTTL time.Duration `json:"defaultTTL"`
+		MaxTTL     time.Duration `json:"maxTTL"`
+	} `json:"apiKey"`
+	// Password is a policy of new passwords and the algorithm to hash passwords.
+	// Hashes of outdated algorithm or params are upgraded at the next login.
+	Password struct {
+		MinLength        int    `json:"minLength"`
+		MinCharClasses   int    `json:"minCharClasses"`
+		BreachedListFile string `json:"breachedListFile"`
+		Algorithm        string `json:"algorithm"`
+		BcryptCost       int    `json:"bcryptCost"`
+		Argon2           struct {
+			Time       int `json:"time"`
+			Memory     int `json:"memory"`
+			Threads    int `json:"threads"`
+			KeyLength  int `json:"keyLength"`
+			SaltLength int `json:"saltLength"`
+		} `json:"argon2"`
+	} `json:"password"`
+	// Username is a policy of usernames which are unique and shown publicly.
+	// Usernames consist of letters, digits, '.', '_' and '-' starting with a letter or digit.
+	Username struct {
+		MinLength int      `json:"minLength"`
+		MaxLength int      `json:"maxLength"`
+		Reserved  []string `json:"reserved"`
+	} `json:"username"`
+	// Deletion is how contents of deleted accounts are handled.
+	// ContentPolicy is "reassign" to reassign contents to the account of GhostEmail or "delete" to delete contents.
+	// Accounts logged in within RecentLogin are deleted without the password or a two-factor code if positive.
+	Deletion struct {
+		ContentPolicy string        `json:"contentPolicy"`
+		GhostEmail    string        `json:"ghostEmail"`
+		RecentLogin   time.Duration `json:"recentLogin"`
+	} `json:"deletion"`
+	// Lockout delays logins exponentially after FreeAttempts failures of an email address
+	// and locks logins of the email address or the client ip for Duration after max failures.
+	Lockout struct {
+		Enabled       bool          `json:"enabled"`
+		FreeAttempts  int           `json:"freeAttempts"`
+		BaseDelay     time.Duration `json:"baseDelay"`
+		MaxDelay      time.Duration `json:"maxDelay"`
+		MaxFailures   int           `json:"maxFailures"`
+		MaxIPFailures int           `json:"maxIPFailures"`
+		Duration      time.Duration `json:"duration"`
+		Window        time.Duration `json:"window"`
+	} `json:"lockout"`
+}
+
 func Load(configPath string) (*Config, error) {
 	k := koanf.New(".")
 
@@ -145,14 +334,24 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 
 	maskKeys := map[string]struct{}{
 		// add keys if u want to mask some properties.
-		"jwt.secret": {},
+		"jwt.secret":         {},
+		"mail.smtp.password": {},
+	}
+
+	// suffixes of keys in lists e.g. oauth.providers.0.clientSecret
+	maskKeySuffixes := []string{
+		".clientSecret",
 	}
 
 	for key, val := range m {
 		if v, ok := val.(string); ok {
 			m[key] = maskPassword(v)
 		}
-		if _, ok := maskKeys[key]; ok {
+		_, ok := maskKeys[key]
+		for _, suffix := range maskKeySuffixes {
+			ok = ok || strings.HasSuffix(key, suffix)
+		}
+		if ok {
 			switch v := val.(type) {
 			case string:
 				if v != "" {
@@ -20,7 +20,15 @@ func TestLoad(t *testing.T) {
 	equal(t, 8080, defaultConfig["server.port"], cfg.ServerConfig.Port)
 	equalDuration(t, 5*time.Second, defaultConfig["server.readTimeout"], cfg.ServerConfig.ReadTimeout)
 	equalDuration(t, 10*time.Second, defaultConfig["server.writeTimeout"], cfg.ServerConfig.WriteTimeout)
+	equalDuration(t, 10*time.Minute, defaultConfig["server.bulkTimeout"], cfg.ServerConfig.BulkTimeout)
 	equalDuration(t, 30*time.Second, defaultConfig["server.gracefulShutdown"], cfg.ServerConfig.GracefulShutdown)
+	equal(t, "problem", defaultConfig["server.errors.format"], cfg.ServerConfig.Errors.Format)
+	equal(t, "", defaultConfig["server.errors.typeBaseURL"], cfg.ServerConfig.Errors.TypeBaseURL)
+	equal(t, "2026-11-01T00:00:00Z", defaultConfig["server.v1.deprecation"], cfg.ServerConfig.V1.Deprecation)
+	equal(t, "2027-05-01T00:00:00Z", defaultConfig["server.v1.sunset"], cfg.ServerConfig.V1.Sunset)
+	equal(t, 9090, defaultConfig["server.grpc.port"], cfg.ServerConfig.Grpc.Port)
+	equal(t, 8, defaultConfig["server.graphql.maxDepth"], cfg.ServerConfig.Graphql.MaxDepth)
+	equal(t, 1000, defaultConfig["server.graphql.maxComplexity"], cfg.ServerConfig.Graphql.MaxComplexity)
 	// logging configs
 	equal(t, -1, defaultConfig["logging.level"], cfg.LoggingConfig.Level)
 	equal(t, "console", defaultConfig["logging.encoding"], cfg.LoggingConfig.Encoding)
@@ -28,6 +36,10 @@ func TestLoad(t *testing.T) {
 	// jwt configs
 	equal(t, "secret-key", defaultConfig["jwt.secret"], cfg.JwtConfig.Secret)
 	equalDuration(t, 864000*time.Second, defaultConfig["jwt.sessionTime"], cfg.JwtConfig.SessionTime)
+	equal(t, "", defaultConfig["jwt.signingKeyId"], cfg.JwtConfig.SigningKeyID)
+	assert.Empty(t, cfg.JwtConfig.Keys)
+	equal(t, false, defaultConfig["jwt.acceptEmailIdentity"], cfg.JwtConfig.AcceptEmailIdentity)
+	equal(t, "", defaultConfig["jwt.emailIdentityCutover"], cfg.JwtConfig.EmailIdentityCutover)
 	// db configs
 	equal(t, "root:password@tcp(127.0.0.1:3306)/local_db?charset=utf8&parseTime=True&multiStatements=true", defaultConfig["db.dataSourceName"], cfg.DBConfig.DataSourceName)
 	equal(t, 1, defaultConfig["db.logLevel"], cfg.DBConfig.LogLevel)
@@ -53,6 +65,78 @@ func TestLoad(t *testing.T) {
 	// metrics configs
 	equal(t, "article_server", defaultConfig["metrics.namespace"], cfg.MetricsConfig.Namespace)
 	equal(t, "", defaultConfig["metrics.subsystem"], cfg.MetricsConfig.Subsystem)
+	// mail configs
+	equal(t, "file", defaultConfig["mail.type"], cfg.MailConfig.Type)
+	equal(t, "no-reply@article-server.local", defaultConfig["mail.from"], cfg.MailConfig.From)
+	equal(t, "localhost", defaultConfig["mail.smtp.host"], cfg.MailConfig.SMTP.Host)
+	equal(t, 25, defaultConfig["mail.smtp.port"], cfg.MailConfig.SMTP.Port)
+	equal(t, "", defaultConfig["mail.file.path"], cfg.MailConfig.File.Path)
+	// account configs
+	equal(t, "http://localhost:8080/verify?token=%s", defaultConfig["account.verification.url"], cfg.AccountConfig.Verification.URL)
+	equalDuration(t, 24*time.Hour, defaultConfig["account.verification.tokenTTL"], cfg.AccountConfig.Verification.TokenTTL)
+	equal(t, []string{}, defaultConfig["account.verification.restrictions"], cfg.AccountConfig.Verification.Restrictions)
+	equal(t, "http://localhost:8080/password/reset?token=%s", defaultConfig["account.passwordReset.url"], cfg.AccountConfig.PasswordReset.URL)
+	equalDuration(t, time.Hour, defaultConfig["account.passwordReset.tokenTTL"], cfg.AccountConfig.PasswordReset.TokenTTL)
+	equal(t, "http://localhost:8080/email/confirm?token=%s", defaultConfig["account.emailChange.url"], cfg.AccountConfig.EmailChange.URL)
+	equalDuration(t, time.Hour, defaultConfig["account.emailChange.tokenTTL"], cfg.AccountConfig.EmailChange.TokenTTL)
+	equal(t, "article-server", defaultConfig["account.mfa.issuer"], cfg.AccountConfig.MFA.Issuer)
+	equalDuration(t, 5*time.Minute, defaultConfig["account.mfa.challengeTTL"], cfg.AccountConfig.MFA.ChallengeTTL)
+	equal(t, []string{"editor", "admin"}, defaultConfig["account.mfa.requiredRoles"], cfg.AccountConfig.MFA.RequiredRoles)
+	equal(t, 10, defaultConfig["account.mfa.recoveryCodes"], cfg.AccountConfig.MFA.RecoveryCodes)
+	equalDuration(t, 90*24*time.Hour, defaultConfig["account.apiKey.defaultTTL"], cfg.AccountConfig.APIKey.DefaultTTL)
+	equalDuration(t, 365*24*time.Hour, defaultConfig["account.apiKey.maxTTL"], cfg.AccountConfig.APIKey.MaxTTL)
+	equal(t, 5, defaultConfig["account.password.minLength"], cfg.AccountConfig.Password.MinLength)
+	equal(t, 1, defaultConfig["account.password.minCharClasses"], cfg.AccountConfig.Password.MinCharClasses)
+	equal(t, "", defaultConfig["account.password.breachedListFile"], cfg.AccountConfig.Password.BreachedListFile)
+	equal(t, "bcrypt", defaultConfig["account.password.algorithm"], cfg.AccountConfig.Password.Algorithm)
+	equal(t, 10, defaultConfig["account.password.bcryptCost"], cfg.AccountConfig.Password.BcryptCost)
+	equal(t, 1, defaultConfig["account.password.argon2.time"], cfg.AccountConfig.Password.Argon2.Time)
+	equal(t, 65536, defaultConfig["account.password.argon2.memory"], cfg.AccountConfig.Password.Argon2.Memory)
+	equal(t, 4, defaultConfig["account.password.argon2.threads"], cfg.AccountConfig.Password.Argon2.Threads)
+	equal(t, 32, defaultConfig["account.password.argon2.keyLength"], cfg.AccountConfig.Password.Argon2.KeyLength)
+	equal(t, 16, defaultConfig["account.password.argon2.saltLength"], cfg.AccountConfig.Password.Argon2.SaltLength)
+	equal(t, 3, defaultConfig["account.username.minLength"], cfg.AccountConfig.Username.MinLength)
+	equal(t, 30, defaultConfig["account.username.maxLength"], cfg.AccountConfig.Username.MaxLength)
+	equal(t, []string{"admin", "administrator", "root", "system", "support", "ghost", "me", "api", "anonymous"}, defaultConfig["account.username.reserved"], cfg.AccountConfig.Username.Reserved)
+	equal(t, "reassign", defaultConfig["account.deletion.contentPolicy"], cfg.AccountConfig.Deletion.ContentPolicy)
+	equal(t, "ghost@article-server.local", defaultConfig["account.deletion.ghostEmail"], cfg.AccountConfig.Deletion.GhostEmail)
+	equalDuration(t, 5*time.Minute, defaultConfig["account.deletion.recentLogin"], cfg.AccountConfig.Deletion.RecentLogin)
+	equal(t, true, defaultConfig["account.lockout.enabled"], cfg.AccountConfig.Lockout.Enabled)
+	equal(t, 3, defaultConfig["account.lockout.freeAttempts"], cfg.AccountConfig.Lockout.FreeAttempts)
+	equalDuration(t, time.Second, defaultConfig["account.lockout.baseDelay"], cfg.AccountConfig.Lockout.BaseDelay)
+	equalDuration(t, time.Minute, defaultConfig["account.lockout.maxDelay"], cfg.AccountConfig.Lockout.MaxDelay)
+	equal(t, 10, defaultConfig["account.lockout.maxFailures"], cfg.AccountConfig.Lockout.MaxFailures)
+	equal(t, 100, defaultConfig["account.lockout.maxIPFailures"], cfg.AccountConfig.Lockout.MaxIPFailures)
+	equalDuration(t, 15*time.Minute, defaultConfig["account.lockout.duration"], cfg.AccountConfig.Lockout.Duration)
+	equalDuration(t, time.Hour, defaultConfig["account.lockout.window"], cfg.AccountConfig.Lockout.Window)
+	// oauth configs
+	equal(t, "http://localhost:8080/v1/api/auth/%s/callback", defaultConfig["oauth.redirectURL"], cfg.OAuthConfig.RedirectURL)
+	equalDuration(t, 10*time.Minute, defaultConfig["oauth.stateTTL"], cfg.OAuthConfig.StateTTL)
+	// audit configs
+	equal(t, "", defaultConfig["audit.file.path"], cfg.AuditConfig.File.Path)
+	// rate limit configs
+	equal(t, true, defaultConfig["rateLimit.enabled"], cfg.RateLimitConfig.Enabled)
+	equal(t, 10, defaultConfig["rateLimit.routes.login.limit"], cfg.RateLimitConfig.Routes["login"].Limit)
+	equalDuration(t, time.Minute, defaultConfig["rateLimit.routes.login.window"], cfg.RateLimitConfig.Routes["login"].Window)
+	equal(t, "ip", defaultConfig["rateLimit.routes.login.key"], cfg.RateLimitConfig.Routes["login"].Key)
+	equal(t, 5, defaultConfig["rateLimit.routes.signUp.limit"], cfg.RateLimitConfig.Routes["signUp"].Limit)
+	equalDuration(t, time.Hour, defaultConfig["rateLimit.routes.signUp.window"], cfg.RateLimitConfig.Routes["signUp"].Window)
+	equal(t, "ip", defaultConfig["rateLimit.routes.signUp.key"], cfg.RateLimitConfig.Routes["signUp"].Key)
+	equal(t, 5, defaultConfig["rateLimit.routes.forgotPassword.limit"], cfg.RateLimitConfig.Routes["forgotPassword"].Limit)
+	equalDuration(t, time.Hour, defaultConfig["rateLimit.routes.forgotPassword.window"], cfg.RateLimitConfig.Routes["forgotPassword"].Window)
+	equal(t, "ip", defaultConfig["rateLimit.routes.forgotPassword.key"], cfg.RateLimitConfig.Routes["forgotPassword"].Key)
+	equal(t, 30, defaultConfig["rateLimit.routes.writeArticle.limit"], cfg.RateLimitConfig.Routes["writeArticle"].Limit)
+	equalDuration(t, time.Hour, defaultConfig["rateLimit.routes.writeArticle.window"], cfg.RateLimitConfig.Routes["writeArticle"].Window)
+	equal(t, "account", defaultConfig["rateLimit.routes.writeArticle.key"], cfg.RateLimitConfig.Routes["writeArticle"].Key)
+	equal(t, 60, defaultConfig["rateLimit.routes.writeComment.limit"], cfg.RateLimitConfig.Routes["writeComment"].Limit)
+	equalDuration(t, time.Hour, defaultConfig["rateLimit.routes.writeComment.window"], cfg.RateLimitConfig.Routes["writeComment"].Window)
+	equal(t, "account", defaultConfig["rateLimit.routes.writeComment.key"], cfg.RateLimitConfig.Routes["writeComment"].Key)
+	// idempotency configs
+	equal(t, true, defaultConfig["idempotency.enabled"], cfg.IdempotencyConfig.Enabled)
+	equalDuration(t, 24*time.Hour, defaultConfig["idempotency.ttl"], cfg.IdempotencyConfig.TTL)
+	// i18n configs
+	equal(t, "en", defaultConfig["i18n.fallbackLocale"], cfg.I18nConfig.FallbackLocale)
+	assert.Empty(t, cfg.OAuthConfig.Providers)
 }
 
 func TestLoadWithEnv(t *testing.T) {
@@ -76,6 +160,16 @@ func TestLoadWithConfigFile(t *testing.T) {
 	config := `
 server:
   port: 5000
+jwt:
+  signingKeyId: key-2
+  keys:
+    - id: key-1
+      algorithm: RS256
+      publicKeyFile: /config/jwt/key-1.pub.pem
+      retired: true
+    - id: key-2
+      algorithm: EdDSA
+      privateKeyFile: /config/jwt/key-2.pem
 `
 	tempFile, err := ioutil.TempFile(os.TempDir(), "article-server-test")
 	assert.NoError(t, err)
@@ -91,11 +185,17 @@ server:
 	// then
 	assert.NoError(t, err)
 	assert.Equal(t, 5000, cfg.ServerConfig.Port)
+	assert.Equal(t, "key-2", cfg.JwtConfig.SigningKeyID)
+	assert.Equal(t, []JWTKeyConfig{
+		{ID: "key-1", Algorithm: "RS256", PublicKeyFile: "/config/jwt/key-1.pub.pem", Retired: true},
+		{ID: "key-2", Algorithm: "EdDSA", PrivateKeyFile: "/config/jwt/key-2.pem"},
+	}, cfg.JwtConfig.Keys)
 }
 
 func TestMarshalJSON(t *testing.T) {
 	conf, err := Load("")
 	assert.NoError(t, err)
+	conf.OAuthConfig.Providers = []OAuthProviderConfig{{Name: "github", Type: "github", ClientID: "client-id", ClientSecret: "client-secret"}}
 	data, err := json.Marshal(conf)
 	assert.NoError(t, err)
 
@@ -103,6 +203,8 @@ func TestMarshalJSON(t *testing.T) {
 	assert.NoError(t, json.Unmarshal(data, &configMap))
 	assert.True(t, strings.HasPrefix(configMap["db.dataSourceName"].(string), "root:****@tcp"))
 	assert.Equal(t, "****", configMap["jwt.secret"])
+	assert.Equal(t, "client-id", configMap["oauth.providers.0.clientId"])
+	assert.Equal(t, "****", configMap["oauth.providers.0.clientSecret"])
 }
 
 func equal(t *testing.T, expected interface{}, values ...interface{}) {
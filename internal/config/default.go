@@ -5,17 +5,28 @@ import (
 )
 
 var defaultConfig = map[string]interface{}{
-	"server.port":             8080,
-	"server.readTimeout":      "5s",
-	"server.writeTimeout":     "10s",
-	"server.gracefulShutdown": "30s",
+	"server.port":                  8080,
+	"server.readTimeout":           "5s",
+	"server.writeTimeout":          "10s",
+	"server.bulkTimeout":           "10m",
+	"server.gracefulShutdown":      "30s",
+	"server.errors.format":         "problem",
+	"server.errors.typeBaseURL":    "",
+	"server.v1.deprecation":        "2026-11-01T00:00:00Z",
+	"server.v1.sunset":             "2027-05-01T00:00:00Z",
+	"server.grpc.port":             9090,
+	"server.graphql.maxDepth":      8,
+	"server.graphql.maxComplexity": 1000,
 
 	"logging.level":       -1,
 	"logging.encoding":    "console",
 	"logging.development": true,
 
-	"jwt.secret":      "secret-key",
-	"jwt.sessionTime": "864000s",
+	"jwt.secret":               "secret-key",
+	"jwt.sessionTime":          "864000s",
+	"jwt.signingKeyId":         "",
+	"jwt.acceptEmailIdentity":  false,
+	"jwt.emailIdentityCutover": "",
 
 	"db.dataSourceName":   "root:password@tcp(127.0.0.1:3306)/local_db?charset=utf8&parseTime=True&multiStatements=true",
 	"db.logLevel":         1,
@@ -41,4 +52,76 @@ var defaultConfig = map[string]interface{}{
 
 	"metrics.namespace": "article_server",
 	"metrics.subsystem": "",
+
+	"mail.type":          "file",
+	"mail.from":          "no-reply@article-server.local",
+	"mail.smtp.host":     "localhost",
+	"mail.smtp.port":     25,
+	"mail.smtp.username": "",
+	"mail.smtp.password": "",
+	"mail.file.path":     "",
+
+	"account.verification.url":           "http://localhost:8080/verify?token=%s",
+	"account.verification.tokenTTL":      "24h",
+	"account.verification.restrictions":  []string{},
+	"account.passwordReset.url":          "http://localhost:8080/password/reset?token=%s",
+	"account.passwordReset.tokenTTL":     "1h",
+	"account.emailChange.url":            "http://localhost:8080/email/confirm?token=%s",
+	"account.emailChange.tokenTTL":       "1h",
+	"account.mfa.issuer":                 "article-server",
+	"account.mfa.challengeTTL":           "5m",
+	"account.mfa.requiredRoles":          []string{"editor", "admin"},
+	"account.mfa.recoveryCodes":          10,
+	"account.apiKey.defaultTTL":          "2160h",
+	"account.apiKey.maxTTL":              "8760h",
+	"account.password.minLength":         5,
+	"account.password.minCharClasses":    1,
+	"account.password.breachedListFile":  "",
+	"account.password.algorithm":         "bcrypt",
+	"account.password.bcryptCost":        10,
+	"account.password.argon2.time":       1,
+	"account.password.argon2.memory":     64 * 1024,
+	"account.password.argon2.threads":    4,
+	"account.password.argon2.keyLength":  32,
+	"account.password.argon2.saltLength": 16,
+	"account.username.minLength":         3,
+	"account.username.maxLength":         30,
+	"account.username.reserved":          []string{"admin", "administrator", "root", "system", "support", "ghost", "me", "api", "anonymous"},
+	"account.deletion.contentPolicy":     "reassign",
+	"account.deletion.ghostEmail":        "ghost@article-server.local",
+	"account.deletion.recentLogin":       "5m",
+	"account.lockout.enabled":            true,
+	"account.lockout.freeAttempts":       3,
+	"account.lockout.baseDelay":          "1s",
+	"account.lockout.maxDelay":           "1m",
+	"account.lockout.maxFailures":        10,
+	"account.lockout.maxIPFailures":      100,
+	"account.lockout.duration":           "15m",
+	"account.lockout.window":             "1h",
+	"oauth.redirectURL":                  "http://localhost:8080/v1/api/auth/%s/callback",
+	"oauth.stateTTL":                     "10m",
+
+	"audit.file.path": "",
+
+	"rateLimit.enabled":                      true,
+	"rateLimit.routes.login.limit":           10,
+	"rateLimit.routes.login.window":          "1m",
+	"rateLimit.routes.login.key":             "ip",
+	"rateLimit.routes.signUp.limit":          5,
+	"rateLimit.routes.signUp.window":         "1h",
+	"rateLimit.routes.signUp.key":            "ip",
+	"rateLimit.routes.forgotPassword.limit":  5,
+	"rateLimit.routes.forgotPassword.window": "1h",
+	"rateLimit.routes.forgotPassword.key":    "ip",
+	"rateLimit.routes.writeArticle.limit":    30,
+	"rateLimit.routes.writeArticle.window":   "1h",
+	"rateLimit.routes.writeArticle.key":      "account",
+	"rateLimit.routes.writeComment.limit":    60,
+	"rateLimit.routes.writeComment.window":   "1h",
+	"rateLimit.routes.writeComment.key":      "account",
+
+	"idempotency.enabled": true,
+	"idempotency.ttl":     "24h",
+
+	"i18n.fallbackLocale": "en",
 }
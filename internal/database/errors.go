@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"strings"
 )
 
 var (
@@ -16,9 +17,9 @@ func IsRecordNotFoundErr(err error) bool {
 	return err == gorm.ErrRecordNotFound || err == ErrNotFound
 }
 
-// IsKeyConflictErr returns true if err is ErrKeyConflict or MySQLError with 1062 code number
+// IsKeyConflictErr returns true if err is or wraps ErrKeyConflict or MySQLError with 1062 code number
 func IsKeyConflictErr(err error) bool {
-	if err == ErrKeyConflict {
+	if errors.Is(err, ErrKeyConflict) {
 		return true
 	}
 	switch err.(type) {
@@ -30,3 +31,9 @@ func IsKeyConflictErr(err error) bool {
 	}
 	return false
 }
+
+// IsKeyConflictOn returns true if err is MySQLError with 1062 code number on the unique key of given name
+func IsKeyConflictOn(err error, key string) bool {
+	e, ok := err.(*mysql.MySQLError)
+	return ok && e.Number == 1062 && strings.Contains(e.Message, key)
+}
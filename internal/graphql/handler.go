@@ -0,0 +1,90 @@
+package graphql
+
+import (
+	"encoding/json"
+	"gin-rest-api-example/internal/account"
+	accountDB "gin-rest-api-example/internal/account/database"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/middleware"
+	gql "gin-rest-api-example/pkg/graphql"
+	"gin-rest-api-example/pkg/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler executes GraphQL queries of articles, comments, tags and profiles.
+// Requests are authenticated optionally and the current user is passed to resolvers.
+type Handler struct {
+	schema   *gql.Schema
+	resolver *resolver
+	limits   gql.Limits
+}
+
+func NewHandler(cfg *config.Config, articleDB articleDB.ArticleDB, accountDB accountDB.AccountDB) (*Handler, error) {
+	r := &resolver{articleDB: articleDB, accountDB: accountDB}
+	schema, err := newSchema(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		schema:   schema,
+		resolver: r,
+		limits: gql.Limits{
+			MaxDepth:      cfg.ServerConfig.Graphql.MaxDepth,
+			MaxComplexity: cfg.ServerConfig.Graphql.MaxComplexity,
+		},
+	}, nil
+}
+
+// query handles GET /graphql and POST /graphql
+func (h *Handler) query(c *gin.Context) {
+	logger := logging.FromContext(c)
+	var params gql.Params
+	if c.Request.Method == http.MethodGet {
+		params.Query = c.Query("query")
+		params.OperationName = c.Query("operationName")
+		if v := c.Query("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &params.Variables); err != nil {
+				logger.Errorw("graphql.handler.query failed to bind variables", "err", err)
+				c.JSON(http.StatusBadRequest, &gql.Result{Errors: []*gql.Error{{Message: "Variables are invalid JSON."}}})
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&params); err != nil {
+		logger.Errorw("graphql.handler.query failed to bind", "err", err)
+		c.JSON(http.StatusBadRequest, &gql.Result{Errors: []*gql.Error{{Message: "Body is invalid JSON."}}})
+		return
+	}
+
+	req := request{loaders: h.resolver.newLoaders()}
+	if acc, ok := account.CurrentUser(c); ok {
+		req.identity = &identity{account: acc}
+		if key, ok := account.CurrentAPIKey(c); ok {
+			req.identity.apiKey = key
+		}
+	}
+	res := h.schema.Execute(withRequest(c.Request.Context(), &req), params, h.limits)
+	// requests failed to validate are not executed
+	if res.Data == nil {
+		c.JSON(http.StatusBadRequest, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// schemaSDL handles GET /graphql/schema.graphql
+func (h *Handler) schemaSDL(c *gin.Context) {
+	c.String(http.StatusOK, h.schema.SDL())
+}
+
+func Route(cfg *config.Config, h *Handler, r *gin.Engine, auth *account.AuthMiddleware) {
+	g := r.Group("graphql")
+	g.Use(middleware.RequestIDMiddleware(), middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
+	{
+		g.GET("schema.graphql", h.schemaSDL)
+		g.GET("", auth.OptionalMiddlewareFunc(), h.query)
+		g.POST("", auth.OptionalMiddlewareFunc(), h.query)
+	}
+}
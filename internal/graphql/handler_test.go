@@ -0,0 +1,322 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gin-rest-api-example/internal/account"
+	accountDBMock "gin-rest-api-example/internal/account/database/mocks"
+	accountModel "gin-rest-api-example/internal/account/model"
+	articleDB "gin-rest-api-example/internal/article/database"
+	articleDBMock "gin-rest-api-example/internal/article/database/mocks"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/pkg/logging"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	dUser = accountModel.Account{
+		ID:       1,
+		Username: "user1",
+		Email:    "user1@gmail.com",
+		Bio:      "I am working!",
+	}
+	dUser2 = accountModel.Account{
+		ID:       2,
+		Username: "user2",
+		Email:    "user2@gmail.com",
+	}
+
+	dArticle = model.Article{
+		ID:        1,
+		Slug:      "how-to-train-your-dragon",
+		Title:     "How to train your dragon",
+		Body:      "You have to believe",
+		CreatedAt: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdatedAt: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		Author:    dUser,
+		AuthorID:  dUser.ID,
+		Tags:      []*model.Tag{{ID: 1, Name: "dragons"}},
+	}
+	dArticle2 = model.Article{
+		ID:       2,
+		Slug:     "how-to-train-your-dragon-2",
+		Title:    "How to train your dragon 2",
+		Body:     "So toothless",
+		Author:   dUser2,
+		AuthorID: dUser2.ID,
+	}
+	dComment = model.Comment{
+		ID:       1,
+		Body:     "Thank you so much!",
+		Slug:     dArticle.Slug,
+		Author:   dUser2,
+		AuthorID: dUser2.ID,
+	}
+)
+
+type HandlerSuite struct {
+	suite.Suite
+	cfg       *config.Config
+	r         *gin.Engine
+	db        *articleDBMock.ArticleDB
+	accountDB *accountDBMock.AccountDB
+	auth      *account.AuthMiddleware
+}
+
+func (s *HandlerSuite) SetupSuite() {
+	logging.SetLevel(zapcore.FatalLevel)
+}
+
+func (s *HandlerSuite) SetupTest() {
+	cfg, err := config.Load("")
+	s.NoError(err)
+	s.cfg = cfg
+	s.db = &articleDBMock.ArticleDB{}
+	s.accountDB = &accountDBMock.AccountDB{}
+	s.accountDB.On("FindByID", mock.Anything, dUser.ID).Return(&dUser, nil)
+	s.setupRouter()
+}
+
+func (s *HandlerSuite) setupRouter() {
+	auth, err := account.NewAuthMiddleware(s.cfg, s.accountDB, nil, nil, audit.New(audit.NewWriterSink(ioutil.Discard)))
+	s.NoError(err)
+	s.auth = auth
+
+	gin.SetMode(gin.TestMode)
+	s.r = gin.Default()
+
+	h, err := NewHandler(s.cfg, s.db, s.accountDB)
+	s.NoError(err)
+	Route(s.cfg, h, s.r, auth)
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSuite))
+}
+
+func (s *HandlerSuite) TestArticles() {
+	// given
+	s.db.On("FindArticles", mock.Anything, mock.Anything).Return([]*model.Article{&dArticle, &dArticle2}, int64(12), nil)
+	s.db.On("FindCommentsBySlugs", mock.Anything, mock.Anything).Return(map[string][]*model.Comment{
+		dArticle.Slug: {&dComment},
+	}, nil)
+
+	// when
+	res := s.doRequest("POST", "", "", map[string]interface{}{
+		"query": `query Articles($tag: String) {
+			articles(tag: $tag, limit: 2, offset: 10) {
+				totalCount
+				nodes { slug title tags { name } author { username } createdAt comments { id body author { username } } }
+			}
+		}`,
+		"variables": map[string]interface{}{"tag": "dragons"},
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{"data": {"articles": {"totalCount": 12, "nodes": [
+		{
+			"slug": "how-to-train-your-dragon",
+			"title": "How to train your dragon",
+			"tags": [{"name": "dragons"}],
+			"author": {"username": "user1"},
+			"createdAt": "2021-01-02T03:04:05Z",
+			"comments": [{"id": "1", "body": "Thank you so much!", "author": {"username": "user2"}}]
+		},
+		{
+			"slug": "how-to-train-your-dragon-2",
+			"title": "How to train your dragon 2",
+			"tags": [],
+			"author": {"username": "user2"},
+			"createdAt": "0001-01-01T00:00:00Z",
+			"comments": []
+		}
+	]}}}`, res.Body.String())
+	s.db.AssertCalled(s.T(), "FindArticles", mock.Anything, articleDB.IterateArticleCriteria{
+		Tags:   []string{"dragons"},
+		Offset: 10,
+		Limit:  2,
+	})
+	// comments of all articles are loaded at once
+	s.db.AssertNumberOfCalls(s.T(), "FindCommentsBySlugs", 1)
+	s.db.AssertCalled(s.T(), "FindCommentsBySlugs", mock.Anything, []string{dArticle.Slug, dArticle2.Slug})
+}
+
+func (s *HandlerSuite) TestArticles_InvalidLimit() {
+	// when
+	res := s.doRequest("POST", "", "", map[string]interface{}{
+		"query": `{ articles(limit: 0) { totalCount } }`,
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("limit must be between 1 and 100", gjson.Get(res.Body.String(), "errors.0.message").String())
+	s.Equal("InvalidQueryValue", gjson.Get(res.Body.String(), "errors.0.extensions.code").String())
+	s.db.AssertNotCalled(s.T(), "FindArticles", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestArticle_Get() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(&dArticle, nil)
+	s.db.On("FindArticleBySlug", mock.Anything, "unknown").Return(nil, database.ErrNotFound)
+
+	// when
+	query := url.Values{}
+	query.Set("query", `query($slug: String!) { article(slug: $slug) { title } unknown: article(slug: "unknown") { title } }`)
+	query.Set("variables", `{"slug": "how-to-train-your-dragon"}`)
+	res := s.doRequest("GET", "?"+query.Encode(), "", nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{"data": {"article": {"title": "How to train your dragon"}, "unknown": null}}`, res.Body.String())
+}
+
+func (s *HandlerSuite) TestArticle_InternalError() {
+	// given
+	s.db.On("FindArticleBySlug", mock.Anything, dArticle.Slug).Return(nil, errors.New("force error"))
+
+	// when
+	res := s.doRequest("POST", "", "", map[string]interface{}{
+		"query": `{ article(slug: "how-to-train-your-dragon") { title } }`,
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("null", gjson.Get(res.Body.String(), "data.article").Raw)
+	s.Equal("InternalServerError", gjson.Get(res.Body.String(), "errors.0.extensions.code").String())
+	s.NotContains(res.Body.String(), "force error")
+}
+
+func (s *HandlerSuite) TestProfiles() {
+	// given
+	s.accountDB.On("FindByUsernames", mock.Anything, mock.Anything).Return([]*accountModel.Account{&dUser2, &dUser}, nil)
+
+	// when
+	res := s.doRequest("POST", "", "", map[string]interface{}{
+		"query": `{ profiles(usernames: ["user1", "unknown", "user2"]) { username bio } }`,
+	})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{"data": {"profiles": [{"username": "user1", "bio": "I am working!"}, {"username": "user2", "bio": ""}]}}`,
+		res.Body.String())
+	s.accountDB.AssertNumberOfCalls(s.T(), "FindByUsernames", 1)
+	s.accountDB.AssertCalled(s.T(), "FindByUsernames", mock.Anything, []string{"user1", "unknown", "user2"})
+}
+
+func (s *HandlerSuite) TestViewer() {
+	// given
+	token, _, err := s.auth.TokenGenerator(&dUser)
+	s.NoError(err)
+
+	// when
+	anonymous := s.doRequest("POST", "", "", map[string]interface{}{"query": `{ viewer { username } }`})
+	authenticated := s.doRequest("POST", "", "Bearer "+token, map[string]interface{}{"query": `{ viewer { username email } }`})
+	invalid := s.doRequest("POST", "", "Bearer invalid", map[string]interface{}{"query": `{ viewer { username } }`})
+
+	// then
+	s.Equal(http.StatusOK, anonymous.Code)
+	s.JSONEq(`{"data": {"viewer": null}}`, anonymous.Body.String())
+	s.Equal(http.StatusOK, authenticated.Code)
+	s.JSONEq(`{"data": {"viewer": {"username": "user1", "email": "user1@gmail.com"}}}`, authenticated.Body.String())
+	s.Equal(http.StatusUnauthorized, invalid.Code)
+}
+
+func (s *HandlerSuite) TestViewer_APIKeyScope() {
+	// given
+	s.accountDB.On("FindAPIKeyByHash", mock.Anything, mock.Anything).Return(&accountModel.APIKey{
+		ID:        1,
+		AccountID: dUser.ID,
+		Scopes:    account.ScopeArticleWrite,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	s.accountDB.On("TouchAPIKey", mock.Anything, uint(1), mock.Anything).Return(nil)
+
+	// when
+	res := s.doRequest("POST", "", "ApiKey key", map[string]interface{}{"query": `{ viewer { username } }`})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.JSONEq(`{"data": {"viewer": null}, "errors": [{
+		"message": "api key requires user:read scope",
+		"locations": [{"line": 1, "column": 3}],
+		"path": ["viewer"],
+		"extensions": {"code": "InsufficientScope"}
+	}]}`, res.Body.String())
+}
+
+func (s *HandlerSuite) TestQuery_Limits() {
+	// given
+	s.cfg.ServerConfig.Graphql.MaxDepth = 2
+	s.cfg.ServerConfig.Graphql.MaxComplexity = 50
+	s.setupRouter()
+
+	// when
+	deep := s.doRequest("POST", "", "", map[string]interface{}{
+		"query": `{ article(slug: "a") { author { username } } }`,
+	})
+	complex := s.doRequest("POST", "", "", map[string]interface{}{
+		"query": `{ articles(limit: 100) { totalCount } }`,
+	})
+
+	// then
+	s.Equal(http.StatusBadRequest, deep.Code)
+	s.Equal("Query depth 3 exceeds the maximum depth 2.", gjson.Get(deep.Body.String(), "errors.0.message").String())
+	s.Equal(http.StatusBadRequest, complex.Code)
+	s.Equal("Query complexity 101 exceeds the maximum complexity 50.", gjson.Get(complex.Body.String(), "errors.0.message").String())
+	s.db.AssertNotCalled(s.T(), "FindArticleBySlug", mock.Anything, mock.Anything)
+	s.db.AssertNotCalled(s.T(), "FindArticles", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestQuery_InvalidRequest() {
+	// when
+	invalidQuery := s.doRequest("POST", "", "", map[string]interface{}{"query": `{ articles { unknown } }`})
+	invalidBody := s.doRequest("POST", "", "", "query")
+
+	// then
+	s.Equal(http.StatusBadRequest, invalidQuery.Code)
+	s.Equal(`Cannot query field "unknown" on type "ArticleList".`, gjson.Get(invalidQuery.Body.String(), "errors.0.message").String())
+	s.Equal(http.StatusBadRequest, invalidBody.Code)
+	s.Equal("Body is invalid JSON.", gjson.Get(invalidBody.Body.String(), "errors.0.message").String())
+}
+
+func (s *HandlerSuite) TestSchemaSDL() {
+	// when
+	res := s.doRequest("GET", "/schema.graphql", "", nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Contains(res.Body.String(), "type Query {")
+	s.Contains(res.Body.String(), "articles(tag: String, author: String, limit: Int = 20, offset: Int = 0): ArticleList!")
+	s.Contains(res.Body.String(), "scalar DateTime")
+}
+
+func (s *HandlerSuite) doRequest(method, path, authorization string, body interface{}) *httptest.ResponseRecorder {
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/graphql"+path, bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	s.r.ServeHTTP(res, req)
+	return res
+}
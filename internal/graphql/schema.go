@@ -0,0 +1,337 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"gin-rest-api-example/internal/account"
+	accountDB "gin-rest-api-example/internal/account/database"
+	accountModel "gin-rest-api-example/internal/account/model"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware/handler"
+	gql "gin-rest-api-example/pkg/graphql"
+	"gin-rest-api-example/pkg/logging"
+	"time"
+)
+
+const maxArticlesLimit = 100
+
+// DateTime is a RFC 3339 timestamp like REST apis.
+var DateTime = &gql.Scalar{
+	Name:        "DateTime",
+	Description: "RFC 3339 timestamp",
+	Serialize: func(v interface{}) (interface{}, error) {
+		t, ok := v.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("DateTime cannot represent value: %v", v)
+		}
+		return t.Format(time.RFC3339Nano), nil
+	},
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("DateTime cannot represent a non string value: %v", v)
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	},
+	ParseLiteral: func(v gql.Value) (interface{}, error) {
+		s, ok := v.(gql.StringValue)
+		if !ok {
+			return nil, fmt.Errorf("DateTime cannot represent a non string value: %v", v)
+		}
+		return time.Parse(time.RFC3339Nano, string(s))
+	},
+}
+
+// identity is the account and the api key of an authenticated request.
+type identity struct {
+	account *accountModel.Account
+	apiKey  *accountModel.APIKey
+}
+
+// loaders is loaders of a request batching queries of sibling objects.
+type loaders struct {
+	comments *gql.Loader
+	profiles *gql.Loader
+}
+
+type requestKey struct{}
+
+// request is values of a request passed to resolvers by the context.
+type request struct {
+	identity *identity
+	loaders  *loaders
+}
+
+func withRequest(ctx context.Context, r *request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// newSchema returns the schema of articles, comments, tags and profiles resolved by given resolver.
+// Comments of articles and profiles are loaded at once by loaders of each request.
+func newSchema(r *resolver) (*gql.Schema, error) {
+	profile := &gql.Object{Name: "Profile", Description: "Public profile of an account", Fields: []*gql.FieldDefinition{
+		{Name: "username", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Username })},
+		{Name: "bio", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Bio })},
+		{Name: "image", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Image })},
+	}}
+	user := &gql.Object{Name: "User", Description: "The current user", Fields: []*gql.FieldDefinition{
+		{Name: "username", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Username })},
+		{Name: "email", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Email })},
+		{Name: "bio", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Bio })},
+		{Name: "image", Type: &gql.NonNull{Of: gql.String}, Resolve: accountField(func(a *accountModel.Account) interface{} { return a.Image })},
+	}}
+	tag := &gql.Object{Name: "Tag", Fields: []*gql.FieldDefinition{
+		{Name: "name", Type: &gql.NonNull{Of: gql.String}, Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Tag).Name, nil
+		}},
+	}}
+	comment := &gql.Object{Name: "Comment", Fields: []*gql.FieldDefinition{
+		{Name: "id", Type: &gql.NonNull{Of: gql.ID}, Resolve: commentField(func(c *model.Comment) interface{} { return c.ID })},
+		{Name: "body", Type: &gql.NonNull{Of: gql.String}, Resolve: commentField(func(c *model.Comment) interface{} { return c.Body })},
+		{Name: "author", Type: &gql.NonNull{Of: profile}, Resolve: commentField(func(c *model.Comment) interface{} { return &c.Author })},
+		{Name: "createdAt", Type: &gql.NonNull{Of: DateTime}, Resolve: commentField(func(c *model.Comment) interface{} { return c.CreatedAt })},
+		{Name: "updatedAt", Type: &gql.NonNull{Of: DateTime}, Resolve: commentField(func(c *model.Comment) interface{} { return c.UpdatedAt })},
+	}}
+	article := &gql.Object{Name: "Article", Fields: []*gql.FieldDefinition{
+		{Name: "slug", Type: &gql.NonNull{Of: gql.String}, Resolve: articleField(func(a *model.Article) interface{} { return a.Slug })},
+		{Name: "title", Type: &gql.NonNull{Of: gql.String}, Resolve: articleField(func(a *model.Article) interface{} { return a.Title })},
+		{Name: "body", Type: &gql.NonNull{Of: gql.String}, Resolve: articleField(func(a *model.Article) interface{} { return a.Body })},
+		{Name: "tags", Type: &gql.NonNull{Of: &gql.List{Of: &gql.NonNull{Of: tag}}}, Resolve: articleField(func(a *model.Article) interface{} {
+			if a.Tags == nil {
+				return []*model.Tag{}
+			}
+			return a.Tags
+		})},
+		{Name: "author", Type: &gql.NonNull{Of: profile}, Resolve: articleField(func(a *model.Article) interface{} { return &a.Author })},
+		{
+			Name:        "comments",
+			Description: "Comments of the article loaded with comments of sibling articles at once",
+			Type:        &gql.NonNull{Of: &gql.List{Of: &gql.NonNull{Of: comment}}},
+			Resolve:     r.comments,
+		},
+		{Name: "createdAt", Type: &gql.NonNull{Of: DateTime}, Resolve: articleField(func(a *model.Article) interface{} { return a.CreatedAt })},
+		{Name: "updatedAt", Type: &gql.NonNull{Of: DateTime}, Resolve: articleField(func(a *model.Article) interface{} { return a.UpdatedAt })},
+	}}
+	articleList := &gql.Object{Name: "ArticleList", Fields: []*gql.FieldDefinition{
+		{Name: "totalCount", Type: &gql.NonNull{Of: gql.Int}},
+		{Name: "nodes", Type: &gql.NonNull{Of: &gql.List{Of: &gql.NonNull{Of: article}}}},
+	}}
+
+	query := &gql.Object{Name: "Query", Fields: []*gql.FieldDefinition{
+		{
+			Name:    "article",
+			Type:    article,
+			Args:    []*gql.ArgumentDefinition{{Name: "slug", Type: &gql.NonNull{Of: gql.String}}},
+			Resolve: r.article,
+		},
+		{
+			Name:        "articles",
+			Description: "Articles ordered by the latest",
+			Type:        &gql.NonNull{Of: articleList},
+			Args: []*gql.ArgumentDefinition{
+				{Name: "tag", Type: gql.String},
+				{Name: "author", Type: gql.String},
+				{Name: "limit", Type: gql.Int, Default: 20},
+				{Name: "offset", Type: gql.Int, Default: 0},
+			},
+			Resolve: r.articles,
+			Complexity: func(args map[string]interface{}, childComplexity int) int {
+				limit, _ := args["limit"].(int)
+				return 1 + limit*childComplexity
+			},
+		},
+		{
+			Name:    "profile",
+			Type:    profile,
+			Args:    []*gql.ArgumentDefinition{{Name: "username", Type: &gql.NonNull{Of: gql.String}}},
+			Resolve: r.profile,
+		},
+		{
+			Name:    "profiles",
+			Type:    &gql.NonNull{Of: &gql.List{Of: &gql.NonNull{Of: profile}}},
+			Args:    []*gql.ArgumentDefinition{{Name: "usernames", Type: &gql.NonNull{Of: &gql.List{Of: &gql.NonNull{Of: gql.String}}}}},
+			Resolve: r.profiles,
+			Complexity: func(args map[string]interface{}, childComplexity int) int {
+				usernames, _ := args["usernames"].([]interface{})
+				return 1 + len(usernames)*childComplexity
+			},
+		},
+		{
+			Name:        "viewer",
+			Description: "The current user which is null if anonymous. Api keys require user:read scope",
+			Type:        user,
+			Resolve:     r.viewer,
+		},
+	}}
+	return gql.NewSchema(query)
+}
+
+func accountField(f func(a *accountModel.Account) interface{}) gql.ResolveFunc {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return f(p.Source.(*accountModel.Account)), nil
+	}
+}
+
+func articleField(f func(a *model.Article) interface{}) gql.ResolveFunc {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return f(p.Source.(*model.Article)), nil
+	}
+}
+
+func commentField(f func(c *model.Comment) interface{}) gql.ResolveFunc {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return f(p.Source.(*model.Comment)), nil
+	}
+}
+
+// resolver resolves fields of Query and fields loaded from databases.
+type resolver struct {
+	articleDB articleDB.ArticleDB
+	accountDB accountDB.AccountDB
+}
+
+// newLoaders returns loaders of a request.
+func (r *resolver) newLoaders() *loaders {
+	return &loaders{
+		comments: gql.NewLoader(r.loadComments),
+		profiles: gql.NewLoader(r.loadProfiles),
+	}
+}
+
+func (r *resolver) article(p gql.ResolveParams) (interface{}, error) {
+	article, err := r.articleDB.FindArticleBySlug(p.Context, p.Args["slug"].(string))
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, internalError(p.Context, err)
+	}
+	return article, nil
+}
+
+func (r *resolver) articles(p gql.ResolveParams) (interface{}, error) {
+	limit, offset := p.Args["limit"].(int), p.Args["offset"].(int)
+	if limit < 1 || limit > maxArticlesLimit {
+		return nil, invalidArgumentError(fmt.Sprintf("limit must be between 1 and %d", maxArticlesLimit))
+	}
+	if offset < 0 {
+		return nil, invalidArgumentError("offset must not be negative")
+	}
+	criteria := articleDB.IterateArticleCriteria{
+		Offset: uint(offset),
+		Limit:  uint(limit),
+	}
+	if tag, ok := p.Args["tag"].(string); ok {
+		criteria.Tags = []string{tag}
+	}
+	if author, ok := p.Args["author"].(string); ok {
+		criteria.Author = author
+	}
+	articles, total, err := r.articleDB.FindArticles(p.Context, criteria)
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	return map[string]interface{}{"totalCount": int(total), "nodes": articles}, nil
+}
+
+func (r *resolver) comments(p gql.ResolveParams) (interface{}, error) {
+	return requestFrom(p.Context).loaders.comments.Load(p.Context, p.Source.(*model.Article).Slug), nil
+}
+
+func (r *resolver) loadComments(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+	slugs := make([]string, len(keys))
+	for i, key := range keys {
+		slugs[i] = key.(string)
+	}
+	comments, err := r.articleDB.FindCommentsBySlugs(ctx, slugs)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	ret := make(map[interface{}]interface{}, len(slugs))
+	for _, slug := range slugs {
+		if c, ok := comments[slug]; ok {
+			ret[slug] = c
+		} else {
+			ret[slug] = []*model.Comment{}
+		}
+	}
+	return ret, nil
+}
+
+func (r *resolver) profile(p gql.ResolveParams) (interface{}, error) {
+	return requestFrom(p.Context).loaders.profiles.Load(p.Context, p.Args["username"].(string)), nil
+}
+
+// profiles returns profiles of given usernames which exist in the order.
+func (r *resolver) profiles(p gql.ResolveParams) (interface{}, error) {
+	loader := requestFrom(p.Context).loaders.profiles
+	var thunks []gql.Thunk
+	for _, username := range p.Args["usernames"].([]interface{}) {
+		thunks = append(thunks, loader.Load(p.Context, username))
+	}
+	return gql.Thunk(func() (interface{}, error) {
+		ret := []*accountModel.Account{}
+		for _, thunk := range thunks {
+			v, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			if acc, ok := v.(*accountModel.Account); ok {
+				ret = append(ret, acc)
+			}
+		}
+		return ret, nil
+	}), nil
+}
+
+// loadProfiles loads accounts of given usernames. Disabled accounts are not found like profile apis.
+func (r *resolver) loadProfiles(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+	usernames := make([]string, len(keys))
+	for i, key := range keys {
+		usernames[i] = key.(string)
+	}
+	accounts, err := r.accountDB.FindByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	ret := make(map[interface{}]interface{}, len(accounts))
+	for _, acc := range accounts {
+		if !acc.Disabled {
+			ret[acc.Username] = acc
+		}
+	}
+	return ret, nil
+}
+
+func (r *resolver) viewer(p gql.ResolveParams) (interface{}, error) {
+	id := requestFrom(p.Context).identity
+	if id == nil {
+		return nil, nil
+	}
+	if id.apiKey != nil && !id.apiKey.HasScope(account.ScopeUserRead) {
+		return nil, &gql.Error{
+			Message:    "api key requires " + account.ScopeUserRead + " scope",
+			Extensions: map[string]interface{}{"code": handler.InsufficientScope},
+		}
+	}
+	return id.account, nil
+}
+
+func invalidArgumentError(message string) error {
+	return &gql.Error{Message: message, Extensions: map[string]interface{}{"code": handler.InvalidQueryValue}}
+}
+
+// internalError logs given error and returns the error hiding details from responses.
+func internalError(ctx context.Context, err error) error {
+	logging.FromContext(ctx).Errorw("graphql.resolver failed to resolve a field", "err", err)
+	return &gql.Error{
+		Message:    "An error has occurred, please try again later",
+		Extensions: map[string]interface{}{"code": handler.InternalServerError},
+	}
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/pkg/logging"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var _ Sender = (*writerSender)(nil)
+
+// NewWriterSender creates a new sender that writes mails to given writer as json lines
+// instead of delivering them.
+func NewWriterSender(from string, w io.Writer) Sender {
+	return &writerSender{
+		from: from,
+		w:    w,
+	}
+}
+
+func newFileSender(from, path string) (Sender, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSender(from, f), nil
+}
+
+type writerSender struct {
+	mu   sync.Mutex
+	from string
+	w    io.Writer
+}
+
+func (s *writerSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	logging.FromContext(ctx).Debugw("mailer.file.Send", "to", msg.To, "subject", msg.Subject)
+
+	b, err := json.Marshal(&struct {
+		From string `json:"from"`
+		*Message
+		SentAt time.Time `json:"sentAt"`
+	}{
+		From:    s.from,
+		Message: msg,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
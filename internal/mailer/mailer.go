@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-rest-api-example/internal/config"
+	"os"
+)
+
+var (
+	ErrNoRecipients = errors.New("no recipients")
+)
+
+type Message struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+type Sender interface {
+	// Send sends a given message
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender creates a new mail sender with given config.
+// "smtp" type sends mails to smtp server and "file" type appends mails to a file
+// or stdout if path is empty, which is useful for development and tests.
+func NewSender(conf *config.Config) (Sender, error) {
+	mconf := conf.MailConfig
+	switch mconf.Type {
+	case "smtp":
+		return newSMTPSender(mconf), nil
+	case "file":
+		if mconf.File.Path == "" {
+			return NewWriterSender(mconf.From, os.Stdout), nil
+		}
+		return newFileSender(mconf.From, mconf.File.Path)
+	default:
+		return nil, fmt.Errorf("unknown mail type: %s", mconf.Type)
+	}
+}
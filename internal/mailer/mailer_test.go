@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/internal/config"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterSender(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewWriterSender("no-reply@email.com", &buf)
+
+	// when
+	err1 := sender.Send(context.TODO(), &Message{To: []string{"user1@email.com"}, Subject: "subject1", Body: "body1"})
+	err2 := sender.Send(context.TODO(), &Message{To: []string{"user2@email.com"}, Subject: "subject2", Body: "body2"})
+	err3 := sender.Send(context.TODO(), &Message{Subject: "subject3", Body: "body3"})
+
+	// then
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, ErrNoRecipients, err3)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	var sent struct {
+		From    string   `json:"from"`
+		To      []string `json:"to"`
+		Subject string   `json:"subject"`
+		Body    string   `json:"body"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &sent))
+	assert.Equal(t, "no-reply@email.com", sent.From)
+	assert.Equal(t, []string{"user2@email.com"}, sent.To)
+	assert.Equal(t, "subject2", sent.Subject)
+	assert.Equal(t, "body2", sent.Body)
+}
+
+func TestNewSender(t *testing.T) {
+	f, err := ioutil.TempFile("", "mails")
+	assert.NoError(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+	conf, err := config.Load("")
+	assert.NoError(t, err)
+	conf.MailConfig.File.Path = f.Name()
+
+	// when
+	sender, err := NewSender(conf)
+	assert.NoError(t, err)
+	err = sender.Send(context.TODO(), &Message{To: []string{"user1@email.com"}, Subject: "subject1", Body: "body1"})
+
+	// then
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile(f.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"subject":"subject1"`)
+
+	conf.MailConfig.Type = "unknown"
+	_, err = NewSender(conf)
+	assert.Error(t, err)
+}
+
+func TestSMTPSender_Encode(t *testing.T) {
+	conf, err := config.Load("")
+	assert.NoError(t, err)
+	s := newSMTPSender(conf.MailConfig).(*smtpSender)
+
+	// when
+	b := s.encode(&Message{To: []string{"user1@email.com", "user2@email.com"}, Subject: "subject", Body: "line1\nline2"})
+
+	// then
+	msg := string(b)
+	assert.Contains(t, msg, "From: no-reply@article-server.local\r\n")
+	assert.Contains(t, msg, "To: user1@email.com, user2@email.com\r\n")
+	assert.Contains(t, msg, "Subject: subject\r\n")
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\nline1\r\nline2"))
+}
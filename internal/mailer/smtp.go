@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/logging"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+var _ Sender = (*smtpSender)(nil)
+
+type smtpSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func newSMTPSender(conf config.MailConfig) Sender {
+	s := smtpSender{
+		addr: fmt.Sprintf("%s:%d", conf.SMTP.Host, conf.SMTP.Port),
+		from: conf.From,
+	}
+	if conf.SMTP.Username != "" {
+		s.auth = smtp.PlainAuth("", conf.SMTP.Username, conf.SMTP.Password, conf.SMTP.Host)
+	}
+	return &s
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	logging.FromContext(ctx).Debugw("mailer.smtp.Send", "to", msg.To, "subject", msg.Subject)
+
+	if err := smtp.SendMail(s.addr, s.auth, s.from, msg.To, s.encode(msg)); err != nil {
+		logging.FromContext(ctx).Errorw("mailer.smtp.Send failed to send a mail", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (s *smtpSender) encode(msg *Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
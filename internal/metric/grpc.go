@@ -0,0 +1,23 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsUnaryInterceptor is the gRPC equivalent of MetricsMiddleware recording counts and latencies
+// of requests by status codes and full methods.
+func MetricsUnaryInterceptor(mp *MetricsProvider) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		res, err := h(ctx, req)
+		elapsed := time.Now().Sub(start)
+		code := status.Code(err).String()
+		mp.RecordGrpcCount(code, info.FullMethod)
+		mp.RecordGrpcLatency(code, info.FullMethod, elapsed)
+		return res, err
+	}
+}
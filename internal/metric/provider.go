@@ -14,14 +14,26 @@ type MetricsProvider struct {
 	Subsystem string
 
 	apiMetricsProvider   apiMetricsProvider
+	grpcMetricsProvider  grpcMetricsProvider
 	cacheMetricsProvider cacheMetricsProvider
+	authMetricsProvider  authMetricsProvider
 }
 
 type apiMetricsProvider struct {
+	requestCounter   *prometheus.CounterVec
+	requestLatency   *prometheus.SummaryVec
+	rateLimitCounter *prometheus.CounterVec
+}
+
+type grpcMetricsProvider struct {
 	requestCounter *prometheus.CounterVec
 	requestLatency *prometheus.SummaryVec
 }
 
+type authMetricsProvider struct {
+	loginLockoutCounter *prometheus.CounterVec
+}
+
 type cacheMetricsProvider struct {
 	cacheTotalCounter *prometheus.CounterVec
 	cacheHitCounter   *prometheus.CounterVec
@@ -38,6 +50,22 @@ func (mp *MetricsProvider) RecordApiLatency(code int, method, path string, elaps
 	mp.apiMetricsProvider.requestLatency.WithLabelValues(strconv.Itoa(code), method, path).Observe(mills)
 }
 
+// RecordGrpcCount increases count of gRPC request with given code, method labels
+func (mp *MetricsProvider) RecordGrpcCount(code, method string) {
+	mp.grpcMetricsProvider.requestCounter.WithLabelValues(code, method).Inc()
+}
+
+// RecordGrpcLatency observes given elapsed mills with given code, method labels
+func (mp *MetricsProvider) RecordGrpcLatency(code, method string, elapsed time.Duration) {
+	mills := float64(elapsed.Milliseconds())
+	mp.grpcMetricsProvider.requestLatency.WithLabelValues(code, method).Observe(mills)
+}
+
+// RecordRateLimitRejection increases count of requests rejected by the rate limit with given route, key labels
+func (mp *MetricsProvider) RecordRateLimitRejection(route, key string) {
+	mp.apiMetricsProvider.rateLimitCounter.WithLabelValues(route, key).Inc()
+}
+
 // RecordCache increases count of cache request with given key, hit
 func (mp *MetricsProvider) RecordCache(key string, hit bool) {
 	mp.cacheMetricsProvider.cacheTotalCounter.WithLabelValues(key).Inc()
@@ -46,6 +74,11 @@ func (mp *MetricsProvider) RecordCache(key string, hit bool) {
 	}
 }
 
+// RecordLoginLockout increases count of locked logins with given scope label e.g. "email" or "ip"
+func (mp *MetricsProvider) RecordLoginLockout(scope string) {
+	mp.authMetricsProvider.loginLockoutCounter.WithLabelValues(scope).Inc()
+}
+
 // NewMetricsProvider creates a new metrics provider to record metrics
 func NewMetricsProvider(cfg *config.Config) *MetricsProvider {
 	var (
@@ -74,6 +107,35 @@ func NewMetricsProvider(cfg *config.Config) *MetricsProvider {
 				},
 				[]string{"code", "method", "path"},
 			),
+			rateLimitCounter: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "api_rate_limit_rejected_total",
+					Help:      "Total count of requests rejected by the rate limit",
+				},
+				[]string{"route", "key"},
+			),
+		},
+		grpcMetricsProvider: grpcMetricsProvider{
+			requestCounter: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "grpc_request_count",
+					Help:      "Total count of gRPC request",
+				},
+				[]string{"code", "method"},
+			),
+			requestLatency: promauto.NewSummaryVec(
+				prometheus.SummaryOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "grpc_request_latency",
+					Help:      "Elapsed time of gRPC request",
+				},
+				[]string{"code", "method"},
+			),
 		},
 		cacheMetricsProvider: cacheMetricsProvider{
 			cacheTotalCounter: promauto.NewCounterVec(
@@ -95,6 +157,17 @@ func NewMetricsProvider(cfg *config.Config) *MetricsProvider {
 				[]string{"key"},
 			),
 		},
+		authMetricsProvider: authMetricsProvider{
+			loginLockoutCounter: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "login_lockout_total",
+					Help:      "Total count of login lockouts",
+				},
+				[]string{"scope"},
+			),
+		},
 	}
 	return &mp
 }
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// customMethodSeparator separates a resource and a custom method in gin routes e.g. "articles@batchGet",
+// since ':' of custom methods like "articles:batchGet" starts a path parameter in gin routes.
+const customMethodSeparator = "@"
+
+type customMethodKey struct{}
+
+// CustomMethodRegistry is the set of last segments of registered custom methods e.g. "articles:batchGet".
+type CustomMethodRegistry struct {
+	mu      sync.RWMutex
+	methods map[string]struct{}
+}
+
+// NewCustomMethodRegistry returns a new empty CustomMethodRegistry.
+func NewCustomMethodRegistry() *CustomMethodRegistry {
+	return &CustomMethodRegistry{methods: make(map[string]struct{})}
+}
+
+// CustomMethodRoute registers given custom method of the resource to the registry and returns the gin route of it
+// e.g. "articles@batchGet" of "articles" and "batchGet". Requests of "articles:batchGet" are served by the route
+// if the server handler is wrapped by CustomMethods of the same registry.
+func CustomMethodRoute(registry *CustomMethodRegistry, resource, method string) string {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.methods[resource+":"+method] = struct{}{}
+	return resource + customMethodSeparator + method
+}
+
+// CustomMethodPath returns the path of requests and documents of given gin route
+// e.g. "/v1/api/articles:batchGet" of "/v1/api/articles@batchGet".
+func CustomMethodPath(route string) string {
+	return strings.Replace(route, customMethodSeparator, ":", 1)
+}
+
+// CustomMethods returns a http.Handler serving requests of custom methods of the registry like
+// "POST /v1/api/articles:batchGet" by routes of CustomMethodRoute of given handler, and other requests as is.
+// Requests of the routes themselves e.g. "/v1/api/articles@batchGet" are rejected by RejectRawCustomMethods.
+func CustomMethods(registry *CustomMethodRegistry, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		i := strings.LastIndex(req.URL.Path, "/")
+		last := req.URL.Path[i+1:]
+		if !registry.contains(last) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		// rewrite the copy of the request like http.StripPrefix
+		rewritten := req.WithContext(context.WithValue(req.Context(), customMethodKey{}, true))
+		rewritten.URL = new(url.URL)
+		*rewritten.URL = *req.URL
+		rewritten.URL.Path = req.URL.Path[:i+1] + strings.Replace(last, ":", customMethodSeparator, 1)
+		rewritten.URL.RawPath = ""
+		h.ServeHTTP(w, rewritten)
+	})
+}
+
+// RejectRawCustomMethods returns a middleware responding 404 to requests of routes of CustomMethodRoute
+// which are not rewritten by CustomMethods, so that custom methods are served only by the ':' separator.
+func RejectRawCustomMethods(registry *CustomMethodRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if !strings.Contains(route, customMethodSeparator) {
+			return
+		}
+		if !registry.contains(CustomMethodPath(route[strings.LastIndex(route, "/")+1:])) {
+			return
+		}
+		if rewritten, _ := c.Request.Context().Value(customMethodKey{}).(bool); rewritten {
+			return
+		}
+		handler.AbortWithError(c, http.StatusNotFound, &handler.ErrorResponse{
+			Code:    handler.NotFoundRoute,
+			Message: fmt.Sprintf("%s %s is not found", c.Request.Method, c.Request.URL.Path),
+		})
+	}
+}
+
+// contains returns true if given last segment of a path is a registered custom method.
+func (r *CustomMethodRegistry) contains(segment string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.methods[segment]
+	return ok
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := NewCustomMethodRegistry()
+	r := gin.New()
+	r.Use(RejectRawCustomMethods(registry))
+	r.POST("/v1/api/items", func(c *gin.Context) {
+		c.String(http.StatusOK, "create")
+	})
+	r.POST("/v1/api/items/:id/comments", func(c *gin.Context) {
+		c.String(http.StatusOK, "comment "+c.Param("id"))
+	})
+	r.POST("/v1/api/"+CustomMethodRoute(registry, "items", "batchGet"), func(c *gin.Context) {
+		c.String(http.StatusOK, "batchGet "+CustomMethodPath(c.FullPath()))
+	})
+	r.POST("/v1/api/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "update "+c.Param("id"))
+	})
+	h := CustomMethods(registry, r)
+
+	cases := []struct {
+		Name   string
+		Target string
+		// expected
+		Code int
+		Body string
+	}{
+		{Name: "custom method", Target: "/v1/api/items:batchGet", Code: http.StatusOK, Body: "batchGet /v1/api/items:batchGet"},
+		{Name: "resource", Target: "/v1/api/items", Code: http.StatusOK, Body: "create"},
+		{Name: "sub resource", Target: "/v1/api/items/1/comments", Code: http.StatusOK, Body: "comment 1"},
+		{Name: "unknown custom method", Target: "/v1/api/items:batchDelete", Code: http.StatusNotFound},
+		{Name: "no resource", Target: "/v1/api/:batchGet", Code: http.StatusNotFound},
+		{Name: "unregistered resource", Target: "/v1/api/others:batchGet", Code: http.StatusNotFound},
+		{Name: "not custom method", Target: "/v1/api/items/a:b", Code: http.StatusOK, Body: "update a:b"},
+		{Name: "raw separator", Target: "/v1/api/items@batchGet", Code: http.StatusNotFound},
+		{Name: "escaped raw separator", Target: "/v1/api/items%40batchGet", Code: http.StatusNotFound},
+		{Name: "separator in parameter", Target: "/v1/api/items/a@b", Code: http.StatusOK, Body: "update a@b"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.Target, nil)
+
+			h.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.Code, res.Code)
+			if tc.Body != "" {
+				assert.Equal(t, tc.Body, res.Body.String())
+			}
+		})
+	}
+}
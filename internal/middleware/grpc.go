@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/trace"
+	"gin-rest-api-example/pkg/validate"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// xRequestIdMetadataKey is the metadata key of request ids which is lower case of XRequestIdKey.
+var xRequestIdMetadataKey = strings.ToLower(XRequestIdKey)
+
+// RequestIDUnaryInterceptor is the gRPC equivalent of RequestIDMiddleware.
+// It attaches the request id of "x-request-id" metadata or a generated one and the logger with it to the context,
+// and sends the request id as header metadata.
+func RequestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+		requestId := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(xRequestIdMetadataKey); len(values) != 0 {
+				requestId = values[0]
+			}
+		}
+		if requestId == "" {
+			requestId = uuid.New().String()
+		}
+
+		ctx = trace.WithRequestID(ctx, requestId)
+		ctx = logging.WithLogger(ctx, logging.DefaultLogger().With("requestId", requestId))
+		if err := grpc.SetHeader(ctx, metadata.Pairs(xRequestIdMetadataKey, requestId)); err != nil {
+			logging.FromContext(ctx).Warnw("middleware.grpc failed to set the request id header", "err", err)
+		}
+		return h(ctx, req)
+	}
+}
+
+// LoggingUnaryInterceptor is the gRPC equivalent of LoggingMiddleware logging methods, status codes and latencies.
+func LoggingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		res, err := h(ctx, req)
+
+		logger := logging.FromContext(ctx)
+		timestamp := time.Now()
+		latency := timestamp.Sub(start)
+		code := status.Code(err)
+		// append logger keys if not success or too slow latency.
+		if code != codes.OK {
+			logger = logger.With("err", err)
+		}
+		if latency > time.Second*3 {
+			logger = logger.With("latency", latency.String())
+		}
+		logger.Infof("[ARTICLE_GRPC] %v | %-16s | %13v | %15s | %s",
+			timestamp.Format("2006/01/02 - 15:04:05"),
+			code,
+			latency,
+			PeerIP(ctx),
+			info.FullMethod,
+		)
+		return res, err
+	}
+}
+
+// RecoveryUnaryInterceptor is the gRPC equivalent of RecoveryMiddleware responding Internal status with the logged stack.
+func RecoveryUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (res interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(ctx).Errorw("middleware.recovery recovered from a panic",
+					"panic", r, "stack", string(debug.Stack()))
+				err = status.Error(codes.Internal, "An error has occurred, please try again later")
+			}
+		}()
+		return h(ctx, req)
+	}
+}
+
+// InternalStatusError logs given error of the method and returns an Internal status error without the cause.
+func InternalStatusError(ctx context.Context, method string, err error) error {
+	logging.FromContext(ctx).Errorw(method+" failed to handle a request", "err", err)
+	return status.Error(codes.Internal, "An error has occurred, please try again later")
+}
+
+// PeerIP returns the ip address of the gRPC client of given context or empty string "" if unknown.
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// ValidateMessage validates given request struct by binding tags like binding of gin and returns an InvalidArgument
+// status error with field violations of fields named by given tag e.g. "json" if invalid.
+func ValidateMessage(obj interface{}, tag string) error {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, "invalid request")
+	vErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return st.Err()
+	}
+	badRequest := &errdetails.BadRequest{}
+	for _, detail := range validate.ValidationErrorDetails(obj, tag, vErrs) {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       detail.Field,
+			Description: detail.Message,
+		})
+	}
+	withDetails, err := st.WithDetails(badRequest)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"gin-rest-api-example/pkg/trace"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testUnaryInfo = &grpc.UnaryServerInfo{FullMethod: "/test.v1.TestService/Test"}
+
+func TestRequestIDUnaryInterceptor(t *testing.T) {
+	cases := []struct {
+		Name      string
+		RequestId string
+	}{
+		{Name: "empty request id"},
+		{Name: "use requested value", RequestId: "custom"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.RequestId != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-request-id", tc.RequestId))
+			}
+
+			// when
+			var requestId string
+			_, err := RequestIDUnaryInterceptor()(ctx, nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+				requestId = trace.RequestIDFromContext(ctx)
+				return nil, nil
+			})
+
+			// then
+			assert.NoError(t, err)
+			if tc.RequestId == "" {
+				assert.Len(t, requestId, 36)
+			} else {
+				assert.Equal(t, tc.RequestId, requestId)
+			}
+		})
+	}
+}
+
+func TestRecoveryUnaryInterceptor(t *testing.T) {
+	// when
+	res, err := RecoveryUnaryInterceptor()(context.Background(), nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("test")
+	})
+
+	// then
+	assert.Nil(t, res)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestValidateMessage(t *testing.T) {
+	type message struct {
+		Name  string `json:"name" binding:"required"`
+		Email string `json:"email" binding:"omitempty,email"`
+	}
+
+	assert.NoError(t, ValidateMessage(&message{Name: "user1"}, "json"))
+
+	err := ValidateMessage(&message{Email: "invalid"}, "json")
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	assert.True(t, ok)
+	var fields []string
+	for _, violation := range badRequest.FieldViolations {
+		fields = append(fields, violation.Field)
+	}
+	assert.ElementsMatch(t, []string{"name", "email"}, fields)
+}
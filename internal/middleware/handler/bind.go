@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// source is a source of values of a request bound by Bind.
+type source struct {
+	name    string
+	tag     string
+	code    ErrorCode
+	binding func(c *gin.Context, obj interface{}) error
+}
+
+var sources = []source{
+	{name: "uri", tag: "uri", code: InvalidUriValue, binding: func(c *gin.Context, obj interface{}) error {
+		return c.ShouldBindUri(obj)
+	}},
+	{name: "query", tag: "form", code: InvalidQueryValue, binding: func(c *gin.Context, obj interface{}) error {
+		return c.ShouldBindWith(obj, binding.Query)
+	}},
+	{name: "body", tag: "json", code: InvalidBodyValue, binding: func(c *gin.Context, obj interface{}) error {
+		return c.ShouldBindWith(obj, binding.JSON)
+	}},
+}
+
+// Bind binds path parameters to uri, query parameters to query and the json body to body in the order.
+// Nil targets are not bound e.g. Bind(c, &uri, nil, &body).
+//
+// It returns a 400 error response of the first source failed to bind with InvalidUriValue, InvalidQueryValue
+// or InvalidBodyValue code and validation error details of fields named by uri, form or json tags.
+func Bind(c *gin.Context, uri, query, body interface{}) *Response {
+	for i, obj := range []interface{}{uri, query, body} {
+		if obj == nil {
+			continue
+		}
+		s := sources[i]
+		if err := s.binding(c, obj); err != nil {
+			logging.FromContext(c).Errorw("handler.Bind failed to bind", "path", c.FullPath(), "source", s.name, "err", err)
+			var details []*validate.ValidationErrDetail
+			if vErrs, ok := err.(validator.ValidationErrors); ok {
+				details = validate.ValidationErrorDetails(obj, s.tag, vErrs)
+			}
+			return NewErrorResponse(http.StatusBadRequest, s.code, "invalid request in "+s.name, details)
+		}
+	}
+	return nil
+}
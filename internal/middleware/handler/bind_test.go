@@ -0,0 +1,123 @@
+package handler_test
+
+import (
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/validate"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testURI struct {
+	Slug string `uri:"slug" binding:"required"`
+	ID   string `uri:"id" binding:"numeric"`
+}
+
+type testQuery struct {
+	Limit string `form:"limit,default=5" binding:"numeric"`
+}
+
+type testBody struct {
+	Comment struct {
+		Body string `json:"body" binding:"required"`
+	} `json:"comment"`
+}
+
+func TestBind(t *testing.T) {
+	assert.NoError(t, validate.SetupBinding())
+	cases := []struct {
+		Name    string
+		Target  string
+		Request string
+		// expected
+		Code     int
+		Expected string
+	}{
+		{
+			Name:     "bound",
+			Target:   "/articles/article1/comments/1?limit=10",
+			Request:  `{"comment": {"body": "comment1"}}`,
+			Code:     http.StatusOK,
+			Expected: `{"slug": "article1", "id": "1", "limit": "10", "body": "comment1"}`,
+		},
+		{
+			Name:    "invalid uri",
+			Target:  "/articles/article1/comments/a?limit=a",
+			Request: `{"comment": {}}`,
+			Code:    http.StatusBadRequest,
+			Expected: `{
+				"type": "about:blank",
+				"title": "Invalid path parameters",
+				"status": 400,
+				"detail": "invalid request in uri",
+				"code": "InvalidUriValue",
+				"errors": [{"field": "id", "value": "a", "message": "id must be numeric"}]
+			}`,
+		},
+		{
+			Name:    "invalid query",
+			Target:  "/articles/article1/comments/1?limit=a",
+			Request: `{"comment": {}}`,
+			Code:    http.StatusBadRequest,
+			Expected: `{
+				"type": "about:blank",
+				"title": "Invalid query parameters",
+				"status": 400,
+				"detail": "invalid request in query",
+				"code": "InvalidQueryValue",
+				"errors": [{"field": "limit", "value": "a", "message": "limit must be numeric"}]
+			}`,
+		},
+		{
+			Name:    "invalid body",
+			Target:  "/articles/article1/comments/1",
+			Request: `{"comment": {}}`,
+			Code:    http.StatusBadRequest,
+			Expected: `{
+				"type": "about:blank",
+				"title": "Invalid request body",
+				"status": 400,
+				"detail": "invalid request in body",
+				"code": "InvalidBodyValue",
+				"errors": [{"field": "body", "value": "", "message": "required body"}]
+			}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.POST("/articles/:slug/comments/:id", func(c *gin.Context) {
+				handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+					var (
+						uri   testURI
+						query testQuery
+						body  testBody
+					)
+					if res := handler.Bind(c, &uri, &query, &body); res != nil {
+						return res
+					}
+					return handler.NewSuccessResponse(http.StatusOK, gin.H{
+						"slug":  uri.Slug,
+						"id":    uri.ID,
+						"limit": query.Limit,
+						"body":  body.Comment.Body,
+					})
+				})
+			})
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tc.Target, strings.NewReader(tc.Request))
+
+			// when
+			r.ServeHTTP(res, req)
+
+			// then
+			assert.Equal(t, tc.Code, res.Code)
+			assert.JSONEq(t, tc.Expected, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Links is links of a resource or a page of resources in {data, meta, links} envelopes of v2 apis.
+// Links are relative references of the path and the query.
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// PageMeta is the meta of a page of resources listed by limit and offset query parameters.
+type PageMeta struct {
+	Total  int64 `json:"total"`
+	Limit  uint  `json:"limit"`
+	Offset uint  `json:"offset"`
+}
+
+// NewSelfLinks returns Links of a resource at given path.
+func NewSelfLinks(path string) Links {
+	return Links{Self: path}
+}
+
+// NewPageLinks returns Links of the page of given url with limit and offset query parameters of
+// the first, previous, next and last pages. Previous and next links are omitted if there is no such page.
+func NewPageLinks(u *url.URL, meta PageMeta) Links {
+	links := Links{
+		Self:  pageLink(u, meta.Limit, meta.Offset),
+		First: pageLink(u, meta.Limit, 0),
+	}
+	if meta.Limit == 0 {
+		return links
+	}
+	last := uint(0)
+	if meta.Total > 0 {
+		last = uint(meta.Total-1) / meta.Limit * meta.Limit
+	}
+	links.Last = pageLink(u, meta.Limit, last)
+	if meta.Offset > 0 {
+		prev := uint(0)
+		if meta.Offset > meta.Limit {
+			prev = meta.Offset - meta.Limit
+		}
+		links.Prev = pageLink(u, meta.Limit, prev)
+	}
+	if int64(meta.Offset+meta.Limit) < meta.Total {
+		links.Next = pageLink(u, meta.Limit, meta.Offset+meta.Limit)
+	}
+	return links
+}
+
+// pageLink returns the path and the query of given url with limit and offset query parameters.
+func pageLink(u *url.URL, limit, offset uint) string {
+	query := u.Query()
+	query.Set("limit", strconv.FormatUint(uint64(limit), 10))
+	query.Set("offset", strconv.FormatUint(uint64(offset), 10))
+	return u.Path + "?" + query.Encode()
+}
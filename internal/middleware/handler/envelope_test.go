@@ -0,0 +1,63 @@
+package handler_test
+
+import (
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPageLinks(t *testing.T) {
+	u, _ := url.Parse("/v2/api/articles?tag=go&limit=2&offset=2")
+	cases := []struct {
+		Name     string
+		Meta     handler.PageMeta
+		Expected handler.Links
+	}{
+		{
+			Name: "middle page",
+			Meta: handler.PageMeta{Total: 7, Limit: 2, Offset: 2},
+			Expected: handler.Links{
+				Self:  "/v2/api/articles?limit=2&offset=2&tag=go",
+				First: "/v2/api/articles?limit=2&offset=0&tag=go",
+				Prev:  "/v2/api/articles?limit=2&offset=0&tag=go",
+				Next:  "/v2/api/articles?limit=2&offset=4&tag=go",
+				Last:  "/v2/api/articles?limit=2&offset=6&tag=go",
+			},
+		},
+		{
+			Name: "last page",
+			Meta: handler.PageMeta{Total: 4, Limit: 2, Offset: 3},
+			Expected: handler.Links{
+				Self:  "/v2/api/articles?limit=2&offset=3&tag=go",
+				First: "/v2/api/articles?limit=2&offset=0&tag=go",
+				Prev:  "/v2/api/articles?limit=2&offset=1&tag=go",
+				Last:  "/v2/api/articles?limit=2&offset=2&tag=go",
+			},
+		},
+		{
+			Name: "empty",
+			Meta: handler.PageMeta{Total: 0, Limit: 2, Offset: 0},
+			Expected: handler.Links{
+				Self:  "/v2/api/articles?limit=2&offset=0&tag=go",
+				First: "/v2/api/articles?limit=2&offset=0&tag=go",
+				Last:  "/v2/api/articles?limit=2&offset=0&tag=go",
+			},
+		},
+		{
+			Name: "zero limit",
+			Meta: handler.PageMeta{Total: 4, Limit: 0, Offset: 0},
+			Expected: handler.Links{
+				Self:  "/v2/api/articles?limit=0&offset=0&tag=go",
+				First: "/v2/api/articles?limit=0&offset=0&tag=go",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, handler.NewPageLinks(u, tc.Meta))
+		})
+	}
+}
@@ -9,18 +9,41 @@ type ErrorCode string
 
 const (
 	// 400 bad request
-	InvalidQueryValue = ErrorCode("InvalidQueryValue")
-	InvalidUriValue   = ErrorCode("InvalidUriValue")
-	InvalidBodyValue  = ErrorCode("InvalidBodyValue")
+	InvalidQueryValue  = ErrorCode("InvalidQueryValue")
+	InvalidUriValue    = ErrorCode("InvalidUriValue")
+	InvalidBodyValue   = ErrorCode("InvalidBodyValue")
+	InvalidToken       = ErrorCode("InvalidToken")
+	InvalidMFACode     = ErrorCode("InvalidMFACode")
+	InvalidHeaderValue = ErrorCode("InvalidHeaderValue")
+
+	// 401 unauthorized
+	Unauthorized = ErrorCode("Unauthorized")
+
+	// 403 forbidden
+	Forbidden             = ErrorCode("Forbidden")
+	UnverifiedAccount     = ErrorCode("UnverifiedAccount")
+	MFAEnrollmentRequired = ErrorCode("MFAEnrollmentRequired")
+	InsufficientScope     = ErrorCode("InsufficientScope")
+	InsufficientRole      = ErrorCode("InsufficientRole")
 
 	// 404 not found
 	NotFoundEntity = ErrorCode("NotFoundEntity")
+	NotFoundRoute  = ErrorCode("NotFoundRoute")
 
 	// 409 duplicate
-	DuplicateEntry = ErrorCode("DuplicateEntry")
+	DuplicateEntry      = ErrorCode("DuplicateEntry")
+	IdempotencyConflict = ErrorCode("IdempotencyConflict")
 
+	// 412 precondition failed
+	PreconditionFailed = ErrorCode("PreconditionFailed")
+	// 428 precondition required
+	PreconditionRequired = ErrorCode("PreconditionRequired")
+	// 429 too many requests
+	TooManyRequests = ErrorCode("TooManyRequests")
 	// 500
 	InternalServerError = ErrorCode("InternalServerError")
+	// 504
+	RequestTimeout = ErrorCode("RequestTimeout")
 )
 
 type ErrorResponse struct {
@@ -1,18 +1,36 @@
 package handler
 
 import (
-	"github.com/gin-gonic/gin"
+	"fmt"
+	"gin-rest-api-example/pkg/logging"
 	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
 )
 
+// handlerDoneKey is the key of a channel closed when the handler goroutine of the request finishes.
+const handlerDoneKey = "handler.done"
+
 func HandleRequest(c *gin.Context, f func(c *gin.Context) *Response) {
 	ctx := c.Request.Context()
 	if _, ok := ctx.Deadline(); !ok {
 		handleRequestReal(c, f(c))
 		return
 	}
-	doneChan := make(chan *Response)
+	done := make(chan struct{})
+	c.Set(handlerDoneKey, done)
+	// doneChan is buffered so that the goroutine does not leak after the request timed out.
+	doneChan := make(chan *Response, 1)
 	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(ctx).Errorw("handler.HandleRequest recovered from a panic",
+					"panic", r, "stack", string(debug.Stack()))
+				doneChan <- NewInternalErrorResponse(fmt.Errorf("panic: %v", r))
+			}
+		}()
 		doneChan <- f(c)
 	}()
 	select {
@@ -23,6 +41,17 @@ func HandleRequest(c *gin.Context, f func(c *gin.Context) *Response) {
 	}
 }
 
+// Done returns a channel closed when the handler of the request finishes even if the request timed out.
+// The channel is closed already if the handler has not run in a goroutine.
+func Done(c *gin.Context) <-chan struct{} {
+	if done, ok := c.Get(handlerDoneKey); ok {
+		return done.(chan struct{})
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
 func handleRequestReal(c *gin.Context, res *Response) {
 	if res.Err == nil {
 		statusCode := res.StatusCode
@@ -43,5 +72,5 @@ func handleRequestReal(c *gin.Context, res *Response) {
 		res.StatusCode = http.StatusInternalServerError
 		err = &ErrorResponse{Code: InternalServerError, Message: "An error has occurred, please try again later"}
 	}
-	c.AbortWithStatusJSON(res.StatusCode, err)
+	AbortWithError(c, res.StatusCode, err)
 }
@@ -1,8 +1,9 @@
-package handler
+package handler_test
 
 import (
 	"errors"
 	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -14,15 +15,15 @@ import (
 func TestHandleRequest(t *testing.T) {
 	cases := []struct {
 		Name string
-		Func func(c *gin.Context) *Response
+		Func func(c *gin.Context) *handler.Response
 		// expected
 		Code int
 		Body string
 	}{
 		{
 			Name: "Success with data",
-			Func: func(c *gin.Context) *Response {
-				return NewSuccessResponse(http.StatusOK, map[string]interface{}{
+			Func: func(c *gin.Context) *handler.Response {
+				return handler.NewSuccessResponse(http.StatusOK, map[string]interface{}{
 					"data": "ok",
 				})
 			},
@@ -34,37 +35,67 @@ func TestHandleRequest(t *testing.T) {
 			`,
 		}, {
 			Name: "Fail with ErrorResponse",
-			Func: func(c *gin.Context) *Response {
-				return NewErrorResponse(http.StatusBadRequest, InvalidQueryValue, "invalid query", nil)
+			Func: func(c *gin.Context) *handler.Response {
+				return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidQueryValue, "invalid query", nil)
 			},
 			Code: http.StatusBadRequest,
 			Body: `
 			{
-				"code": "InvalidQueryValue",
-				"message": "[InvalidQueryValue] invalid query"
+				"type": "about:blank",
+				"title": "Invalid query parameters",
+				"status": 400,
+				"detail": "invalid query",
+				"code": "InvalidQueryValue"
 			}
 			`,
 		}, {
 			Name: "Fail with any error",
-			Func: func(c *gin.Context) *Response {
-				return &Response{
+			Func: func(c *gin.Context) *handler.Response {
+				return &handler.Response{
 					Err: errors.New("any error"),
 				}
 			},
 			Code: http.StatusInternalServerError,
 			Body: `
 			{
-				"code": "InternalServerError",
-				"message": "[InternalServerError] An error has occurred, please try again later"
+				"type": "about:blank",
+				"title": "Internal server error",
+				"status": 500,
+				"detail": "An error has occurred, please try again later",
+				"code": "InternalServerError"
+			}
+			`,
+		}, {
+			Name: "Fail with panic",
+			Func: func(c *gin.Context) *handler.Response {
+				panic("any panic")
+			},
+			Code: http.StatusInternalServerError,
+			Body: `
+			{
+				"type": "about:blank",
+				"title": "Internal server error",
+				"status": 500,
+				"detail": "An error has occurred, please try again later",
+				"code": "InternalServerError"
 			}
 			`,
 		}, {
 			Name: "Timeout with ErrorResponse",
-			Func: func(c *gin.Context) *Response {
+			Func: func(c *gin.Context) *handler.Response {
 				time.Sleep(250 * time.Millisecond)
 				return nil
 			},
 			Code: http.StatusGatewayTimeout,
+			Body: `
+			{
+				"type": "about:blank",
+				"title": "Request timeout",
+				"status": 504,
+				"detail": "request timeout",
+				"code": "RequestTimeout"
+			}
+			`,
 		},
 	}
 
@@ -73,7 +104,7 @@ func TestHandleRequest(t *testing.T) {
 			s := setupRouterWithHandler(func(c *gin.Engine) {
 				c.Use(middleware.TimeoutMiddleware(200 * time.Millisecond))
 			}, func(c *gin.Context) {
-				HandleRequest(c, tc.Func)
+				handler.HandleRequest(c, tc.Func)
 			})
 
 			res := httptest.NewRecorder()
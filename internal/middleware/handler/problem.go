@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/i18n"
+	"gin-rest-api-example/pkg/trace"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	ErrorFormatProblem = "problem"
+	ErrorFormatLegacy  = "legacy"
+
+	ProblemJSONContentType = "application/problem+json"
+
+	errorFormatKey = "errorFormat"
+)
+
+// Problem is an error response of RFC 7807 problem details
+// with "code" and "errors" extension members of the ErrorResponse.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     ErrorCode   `json:"code"`
+	Errors   interface{} `json:"errors,omitempty"`
+}
+
+type errorFormat struct {
+	legacy      bool
+	typeBaseURL string
+}
+
+// ErrorFormatMiddleware stores the format of error responses by "server.errors" configs to the gin.Context.
+// Errors are responded as problem details if the middleware is not used.
+func ErrorFormatMiddleware(cfg *config.Config) gin.HandlerFunc {
+	conf := cfg.ServerConfig.Errors
+	format := &errorFormat{
+		legacy:      conf.Format == ErrorFormatLegacy,
+		typeBaseURL: conf.TypeBaseURL,
+	}
+	return func(c *gin.Context) {
+		c.Set(errorFormatKey, format)
+	}
+}
+
+// AbortWithError aborts the request with given status code and error in the format of ErrorFormatMiddleware.
+// Problem details are responded as application/problem+json unless the client accepts only application/json.
+// Titles of problems and messages of validation errors are localized by the i18n.Localizer of the request.
+func AbortWithError(c *gin.Context, status int, err *ErrorResponse) {
+	format := &errorFormat{}
+	if v, ok := c.Get(errorFormatKey); ok {
+		format = v.(*errorFormat)
+	}
+	l := i18n.FromContext(c)
+	if details, ok := err.Errors.([]*validate.ValidationErrDetail); ok {
+		err = &ErrorResponse{Code: err.Code, Message: err.Message, Errors: validate.Localize(l, details)}
+	}
+	if format.legacy {
+		c.AbortWithStatusJSON(status, err)
+		return
+	}
+
+	if c.NegotiateFormat(ProblemJSONContentType, binding.MIMEJSON) != binding.MIMEJSON {
+		c.Header("Content-Type", ProblemJSONContentType)
+	}
+	c.AbortWithStatusJSON(status, format.newProblem(c, l, status, err))
+}
+
+func (f *errorFormat) newProblem(c *gin.Context, l *i18n.Localizer, status int, err *ErrorResponse) *Problem {
+	problemType := "about:blank"
+	if f.typeBaseURL != "" {
+		problemType = f.typeBaseURL + string(err.Code)
+	}
+	// the title is the message of the error code which is the same for the problem type
+	title, ok := l.Lookup("error."+string(err.Code), nil)
+	if !ok {
+		title = http.StatusText(status)
+	}
+	return &Problem{
+		Type:     problemType,
+		Title:    title,
+		Status:   status,
+		Detail:   err.Message,
+		Instance: trace.RequestIDFromContext(c.Request.Context()),
+		Code:     err.Code,
+		Errors:   nilIfEmpty(err.Errors),
+	}
+}
+
+// nilIfEmpty returns nil if given value is a nil slice, map or pointer so that it is omitted.
+func nilIfEmpty(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+	}
+	return v
+}
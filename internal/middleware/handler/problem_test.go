@@ -0,0 +1,193 @@
+package handler_test
+
+import (
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/i18n"
+	"gin-rest-api-example/pkg/validate"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbortWithError(t *testing.T) {
+	details := validate.NewValidationErrorDetails("title", "title required", "")
+	cases := []struct {
+		Name        string
+		Format      string
+		TypeBaseURL string
+		Accept      string
+		// expected
+		ContentType string
+		Body        string
+	}{
+		{
+			Name:        "problem details",
+			Format:      handler.ErrorFormatProblem,
+			ContentType: "application/problem+json",
+			Body: `{
+				"type": "about:blank",
+				"title": "Invalid request body",
+				"status": 400,
+				"detail": "invalid article request in body",
+				"instance": "request-1",
+				"code": "InvalidBodyValue",
+				"errors": [{"field": "title", "message": "title required", "value": ""}]
+			}`,
+		}, {
+			Name:        "problem details with type base url",
+			Format:      handler.ErrorFormatProblem,
+			TypeBaseURL: "https://example.com/problems/",
+			Accept:      "application/problem+json, application/json",
+			ContentType: "application/problem+json",
+			Body: `{
+				"type": "https://example.com/problems/InvalidBodyValue",
+				"title": "Invalid request body",
+				"status": 400,
+				"detail": "invalid article request in body",
+				"instance": "request-1",
+				"code": "InvalidBodyValue",
+				"errors": [{"field": "title", "message": "title required", "value": ""}]
+			}`,
+		}, {
+			Name:        "problem details to clients accepting only json",
+			Format:      handler.ErrorFormatProblem,
+			Accept:      "application/json",
+			ContentType: "application/json; charset=utf-8",
+			Body: `{
+				"type": "about:blank",
+				"title": "Invalid request body",
+				"status": 400,
+				"detail": "invalid article request in body",
+				"instance": "request-1",
+				"code": "InvalidBodyValue",
+				"errors": [{"field": "title", "message": "title required", "value": ""}]
+			}`,
+		}, {
+			Name:        "legacy",
+			Format:      handler.ErrorFormatLegacy,
+			Accept:      "application/problem+json",
+			ContentType: "application/json; charset=utf-8",
+			Body: `{
+				"code": "InvalidBodyValue",
+				"message": "[InvalidBodyValue] invalid article request in body",
+				"errors": [{"field": "title", "message": "title required", "value": ""}]
+			}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg, err := config.Load("")
+			assert.NoError(t, err)
+			cfg.ServerConfig.Errors.Format = tc.Format
+			cfg.ServerConfig.Errors.TypeBaseURL = tc.TypeBaseURL
+			s := setupRouterWithHandler(func(c *gin.Engine) {
+				c.Use(handler.ErrorFormatMiddleware(cfg), middleware.RequestIDMiddleware())
+			}, func(c *gin.Context) {
+				handler.AbortWithError(c, http.StatusBadRequest, &handler.ErrorResponse{
+					Code:    handler.InvalidBodyValue,
+					Message: "invalid article request in body",
+					Errors:  details,
+				})
+			})
+
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "http://localhost/foo", nil)
+			req.Header.Set(middleware.XRequestIdKey, "request-1")
+			if tc.Accept != "" {
+				req.Header.Set("Accept", tc.Accept)
+			}
+
+			// when
+			s.ServeHTTP(res, req)
+
+			// then
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+			assert.Equal(t, tc.ContentType, res.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.Body, res.Body.String())
+		})
+	}
+}
+
+func TestAbortWithError_Localized(t *testing.T) {
+	type request struct {
+		Title string `json:"title" binding:"required"`
+		Body  string `json:"body" binding:"required,min=5"`
+	}
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	catalog, err := middleware.NewCatalog(cfg)
+	assert.NoError(t, err)
+	s := setupRouterWithHandler(func(c *gin.Engine) {
+		c.Use(handler.ErrorFormatMiddleware(cfg), middleware.LocaleMiddleware(catalog))
+	}, func(c *gin.Context) {
+		var body request
+		err := c.ShouldBindQuery(&body)
+		handler.AbortWithError(c, http.StatusBadRequest, &handler.ErrorResponse{
+			Code:    handler.InvalidBodyValue,
+			Message: "invalid request in body",
+			Errors:  validate.ValidationErrorDetails(&body, "json", err.(validator.ValidationErrors)),
+		})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost/foo?Body=a", nil)
+	req.Header.Set("Accept-Language", "ko-KR,ko;q=0.9,en;q=0.8")
+
+	// when
+	s.ServeHTTP(res, req)
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "ko", res.Header().Get("Content-Language"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "잘못된 요청 본문입니다",
+		"status": 400,
+		"detail": "invalid request in body",
+		"code": "InvalidBodyValue",
+		"errors": [
+			{"field": "title", "value": "", "message": "title은(는) 필수입니다"},
+			{"field": "body", "value": "a", "message": "body은(는) 최소 5자 이상이어야 합니다"}
+		]
+	}`, res.Body.String())
+}
+
+// TestErrorCodeMessages fails if messages of ErrorCode constants are missing in the i18n.Catalog.
+func TestErrorCodeMessages(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "error.go", nil, 0)
+	assert.NoError(t, err)
+	var codes []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "ErrorCode" {
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok {
+				code, _ := strconv.Unquote(lit.Value)
+				codes = append(codes, code)
+			}
+		}
+		return true
+	})
+	assert.NotEmpty(t, codes)
+
+	catalog := i18n.DefaultCatalog()
+	for _, locale := range catalog.Locales() {
+		keys := catalog.Keys(locale)
+		for _, code := range codes {
+			assert.Contains(t, keys, "error."+code, "locale %s", locale)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyReleaseTimeout is the timeout to release a claim after the request finished.
+	idempotencyReleaseTimeout = 3 * time.Second
+)
+
+// Idempotency stores responses of requests with Idempotency-Key header per account to replay them on retries.
+// Requests in progress are claimed atomically so that concurrent retries are processed at most once.
+type Idempotency struct {
+	cacher cache.Cacher
+	claims idempotencyClaims
+	ttl    time.Duration
+	// claimTTL is how long a request in progress is claimed in case the process stops without releasing it.
+	claimTTL time.Duration
+}
+
+// idempotencyClaims claims keys of requests in progress.
+type idempotencyClaims interface {
+	// claim sets given key with ttl only if the key is not claimed and returns true if it is set.
+	claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// release deletes given claimed key.
+	release(ctx context.Context, key string) error
+}
+
+// idempotentResponse is a stored response of a request.
+type idempotentResponse struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// NewIdempotency returns a new Idempotency or nil if idempotency keys are disabled.
+// Responses are stored in the memory if the cache is disabled.
+func NewIdempotency(cfg *config.Config, cacher cache.Cacher) *Idempotency {
+	conf := cfg.IdempotencyConfig
+	if !conf.Enabled {
+		return nil
+	}
+	if cacher == nil {
+		logging.DefaultLogger().Warn("responses of idempotency keys are stored in the memory because the cache is disabled")
+		cacher = cache.NewMemoryCacher(conf.TTL)
+	}
+	var claims idempotencyClaims
+	if cli, prefix, ok := cache.RedisClient(cacher); ok {
+		claims = newRedisIdempotencyClaims(cli, prefix)
+	} else {
+		claims = newMemoryIdempotencyClaims()
+	}
+	// requests in progress are timed out after the write timeout of the server
+	claimTTL := cfg.ServerConfig.WriteTimeout
+	if claimTTL <= 0 {
+		claimTTL = time.Minute
+	}
+	return &Idempotency{cacher: cacher, claims: claims, ttl: conf.TTL, claimTTL: claimTTL}
+}
+
+// Idempotent returns a middleware replaying the first response of an Idempotency-Key header of the account.
+// Retries with the same key and a different request are rejected with 409 as well as retries while the key is claimed
+// by a request in progress.
+// Requests pass through if the Idempotency is nil, the header is missing or the request is anonymous.
+// Server errors and 429 responses are not stored so that retries are processed again.
+func Idempotent(i *Idempotency) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if i == nil || key == "" {
+			return
+		}
+		accountID, ok := clientAccountID(c)
+		if !ok {
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			handler.AbortWithError(c, http.StatusBadRequest, &handler.ErrorResponse{
+				Code:    handler.InvalidHeaderValue,
+				Message: fmt.Sprintf("%s header required at most %d length", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+			})
+			return
+		}
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			handler.AbortWithError(c, http.StatusBadRequest, &handler.ErrorResponse{
+				Code:    handler.InvalidBodyValue,
+				Message: "failed to read the request body",
+			})
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		logger := logging.FromContext(ctx)
+		cacheKey := fmt.Sprintf("idempotency:%d:%s", accountID, key)
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		if i.replay(c, cacheKey, fingerprint) {
+			return
+		}
+		claimed, err := i.claims.claim(ctx, cacheKey, i.claimTTL)
+		if err != nil {
+			// process the request rather than failing it if claims are unavailable
+			logger.Warnw("middleware.idempotency failed to claim a request", "key", cacheKey, "err", err)
+			return
+		}
+		if !claimed {
+			handler.AbortWithError(c, http.StatusConflict, &handler.ErrorResponse{
+				Code:    handler.IdempotencyConflict,
+				Message: fmt.Sprintf("a request with the %s is in progress", IdempotencyKeyHeader),
+			})
+			return
+		}
+		defer i.release(c, cacheKey)
+		// the response may be stored by a request released after the first lookup
+		if i.replay(c, cacheKey, fingerprint) {
+			return
+		}
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		status := recorder.Status()
+		if !recorder.Written() || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			return
+		}
+		stored := idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := i.cacher.SetWithTTL(ctx, cacheKey, &stored, i.ttl); err != nil {
+			logger.Warnw("middleware.idempotency failed to store a response", "key", cacheKey, "err", err)
+		}
+	}
+}
+
+// release releases the claim of given key after the handler of the request finishes.
+// The claim is released with a new context because the context of the request may be done already.
+func (i *Idempotency) release(c *gin.Context, cacheKey string) {
+	logger := logging.FromContext(c.Request.Context())
+	done := handler.Done(c)
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), idempotencyReleaseTimeout)
+		defer cancel()
+		if err := i.claims.release(ctx, cacheKey); err != nil {
+			logger.Warnw("middleware.idempotency failed to release a request", "key", cacheKey, "err", err)
+		}
+	}
+	select {
+	case <-done:
+		release()
+	default:
+		// the request timed out while the handler is still running
+		go func() {
+			<-done
+			release()
+		}()
+	}
+}
+
+// replay writes the stored response of given key and returns true if the response is stored.
+// A stored response of a different request is rejected with 409.
+func (i *Idempotency) replay(c *gin.Context, cacheKey, fingerprint string) bool {
+	var stored idempotentResponse
+	err := i.cacher.Get(c.Request.Context(), cacheKey, &stored)
+	switch {
+	case err == nil && stored.Fingerprint != fingerprint:
+		handler.AbortWithError(c, http.StatusConflict, &handler.ErrorResponse{
+			Code:    handler.IdempotencyConflict,
+			Message: fmt.Sprintf("%s is already used by a different request", IdempotencyKeyHeader),
+		})
+		return true
+	case err == nil:
+		c.Header(IdempotencyReplayedHeader, "true")
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+		c.Abort()
+		return true
+	case err != cache.ErrCacheMiss:
+		// process the request rather than failing it if stored responses are unavailable
+		logging.FromContext(c).Warnw("middleware.idempotency failed to get a stored response", "key", cacheKey, "err", err)
+	}
+	return false
+}
+
+// memoryIdempotencyClaims claims keys in the memory of the process.
+type memoryIdempotencyClaims struct {
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+	now       func() time.Time
+}
+
+func newMemoryIdempotencyClaims() *memoryIdempotencyClaims {
+	return &memoryIdempotencyClaims{
+		expiresAt: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+func (m *memoryIdempotencyClaims) claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if expiresAt, ok := m.expiresAt[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	m.expiresAt[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (m *memoryIdempotencyClaims) release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.expiresAt, key)
+	return nil
+}
+
+// requestFingerprint returns a hash of given request to detect a reused key with a different request.
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder copies the response body written to the gin.ResponseWriter.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisIdempotencyClaims claims keys in the redis shared between servers.
+type redisIdempotencyClaims struct {
+	cli    redis.UniversalClient
+	prefix string
+}
+
+func newRedisIdempotencyClaims(cli redis.UniversalClient, prefix string) *redisIdempotencyClaims {
+	return &redisIdempotencyClaims{cli: cli, prefix: prefix}
+}
+
+func (r *redisIdempotencyClaims) claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.cli.SetNX(ctx, r.claimKey(key), 1, ttl).Result()
+}
+
+func (r *redisIdempotencyClaims) release(ctx context.Context, key string) error {
+	return r.cli.Del(ctx, r.claimKey(key)).Err()
+}
+
+func (r *redisIdempotencyClaims) claimKey(key string) string {
+	return r.prefix + key + ":claim"
+}
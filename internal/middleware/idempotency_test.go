@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"context"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/middleware/handler"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type idempotencyTest struct {
+	r      *gin.Engine
+	i      *Idempotency
+	calls  int
+	status int
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	it := idempotencyTest{i: NewIdempotency(cfg, nil), status: http.StatusCreated}
+
+	gin.SetMode(gin.TestMode)
+	it.r = gin.New()
+	it.r.POST("/foo", func(c *gin.Context) {
+		if accountID, err := strconv.ParseUint(c.GetHeader("X-Account-ID"), 10, 64); err == nil {
+			SetClientIdentity(c, uint(accountID), 0)
+		}
+	}, Idempotent(it.i), func(c *gin.Context) {
+		it.calls++
+		c.JSON(it.status, gin.H{"calls": it.calls})
+	})
+	return &it
+}
+
+func (it *idempotencyTest) do(accountID, key, body string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "http://localhost/foo", strings.NewReader(body))
+	req.Header.Set("X-Account-ID", accountID)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	it.r.ServeHTTP(res, req)
+	return res
+}
+
+func TestIdempotent_Replay(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	res := it.do("1", "key1", `{"title":"title1"}`)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.JSONEq(t, `{"calls":1}`, res.Body.String())
+	assert.Empty(t, res.Header().Get(IdempotencyReplayedHeader))
+
+	// replays the first response
+	res = it.do("1", "key1", `{"title":"title1"}`)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.JSONEq(t, `{"calls":1}`, res.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, "true", res.Header().Get(IdempotencyReplayedHeader))
+	assert.Equal(t, 1, it.calls)
+
+	// keys are scoped by accounts
+	res = it.do("2", "key1", `{"title":"title1"}`)
+	assert.JSONEq(t, `{"calls":2}`, res.Body.String())
+	// requests without keys or accounts are not replayed
+	res = it.do("1", "", `{"title":"title1"}`)
+	assert.JSONEq(t, `{"calls":3}`, res.Body.String())
+	res = it.do("", "key1", `{"title":"title1"}`)
+	assert.JSONEq(t, `{"calls":4}`, res.Body.String())
+}
+
+func TestIdempotent_Conflict(t *testing.T) {
+	it := newIdempotencyTest(t)
+	it.do("1", "key1", `{"title":"title1"}`)
+
+	// when then: reused key with a different body
+	res := it.do("1", "key1", `{"title":"title2"}`)
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Idempotency key conflict","status":409,"detail":"Idempotency-Key is already used by a different request","code":"IdempotencyConflict"}`, res.Body.String())
+
+	// when then: a request in progress
+	claimed, err := it.i.claims.claim(context.Background(), "idempotency:1:key2", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	res = it.do("1", "key2", `{"title":"title1"}`)
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Idempotency key conflict","status":409,"detail":"a request with the Idempotency-Key is in progress","code":"IdempotencyConflict"}`, res.Body.String())
+	assert.Equal(t, 1, it.calls)
+}
+
+func TestIdempotent_Concurrent_Memory(t *testing.T) {
+	testIdempotentConcurrent(t, nil)
+}
+
+func TestIdempotent_Concurrent_Redis(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg, _ := config.Load("")
+	cfg.CacheConfig.Enabled = true
+	cfg.CacheConfig.RedisConfig.Endpoints = []string{s.Addr()}
+	cacher, err := cache.NewCacher(cfg)
+	assert.NoError(t, err)
+	defer cacher.Close()
+
+	testIdempotentConcurrent(t, cacher)
+}
+
+// testIdempotentConcurrent sends concurrent retries with the same key and asserts only one of them is processed.
+func testIdempotentConcurrent(t *testing.T, cacher cache.Cacher) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	var calls int32
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/foo", func(c *gin.Context) {
+		SetClientIdentity(c, 1, 0)
+	}, Idempotent(NewIdempotency(cfg, cacher)), func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	// when
+	const n = 10
+	var wg sync.WaitGroup
+	codes := make(chan int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "http://localhost/foo", strings.NewReader(`{}`))
+			req.Header.Set(IdempotencyKeyHeader, "key1")
+			r.ServeHTTP(res, req)
+			if res.Header().Get(IdempotencyReplayedHeader) == "" {
+				codes <- res.Code
+			}
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	// then
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	created := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusConflict, code)
+		}
+	}
+	assert.Equal(t, 1, created)
+}
+
+func TestIdempotent_NotStored(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	// server errors are processed again
+	it.status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, it.do("1", "key1", `{}`).Code)
+	it.status = http.StatusCreated
+	res := it.do("1", "key1", `{}`)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.JSONEq(t, `{"calls":2}`, res.Body.String())
+
+	// too long keys are rejected
+	res = it.do("1", strings.Repeat("a", 256), `{}`)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Invalid request header","status":400,"detail":"Idempotency-Key header required at most 255 length","code":"InvalidHeaderValue"}`, res.Body.String())
+	assert.Equal(t, 2, it.calls)
+}
+
+func TestIdempotent_Timeout(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	i := NewIdempotency(cfg, nil)
+	var calls int32
+	finished := make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/foo", TimeoutMiddleware(50*time.Millisecond), func(c *gin.Context) {
+		SetClientIdentity(c, 1, 0)
+	}, Idempotent(i), func(c *gin.Context) {
+		handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				defer close(finished)
+				time.Sleep(200 * time.Millisecond)
+			}
+			return handler.NewSuccessResponse(http.StatusCreated, gin.H{})
+		})
+	})
+	do := func() int {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "http://localhost/foo", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key1")
+		r.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	// the request is claimed only for the write timeout
+	claims := i.claims.(*memoryIdempotencyClaims)
+	now := time.Now()
+	claims.now = func() time.Time { return now }
+	assert.Equal(t, http.StatusGatewayTimeout, do())
+	claims.mu.Lock()
+	assert.Equal(t, now.Add(cfg.ServerConfig.WriteTimeout), claims.expiresAt["idempotency:1:key1"])
+	claims.mu.Unlock()
+	claims.now = time.Now
+
+	// the claim is kept until the handler of the timed out request finishes
+	assert.Equal(t, http.StatusConflict, do())
+	<-finished
+	assert.Eventually(t, func() bool {
+		return do() == http.StatusCreated
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotent_Disabled(t *testing.T) {
+	cfg, _ := config.Load("")
+	cfg.IdempotencyConfig.Enabled = false
+	assert.Nil(t, NewIdempotency(cfg, nil))
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+const (
+	clientAccountCtxKey = "clientAccountID"
+	clientAPIKeyCtxKey  = "clientAPIKeyID"
+)
+
+// SetClientIdentity stores the authenticated account and api key ids to the gin.Context
+// for middlewares keyed by clients e.g. rate limits. apiKeyID is zero if not authenticated by an api key.
+func SetClientIdentity(c *gin.Context, accountID, apiKeyID uint) {
+	c.Set(clientAccountCtxKey, accountID)
+	if apiKeyID != 0 {
+		c.Set(clientAPIKeyCtxKey, apiKeyID)
+	}
+}
+
+// clientAccountID returns the authenticated account id or false if anonymous.
+func clientAccountID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(clientAccountCtxKey)
+	if !ok {
+		return 0, false
+	}
+	accountID, ok := id.(uint)
+	return accountID, ok
+}
+
+// clientAPIKeyID returns the api key id or false if not authenticated by an api key.
+func clientAPIKeyID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(clientAPIKeyCtxKey)
+	if !ok {
+		return 0, false
+	}
+	apiKeyID, ok := id.(uint)
+	return apiKeyID, ok
+}
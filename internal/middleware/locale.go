@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewCatalog returns the i18n.Catalog of embedded locales with the fallback locale of "i18n.fallbackLocale" config.
+func NewCatalog(cfg *config.Config) (*i18n.Catalog, error) {
+	return i18n.NewCatalog(cfg.I18nConfig.FallbackLocale)
+}
+
+// LocaleMiddleware attach the i18n.Localizer of the locale matched by Accept-Language header to context
+// and responds the locale as Content-Language header.
+func LocaleMiddleware(catalog *i18n.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := catalog.Match(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocalizer(c, catalog.Localizer(locale)))
+		c.Header("Content-Language", locale)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/i18n"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleMiddleware(t *testing.T) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	catalog, err := NewCatalog(cfg)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		AcceptLanguage string
+		// expected
+		Locale string
+	}{
+		{AcceptLanguage: "", Locale: "en"},
+		{AcceptLanguage: "ko-KR,ko;q=0.9", Locale: "ko"},
+		{AcceptLanguage: "fr-FR", Locale: "en"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.AcceptLanguage, func(t *testing.T) {
+			var locale string
+			s := setupRouterWithHandler(func(c *gin.Engine) {
+				c.Use(LocaleMiddleware(catalog))
+			}, func(c *gin.Context) {
+				locale = i18n.FromContext(c).Locale()
+			})
+
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "http://localhost/foo", nil)
+			req.Header.Set("Accept-Language", tc.AcceptLanguage)
+
+			// when
+			s.ServeHTTP(res, req)
+
+			// then
+			assert.Equal(t, tc.Locale, locale)
+			assert.Equal(t, tc.Locale, res.Header().Get("Content-Language"))
+		})
+	}
+}
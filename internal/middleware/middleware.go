@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"context"
+	"gin-rest-api-example/internal/middleware/handler"
 	"gin-rest-api-example/pkg/logging"
 	"gin-rest-api-example/pkg/trace"
 	"net/http"
+	"runtime/debug"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,8 +40,11 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 
 		defer func() {
-			if ctx.Err() == context.DeadlineExceeded {
-				c.AbortWithStatus(http.StatusGatewayTimeout)
+			if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+				handler.AbortWithError(c, http.StatusGatewayTimeout, &handler.ErrorResponse{
+					Code:    handler.RequestTimeout,
+					Message: "request timeout",
+				})
 			}
 			cancel()
 		}()
@@ -48,6 +53,27 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	}
 }
 
+// RecoveryMiddleware recovers from panics of handlers and responds 500 error with the logged stack.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(c.Request.Context()).Errorw("middleware.recovery recovered from a panic",
+					"panic", r, "stack", string(debug.Stack()))
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				handler.AbortWithError(c, http.StatusInternalServerError, &handler.ErrorResponse{
+					Code:    handler.InternalServerError,
+					Message: "An error has occurred, please try again later",
+				})
+			}
+		}()
+		c.Next()
+	}
+}
+
 // LoggingMiddleware use logging.DefaultLogger() i.e *zap.SugaredLogger with x-request-id
 func LoggingMiddleware(skipPaths ...string) gin.HandlerFunc {
 	skip := make(map[string]struct{}, len(skipPaths))
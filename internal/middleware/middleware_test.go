@@ -69,6 +69,31 @@ func TestTimeoutMiddleware(t *testing.T) {
 	s.ServeHTTP(res, req)
 }
 
+func TestRecoveryMiddleware(t *testing.T) {
+	s := setupRouterWithHandler(func(c *gin.Engine) {
+		c.Use(RecoveryMiddleware())
+	}, func(c *gin.Context) {
+		panic("unexpected")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost/foo", nil)
+
+	// when
+	s.ServeHTTP(res, req)
+
+	// then
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Internal server error",
+		"status": 500,
+		"detail": "An error has occurred, please try again later",
+		"code": "InternalServerError"
+	}`, res.Body.String())
+}
+
 func setupRouterWithHandler(middlewareFunc func(c *gin.Engine), handler func(c *gin.Context)) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequest returns a middleware validating path parameters, query parameters and the json body
+// of requests by operations of the OpenAPI document before handlers.
+// Invalid requests are rejected with 400 and validation error details.
+// Requests pass through if the spec is nil or the route is not documented.
+func ValidateRequest(spec *openapi.Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if spec == nil {
+			return
+		}
+		err := spec.ValidateRequest(c.Request, CustomMethodPath(c.FullPath()), c.Params)
+		if err == nil {
+			return
+		}
+		vErr, ok := err.(*openapi.ValidationError)
+		if !ok {
+			logging.FromContext(c).Errorw("middleware.openapi failed to validate a request", "err", err)
+			handler.AbortWithError(c, http.StatusBadRequest, &handler.ErrorResponse{
+				Code:    handler.InvalidBodyValue,
+				Message: "failed to read request body",
+			})
+			return
+		}
+		code := handler.InvalidBodyValue
+		switch vErr.In {
+		case openapi.InPath:
+			code = handler.InvalidUriValue
+		case openapi.InQuery:
+			code = handler.InvalidQueryValue
+		}
+		res := handler.ErrorResponse{Code: code, Message: vErr.Message}
+		if len(vErr.Details) != 0 {
+			res.Errors = vErr.Details
+		}
+		handler.AbortWithError(c, http.StatusBadRequest, &res)
+	}
+}
+
+// ValidateResponse returns a middleware reporting responses not conforming to the OpenAPI document
+// to given func. It is used in tests to catch the drift of handlers from the document.
+func ValidateResponse(spec *openapi.Spec, report func(c *gin.Context, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		if err := spec.ValidateResponse(c.Request.Method, c.FullPath(), recorder.Status(), recorder.Header().Get("Content-Type"),
+			recorder.body.Bytes()); err != nil {
+			report(c, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/openapi"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSaveItemRequest struct {
+	Item struct {
+		Name string `json:"name" binding:"required,min=3"`
+	} `json:"item"`
+}
+
+type testItemResponse struct {
+	Name string `json:"name"`
+}
+
+type testListItemsQuery struct {
+	Limit string `form:"limit,default=5" binding:"numeric"`
+}
+
+func newTestOpenAPISpec() *openapi.Spec {
+	spec := openapi.NewSpec(openapi.Info{Title: "test", Version: "v1"}, handler.Problem{})
+	spec.Add(openapi.Route{
+		Method:    http.MethodPost,
+		Path:      "/items/:name",
+		Request:   &testSaveItemRequest{},
+		Responses: map[int]interface{}{http.StatusCreated: &testItemResponse{}},
+	}, openapi.Route{
+		Method:    http.MethodGet,
+		Path:      "/items",
+		Query:     &testListItemsQuery{},
+		Responses: map[int]interface{}{http.StatusOK: &testItemResponse{}},
+	})
+	return spec
+}
+
+func TestValidateRequest(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Method string
+		Target string
+		Body   string
+		// expected
+		Status   int
+		Expected string
+	}{
+		{
+			Name:   "valid body",
+			Method: http.MethodPost, Target: "/items/item", Body: `{"item": {"name": "item1"}}`,
+			Status: http.StatusCreated,
+		},
+		{
+			Name:   "invalid body",
+			Method: http.MethodPost, Target: "/items/item", Body: `{"item": {"name": "i"}}`,
+			Status: http.StatusBadRequest,
+			Expected: `{
+				"type": "about:blank",
+				"title": "Invalid request body",
+				"status": 400,
+				"detail": "invalid request in body",
+				"code": "InvalidBodyValue",
+				"errors": [{"field": "name", "value": "i", "message": "name required at least 3 length"}]
+			}`,
+		},
+		{
+			Name:   "invalid query",
+			Method: http.MethodGet, Target: "/items?limit=a",
+			Status: http.StatusBadRequest,
+			Expected: `{
+				"type": "about:blank",
+				"title": "Invalid query parameters",
+				"status": 400,
+				"detail": "invalid query parameters",
+				"code": "InvalidQueryValue",
+				"errors": [{"field": "limit", "value": "a", "message": "limit must be numeric"}]
+			}`,
+		},
+		{
+			Name:   "not documented",
+			Method: http.MethodGet, Target: "/undocumented",
+			Status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(ValidateRequest(newTestOpenAPISpec()))
+			r.POST("/items/:name", func(c *gin.Context) {
+				// the body is bound again by handlers
+				var body testSaveItemRequest
+				assert.NoError(t, c.ShouldBindJSON(&body))
+				c.JSON(http.StatusCreated, &testItemResponse{Name: body.Item.Name})
+			})
+			r.GET("/items", func(c *gin.Context) {
+				c.JSON(http.StatusOK, &testItemResponse{})
+			})
+			r.GET("/undocumented", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.Method, tc.Target, strings.NewReader(tc.Body))
+
+			// when
+			r.ServeHTTP(res, req)
+
+			// then
+			assert.Equal(t, tc.Status, res.Code)
+			if tc.Expected != "" {
+				assert.JSONEq(t, tc.Expected, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	var errs []error
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateResponse(newTestOpenAPISpec(), func(c *gin.Context, err error) {
+		errs = append(errs, err)
+	}))
+	r.GET("/items", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"name": "item1", "unknown": true})
+	})
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/items", nil)
+	r.ServeHTTP(res, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"name": "item1", "unknown": true}`, res.Body.String())
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unknown field unknown")
+}
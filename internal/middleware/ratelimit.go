@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/metric"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RateLimitKeyIP      = "ip"
+	RateLimitKeyAccount = "account"
+	RateLimitKeyAPIKey  = "apiKey"
+
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
+
+// rateLimitStore counts requests of keys in fixed windows which are combined into a sliding window.
+type rateLimitStore interface {
+	// take increments the count of the current window of given key if the estimated count i.e.
+	// the previous count * weight + the current count is less than the limit.
+	// It returns whether the request is allowed and the estimated count including the request.
+	take(ctx context.Context, key string, window int64, weight float64, limit int, size time.Duration) (bool, int, error)
+}
+
+// RateLimiter limits requests of routes by "rateLimit.routes" configs with sliding window counters.
+// Counters are stored in the redis if the cache is redis so that they are shared between servers.
+type RateLimiter struct {
+	rules map[string]config.RateLimitRule
+	store rateLimitStore
+	mp    *metric.MetricsProvider
+	now   func() time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter or nil if the rate limit is disabled.
+func NewRateLimiter(cfg *config.Config, cacher cache.Cacher, mp *metric.MetricsProvider) (*RateLimiter, error) {
+	conf := cfg.RateLimitConfig
+	if !conf.Enabled {
+		return nil, nil
+	}
+	for route, rule := range conf.Routes {
+		switch rule.Key {
+		case RateLimitKeyIP, RateLimitKeyAccount, RateLimitKeyAPIKey:
+		default:
+			return nil, fmt.Errorf("unknown rate limit key of route %s: %s", route, rule.Key)
+		}
+		if rule.Limit <= 0 || rule.Window <= 0 {
+			return nil, fmt.Errorf("rate limit of route %s requires positive limit and window", route)
+		}
+	}
+
+	var store rateLimitStore
+	if cli, prefix, ok := cache.RedisClient(cacher); ok {
+		store = newRedisRateLimitStore(cli, prefix)
+	} else {
+		logging.DefaultLogger().Warn("rate limits are counted in the memory since the redis cache is disabled")
+		store = newMemoryRateLimitStore()
+	}
+	return &RateLimiter{
+		rules: conf.Routes,
+		store: store,
+		mp:    mp,
+		now:   time.Now,
+	}, nil
+}
+
+// RateLimit returns a middleware limiting requests by the rule of given route name.
+// Requests pass through if the limiter is nil or the route is not configured.
+// Allowed and rejected responses have RateLimit-* headers and rejected responses are 429 with Retry-After header.
+func RateLimit(l *RateLimiter, route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			return
+		}
+		rule, ok := l.rules[route]
+		if !ok {
+			return
+		}
+		ctx := c.Request.Context()
+		now := l.now()
+		window := now.UnixNano() / int64(rule.Window)
+		elapsed := time.Duration(now.UnixNano() - window*int64(rule.Window))
+		weight := 1 - float64(elapsed)/float64(rule.Window)
+		key := fmt.Sprintf("rate-limit:%s:%s", route, rateLimitKey(c, rule.Key))
+
+		allowed, count, err := l.store.take(ctx, key, window, weight, rule.Limit, rule.Window)
+		if err != nil {
+			// allow requests rather than failing them if counters are unavailable
+			logging.FromContext(ctx).Warnw("middleware.ratelimit failed to count a request", "key", key, "err", err)
+			return
+		}
+
+		remaining := rule.Limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		reset := strconv.Itoa(int(math.Ceil((rule.Window - elapsed).Seconds())))
+		c.Header(RateLimitLimitHeader, strconv.Itoa(rule.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(remaining))
+		c.Header(RateLimitResetHeader, reset)
+		if allowed {
+			return
+		}
+
+		if l.mp != nil {
+			l.mp.RecordRateLimitRejection(route, rule.Key)
+		}
+		c.Header("Retry-After", reset)
+		handler.AbortWithError(c, http.StatusTooManyRequests, &handler.ErrorResponse{
+			Code:    handler.TooManyRequests,
+			Message: "too many requests, please try again later",
+		})
+	}
+}
+
+// rateLimitKey returns the identity of the client by given key type set by SetClientIdentity.
+// Anonymous requests are identified by the client ip.
+func rateLimitKey(c *gin.Context, key string) string {
+	if key == RateLimitKeyAPIKey {
+		if id, ok := clientAPIKeyID(c); ok {
+			return fmt.Sprintf("apikey:%d", id)
+		}
+	}
+	if key == RateLimitKeyAccount || key == RateLimitKeyAPIKey {
+		if id, ok := clientAccountID(c); ok {
+			return fmt.Sprintf("account:%d", id)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// memoryRateLimitStore counts requests in the memory of the process.
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryRateLimitCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryRateLimitCounter struct {
+	window    int64
+	current   int
+	previous  int
+	expiresAt time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{
+		counters: make(map[string]*memoryRateLimitCounter),
+		now:      time.Now,
+	}
+}
+
+func (m *memoryRateLimitStore) take(_ context.Context, key string, window int64, weight float64, limit int, size time.Duration) (bool, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+
+	counter, ok := m.counters[key]
+	if !ok {
+		counter = &memoryRateLimitCounter{window: window}
+		m.counters[key] = counter
+	}
+	if counter.window != window {
+		if counter.window == window-1 {
+			counter.previous = counter.current
+		} else {
+			counter.previous = 0
+		}
+		counter.current = 0
+		counter.window = window
+	}
+	// the counter is used until the next window ends since it is the previous window of the next window.
+	counter.expiresAt = time.Unix(0, (window+2)*int64(size))
+
+	count := int(float64(counter.previous)*weight) + counter.current
+	if count >= limit {
+		return false, count, nil
+	}
+	counter.current++
+	return true, count + 1, nil
+}
+
+// sweep removes expired counters at most once a minute.
+func (m *memoryRateLimitStore) sweep() {
+	now := m.now()
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, counter := range m.counters {
+		if !now.Before(counter.expiresAt) {
+			delete(m.counters, key)
+		}
+	}
+}
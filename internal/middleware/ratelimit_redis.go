@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// rateLimitScript increments the counter of the current window if the estimated count is less than the limit.
+// KEYS[1] is the counter of the current window and KEYS[2] is the counter of the previous window.
+// ARGV[1] is the limit, ARGV[2] is the weight of the previous window and ARGV[3] is the ttl in milliseconds.
+var rateLimitScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local count = math.floor(previous * tonumber(ARGV[2])) + current
+if count >= tonumber(ARGV[1]) then
+	return {0, count}
+end
+if redis.call('INCR', KEYS[1]) == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, count + 1}
+`)
+
+// redisRateLimitStore counts requests in the redis shared between servers.
+type redisRateLimitStore struct {
+	cli    redis.UniversalClient
+	prefix string
+}
+
+func newRedisRateLimitStore(cli redis.UniversalClient, prefix string) *redisRateLimitStore {
+	return &redisRateLimitStore{cli: cli, prefix: prefix}
+}
+
+func (r *redisRateLimitStore) take(ctx context.Context, key string, window int64, weight float64, limit int, size time.Duration) (bool, int, error) {
+	// hash tag keeps counters of a key in the same slot of the redis cluster.
+	keys := []string{
+		fmt.Sprintf("%s{%s}:%d", r.prefix, key, window),
+		fmt.Sprintf("%s{%s}:%d", r.prefix, key, window-1),
+	}
+	ttl := (2 * size).Milliseconds()
+	res, err := rateLimitScript.Run(ctx, r.cli, keys, limit, weight, ttl).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	allowed, _ := values[0].(int64)
+	count, _ := values[1].(int64)
+	return allowed == 1, int(count), nil
+}
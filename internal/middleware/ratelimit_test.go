@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(t *testing.T, cacher cache.Cacher, rule config.RateLimitRule) (*RateLimiter, *time.Time) {
+	cfg, err := config.Load("")
+	assert.NoError(t, err)
+	cfg.RateLimitConfig.Routes = map[string]config.RateLimitRule{"foo": rule}
+	l, err := NewRateLimiter(cfg, cacher, nil)
+	assert.NoError(t, err)
+
+	now := time.Unix(0, 0).Add(100 * time.Minute)
+	l.now = func() time.Time {
+		return now
+	}
+	return l, &now
+}
+
+func doRateLimitRequest(r *gin.Engine, ip string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost/foo", nil)
+	req.RemoteAddr = ip + ":12345"
+	r.ServeHTTP(res, req)
+	return res
+}
+
+func testRateLimitSlidingWindow(t *testing.T, cacher cache.Cacher) {
+	l, now := newTestRateLimiter(t, cacher, config.RateLimitRule{Limit: 2, Window: time.Minute, Key: RateLimitKeyIP})
+	r := setupRouterWithHandler(func(e *gin.Engine) {
+		e.Use(RateLimit(l, "foo"))
+	}, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// allowed until the limit
+	for i := 1; i >= 0; i-- {
+		res := doRateLimitRequest(r, "10.0.0.1")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "2", res.Header().Get(RateLimitLimitHeader))
+		assert.Equal(t, strconv.Itoa(i), res.Header().Get(RateLimitRemainingHeader))
+		assert.Equal(t, "60", res.Header().Get(RateLimitResetHeader))
+	}
+
+	// rejected over the limit
+	res := doRateLimitRequest(r, "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "0", res.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "60", res.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Too many requests","status":429,"detail":"too many requests, please try again later","code":"TooManyRequests"}`, res.Body.String())
+
+	// other clients are counted separately
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.2").Code)
+
+	// the previous window weighs half in the middle of the next window
+	*now = now.Add(90 * time.Second)
+	res = doRateLimitRequest(r, "10.0.0.1")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "0", res.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "30", res.Header().Get(RateLimitResetHeader))
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitRequest(r, "10.0.0.1").Code)
+
+	// the previous window is expired after the next window
+	*now = now.Add(2 * time.Minute)
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1").Code)
+}
+
+func TestRateLimit_Memory(t *testing.T) {
+	testRateLimitSlidingWindow(t, nil)
+}
+
+func TestRateLimit_Redis(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg, _ := config.Load("")
+	cfg.CacheConfig.Enabled = true
+	cfg.CacheConfig.RedisConfig.Endpoints = []string{s.Addr()}
+	cacher, err := cache.NewCacher(cfg)
+	assert.NoError(t, err)
+	defer cacher.Close()
+
+	testRateLimitSlidingWindow(t, cacher)
+	assert.NotEmpty(t, s.Keys())
+}
+
+func TestRateLimit_AccountKey(t *testing.T) {
+	l, _ := newTestRateLimiter(t, nil, config.RateLimitRule{Limit: 1, Window: time.Minute, Key: RateLimitKeyAccount})
+	var accountID uint
+	r := setupRouterWithHandler(func(e *gin.Engine) {
+		e.Use(func(c *gin.Context) {
+			if accountID != 0 {
+				SetClientIdentity(c, accountID, 0)
+			}
+		}, RateLimit(l, "foo"))
+	}, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	accountID = 1
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitRequest(r, "10.0.0.2").Code)
+	accountID = 2
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1").Code)
+	// anonymous requests are counted by the client ip
+	accountID = 0
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitRequest(r, "10.0.0.1").Code)
+}
+
+func TestRateLimit_PassThrough(t *testing.T) {
+	l, _ := newTestRateLimiter(t, nil, config.RateLimitRule{Limit: 1, Window: time.Minute, Key: RateLimitKeyIP})
+	cases := []struct {
+		Name    string
+		Limiter *RateLimiter
+		Route   string
+	}{
+		{Name: "nil limiter", Limiter: nil, Route: "foo"},
+		{Name: "unknown route", Limiter: l, Route: "bar"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := setupRouterWithHandler(func(e *gin.Engine) {
+				e.Use(RateLimit(tc.Limiter, tc.Route))
+			}, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			for i := 0; i < 3; i++ {
+				res := doRateLimitRequest(r, "10.0.0.1")
+				assert.Equal(t, http.StatusOK, res.Code)
+				assert.Empty(t, res.Header().Get(RateLimitLimitHeader))
+			}
+		})
+	}
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	cfg, _ := config.Load("")
+	cfg.RateLimitConfig.Enabled = false
+	l, err := NewRateLimiter(cfg, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+
+	cfg.RateLimitConfig.Enabled = true
+	cfg.RateLimitConfig.Routes = map[string]config.RateLimitRule{
+		"foo": {Limit: 1, Window: time.Minute, Key: "email"},
+	}
+	_, err = NewRateLimiter(cfg, nil, nil)
+	assert.EqualError(t, err, "unknown rate limit key of route foo: email")
+
+	cfg.RateLimitConfig.Routes = map[string]config.RateLimitRule{
+		"foo": {Limit: 0, Window: time.Minute, Key: RateLimitKeyIP},
+	}
+	_, err = NewRateLimiter(cfg, nil, nil)
+	assert.EqualError(t, err, "rate limit of route foo requires positive limit and window")
+}
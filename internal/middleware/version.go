@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"fmt"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/pkg/logging"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MediaTypeV2 = "application/vnd.article.v2+json" // media type of v2 apis negotiated by Accept header
+
+	pathPrefixV1 = "/v1/api/"
+	pathPrefixV2 = "/v2/api/"
+)
+
+// NegotiateVersion returns a http.Handler of given engine serving requests of v1 apis accepting MediaTypeV2
+// by v2 apis of the same path under /v2/api if registered, otherwise as is.
+// Responses of v1 apis vary by Accept header.
+func NegotiateVersion(r *gin.Engine) http.Handler {
+	var (
+		once   sync.Once
+		routes map[string][][]string
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, pathPrefixV1) {
+			r.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+		if !acceptsV2(req.Header.Get("Accept")) {
+			r.ServeHTTP(w, req)
+			return
+		}
+		// routes are registered before serving requests
+		once.Do(func() {
+			routes = make(map[string][][]string)
+			for _, route := range r.Routes() {
+				if strings.HasPrefix(route.Path, pathPrefixV2) {
+					routes[route.Method] = append(routes[route.Method], strings.Split(strings.Trim(route.Path, "/"), "/"))
+				}
+			}
+		})
+		path := pathPrefixV2 + strings.TrimPrefix(req.URL.Path, pathPrefixV1)
+		if !matchRoute(routes[req.Method], path) {
+			r.ServeHTTP(w, req)
+			return
+		}
+		// rewrite the copy of the request like http.StripPrefix
+		rewritten := new(http.Request)
+		*rewritten = *req
+		rewritten.URL = new(url.URL)
+		*rewritten.URL = *req.URL
+		rewritten.URL.Path = path
+		rewritten.URL.RawPath = ""
+		r.ServeHTTP(w, rewritten)
+	})
+}
+
+// acceptsV2 returns true if given Accept header has MediaTypeV2 with non zero quality.
+func acceptsV2(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || mediaType != MediaTypeV2 {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			return err == nil && quality > 0
+		}
+		return true
+	}
+	return false
+}
+
+// matchRoute returns true if given path is matched by one of route patterns split by "/".
+func matchRoute(patterns [][]string, path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, pattern := range patterns {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "*") {
+			return true
+		}
+		if i >= len(segments) || segments[i] == "" {
+			return false
+		}
+		if !strings.HasPrefix(p, ":") && p != segments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segments)
+}
+
+// Deprecated returns a middleware adding Deprecation and Sunset headers of "server.v1" configs and
+// the Link header of the successor version under /v2/api to responses of deprecated v1 apis.
+// The Deprecation header is a unix timestamp of RFC 9745 and the Sunset header is a HTTP date of RFC 8594.
+func Deprecated(cfg *config.Config) gin.HandlerFunc {
+	deprecation := parseDate("server.v1.deprecation", cfg.ServerConfig.V1.Deprecation)
+	sunset := parseDate("server.v1.sunset", cfg.ServerConfig.V1.Sunset)
+	return func(c *gin.Context) {
+		if !deprecation.IsZero() {
+			c.Header("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+		}
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if strings.HasPrefix(c.Request.URL.Path, pathPrefixV1) {
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, pathPrefixV2+strings.TrimPrefix(c.Request.URL.Path, pathPrefixV1)))
+		}
+	}
+}
+
+// parseDate returns the time of given RFC 3339 date or zero time if the date is empty or invalid.
+func parseDate(key, date string) time.Time {
+	if date == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		logging.DefaultLogger().Warnw("middleware.version ignores an invalid date", "key", key, "date", date, "err", err)
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"gin-rest-api-example/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/api/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "v1 "+c.Param("id"))
+	})
+	r.GET("/v1/api/users", func(c *gin.Context) {
+		c.String(http.StatusOK, "v1 users")
+	})
+	r.GET("/v2/api/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "v2 "+c.Param("id"))
+	})
+	h := NegotiateVersion(r)
+
+	cases := []struct {
+		Name   string
+		Target string
+		Accept string
+		// expected
+		Body string
+		Vary string
+	}{
+		{Name: "v1", Target: "/v1/api/items/1", Accept: "application/json", Body: "v1 1", Vary: "Accept"},
+		{Name: "v1 by path", Target: "/v1/api/items/1", Body: "v1 1", Vary: "Accept"},
+		{Name: "v2 by path", Target: "/v2/api/items/1", Accept: MediaTypeV2, Body: "v2 1"},
+		{Name: "v2 by accept", Target: "/v1/api/items/1", Accept: MediaTypeV2, Body: "v2 1", Vary: "Accept"},
+		{Name: "v2 in media ranges", Target: "/v1/api/items/1", Accept: "application/json;q=0.5, " + MediaTypeV2 + ";q=0.9", Body: "v2 1", Vary: "Accept"},
+		{Name: "v2 not acceptable", Target: "/v1/api/items/1", Accept: MediaTypeV2 + ";q=0", Body: "v1 1", Vary: "Accept"},
+		{Name: "v2 not exist", Target: "/v1/api/users", Accept: MediaTypeV2, Body: "v1 users", Vary: "Accept"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.Target, nil)
+			if tc.Accept != "" {
+				req.Header.Set("Accept", tc.Accept)
+			}
+
+			// when
+			h.ServeHTTP(res, req)
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.Equal(t, tc.Body, res.Body.String())
+			assert.Equal(t, tc.Vary, res.Header().Get("Vary"))
+			assert.Equal(t, tc.Target, req.URL.Path)
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ServerConfig.V1.Deprecation = "2026-11-01T00:00:00Z"
+	cfg.ServerConfig.V1.Sunset = "2027-05-01T09:00:00+09:00"
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/api/items/:id", Deprecated(cfg), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/api/items/1", nil)
+	r.ServeHTTP(res, req)
+
+	// then
+	assert.Equal(t, "@1793491200", res.Header().Get("Deprecation"))
+	assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", res.Header().Get("Sunset"))
+	assert.Equal(t, `</v2/api/items/1>; rel="successor-version"`, res.Header().Get("Link"))
+}
+
+func TestDeprecated_EmptyDates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/api/items/:id", Deprecated(&config.Config{}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// when
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/api/items/1", nil)
+	r.ServeHTTP(res, req)
+
+	// then
+	assert.Empty(t, res.Header().Get("Deprecation"))
+	assert.Empty(t, res.Header().Get("Sunset"))
+}
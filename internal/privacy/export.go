@@ -0,0 +1,200 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/internal/account"
+	accountModel "gin-rest-api-example/internal/account/model"
+	"gin-rest-api-example/internal/article"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"io"
+	"time"
+)
+
+// Formats of exported archives
+const (
+	FormatZip  = "zip"
+	FormatJSON = "json"
+)
+
+const exportPageSize = 100
+
+// Account is personal data of an account in exported archives.
+type Account struct {
+	Username      string           `json:"username"`
+	Email         string           `json:"email"`
+	Bio           string           `json:"bio"`
+	Image         string           `json:"image"`
+	Role          string           `json:"role"`
+	EmailVerified bool             `json:"emailVerified"`
+	MFAEnabled    bool             `json:"mfaEnabled"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
+	Identities    []Identity       `json:"identities"`
+	APIKeys       []account.APIKey `json:"apiKeys"`
+}
+
+// Identity is an identity of an external provider linked to the account.
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Comment is a comment written by the account with the slug of the commented article.
+type Comment struct {
+	ID        uint      `json:"id"`
+	Slug      string    `json:"slug"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func newAccount(acc *accountModel.Account, identities []*accountModel.AccountIdentity, keys []*accountModel.APIKey) *Account {
+	ret := Account{
+		Username:      acc.Username,
+		Email:         acc.Email,
+		Bio:           acc.Bio,
+		Image:         acc.Image,
+		Role:          acc.Role,
+		EmailVerified: acc.EmailVerified,
+		MFAEnabled:    acc.MFAEnabled,
+		CreatedAt:     acc.CreatedAt,
+		UpdatedAt:     acc.UpdatedAt,
+		Identities:    []Identity{},
+		APIKeys:       account.NewAPIKeysResponse(keys).APIKeys,
+	}
+	for _, identity := range identities {
+		ret.Identities = append(ret.Identities, Identity{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	return &ret
+}
+
+// exporter writes personal data of an account to an archive.
+// Articles are read page by page, so the archive is streamed without loading all articles.
+type exporter struct {
+	articleDB articleDB.ArticleDB
+	account   *Account
+	accountID uint
+}
+
+// writeZip writes account.json, articles.json and comments.json files in a zip archive to w.
+func (e *exporter) writeZip(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		write func(ctx context.Context, w io.Writer) error
+	}{
+		{name: "account.json", write: e.writeAccount},
+		{name: "articles.json", write: e.writeArticles},
+		{name: "comments.json", write: e.writeComments},
+	}
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := file.write(ctx, fw); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// writeJSON writes a json document of account, articles and comments to w.
+func (e *exporter) writeJSON(ctx context.Context, w io.Writer) error {
+	sections := []struct {
+		prefix string
+		write  func(ctx context.Context, w io.Writer) error
+	}{
+		{prefix: `{"account":`, write: e.writeAccount},
+		{prefix: `,"articles":`, write: e.writeArticles},
+		{prefix: `,"comments":`, write: e.writeComments},
+	}
+	for _, section := range sections {
+		if _, err := io.WriteString(w, section.prefix); err != nil {
+			return err
+		}
+		if err := section.write(ctx, w); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}")
+	return err
+}
+
+func (e *exporter) writeAccount(_ context.Context, w io.Writer) error {
+	return writeElement(w, 0, e.account)
+}
+
+func (e *exporter) writeArticles(ctx context.Context, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	written := 0
+	for offset := uint(0); ; offset += exportPageSize {
+		articles, _, err := e.articleDB.FindArticles(ctx, articleDB.IterateArticleCriteria{
+			AuthorID: e.accountID,
+			Offset:   offset,
+			Limit:    exportPageSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, a := range articles {
+			if err := writeElement(w, written, article.NewArticleResponse(a).Article); err != nil {
+				return err
+			}
+			written++
+		}
+		if len(articles) < exportPageSize {
+			break
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+func (e *exporter) writeComments(ctx context.Context, w io.Writer) error {
+	comments, err := e.articleDB.FindCommentsByAuthor(ctx, e.accountID)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, comment := range comments {
+		c := Comment{
+			ID:        comment.ID,
+			Slug:      comment.Slug,
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		}
+		if err := writeElement(w, i, &c); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// writeElement writes a json array element with a separator if not the first element.
+func writeElement(w io.Writer, index int, v interface{}) error {
+	if index > 0 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
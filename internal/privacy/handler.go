@@ -0,0 +1,236 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+	"gin-rest-api-example/internal/account"
+	accountDB "gin-rest-api-example/internal/account/database"
+	"gin-rest-api-example/internal/account/model"
+	articleDB "gin-rest-api-example/internal/article/database"
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/cache"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/middleware"
+	"gin-rest-api-example/internal/middleware/handler"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// Content policies of deleted accounts
+const (
+	ContentPolicyReassign = "reassign"
+	ContentPolicyDelete   = "delete"
+)
+
+// Handler exports personal data of the current user and deletes the account on request.
+type Handler struct {
+	cfg       *config.Config
+	accountDB accountDB.AccountDB
+	articleDB articleDB.ArticleDB
+	auth      *account.AuthMiddleware
+	auditor   *audit.Auditor
+}
+
+func NewHandler(cfg *config.Config, accountDB accountDB.AccountDB, articleDB articleDB.ArticleDB, auth *account.AuthMiddleware,
+	auditor *audit.Auditor) (*Handler, error) {
+	switch cfg.AccountConfig.Deletion.ContentPolicy {
+	case ContentPolicyReassign, ContentPolicyDelete:
+	default:
+		return nil, fmt.Errorf("unsupported content policy of deleted accounts: %s", cfg.AccountConfig.Deletion.ContentPolicy)
+	}
+	return &Handler{
+		cfg:       cfg,
+		accountDB: accountDB,
+		articleDB: articleDB,
+		auth:      auth,
+		auditor:   auditor,
+	}, nil
+}
+
+// exportUser handles GET /v1/api/user/export
+func (h *Handler) exportUser(c *gin.Context) {
+	logger := logging.FromContext(c)
+	type QueryParameter struct {
+		Format string `form:"format,default=zip" binding:"oneof=zip json"`
+	}
+	var query QueryParameter
+	if res := handler.Bind(c, nil, &query, nil); res != nil {
+		respond(c, res)
+		return
+	}
+
+	ctx := c.Request.Context()
+	acc, res := h.findCurrentAccount(c)
+	if res != nil {
+		respond(c, res)
+		return
+	}
+	identities, err := h.accountDB.FindIdentities(ctx, acc.ID)
+	if err != nil {
+		logger.Errorw("privacy.handler.exportUser failed to find identities", "err", err)
+		respond(c, handler.NewInternalErrorResponse(err))
+		return
+	}
+	keys, err := h.accountDB.FindAPIKeys(ctx, acc.ID)
+	if err != nil {
+		logger.Errorw("privacy.handler.exportUser failed to find api keys", "err", err)
+		respond(c, handler.NewInternalErrorResponse(err))
+		return
+	}
+	e := exporter{
+		articleDB: h.articleDB,
+		account:   newAccount(acc, identities, keys),
+		accountID: acc.ID,
+	}
+
+	// the status code is written with the first bytes, so errors while streaming only abort the response
+	write := e.writeZip
+	contentType := "application/zip"
+	if query.Format == FormatJSON {
+		write = e.writeJSON
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-export.%s"`, acc.Username, query.Format))
+	c.Status(http.StatusOK)
+	if err := write(ctx, c.Writer); err != nil {
+		logger.Errorw("privacy.handler.exportUser failed to write an archive", "err", err)
+		c.Abort()
+	}
+}
+
+// deleteUser handles DELETE /v1/api/user
+func (h *Handler) deleteUser(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		logger := logging.FromContext(c)
+		type RequestBody struct {
+			User struct {
+				Password string `json:"password"`
+				Code     string `json:"code"`
+			} `json:"user"`
+		}
+		var body RequestBody
+		if res := handler.Bind(c, nil, nil, &body); res != nil {
+			return res
+		}
+		acc, res := h.findCurrentAccount(c)
+		if res != nil {
+			return res
+		}
+		if res := h.reauthenticate(c, acc, body.User.Password, body.User.Code); res != nil {
+			return res
+		}
+
+		err := h.accountDB.RunInTx(c.Request.Context(), func(ctx context.Context) error {
+			if err := h.deleteContents(ctx, acc); err != nil {
+				return err
+			}
+			return h.accountDB.Anonymize(ctx, acc.ID)
+		})
+		if err != nil {
+			logger.Errorw("privacy.handler.deleteUser failed to delete the account", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		h.auditor.Record(c, audit.Event{
+			Action:     audit.ActionAccountDelete,
+			ActorID:    acc.ID,
+			TargetType: audit.TargetAccount,
+			TargetID:   strconv.FormatUint(uint64(acc.ID), 10),
+			Changes:    audit.Changes{"disabled": {Before: false, After: true}},
+		})
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+// reauthenticate returns an error response unless the current user is re-authenticated by the password,
+// a two-factor code or a login within "account.deletion.recentLogin", so that accounts without passwords
+// e.g. accounts of OAuth logins can be deleted too.
+func (h *Handler) reauthenticate(c *gin.Context, acc *model.Account, password, code string) *handler.Response {
+	switch {
+	case password != "":
+		if err := account.MatchesPassword(acc.Password, password); err != nil {
+			details := validate.NewValidationErrorDetails("password", "password does not match", "")
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid delete user request in body", details)
+		}
+		return nil
+	case code != "":
+		if !acc.MFAEnabled {
+			details := validate.NewValidationErrorDetails("code", "two-factor authentication is not enabled", "")
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid delete user request in body", details)
+		}
+		ok, err := h.auth.VerifyMFACode(c.Request.Context(), acc, code)
+		if err != nil {
+			logging.FromContext(c).Errorw("privacy.handler.deleteUser failed to verify a code", "err", err)
+			return handler.NewInternalErrorResponse(err)
+		}
+		if !ok {
+			return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidMFACode, account.ErrInvalidMFACode.Error(), nil)
+		}
+		return nil
+	}
+	recentLogin := h.cfg.AccountConfig.Deletion.RecentLogin
+	if authTime, ok := account.AuthTime(c); ok && recentLogin > 0 && time.Since(authTime) <= recentLogin {
+		return nil
+	}
+	details := validate.NewValidationErrorDetails("password", "required password or code unless logged in recently", "")
+	return handler.NewErrorResponse(http.StatusBadRequest, handler.InvalidBodyValue, "invalid delete user request in body", details)
+}
+
+// deleteContents reassigns articles and comments of given account to the ghost account
+// or deletes them depending on the content policy.
+func (h *Handler) deleteContents(ctx context.Context, acc *model.Account) error {
+	conf := h.cfg.AccountConfig.Deletion
+	if conf.ContentPolicy == ContentPolicyDelete {
+		if _, err := h.articleDB.DeleteArticlesByAuthor(ctx, acc.ID); err != nil {
+			return err
+		}
+		_, err := h.articleDB.DeleteCommentsByAuthor(ctx, acc.ID)
+		return err
+	}
+
+	ghost, err := h.accountDB.FindByEmail(cache.WithCacheSkip(ctx, true), conf.GhostEmail)
+	if err != nil {
+		return errors.Wrap(err, "find ghost account")
+	}
+	return h.articleDB.ReassignAuthor(ctx, acc.ID, ghost.ID)
+}
+
+// findCurrentAccount returns the current account including the password from database.
+func (h *Handler) findCurrentAccount(c *gin.Context) (*model.Account, *handler.Response) {
+	currentUser := account.MustCurrentUser(c)
+	ctx := cache.WithCacheSkip(c.Request.Context(), true)
+	acc, err := h.accountDB.FindByEmail(ctx, currentUser.Email)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, handler.NewErrorResponse(http.StatusNotFound, handler.NotFoundEntity, "not found current user", nil)
+		}
+		return nil, handler.NewInternalErrorResponse(err)
+	}
+	return acc, nil
+}
+
+func respond(c *gin.Context, res *handler.Response) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		return res
+	})
+}
+
+// RouteV1 routes privacy api of the current user given config and gin.Engine.
+// Personal data is exported and the account is deleted only with access tokens.
+func RouteV1(cfg *config.Config, h *Handler, r *gin.Engine, auth *account.AuthMiddleware) {
+	v1 := r.Group("v1/api")
+	v1.Use(middleware.RequestIDMiddleware(), middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
+
+	userV1 := v1.Group("user", auth.MiddlewareFunc(), account.RejectAPIKey())
+	{
+		userV1.GET("export", h.exportUser)
+		userV1.DELETE("", h.deleteUser)
+	}
+}
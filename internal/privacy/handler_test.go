@@ -0,0 +1,379 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/internal/account"
+	accountDBMock "gin-rest-api-example/internal/account/database/mocks"
+	accountModel "gin-rest-api-example/internal/account/model"
+	articleDB "gin-rest-api-example/internal/article/database"
+	articleDBMock "gin-rest-api-example/internal/article/database/mocks"
+	"gin-rest-api-example/internal/article/model"
+	"gin-rest-api-example/internal/audit"
+	"gin-rest-api-example/internal/config"
+	"gin-rest-api-example/internal/database"
+	"gin-rest-api-example/internal/mailer"
+	"gin-rest-api-example/pkg/logging"
+	"gin-rest-api-example/pkg/validate"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	dUser = accountModel.Account{
+		ID:       1,
+		Username: "user1",
+		Email:    "user1@gmail.com",
+		Password: "$2a$10$lsYsLv8nGPM0.R.ft4sgpe3OP7..KL3ZJqqhSVCKTEnSCMUztoUcW",
+		Bio:      "I am working!",
+	}
+	dUserRawPass = "user1"
+
+	dGhost = accountModel.Account{
+		ID:       2,
+		Username: "ghost",
+		Email:    "ghost@article-server.local",
+		Disabled: true,
+	}
+
+	dArticle = model.Article{
+		ID:        1,
+		Slug:      "how-to-train-your-dragon",
+		Title:     "How to train your dragon",
+		Body:      "You have to believe",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Author:    dUser,
+		AuthorID:  dUser.ID,
+		Tags:      []*model.Tag{{ID: 1, Name: "dragons"}},
+	}
+	dComment = model.Comment{
+		ID:        1,
+		Body:      "Thank you so much!",
+		Slug:      "how-to-train-your-dragon",
+		AuthorID:  dUser.ID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+)
+
+type HandlerSuite struct {
+	suite.Suite
+	cfg       *config.Config
+	r         *gin.Engine
+	db        *articleDBMock.ArticleDB
+	accountDB *accountDBMock.AccountDB
+	auth      *account.AuthMiddleware
+	audits    *bytes.Buffer
+	auditor   *audit.Auditor
+}
+
+func (s *HandlerSuite) SetupSuite() {
+	logging.SetLevel(zapcore.FatalLevel)
+	s.NoError(validate.SetupBinding())
+}
+
+func (s *HandlerSuite) SetupTest() {
+	cfg, err := config.Load("")
+	s.NoError(err)
+	s.cfg = cfg
+	s.audits = &bytes.Buffer{}
+	s.auditor = audit.New(audit.NewWriterSink(s.audits))
+
+	s.db = &articleDBMock.ArticleDB{}
+	s.accountDB = &accountDBMock.AccountDB{}
+	s.accountDB.On("FindByEmail", mock.Anything, dUser.Email).Return(&dUser, nil)
+	s.accountDB.On("FindByID", mock.Anything, dUser.ID).Return(&dUser, nil)
+	s.accountDB.On("FindByEmail", mock.Anything, dGhost.Email).Return(&dGhost, nil)
+	s.accountDB.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, f func(context.Context) error) error {
+		return f(ctx)
+	})
+	s.setupRouter()
+}
+
+func (s *HandlerSuite) setupRouter() {
+	jwtMiddleware, err := account.NewAuthMiddleware(s.cfg, s.accountDB, nil, nil, s.auditor)
+	s.NoError(err)
+	s.auth = jwtMiddleware
+
+	gin.SetMode(gin.TestMode)
+	s.r = gin.Default()
+
+	h, err := NewHandler(s.cfg, s.accountDB, s.db, jwtMiddleware, s.auditor)
+	s.NoError(err)
+	RouteV1(s.cfg, h, s.r, jwtMiddleware)
+
+	policy, err := account.NewPasswordPolicy(s.cfg)
+	s.NoError(err)
+	accountHandler := account.NewHandler(s.cfg, s.accountDB, jwtMiddleware, mailer.NewWriterSender(s.cfg.MailConfig.From, ioutil.Discard), nil, policy)
+	account.RouteV1(s.cfg, accountHandler, s.r, jwtMiddleware, nil, nil)
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(HandlerSuite))
+}
+
+func (s *HandlerSuite) TestNewHandler_UnsupportedContentPolicy() {
+	s.cfg.AccountConfig.Deletion.ContentPolicy = "keep"
+
+	_, err := NewHandler(s.cfg, s.accountDB, s.db, nil, s.auditor)
+
+	s.Error(err)
+}
+
+func (s *HandlerSuite) TestExportUser() {
+	// given
+	s.mockExport()
+
+	// when
+	res := s.doRequest("GET", "/v1/api/user/export", s.getBearerToken(), nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("application/zip", res.Header().Get("Content-Type"))
+	s.Equal(`attachment; filename="user1-export.zip"`, res.Header().Get("Content-Disposition"))
+
+	zr, err := zip.NewReader(bytes.NewReader(res.Body.Bytes()), int64(res.Body.Len()))
+	s.NoError(err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		s.NoError(err)
+		b, err := ioutil.ReadAll(r)
+		s.NoError(err)
+		r.Close()
+		s.True(json.Valid(b), f.Name)
+		files[f.Name] = string(b)
+	}
+	s.Len(files, 3)
+	s.assertAccount(gjson.Parse(files["account.json"]))
+	s.assertArticles(gjson.Parse(files["articles.json"]))
+	s.assertComments(gjson.Parse(files["comments.json"]))
+}
+
+func (s *HandlerSuite) TestExportUser_JSON() {
+	// given
+	s.mockExport()
+
+	// when
+	res := s.doRequest("GET", "/v1/api/user/export?format=json", s.getBearerToken(), nil)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("application/json; charset=utf-8", res.Header().Get("Content-Type"))
+	s.True(json.Valid(res.Body.Bytes()))
+	result := gjson.Parse(res.Body.String())
+	s.assertAccount(result.Get("account"))
+	s.assertArticles(result.Get("articles"))
+	s.assertComments(result.Get("comments"))
+	s.db.AssertCalled(s.T(), "FindArticles", mock.Anything, mock.MatchedBy(func(criteria articleDB.IterateArticleCriteria) bool {
+		return criteria.AuthorID == dUser.ID && criteria.Offset == 0 && criteria.Limit == exportPageSize
+	}))
+}
+
+func (s *HandlerSuite) TestExportUser_InvalidFormat() {
+	// when
+	res := s.doRequest("GET", "/v1/api/user/export?format=xml", s.getBearerToken(), nil)
+
+	// then
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("InvalidQueryValue", gjson.Get(res.Body.String(), "code").String())
+}
+
+func (s *HandlerSuite) TestExportUser_Unauthorized() {
+	// when
+	res := s.doRequest("GET", "/v1/api/user/export", "", nil)
+
+	// then
+	s.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (s *HandlerSuite) TestDeleteUser_Reassign() {
+	// given
+	s.db.On("ReassignAuthor", mock.Anything, dUser.ID, dGhost.ID).Return(nil)
+	s.accountDB.On("Anonymize", mock.Anything, dUser.ID).Return(nil)
+	token := s.getBearerToken()
+
+	// when
+	res := s.doRequest("DELETE", "/v1/api/user", token, deleteUserBody(dUserRawPass))
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "ReassignAuthor", mock.Anything, dUser.ID, dGhost.ID)
+	s.db.AssertNotCalled(s.T(), "DeleteArticlesByAuthor", mock.Anything, mock.Anything)
+	s.accountDB.AssertCalled(s.T(), "Anonymize", mock.Anything, dUser.ID)
+	event := gjson.Get(s.audits.String(), `..#(action=="account.delete")`)
+	s.Equal(int64(dUser.ID), event.Get("actorId").Int())
+	s.True(event.Get("changes.disabled.after").Bool())
+}
+
+func (s *HandlerSuite) TestDeleteUser_Delete() {
+	// given
+	s.cfg.AccountConfig.Deletion.ContentPolicy = ContentPolicyDelete
+	s.setupRouter()
+	s.db.On("DeleteArticlesByAuthor", mock.Anything, dUser.ID).Return(int64(1), nil)
+	s.db.On("DeleteCommentsByAuthor", mock.Anything, dUser.ID).Return(int64(2), nil)
+	s.accountDB.On("Anonymize", mock.Anything, dUser.ID).Return(nil)
+	token := s.getBearerToken()
+
+	// when
+	res := s.doRequest("DELETE", "/v1/api/user", token, deleteUserBody(dUserRawPass))
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.db.AssertCalled(s.T(), "DeleteArticlesByAuthor", mock.Anything, dUser.ID)
+	s.db.AssertCalled(s.T(), "DeleteCommentsByAuthor", mock.Anything, dUser.ID)
+	s.db.AssertNotCalled(s.T(), "ReassignAuthor", mock.Anything, mock.Anything, mock.Anything)
+	s.accountDB.AssertCalled(s.T(), "Anonymize", mock.Anything, dUser.ID)
+}
+
+func (s *HandlerSuite) TestDeleteUser_WrongPassword() {
+	// given
+	token := s.getBearerToken()
+
+	// when
+	res := s.doRequest("DELETE", "/v1/api/user", token, deleteUserBody(dUserRawPass+"wrong"))
+
+	// then
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("InvalidBodyValue", gjson.Get(res.Body.String(), "code").String())
+	s.accountDB.AssertNotCalled(s.T(), "Anonymize", mock.Anything, mock.Anything)
+	s.NotContains(s.audits.String(), "account.delete")
+}
+
+func (s *HandlerSuite) TestDeleteUser_MissingPassword() {
+	// given
+	s.cfg.AccountConfig.Deletion.RecentLogin = 0
+	s.setupRouter()
+	token := s.getBearerToken()
+
+	// when
+	res := s.doRequest("DELETE", "/v1/api/user", token, map[string]interface{}{})
+
+	// then
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("password", gjson.Get(res.Body.String(), "errors.0.field").String())
+	s.accountDB.AssertNotCalled(s.T(), "Anonymize", mock.Anything, mock.Anything)
+}
+
+func (s *HandlerSuite) TestDeleteUser_RecentLogin() {
+	// given
+	s.db.On("ReassignAuthor", mock.Anything, dUser.ID, dGhost.ID).Return(nil)
+	s.accountDB.On("Anonymize", mock.Anything, dUser.ID).Return(nil)
+	token := s.getBearerToken()
+
+	// when
+	res := s.doRequest("DELETE", "/v1/api/user", token, map[string]interface{}{})
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.accountDB.AssertCalled(s.T(), "Anonymize", mock.Anything, dUser.ID)
+}
+
+func (s *HandlerSuite) TestDeleteUser_MFACode() {
+	// given: an account of an OAuth login without the password
+	s.cfg.AccountConfig.Deletion.RecentLogin = 0
+	s.setupRouter()
+	acc := accountModel.Account{ID: 3, Username: "user3", Email: "user3@gmail.com", MFAEnabled: true}
+	s.accountDB.On("FindByEmail", mock.Anything, acc.Email).Return(&acc, nil)
+	s.accountDB.On("FindByID", mock.Anything, acc.ID).Return(&acc, nil)
+	s.accountDB.On("UseRecoveryCode", mock.Anything, acc.ID, mock.Anything).Return(nil).Once()
+	s.accountDB.On("UseRecoveryCode", mock.Anything, acc.ID, mock.Anything).Return(database.ErrNotFound)
+	s.db.On("ReassignAuthor", mock.Anything, acc.ID, dGhost.ID).Return(nil)
+	s.accountDB.On("Anonymize", mock.Anything, acc.ID).Return(nil).Once()
+	token, _, err := s.auth.TokenGenerator(&acc)
+	s.NoError(err)
+	body := map[string]interface{}{"user": map[string]interface{}{"code": "abcde-fghij"}}
+
+	// when
+	res := s.doRequest("DELETE", "/v1/api/user", token, body)
+
+	// then
+	s.Equal(http.StatusOK, res.Code)
+	s.accountDB.AssertCalled(s.T(), "Anonymize", mock.Anything, acc.ID)
+
+	// when then: the used code is rejected
+	res = s.doRequest("DELETE", "/v1/api/user", token, body)
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("InvalidMFACode", gjson.Get(res.Body.String(), "code").String())
+}
+
+func (s *HandlerSuite) mockExport() {
+	s.accountDB.On("FindIdentities", mock.Anything, dUser.ID).Return([]*accountModel.AccountIdentity{
+		{ID: 1, AccountID: dUser.ID, Provider: "github", Subject: "1234", Email: dUser.Email},
+	}, nil)
+	s.accountDB.On("FindAPIKeys", mock.Anything, dUser.ID).Return([]*accountModel.APIKey{
+		{ID: 1, AccountID: dUser.ID, Name: "ci", Prefix: "abcd1234", KeyHash: "hash", Scopes: "article:write"},
+	}, nil)
+	s.db.On("FindArticles", mock.Anything, mock.Anything).Return([]*model.Article{&dArticle}, int64(1), nil)
+	s.db.On("FindCommentsByAuthor", mock.Anything, dUser.ID).Return([]*model.Comment{&dComment}, nil)
+}
+
+func (s *HandlerSuite) assertAccount(result gjson.Result) {
+	s.Equal(dUser.Username, result.Get("username").String())
+	s.Equal(dUser.Email, result.Get("email").String())
+	s.Equal(dUser.Bio, result.Get("bio").String())
+	s.False(result.Get("password").Exists())
+	s.Equal("github", result.Get("identities.0.provider").String())
+	s.False(result.Get("identities.0.subject").Exists())
+	s.Equal("ci", result.Get("apiKeys.0.name").String())
+	s.False(result.Get("apiKeys.0.key").Exists())
+}
+
+func (s *HandlerSuite) assertArticles(result gjson.Result) {
+	s.Len(result.Array(), 1)
+	s.Equal(dArticle.Slug, result.Get("0.slug").String())
+	s.Equal(dArticle.Body, result.Get("0.body").String())
+	s.Equal("dragons", result.Get("0.tagList.0").String())
+}
+
+func (s *HandlerSuite) assertComments(result gjson.Result) {
+	s.Len(result.Array(), 1)
+	s.Equal(dComment.Slug, result.Get("0.slug").String())
+	s.Equal(dComment.Body, result.Get("0.body").String())
+}
+
+func (s *HandlerSuite) doRequest(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	s.r.ServeHTTP(res, req)
+	return res
+}
+
+func (s *HandlerSuite) getBearerToken() string {
+	body := map[string]interface{}{
+		"user": map[string]interface{}{
+			"email":    dUser.Email,
+			"password": dUserRawPass,
+		},
+	}
+	res := s.doRequest("POST", "/v1/api/users/login", "", body)
+	s.Equal(http.StatusOK, res.Code)
+	return gjson.Get(res.Body.String(), "token").String()
+}
+
+func deleteUserBody(password string) map[string]interface{} {
+	return map[string]interface{}{
+		"user": map[string]interface{}{
+			"password": password,
+		},
+	}
+}
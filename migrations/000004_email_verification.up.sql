@@ -0,0 +1,3 @@
+-- existing accounts are regarded as verified accounts
+ALTER TABLE accounts ADD COLUMN email_verified tinyint(1) DEFAULT '0';
+UPDATE accounts SET email_verified = 1;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS account_recovery_codes;
+ALTER TABLE accounts DROP COLUMN mfa_enabled;
+ALTER TABLE accounts DROP COLUMN mfa_secret;
+ALTER TABLE accounts DROP COLUMN role;
@@ -0,0 +1,14 @@
+ALTER TABLE accounts ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'user';
+ALTER TABLE accounts ADD COLUMN mfa_secret VARCHAR(255) NULL;
+ALTER TABLE accounts ADD COLUMN mfa_enabled tinyint(1) DEFAULT '0';
+UPDATE accounts SET role = 'admin' WHERE email = 'admin@email.com';
+
+CREATE TABLE account_recovery_codes (
+    id         INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    account_id INT UNSIGNED NOT NULL,
+    code_hash  VARCHAR(64)  NOT NULL,
+    used_at    DATETIME     NULL,
+    created_at DATETIME     NULL,
+    UNIQUE KEY unique_account_recovery_codes_hash (account_id, code_hash),
+    CONSTRAINT account_recovery_codes_account_id_fk FOREIGN KEY (account_id) REFERENCES accounts(id)
+) CHARACTER SET utf8mb4;
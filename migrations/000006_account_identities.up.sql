@@ -0,0 +1,11 @@
+CREATE TABLE account_identities (
+    id         INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    account_id INT UNSIGNED NOT NULL,
+    provider   VARCHAR(64)  NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NULL,
+    created_at DATETIME     NULL,
+    updated_at DATETIME     NULL,
+    UNIQUE KEY unique_account_identities_provider_subject (provider, subject),
+    CONSTRAINT account_identities_account_id_fk FOREIGN KEY (account_id) REFERENCES accounts(id)
+) CHARACTER SET utf8mb4;
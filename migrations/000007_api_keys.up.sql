@@ -0,0 +1,15 @@
+CREATE TABLE api_keys (
+    id           INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    account_id   INT UNSIGNED  NOT NULL,
+    name         VARCHAR(255)  NOT NULL,
+    prefix       VARCHAR(16)   NOT NULL,
+    key_hash     VARCHAR(64)   NOT NULL,
+    scopes       VARCHAR(1024) NOT NULL,
+    expires_at   DATETIME      NOT NULL,
+    last_used_at DATETIME      NULL,
+    created_at   DATETIME      NULL,
+    updated_at   DATETIME      NULL,
+    UNIQUE KEY unique_api_keys_key_hash (key_hash),
+    CONSTRAINT api_keys_account_id_fk FOREIGN KEY (account_id) REFERENCES accounts(id)
+) CHARACTER SET utf8mb4;
+CREATE INDEX idx_api_keys_account_id ON api_keys(account_id);
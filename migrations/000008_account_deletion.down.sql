@@ -0,0 +1,13 @@
+-- contents reassigned to the ghost can not be restored to deleted accounts, so they are deleted before the ghost.
+DELETE c FROM comments c
+    JOIN articles ar ON c.slug = ar.slug
+    JOIN accounts a ON ar.author_id = a.id
+WHERE a.email = 'ghost@article-server.local';
+DELETE c FROM comments c JOIN accounts a ON c.author_id = a.id WHERE a.email = 'ghost@article-server.local';
+DELETE t FROM article_tags t
+    JOIN articles ar ON t.article_id = ar.id
+    JOIN accounts a ON ar.author_id = a.id
+WHERE a.email = 'ghost@article-server.local';
+DELETE ar FROM articles ar JOIN accounts a ON ar.author_id = a.id WHERE a.email = 'ghost@article-server.local';
+DELETE FROM accounts WHERE email = 'ghost@article-server.local';
+ALTER TABLE accounts DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+ALTER TABLE accounts ADD COLUMN deleted_at DATETIME NULL;
+
+-- a disabled account to which contents of deleted accounts are reassigned.
+INSERT INTO accounts (username, email, password, disabled, email_verified, created_at, updated_at) VALUES
+('ghost', 'ghost@article-server.local', '', 1, 1, now(), now());
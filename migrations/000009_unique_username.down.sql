@@ -0,0 +1 @@
+ALTER TABLE accounts DROP INDEX unique_accounts_username;
@@ -0,0 +1,9 @@
+-- rename duplicate usernames except the oldest account e.g. "user1" to "user1-12".
+-- the ghost of deleted accounts keeps its username instead of the oldest account.
+UPDATE accounts a
+    JOIN (SELECT username, COALESCE(MIN(CASE WHEN email = 'ghost@article-server.local' THEN id END), MIN(id)) AS keep_id
+          FROM accounts GROUP BY username HAVING COUNT(*) > 1) d
+    ON a.username = d.username AND a.id <> d.keep_id
+SET a.username = CONCAT(a.username, '-', a.id);
+
+ALTER TABLE accounts ADD UNIQUE KEY unique_accounts_username (username);
@@ -0,0 +1,20 @@
+CREATE TABLE audit_events (
+    id          BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    action      VARCHAR(64)  NOT NULL,
+    actor_id    INT UNSIGNED NOT NULL DEFAULT 0,
+    target_type VARCHAR(64)  NOT NULL DEFAULT '',
+    target_id   VARCHAR(255) NOT NULL DEFAULT '',
+    ip          VARCHAR(45)  NOT NULL DEFAULT '',
+    request_id  VARCHAR(64)  NOT NULL DEFAULT '',
+    changes     TEXT         NULL,
+    created_at  DATETIME     NOT NULL
+) CHARACTER SET utf8mb4;
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_action ON audit_events(action);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+-- audit events are append-only
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
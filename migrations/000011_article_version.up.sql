@@ -0,0 +1,2 @@
+-- version of articles incremented by every update for optimistic locking
+ALTER TABLE articles ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 AFTER body;
@@ -0,0 +1 @@
+ALTER TABLE accounts DROP COLUMN mfa_last_step;
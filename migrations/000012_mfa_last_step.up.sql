@@ -0,0 +1,2 @@
+-- time step of the last accepted TOTP code to reject replayed codes
+ALTER TABLE accounts ADD COLUMN mfa_last_step BIGINT NULL AFTER mfa_enabled;
@@ -0,0 +1,101 @@
+package breached
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// List is a list of breached passwords in a local file which is used to reject weak passwords.
+//
+// Each line of the file is an upper case hex SHA-1 hash of a breached password optionally followed by
+// ":<count>", and lines are sorted by the hash. It is the format of k-anonymity ranges of
+// Have I Been Pwned with the 5 characters prefix, and "pwned-passwords-sha1-ordered-by-hash" file
+// can be used as is. The file is searched by the binary search, so it is not loaded to the memory.
+type List struct {
+	path string
+}
+
+// Open returns a new List of given file.
+func Open(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return &List{path: path}, nil
+}
+
+// Contains returns a true if given password is in the list.
+func (l *List) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	size := info.Size()
+
+	// finds the first line of which hash is greater than or equal to the hash.
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, err := lineAt(f, mid, size)
+		if err != nil {
+			return false, err
+		}
+		if line == "" || hashOf(line) >= hash {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	line, err := lineAt(f, lo, size)
+	if err != nil {
+		return false, err
+	}
+	return line != "" && hashOf(line) == hash, nil
+}
+
+// lineAt returns the first non empty line starting at or after given offset, or an empty string if no more lines.
+func lineAt(f io.ReaderAt, offset, size int64) (string, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+	r := bufio.NewReader(io.NewSectionReader(f, start, size-start))
+	if offset > 0 {
+		// skips the rest of the line containing the previous byte
+		if _, err := r.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				return "", nil
+			}
+			return "", err
+		}
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if line = strings.TrimSpace(line); line != "" || err == io.EOF {
+			return line, nil
+		}
+	}
+}
+
+func hashOf(line string) string {
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.ToUpper(line)
+}
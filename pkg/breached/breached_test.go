@@ -0,0 +1,52 @@
+package breached
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList_Contains(t *testing.T) {
+	// given
+	breached := []string{"password", "123456", "qwerty", "letmein", "dragon", "monkey", "football"}
+	var lines []string
+	for i, password := range breached {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1))
+	}
+	sort.Strings(lines)
+	file, err := ioutil.TempFile(os.TempDir(), "breached-test")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(strings.Join(lines, "\r\n") + "\r\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	list, err := Open(file.Name())
+	assert.NoError(t, err)
+
+	// when then
+	for _, password := range breached {
+		ok, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.True(t, ok, password)
+	}
+	for _, password := range []string{"", "Password", "correct horse battery staple", "football1"} {
+		ok, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.False(t, ok, password)
+	}
+}
+
+func TestOpen_FailIfNotExist(t *testing.T) {
+	_, err := Open("not-exist-file")
+
+	assert.Error(t, err)
+}
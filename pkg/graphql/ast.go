@@ -0,0 +1,186 @@
+package graphql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed GraphQL request document of operations and fragments.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Location is the line and the column of a token in a document starting from 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Operation is a query, mutation or subscription operation.
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+	Location     Location
+}
+
+// VariableDefinition is a variable of an operation with the type and the default value which is nil if not defined.
+type VariableDefinition struct {
+	Name     string
+	Type     *TypeRef
+	Default  Value
+	Location Location
+}
+
+// TypeRef is a type reference like "String", "[String!]" or "Int!".
+// Elem is the element type of list types, otherwise Name is the name of the named type.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Selection is one of *Field, *FragmentSpread or *InlineFragment.
+type Selection interface {
+	location() Location
+}
+
+// Field is a field selection. Alias is empty if not aliased.
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Location     Location
+}
+
+// ResponseKey returns the key of the field in responses which is the alias if aliased.
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+func (f *Field) location() Location { return f.Location }
+
+// FragmentSpread is a selection of a named fragment like "...articleFields".
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Location   Location
+}
+
+func (f *FragmentSpread) location() Location { return f.Location }
+
+// InlineFragment is a selection like "... on Article { slug }". TypeCondition is empty if omitted.
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Location      Location
+}
+
+func (f *InlineFragment) location() Location { return f.Location }
+
+// Fragment is a named fragment definition.
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Location      Location
+}
+
+// Argument is an argument of a field or a directive.
+type Argument struct {
+	Name     string
+	Value    Value
+	Location Location
+}
+
+// Directive is a directive like "@include(if: $withComments)".
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Location  Location
+}
+
+// Value is a literal or a variable of arguments which is one of Variable, IntValue, FloatValue, StringValue,
+// BooleanValue, NullValue, EnumValue, ListValue or ObjectValue.
+type Value interface{}
+
+type (
+	// Variable is a reference of a variable like "$slug".
+	Variable string
+	// IntValue is the raw text of an integer literal.
+	IntValue string
+	// FloatValue is the raw text of a float literal.
+	FloatValue string
+	// StringValue is a string literal without quotes and escapes.
+	StringValue string
+	// BooleanValue is true or false.
+	BooleanValue bool
+	// NullValue is null.
+	NullValue struct{}
+	// EnumValue is a name literal other than true, false and null.
+	EnumValue string
+	// ListValue is a list literal.
+	ListValue []Value
+	// ObjectValue is an input object literal.
+	ObjectValue []*ObjectField
+)
+
+// ObjectField is a field of an input object literal.
+type ObjectField struct {
+	Name  string
+	Value Value
+}
+
+// printValue returns given value in the query syntax.
+func printValue(v Value) string {
+	switch v := v.(type) {
+	case Variable:
+		return "$" + string(v)
+	case IntValue:
+		return string(v)
+	case FloatValue:
+		return string(v)
+	case StringValue:
+		return strconv.Quote(string(v))
+	case BooleanValue:
+		return strconv.FormatBool(bool(v))
+	case NullValue:
+		return "null"
+	case EnumValue:
+		return string(v)
+	case ListValue:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = printValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ObjectValue:
+		fields := make([]string, len(v))
+		for i, f := range v {
+			fields[i] = f.Name + ": " + printValue(f.Value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return ""
+}
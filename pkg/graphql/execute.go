@@ -0,0 +1,399 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Params is a GraphQL request of a query document, the name of the operation to execute and variables.
+type Params struct {
+	Query         string                 `json:"query" form:"query"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Limits is limits of operations which are not limited if zero.
+// Depth is the max nesting of fields and complexity is the sum of complexities of selected fields.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Result is the response of an executed request. Data is nil if the request is not executed because of errors.
+type Result struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error of a request or a field of given path.
+// Resolvers may return an *Error to respond extensions e.g. error codes.
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(loc Location, format string, args ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+// Thunk returns a value of a field later, so that resolvers of the same field of sibling objects are called
+// before values of them are loaded at once.
+type Thunk func() (interface{}, error)
+
+// Execute validates and executes given request with limits.
+func (s *Schema) Execute(ctx context.Context, params Params, limits Limits) *Result {
+	doc, err := Parse(params.Query)
+	if err != nil {
+		return &Result{Errors: []*Error{toError(err)}}
+	}
+	op, err := selectOperation(doc, params.OperationName)
+	if err != nil {
+		return &Result{Errors: []*Error{toError(err)}}
+	}
+	if op.Type != "query" {
+		return &Result{Errors: []*Error{errorf(op.Location, "%s operations are not supported.", op.Type)}}
+	}
+
+	v := validator{schema: s, doc: doc, args: make(map[*Field]map[string]interface{}), maxComplexity: limits.MaxComplexity}
+	if errs := v.variables(op, params.Variables); len(errs) != 0 {
+		return &Result{Errors: errs}
+	}
+	depth, complexity := v.selectionSet(s.Query, op.SelectionSet, map[string]bool{})
+	if len(v.errors) != 0 {
+		return &Result{Errors: v.errors}
+	}
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return &Result{Errors: []*Error{errorf(op.Location, "Query depth %d exceeds the maximum depth %d.", depth, limits.MaxDepth)}}
+	}
+	if v.exceeded || limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return &Result{Errors: []*Error{errorf(op.Location, "Query complexity %d exceeds the maximum complexity %d.",
+			complexity, limits.MaxComplexity)}}
+	}
+
+	e := executor{doc: doc, vars: v.vars, args: v.args}
+	data := e.selectionSet(ctx, s.Query, []interface{}{nil}, op.SelectionSet, [][]interface{}{nil})
+	return &Result{Data: data[0], Errors: e.errors}
+}
+
+func toError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Message: err.Error()}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) != 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+// executor executes a validated operation resolving fields of sibling objects breadth first.
+type executor struct {
+	doc    *Document
+	vars   map[string]interface{}
+	args   map[*Field]map[string]interface{}
+	errors []*Error
+}
+
+// fieldGroup is fields of the same response key merged.
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+func (e *executor) selectionSet(ctx context.Context, typ *Object, sources []interface{}, set []Selection,
+	paths [][]interface{}) []interface{} {
+	results := make([]*orderedMap, len(sources))
+	for i := range results {
+		results[i] = &orderedMap{values: make(map[string]interface{})}
+	}
+
+	for _, group := range e.collectFields(typ, set, nil, nil) {
+		field := group.fields[0]
+		if field.Name == "__typename" {
+			for _, r := range results {
+				r.set(group.key, typ.Name)
+			}
+			continue
+		}
+		def := typ.Field(field.Name)
+		args := e.args[field]
+
+		// resolve values of all sources before thunks are called, so that loaders load them at once.
+		values := make([]interface{}, len(sources))
+		fieldPaths := make([][]interface{}, len(sources))
+		for i, source := range sources {
+			fieldPaths[i] = appendPath(paths[i], group.key)
+			v, err := resolve(ctx, def, source, args, fieldPaths[i])
+			if err != nil {
+				e.fieldError(err, field, fieldPaths[i])
+				continue
+			}
+			values[i] = v
+		}
+		for i, v := range values {
+			thunk, ok := v.(Thunk)
+			if !ok {
+				continue
+			}
+			v, err := thunk()
+			if err != nil {
+				e.fieldError(err, field, fieldPaths[i])
+				v = nil
+			}
+			values[i] = v
+		}
+
+		completed := e.completeValues(ctx, def.Type, group.fields, values, fieldPaths)
+		for i, r := range results {
+			r.set(group.key, completed[i])
+		}
+	}
+
+	ret := make([]interface{}, len(results))
+	for i, r := range results {
+		ret[i] = r
+	}
+	return ret
+}
+
+func resolve(ctx context.Context, def *FieldDefinition, source interface{}, args map[string]interface{}, path []interface{}) (interface{}, error) {
+	if def.Resolve == nil {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[def.Name], nil
+		}
+		return nil, nil
+	}
+	return def.Resolve(ResolveParams{Context: ctx, Source: source, Args: args, Path: path})
+}
+
+// completeValues converts resolved values of given type to response values.
+func (e *executor) completeValues(ctx context.Context, t Type, fields []*Field, values []interface{},
+	paths [][]interface{}) []interface{} {
+	ret := make([]interface{}, len(values))
+	switch t := t.(type) {
+	case *NonNull:
+		ret = e.completeValues(ctx, t.Of, fields, values, paths)
+		for i, v := range values {
+			if isNil(v) && !e.hasError(paths[i]) {
+				e.fieldError(fmt.Errorf("Cannot return null for non-nullable field %s.", fields[0].Name), fields[0], paths[i])
+			}
+		}
+	case *Scalar:
+		for i, v := range values {
+			if isNil(v) {
+				continue
+			}
+			s, err := t.Serialize(v)
+			if err != nil {
+				e.fieldError(err, fields[0], paths[i])
+				continue
+			}
+			ret[i] = s
+		}
+	case *List:
+		// complete items of all lists at once
+		var (
+			items     []interface{}
+			itemPaths [][]interface{}
+			owners    []int
+		)
+		for i, v := range values {
+			if isNil(v) {
+				continue
+			}
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				e.fieldError(fmt.Errorf("Expected a list for field %s, but got %T.", fields[0].Name, v), fields[0], paths[i])
+				continue
+			}
+			ret[i] = []interface{}{}
+			for j := 0; j < rv.Len(); j++ {
+				items = append(items, rv.Index(j).Interface())
+				itemPaths = append(itemPaths, appendPath(paths[i], j))
+				owners = append(owners, i)
+			}
+		}
+		for j, item := range e.completeValues(ctx, t.Of, fields, items, itemPaths) {
+			ret[owners[j]] = append(ret[owners[j]].([]interface{}), item)
+		}
+	case *Object:
+		var (
+			sources     []interface{}
+			sourcePaths [][]interface{}
+			owners      []int
+		)
+		for i, v := range values {
+			if isNil(v) {
+				continue
+			}
+			sources = append(sources, v)
+			sourcePaths = append(sourcePaths, paths[i])
+			owners = append(owners, i)
+		}
+		if len(sources) == 0 {
+			return ret
+		}
+		var set []Selection
+		for _, f := range fields {
+			set = append(set, f.SelectionSet...)
+		}
+		for j, v := range e.selectionSet(ctx, t, sources, set, sourcePaths) {
+			ret[owners[j]] = v
+		}
+	}
+	return ret
+}
+
+// collectFields returns fields of given selection set by response keys in order expanding fragments.
+func (e *executor) collectFields(typ *Object, set []Selection, groups []*fieldGroup, visited map[string]bool) []*fieldGroup {
+	if visited == nil {
+		visited = make(map[string]bool)
+	}
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *Field:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			merged := false
+			for _, g := range groups {
+				if g.key == sel.ResponseKey() {
+					g.fields = append(g.fields, sel)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				groups = append(groups, &fieldGroup{key: sel.ResponseKey(), fields: []*Field{sel}})
+			}
+		case *InlineFragment:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			groups = e.collectFields(typ, sel.SelectionSet, groups, visited)
+		case *FragmentSpread:
+			if !e.included(sel.Directives) || visited[sel.Name] {
+				continue
+			}
+			visited[sel.Name] = true
+			groups = e.collectFields(typ, e.doc.Fragments[sel.Name].SelectionSet, groups, visited)
+		}
+	}
+	return groups
+}
+
+// included returns false if skipped by @skip(if: true) or @include(if: false).
+func (e *executor) included(directives []*Directive) bool {
+	for _, d := range directives {
+		if d.Name != "skip" && d.Name != "include" {
+			continue
+		}
+		cond := false
+		for _, arg := range d.Arguments {
+			if arg.Name != "if" {
+				continue
+			}
+			switch v := arg.Value.(type) {
+			case BooleanValue:
+				cond = bool(v)
+			case Variable:
+				cond, _ = e.vars[string(v)].(bool)
+			}
+		}
+		if (d.Name == "skip") == cond {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) fieldError(err error, field *Field, path []interface{}) {
+	fe := Error{Message: err.Error(), Locations: []Location{field.Location}, Path: path}
+	if re, ok := err.(*Error); ok {
+		fe.Extensions = re.Extensions
+	}
+	e.errors = append(e.errors, &fe)
+}
+
+// hasError returns true if an error of given path or the descendants is added.
+func (e *executor) hasError(path []interface{}) bool {
+	for _, err := range e.errors {
+		if len(err.Path) < len(path) {
+			continue
+		}
+		if reflect.DeepEqual(err.Path[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendPath(path []interface{}, key interface{}) []interface{} {
+	ret := make([]interface{}, len(path), len(path)+1)
+	copy(ret, path)
+	return append(ret, key)
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a response object of which keys are marshaled in selected order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,320 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAuthor struct {
+	ID   uint
+	Name string
+}
+
+var testBooks = map[interface{}]interface{}{
+	uint(1): []map[string]interface{}{{"title": "dragons"}, {"title": "dragons 2"}},
+	uint(2): []map[string]interface{}{{"title": "trains"}},
+}
+
+// newTestSchema returns a schema of authors and books of them loaded by the loader of the context.
+func newTestSchema(t *testing.T) *Schema {
+	authors := []*testAuthor{{ID: 1, Name: "jake"}, {ID: 2, Name: "jane"}, {ID: 3, Name: "john"}}
+
+	book := &Object{Name: "Book", Fields: []*FieldDefinition{{Name: "title", Type: String}}}
+	author := &Object{Name: "Author", Description: "Author of books"}
+	author.AddFields(
+		&FieldDefinition{Name: "id", Type: &NonNull{Of: ID}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testAuthor).ID, nil
+		}},
+		&FieldDefinition{Name: "name", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*testAuthor).Name, nil
+		}},
+		&FieldDefinition{Name: "books", Type: &List{Of: book}, Resolve: func(p ResolveParams) (interface{}, error) {
+			return loaderFrom(p.Context).Load(p.Context, p.Source.(*testAuthor).ID), nil
+		}},
+	)
+	query := &Object{Name: "Query", Fields: []*FieldDefinition{
+		{
+			Name: "authors",
+			Type: &List{Of: author},
+			Args: []*ArgumentDefinition{{Name: "limit", Type: Int, Default: 10}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				limit := p.Args["limit"].(int)
+				if limit > len(authors) {
+					limit = len(authors)
+				}
+				return authors[:limit], nil
+			},
+			Complexity: func(args map[string]interface{}, childComplexity int) int {
+				return 1 + args["limit"].(int)*childComplexity
+			},
+		},
+		{
+			Name: "author",
+			Type: author,
+			Args: []*ArgumentDefinition{{Name: "name", Type: &NonNull{Of: String}}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				for _, a := range authors {
+					if a.Name == p.Args["name"] {
+						return a, nil
+					}
+				}
+				return (*testAuthor)(nil), nil
+			},
+		},
+		{
+			Name: "names",
+			Type: &List{Of: String},
+			Args: []*ArgumentDefinition{{Name: "names", Type: &List{Of: &NonNull{Of: String}}}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return p.Args["names"], nil
+			},
+		},
+		{
+			Name: "fail",
+			Type: String,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return nil, &Error{Message: "failed", Extensions: map[string]interface{}{"code": "FAILED"}}
+			},
+		},
+		{Name: "required", Type: &NonNull{Of: String}},
+	}}
+
+	schema, err := NewSchema(query)
+	assert.NoError(t, err)
+	return schema
+}
+
+type testLoaderKey struct{}
+
+func loaderFrom(ctx context.Context) *Loader {
+	return ctx.Value(testLoaderKey{}).(*Loader)
+}
+
+// execute executes given request with a new loader of books appending batched keys to batches.
+func execute(t *testing.T, s *Schema, params Params, limits Limits, batches *[][]interface{}) string {
+	loader := NewLoader(func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+		*batches = append(*batches, keys)
+		return testBooks, nil
+	})
+	ctx := context.WithValue(context.Background(), testLoaderKey{}, loader)
+	b, err := json.Marshal(s.Execute(ctx, params, limits))
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestExecute(t *testing.T) {
+	var batches [][]interface{}
+	s := newTestSchema(t)
+
+	res := execute(t, s, Params{Query: `
+		query Authors($limit: Int) {
+			authors(limit: $limit) {
+				__typename
+				id
+				...authorFields
+				books { title }
+			}
+			jake: author(name: "jake") { name }
+			unknown: author(name: "unknown") { name }
+		}
+		fragment authorFields on Author { name }
+	`, Variables: map[string]interface{}{"limit": float64(3)}}, Limits{}, &batches)
+
+	assert.JSONEq(t, `{"data": {
+		"authors": [
+			{"__typename": "Author", "id": "1", "name": "jake", "books": [{"title": "dragons"}, {"title": "dragons 2"}]},
+			{"__typename": "Author", "id": "2", "name": "jane", "books": [{"title": "trains"}]},
+			{"__typename": "Author", "id": "3", "name": "john", "books": null}
+		],
+		"jake": {"name": "jake"},
+		"unknown": null
+	}}`, res)
+	// keys of books are loaded at once
+	assert.Equal(t, [][]interface{}{{uint(1), uint(2), uint(3)}}, batches)
+	// keys are in selected order
+	assert.Regexp(t, `^{"data":{"authors":\[{"__typename":"Author","id":"1","name":"jake","books"`, res)
+}
+
+func TestExecute_Arguments(t *testing.T) {
+	var batches [][]interface{}
+	s := newTestSchema(t)
+
+	cases := []struct {
+		Name      string
+		Query     string
+		Variables map[string]interface{}
+		Expected  string
+	}{
+		{
+			Name:     "default",
+			Query:    `{ authors { name } }`,
+			Expected: `{"data": {"authors": [{"name": "jake"}, {"name": "jane"}, {"name": "john"}]}}`,
+		}, {
+			Name:     "list literal",
+			Query:    `{ names(names: ["a", "b"]) }`,
+			Expected: `{"data": {"names": ["a", "b"]}}`,
+		}, {
+			Name:     "list coerced from an item",
+			Query:    `{ names(names: "a") }`,
+			Expected: `{"data": {"names": ["a"]}}`,
+		}, {
+			Name:      "list variable",
+			Query:     `query($names: [String!]) { names(names: $names) }`,
+			Variables: map[string]interface{}{"names": []interface{}{"a"}},
+			Expected:  `{"data": {"names": ["a"]}}`,
+		}, {
+			Name:     "skip and include",
+			Query:    `query($skip: Boolean = true) { authors(limit: 1) { name @skip(if: $skip) id @include(if: true) } }`,
+			Expected: `{"data": {"authors": [{"id": "1"}]}}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			res := execute(t, s, Params{Query: tc.Query, Variables: tc.Variables}, Limits{}, &batches)
+			assert.JSONEq(t, tc.Expected, res)
+		})
+	}
+}
+
+func TestExecute_FieldErrors(t *testing.T) {
+	var batches [][]interface{}
+	s := newTestSchema(t)
+
+	res := execute(t, s, Params{Query: `{ jake: author(name: "jake") { name } fail required }`}, Limits{}, &batches)
+
+	assert.JSONEq(t, `{
+		"data": {"jake": {"name": "jake"}, "fail": null, "required": null},
+		"errors": [
+			{"message": "failed", "locations": [{"line": 1, "column": 39}], "path": ["fail"], "extensions": {"code": "FAILED"}},
+			{"message": "Cannot return null for non-nullable field required.", "locations": [{"line": 1, "column": 44}], "path": ["required"]}
+		]
+	}`, res)
+}
+
+func TestExecute_RequestErrors(t *testing.T) {
+	s := newTestSchema(t)
+
+	cases := []struct {
+		Name    string
+		Params  Params
+		Limits  Limits
+		Message string
+	}{
+		{Name: "syntax", Params: Params{Query: `{ authors { name }`}, Message: "Syntax Error: Expected Name, found <EOF>."},
+		{Name: "mutation", Params: Params{Query: `mutation { authors { name } }`}, Message: "mutation operations are not supported."},
+		{Name: "operation name", Params: Params{Query: `query A { fail } query B { fail }`}, Message: "Must provide operation name if query contains multiple operations."},
+		{Name: "unknown operation", Params: Params{Query: `query A { fail }`, OperationName: "B"}, Message: `Unknown operation named "B".`},
+		{Name: "unknown field", Params: Params{Query: `{ authors { email } }`}, Message: `Cannot query field "email" on type "Author".`},
+		{Name: "no subfields", Params: Params{Query: `{ authors }`}, Message: `Field "authors" of type "[Author]" must have a selection of subfields.`},
+		{Name: "subfields of leaf", Params: Params{Query: `{ fail { name } }`}, Message: `Field "fail" must not have a selection since type "String" has no subfields.`},
+		{Name: "unknown argument", Params: Params{Query: `{ authors(first: 1) { name } }`}, Message: `Field "authors": unknown argument "first"`},
+		{Name: "required argument", Params: Params{Query: `{ author { name } }`}, Message: `Field "author": argument "name" of type "String!" is required, but it was not provided`},
+		{Name: "invalid argument", Params: Params{Query: `{ authors(limit: "1") { name } }`}, Message: `Field "authors": argument "limit" has an invalid value: Int cannot represent a non 32-bit integer value: "1"`},
+		{Name: "undefined variable", Params: Params{Query: `{ authors(limit: $limit) { name } }`}, Message: `Field "authors": variable "$limit" is not defined`},
+		{Name: "incompatible variable", Params: Params{Query: `query($limit: String) { authors(limit: $limit) { name } }`},
+			Message: `Field "authors": variable "$limit" of type "String" used in position expecting type "Int"`},
+		{Name: "required variable", Params: Params{Query: `query($name: String!) { author(name: $name) { name } }`},
+			Message: `Variable "$name" of required type "String!" was not provided.`},
+		{Name: "invalid variable", Params: Params{Query: `query($limit: Int) { authors(limit: $limit) { name } }`, Variables: map[string]interface{}{"limit": 1.5}},
+			Message: `Variable "$limit" got invalid value: Int cannot represent a non 32-bit integer value: 1.5`},
+		{Name: "unknown fragment", Params: Params{Query: `{ authors { ...f } }`}, Message: `Unknown fragment "f".`},
+		{Name: "fragment cycle", Params: Params{Query: `{ authors { ...f } } fragment f on Author { ...f }`}, Message: `Cannot spread fragment "f" within itself.`},
+		{Name: "fragment type", Params: Params{Query: `{ authors { ...f } } fragment f on Book { title }`},
+			Message: `Fragment "f" cannot be spread here as objects of type "Author" can never be of type "Book".`},
+		{Name: "unknown directive", Params: Params{Query: `{ fail @defer }`}, Message: `Unknown directive "@defer".`},
+		{Name: "max depth", Params: Params{Query: `{ authors { books { title } } }`}, Limits: Limits{MaxDepth: 2},
+			Message: "Query depth 3 exceeds the maximum depth 2."},
+		{Name: "max complexity", Params: Params{Query: `{ authors(limit: 5) { name books { title } } }`}, Limits: Limits{MaxComplexity: 15},
+			Message: "Query complexity 16 exceeds the maximum complexity 15."},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := s.Execute(context.Background(), tc.Params, tc.Limits)
+
+			assert.Nil(t, result.Data)
+			assert.Len(t, result.Errors, 1)
+			assert.Equal(t, tc.Message, result.Errors[0].Message)
+		})
+	}
+}
+
+func TestExecute_NestedFragments(t *testing.T) {
+	s := newTestSchema(t)
+	// every fragment spreads the previous one twice, so the query expands to 2^50 fields.
+	var query strings.Builder
+	query.WriteString("{ authors { ...f50 } } fragment f0 on Author { name }")
+	for i := 1; i <= 50; i++ {
+		fmt.Fprintf(&query, " fragment f%d on Author { ...f%d ...f%d }", i, i-1, i-1)
+	}
+
+	result := s.Execute(context.Background(), Params{Query: query.String()}, Limits{MaxComplexity: 100})
+
+	assert.Nil(t, result.Data)
+	assert.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "exceeds the maximum complexity 100.")
+}
+
+func TestLoader(t *testing.T) {
+	var calls [][]interface{}
+	loader := NewLoader(func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+		calls = append(calls, keys)
+		if keys[0] == "fail" {
+			return nil, errors.New("failed")
+		}
+		values := make(map[interface{}]interface{})
+		for _, key := range keys {
+			values[key] = key.(string) + "!"
+		}
+		return values, nil
+	})
+	ctx := context.Background()
+
+	a, b, a2 := loader.Load(ctx, "a"), loader.Load(ctx, "b"), loader.Load(ctx, "a")
+	v, err := b()
+	assert.NoError(t, err)
+	assert.Equal(t, "b!", v)
+	v, _ = a()
+	assert.Equal(t, "a!", v)
+	v, _ = a2()
+	assert.Equal(t, "a!", v)
+	// cached
+	v, _ = loader.Load(ctx, "a")()
+	assert.Equal(t, "a!", v)
+	// error
+	_, err = loader.Load(ctx, "fail")()
+	assert.EqualError(t, err, "failed")
+
+	assert.Equal(t, [][]interface{}{{"a", "b"}, {"fail"}}, calls)
+}
+
+func TestSchema_SDL(t *testing.T) {
+	s := newTestSchema(t)
+
+	assert.Equal(t, `type Query {
+  authors(limit: Int = 10): [Author]
+  author(name: String!): Author
+  names(names: [String!]): [String]
+  fail: String
+  required: String!
+}
+
+"Author of books"
+type Author {
+  id: ID!
+  name: String
+  books: [Book]
+}
+
+type Book {
+  title: String
+}
+`, s.SDL())
+}
@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc loads values of given keys at once and returns values by the keys. Values of missing keys are nil.
+type BatchFunc func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error)
+
+// Loader loads values of keys requested by resolvers of sibling objects at once like dataloader,
+// and caches loaded values. A Loader must be created for each request.
+type Loader struct {
+	batch BatchFunc
+
+	mu      sync.Mutex
+	pending []interface{}
+	loaded  map[interface{}]bool
+	values  map[interface{}]interface{}
+	errs    map[interface{}]error
+}
+
+// NewLoader returns a new Loader loading values by given function.
+func NewLoader(batch BatchFunc) *Loader {
+	return &Loader{
+		batch:  batch,
+		loaded: make(map[interface{}]bool),
+		values: make(map[interface{}]interface{}),
+		errs:   make(map[interface{}]error),
+	}
+}
+
+// Load requests given key and returns the Thunk returning the value of it.
+// Keys requested before any of thunks is called are loaded by a call of the BatchFunc.
+func (l *Loader) Load(ctx context.Context, key interface{}) Thunk {
+	l.mu.Lock()
+	if !l.loaded[key] && !l.isPending(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.dispatch(ctx)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.values[key], l.errs[key]
+	}
+}
+
+func (l *Loader) isPending(key interface{}) bool {
+	for _, k := range l.pending {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch loads values of pending keys if any.
+func (l *Loader) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.loaded[key] = true
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
@@ -0,0 +1,681 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return t.value
+}
+
+// lexer reads tokens of a document skipping ignored tokens of whitespaces, commas and comments.
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	column int
+}
+
+func (l *lexer) errorf(loc Location, format string, args ...interface{}) *Error {
+	return &Error{Message: "Syntax Error: " + fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := Location{Line: l.line, Column: l.column}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, location: loc}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunct, value: "...", location: loc}, nil
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), location: loc}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], location: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.blockString(loc)
+		}
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, l.errorf(loc, "Unexpected character %q.", r)
+}
+
+func (l *lexer) number(loc Location) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	if !l.digits() {
+		return token{}, l.errorf(loc, "Invalid number %q.", l.src[start:l.pos])
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if !l.digits() {
+			return token{}, l.errorf(loc, "Invalid number %q.", l.src[start:l.pos])
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if !l.digits() {
+			return token{}, l.errorf(loc, "Invalid number %q.", l.src[start:l.pos])
+		}
+	}
+	return token{kind: kind, value: l.src[start:l.pos], location: loc}, nil
+}
+
+func (l *lexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance(1)
+	}
+	return l.pos > start
+}
+
+func (l *lexer) string(loc Location) (token, error) {
+	l.advance(1)
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return token{kind: tokenString, value: b.String(), location: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.errorf(loc, "Unterminated string.")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorf(loc, "Unterminated string.")
+			}
+			switch e := l.src[l.pos+1]; e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+6 > len(l.src) {
+					return token{}, l.errorf(loc, "Invalid unicode escape sequence.")
+				}
+				r, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 32)
+				if err != nil {
+					return token{}, l.errorf(loc, "Invalid unicode escape sequence.")
+				}
+				b.WriteRune(rune(r))
+				l.advance(4)
+			default:
+				return token{}, l.errorf(loc, "Invalid escape sequence \\%c.", e)
+			}
+			l.advance(2)
+		default:
+			b.WriteByte(c)
+			l.advance(1)
+		}
+	}
+	return token{}, l.errorf(loc, "Unterminated string.")
+}
+
+func (l *lexer) blockString(loc Location) (token, error) {
+	l.advance(3)
+	end := strings.Index(l.src[l.pos:], `"""`)
+	if end < 0 {
+		return token{}, l.errorf(loc, "Unterminated string.")
+	}
+	raw := l.src[l.pos : l.pos+end]
+	l.advance(end + 3)
+	return token{kind: tokenString, value: blockStringValue(strings.ReplaceAll(raw, `\"""`, `"""`)), location: loc}, nil
+}
+
+// blockStringValue removes the common indentation and leading and trailing blank lines of a block string.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// parser is a recursive descent parser of executable documents.
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+// Parse parses given executable document of operations and fragments.
+// Type system definitions are not supported.
+func Parse(src string) (*Document, error) {
+	p := parser{lexer: &lexer{src: src, line: 1, column: 1}}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	doc, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (p *parser) read() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	return p.lexer.errorf(p.token.location, "Unexpected %s.", p.token)
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.token.kind == kind && (value == "" || p.token.value == value)
+}
+
+// skip reads the next token and returns true if the current token is given punctuator.
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(tokenPunct, punct) {
+		return false, nil
+	}
+	return true, p.read()
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(tokenPunct, punct) {
+		return p.lexer.errorf(p.token.location, "Expected %q, found %s.", punct, p.token)
+	}
+	return p.read()
+}
+
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.lexer.errorf(p.token.location, "Expected Name, found %s.", p.token)
+	}
+	name := p.token.value
+	return name, p.read()
+}
+
+func (p *parser) document() (*Document, error) {
+	doc := Document{Fragments: make(map[string]*Fragment)}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek(tokenName, "fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[f.Name]; ok {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one fragment named %q.", f.Name), Locations: []Location{f.Location}}
+			}
+			doc.Fragments[f.Name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, &Error{Message: "Syntax Error: no operation is defined."}
+	}
+	return &doc, nil
+}
+
+func (p *parser) operation() (*Operation, error) {
+	op := Operation{Type: "query", Location: p.token.location}
+	if p.token.kind == tokenName {
+		op.Type = p.token.value
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		if p.token.kind == tokenName {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			op.Name = name
+		}
+		vars, err := p.variableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		op.Variables = vars
+		if op.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+	}
+	set, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.SelectionSet = set
+	return &op, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	f := Fragment{Location: p.token.location}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.Name = name
+	if !p.peek(tokenName, "on") {
+		return nil, p.lexer.errorf(p.token.location, "Expected \"on\", found %s.", p.token)
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	if f.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if f.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if f.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (p *parser) variableDefinitions() ([]*VariableDefinition, error) {
+	if ok, err := p.skip("("); !ok || err != nil {
+		return nil, err
+	}
+	var defs []*VariableDefinition
+	for {
+		if ok, err := p.skip(")"); ok || err != nil {
+			return defs, err
+		}
+		def := VariableDefinition{Location: p.token.location}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		def.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if def.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		defs = append(defs, &def)
+	}
+}
+
+func (p *parser) typeRef() (*TypeRef, error) {
+	var t TypeRef
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t.Elem = elem
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t.Name = name
+	}
+	ok, err := p.skip("!")
+	if err != nil {
+		return nil, err
+	}
+	t.NonNull = ok
+	return &t, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var set []Selection
+	for {
+		if ok, err := p.skip("}"); ok || err != nil {
+			if err == nil && len(set) == 0 {
+				return nil, p.lexer.errorf(p.token.location, "Expected Name, found \"}\".")
+			}
+			return set, err
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, sel)
+	}
+}
+
+func (p *parser) selection() (Selection, error) {
+	loc := p.token.location
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.token.kind == tokenName && p.token.value != "on" {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			directives, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &FragmentSpread{Name: name, Directives: directives, Location: loc}, nil
+		}
+		f := InlineFragment{Location: loc}
+		if p.peek(tokenName, "on") {
+			if err := p.read(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			f.TypeCondition = name
+		}
+		var err error
+		if f.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if f.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return &f, nil
+	}
+	return p.field()
+}
+
+func (p *parser) field() (*Field, error) {
+	f := Field{Location: p.token.location}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.Alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.Name = name
+	if f.Arguments, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunct, "{") {
+		if f.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func (p *parser) arguments() ([]*Argument, error) {
+	if ok, err := p.skip("("); !ok || err != nil {
+		return nil, err
+	}
+	var args []*Argument
+	for {
+		if ok, err := p.skip(")"); ok || err != nil {
+			return args, err
+		}
+		arg := Argument{Location: p.token.location}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arg.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arg.Value, err = p.value(false); err != nil {
+			return nil, err
+		}
+		args = append(args, &arg)
+	}
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek(tokenPunct, "@") {
+		d := Directive{Location: p.token.location}
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d.Name = name
+		if d.Arguments, err = p.arguments(); err != nil {
+			return nil, err
+		}
+		directives = append(directives, &d)
+	}
+	return directives, nil
+}
+
+// value parses a value which must not have variables if constant.
+func (p *parser) value(constant bool) (Value, error) {
+	t := p.token
+	switch t.kind {
+	case tokenInt:
+		return IntValue(t.value), p.read()
+	case tokenFloat:
+		return FloatValue(t.value), p.read()
+	case tokenString:
+		return StringValue(t.value), p.read()
+	case tokenName:
+		var v Value
+		switch t.value {
+		case "true":
+			v = BooleanValue(true)
+		case "false":
+			v = BooleanValue(false)
+		case "null":
+			v = NullValue{}
+		default:
+			v = EnumValue(t.value)
+		}
+		return v, p.read()
+	case tokenPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.read(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return Variable(name), nil
+		case "[":
+			if err := p.read(); err != nil {
+				return nil, err
+			}
+			list := ListValue{}
+			for {
+				if ok, err := p.skip("]"); ok || err != nil {
+					return list, err
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+		case "{":
+			if err := p.read(); err != nil {
+				return nil, err
+			}
+			obj := ObjectValue{}
+			for {
+				if ok, err := p.skip("}"); ok || err != nil {
+					return obj, err
+				}
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, &ObjectField{Name: name, Value: v})
+			}
+		}
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# articles of a tag
+		query Articles($tag: String = "dragons", $limit: Int!) {
+			feed: articles(tag: [$tag], limit: $limit, filter: {author: "jake", draft: false, score: 1.5e2}) @include(if: true) {
+				...articleFields
+				... on Article { body }
+			}
+		}
+
+		fragment articleFields on Article {
+			slug
+			title(format: UPPER, note: """
+				multi
+				  line
+			""", escaped: "a\"bé")
+		}
+	`)
+
+	assert.NoError(t, err)
+	assert.Len(t, doc.Operations, 1)
+	op := doc.Operations[0]
+	assert.Equal(t, "query", op.Type)
+	assert.Equal(t, "Articles", op.Name)
+	assert.Len(t, op.Variables, 2)
+	assert.Equal(t, "tag", op.Variables[0].Name)
+	assert.Equal(t, "String", op.Variables[0].Type.String())
+	assert.Equal(t, StringValue("dragons"), op.Variables[0].Default)
+	assert.Equal(t, "Int!", op.Variables[1].Type.String())
+
+	feed := op.SelectionSet[0].(*Field)
+	assert.Equal(t, "feed", feed.ResponseKey())
+	assert.Equal(t, "articles", feed.Name)
+	assert.Equal(t, Location{Line: 4, Column: 4}, feed.Location)
+	assert.Equal(t, ListValue{Variable("tag")}, feed.Arguments[0].Value)
+	assert.Equal(t, Variable("limit"), feed.Arguments[1].Value)
+	assert.Equal(t, ObjectValue{
+		{Name: "author", Value: StringValue("jake")},
+		{Name: "draft", Value: BooleanValue(false)},
+		{Name: "score", Value: FloatValue("1.5e2")},
+	}, feed.Arguments[2].Value)
+	assert.Equal(t, "include", feed.Directives[0].Name)
+	assert.Equal(t, "articleFields", feed.SelectionSet[0].(*FragmentSpread).Name)
+	assert.Equal(t, "Article", feed.SelectionSet[1].(*InlineFragment).TypeCondition)
+
+	f := doc.Fragments["articleFields"]
+	assert.Equal(t, "Article", f.TypeCondition)
+	title := f.SelectionSet[1].(*Field)
+	assert.Equal(t, EnumValue("UPPER"), title.Arguments[0].Value)
+	assert.Equal(t, StringValue("multi\n  line"), title.Arguments[1].Value)
+	assert.Equal(t, StringValue("a\"bé"), title.Arguments[2].Value)
+}
+
+func TestParse_Shorthand(t *testing.T) {
+	doc, err := Parse(`{ article(slug: "a", id: -1, deleted: null) { slug } }`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "query", doc.Operations[0].Type)
+	article := doc.Operations[0].SelectionSet[0].(*Field)
+	assert.Equal(t, IntValue("-1"), article.Arguments[1].Value)
+	assert.Equal(t, NullValue{}, article.Arguments[2].Value)
+}
+
+func TestParse_Fail(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Query    string
+		Message  string
+		Location Location
+	}{
+		{Name: "empty", Query: ``, Message: "Syntax Error: no operation is defined."},
+		{Name: "unclosed", Query: `{ article { slug }`, Message: "Syntax Error: Expected Name, found <EOF>.", Location: Location{1, 19}},
+		{Name: "empty selection", Query: `{ article { } }`, Message: `Syntax Error: Expected Name, found "}".`, Location: Location{1, 15}},
+		{Name: "unexpected character", Query: "{ article ? }", Message: "Syntax Error: Unexpected character '?'.", Location: Location{1, 11}},
+		{Name: "unterminated string", Query: `{ article(slug: "a) { slug } }`, Message: "Syntax Error: Unterminated string.", Location: Location{1, 17}},
+		{Name: "variable in default", Query: `query($a: Int = $b) { slug }`, Message: "Syntax Error: Unexpected $.", Location: Location{1, 17}},
+		{Name: "duplicate fragments", Query: `{ slug } fragment f on A { slug } fragment f on A { slug }`,
+			Message: `There can be only one fragment named "f".`, Location: Location{1, 35}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := Parse(tc.Query)
+
+			assert.Error(t, err)
+			e := err.(*Error)
+			assert.Equal(t, tc.Message, e.Message)
+			if tc.Location.Line != 0 {
+				assert.Equal(t, []Location{tc.Location}, e.Locations)
+			}
+		})
+	}
+}
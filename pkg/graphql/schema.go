@@ -0,0 +1,390 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Type is one of *Scalar, *Object, *List or *NonNull.
+type Type interface {
+	String() string
+}
+
+// List is a list type of the element type.
+type List struct {
+	Of Type
+}
+
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// NonNull is a non null type of the nullable type.
+// Non null output fields resolved to null are responded as null with an error.
+type NonNull struct {
+	Of Type
+}
+
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// Scalar is a leaf type. Serialize converts resolved values to json values, ParseValue converts json values
+// of variables and ParseLiteral converts literals of arguments to go values passed to resolvers.
+type Scalar struct {
+	Name         string
+	Description  string
+	Serialize    func(v interface{}) (interface{}, error)
+	ParseValue   func(v interface{}) (interface{}, error)
+	ParseLiteral func(v Value) (interface{}, error)
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// Object is an object type of fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDefinition
+}
+
+func (o *Object) String() string { return o.Name }
+
+// AddFields adds given fields to the object which may refer the object itself or objects referring it.
+func (o *Object) AddFields(fields ...*FieldDefinition) *Object {
+	o.Fields = append(o.Fields, fields...)
+	return o
+}
+
+// Field returns the field of given name or nil if not defined.
+func (o *Object) Field(name string) *FieldDefinition {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ResolveFunc returns the value of a field which is nil, a value of the field type or a Thunk loading it later.
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+// ComplexityFunc returns the complexity of a field with given arguments and the sum of complexities of selected
+// fields of it.
+type ComplexityFunc func(args map[string]interface{}, childComplexity int) int
+
+// ResolveParams is parameters of a ResolveFunc.
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value of the parent object.
+	Source interface{}
+	// Args is coerced arguments. Arguments are not in the map if omitted without default values.
+	Args map[string]interface{}
+	// Path is the response path of the field.
+	Path []interface{}
+}
+
+// FieldDefinition is a field of an object. Values of fields without Resolve are values of Source if it is a
+// map[string]interface{}, otherwise nil. Complexity is 1 + the child complexity if Complexity is nil.
+type FieldDefinition struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*ArgumentDefinition
+	Resolve     ResolveFunc
+	Complexity  ComplexityFunc
+}
+
+// ArgumentDefinition is an argument of a field. Default is the default value if not nil.
+type ArgumentDefinition struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{}
+}
+
+// Schema is a schema of the query type. Mutations and subscriptions are not supported.
+type Schema struct {
+	Query *Object
+	types map[string]Type
+	names []string
+}
+
+// NewSchema returns the schema of given query type or an error if types of the same name are different.
+func NewSchema(query *Object) (*Schema, error) {
+	s := Schema{Query: query, types: make(map[string]Type)}
+	if err := s.collect(query); err != nil {
+		return nil, err
+	}
+	for _, scalar := range builtinScalars {
+		if _, ok := s.types[scalar.Name]; !ok {
+			s.types[scalar.Name] = scalar
+		}
+	}
+	return &s, nil
+}
+
+// collect registers given named type and types referred from it.
+func (s *Schema) collect(t Type) error {
+	switch t := t.(type) {
+	case *List:
+		return s.collect(t.Of)
+	case *NonNull:
+		return s.collect(t.Of)
+	}
+	name := t.String()
+	if registered, ok := s.types[name]; ok {
+		if registered != t {
+			return fmt.Errorf("graphql: duplicate type %q", name)
+		}
+		return nil
+	}
+	s.types[name] = t
+	s.names = append(s.names, name)
+	if o, ok := t.(*Object); ok {
+		for _, f := range o.Fields {
+			for _, arg := range f.Args {
+				if err := s.collect(arg.Type); err != nil {
+					return err
+				}
+			}
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inputType returns the type of given type reference of variables.
+func (s *Schema) inputType(ref *TypeRef) (Type, error) {
+	var t Type
+	if ref.Elem != nil {
+		elem, err := s.inputType(ref.Elem)
+		if err != nil {
+			return nil, err
+		}
+		t = &List{Of: elem}
+	} else {
+		scalar, ok := s.types[ref.Name].(*Scalar)
+		if !ok {
+			return nil, fmt.Errorf("Unknown input type %q.", ref.Name)
+		}
+		t = scalar
+	}
+	if ref.NonNull {
+		t = &NonNull{Of: t}
+	}
+	return t, nil
+}
+
+// SDL returns the schema definition language of the schema.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	for _, name := range s.names {
+		if isBuiltinScalar(s.types[name]) {
+			continue
+		}
+		if b.Len() != 0 {
+			b.WriteString("\n")
+		}
+		switch t := s.types[name].(type) {
+		case *Scalar:
+			writeDescription(&b, "", t.Description)
+			b.WriteString("scalar " + t.Name + "\n")
+		case *Object:
+			writeDescription(&b, "", t.Description)
+			b.WriteString("type " + t.Name + " {\n")
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				b.WriteString("  " + f.Name)
+				if len(f.Args) != 0 {
+					var args []string
+					for _, arg := range f.Args {
+						def := arg.Name + ": " + arg.Type.String()
+						if arg.Default != nil {
+							v, _ := json.Marshal(arg.Default)
+							def += " = " + string(v)
+						}
+						args = append(args, def)
+					}
+					b.WriteString("(" + strings.Join(args, ", ") + ")")
+				}
+				b.WriteString(": " + f.Type.String() + "\n")
+			}
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	if strings.Contains(description, "\n") {
+		b.WriteString(indent + `"""` + "\n")
+		for _, line := range strings.Split(description, "\n") {
+			b.WriteString(indent + line + "\n")
+		}
+		b.WriteString(indent + `"""` + "\n")
+		return
+	}
+	b.WriteString(indent + strconv.Quote(description) + "\n")
+}
+
+// Built-in scalars.
+var (
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			}
+			return nil, fmt.Errorf("String cannot represent %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent a non string value: %v", v)
+		},
+		ParseLiteral: func(v Value) (interface{}, error) {
+			if s, ok := v.(StringValue); ok {
+				return string(s), nil
+			}
+			return nil, fmt.Errorf("String cannot represent a non string value: %s", printValue(v))
+		},
+	}
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case int:
+				return v, nil
+			case int32:
+				return int(v), nil
+			case int64:
+				return v, nil
+			case uint:
+				return v, nil
+			case uint32:
+				return v, nil
+			case uint64:
+				return v, nil
+			}
+			return nil, fmt.Errorf("Int cannot represent %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			f, ok := v.(float64)
+			if !ok || f != math.Trunc(f) || f > math.MaxInt32 || f < math.MinInt32 {
+				return nil, fmt.Errorf("Int cannot represent a non 32-bit integer value: %v", v)
+			}
+			return int(f), nil
+		},
+		ParseLiteral: func(v Value) (interface{}, error) {
+			if s, ok := v.(IntValue); ok {
+				if i, err := strconv.ParseInt(string(s), 10, 32); err == nil {
+					return int(i), nil
+				}
+			}
+			return nil, fmt.Errorf("Int cannot represent a non 32-bit integer value: %s", printValue(v))
+		},
+	}
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case float64:
+				return v, nil
+			case float32:
+				return float64(v), nil
+			case int:
+				return float64(v), nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if f, ok := v.(float64); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("Float cannot represent a non numeric value: %v", v)
+		},
+		ParseLiteral: func(v Value) (interface{}, error) {
+			switch v := v.(type) {
+			case IntValue:
+				return strconv.ParseFloat(string(v), 64)
+			case FloatValue:
+				return strconv.ParseFloat(string(v), 64)
+			}
+			return nil, fmt.Errorf("Float cannot represent a non numeric value: %s", printValue(v))
+		},
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", v)
+		},
+		ParseLiteral: func(v Value) (interface{}, error) {
+			if b, ok := v.(BooleanValue); ok {
+				return bool(b), nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %s", printValue(v))
+		},
+	}
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case int, int32, int64, uint, uint32, uint64:
+				return fmt.Sprint(v), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case float64:
+				if v == math.Trunc(v) {
+					return strconv.FormatInt(int64(v), 10), nil
+				}
+			}
+			return nil, fmt.Errorf("ID cannot represent value: %v", v)
+		},
+		ParseLiteral: func(v Value) (interface{}, error) {
+			switch v := v.(type) {
+			case StringValue:
+				return string(v), nil
+			case IntValue:
+				return string(v), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent value: %s", printValue(v))
+		},
+	}
+)
+
+var builtinScalars = []*Scalar{String, Int, Float, Boolean, ID}
+
+func isBuiltinScalar(t Type) bool {
+	for _, scalar := range builtinScalars {
+		if t == scalar {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+// validator validates an operation, coerces variables and arguments, and computes the depth and the complexity of it.
+type validator struct {
+	schema   *Schema
+	doc      *Document
+	vars     map[string]interface{}
+	varTypes map[string]Type
+	// varDefaults is names of variables with default values.
+	varDefaults map[string]bool
+	args        map[*Field]map[string]interface{}
+	errors      []*Error
+	// fragmentCosts is the depth and the complexity of fragments by names which are validated once.
+	fragmentCosts map[string][2]int
+	// maxComplexity stops the validation once the complexity exceeds it if positive.
+	maxComplexity int
+	exceeded      bool
+}
+
+// variables coerces given variables by definitions of the operation.
+// Variables omitted without default values are not in coerced variables.
+func (v *validator) variables(op *Operation, input map[string]interface{}) []*Error {
+	v.vars = make(map[string]interface{})
+	v.varTypes = make(map[string]Type)
+	v.varDefaults = make(map[string]bool)
+	var errs []*Error
+	for _, def := range op.Variables {
+		t, err := v.schema.inputType(def.Type)
+		if err != nil {
+			errs = append(errs, errorf(def.Location, "Variable \"$%s\" has an unknown type %q.", def.Name, def.Type))
+			continue
+		}
+		v.varTypes[def.Name] = t
+		v.varDefaults[def.Name] = def.Default != nil
+
+		value, provided := input[def.Name]
+		if !provided {
+			if def.Default != nil {
+				coerced, err := coerceLiteral(t, def.Default, nil)
+				if err != nil {
+					errs = append(errs, errorf(def.Location, "Variable \"$%s\" has an invalid default value: %v", def.Name, err))
+					continue
+				}
+				v.vars[def.Name] = coerced
+			} else if _, ok := t.(*NonNull); ok {
+				errs = append(errs, errorf(def.Location, "Variable \"$%s\" of required type %q was not provided.", def.Name, t))
+			}
+			continue
+		}
+		coerced, err := coerceValue(t, value)
+		if err != nil {
+			errs = append(errs, errorf(def.Location, "Variable \"$%s\" got invalid value: %v", def.Name, err))
+			continue
+		}
+		v.vars[def.Name] = coerced
+	}
+	return errs
+}
+
+// selectionSet validates given selection set of the type and returns the depth and the complexity of it.
+// fragments is names of fragments spread in ancestors to detect cycles.
+// The validation stops once the complexity exceeds the max complexity so that huge queries are rejected early.
+func (v *validator) selectionSet(typ *Object, set []Selection, fragments map[string]bool) (depth, complexity int) {
+	for _, sel := range set {
+		if v.exceeded {
+			return depth, complexity
+		}
+		var d, c int
+		switch sel := sel.(type) {
+		case *Field:
+			d, c = v.field(typ, sel, fragments)
+		case *InlineFragment:
+			v.directives(sel.Directives)
+			if sel.TypeCondition != "" && sel.TypeCondition != typ.Name {
+				v.errors = append(v.errors, errorf(sel.Location,
+					"Fragment cannot be spread here as objects of type %q can never be of type %q.", typ.Name, sel.TypeCondition))
+				continue
+			}
+			d, c = v.selectionSet(typ, sel.SelectionSet, fragments)
+		case *FragmentSpread:
+			v.directives(sel.Directives)
+			f, ok := v.doc.Fragments[sel.Name]
+			if !ok {
+				v.errors = append(v.errors, errorf(sel.Location, "Unknown fragment %q.", sel.Name))
+				continue
+			}
+			if fragments[sel.Name] {
+				v.errors = append(v.errors, errorf(sel.Location, "Cannot spread fragment %q within itself.", sel.Name))
+				continue
+			}
+			if f.TypeCondition != typ.Name {
+				v.errors = append(v.errors, errorf(sel.Location,
+					"Fragment %q cannot be spread here as objects of type %q can never be of type %q.", sel.Name, typ.Name, f.TypeCondition))
+				continue
+			}
+			d, c = v.fragment(typ, f, fragments)
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+		if v.maxComplexity > 0 && complexity > v.maxComplexity {
+			v.exceeded = true
+		}
+	}
+	return depth, complexity
+}
+
+// fragment validates given fragment spread in the type and returns the depth and the complexity of it.
+// Fragments are validated once and costs of them are reused by other spreads, since nested spreads of the same
+// fragments grow exponentially otherwise.
+func (v *validator) fragment(typ *Object, f *Fragment, fragments map[string]bool) (depth, complexity int) {
+	if cost, ok := v.fragmentCosts[f.Name]; ok {
+		return cost[0], cost[1]
+	}
+	fragments[f.Name] = true
+	depth, complexity = v.selectionSet(typ, f.SelectionSet, fragments)
+	delete(fragments, f.Name)
+	if v.fragmentCosts == nil {
+		v.fragmentCosts = make(map[string][2]int)
+	}
+	v.fragmentCosts[f.Name] = [2]int{depth, complexity}
+	return depth, complexity
+}
+
+func (v *validator) field(typ *Object, field *Field, fragments map[string]bool) (depth, complexity int) {
+	v.directives(field.Directives)
+	if field.Name == "__typename" {
+		if len(field.SelectionSet) != 0 {
+			v.errors = append(v.errors, errorf(field.Location, "Field \"__typename\" must not have a selection since type \"String\" has no subfields."))
+		}
+		return 1, 0
+	}
+	def := typ.Field(field.Name)
+	if def == nil {
+		v.errors = append(v.errors, errorf(field.Location, "Cannot query field %q on type %q.", field.Name, typ.Name))
+		return 0, 0
+	}
+	args, err := v.arguments(def.Args, field.Arguments)
+	if err != nil {
+		v.errors = append(v.errors, errorf(field.Location, "Field %q: %v", field.Name, err))
+		return 0, 0
+	}
+	v.args[field] = args
+
+	var childDepth, childComplexity int
+	if obj, ok := namedType(def.Type).(*Object); ok {
+		if len(field.SelectionSet) == 0 {
+			v.errors = append(v.errors, errorf(field.Location, "Field %q of type %q must have a selection of subfields.", field.Name, def.Type))
+			return 0, 0
+		}
+		childDepth, childComplexity = v.selectionSet(obj, field.SelectionSet, fragments)
+	} else if len(field.SelectionSet) != 0 {
+		v.errors = append(v.errors, errorf(field.Location, "Field %q must not have a selection since type %q has no subfields.", field.Name, def.Type))
+		return 0, 0
+	}
+	if def.Complexity != nil {
+		return 1 + childDepth, def.Complexity(args, childComplexity)
+	}
+	return 1 + childDepth, 1 + childComplexity
+}
+
+// directives validates @skip and @include directives which are only supported.
+func (v *validator) directives(directives []*Directive) {
+	for _, d := range directives {
+		if d.Name != "skip" && d.Name != "include" {
+			v.errors = append(v.errors, errorf(d.Location, "Unknown directive \"@%s\".", d.Name))
+			continue
+		}
+		if _, err := v.arguments([]*ArgumentDefinition{{Name: "if", Type: &NonNull{Of: Boolean}}}, d.Arguments); err != nil {
+			v.errors = append(v.errors, errorf(d.Location, "Directive \"@%s\": %v", d.Name, err))
+		}
+	}
+}
+
+// arguments returns coerced arguments of given definitions.
+func (v *validator) arguments(defs []*ArgumentDefinition, args []*Argument) (map[string]interface{}, error) {
+	for _, arg := range args {
+		if findArgumentDefinition(defs, arg.Name) == nil {
+			return nil, fmt.Errorf("unknown argument %q", arg.Name)
+		}
+	}
+	ret := make(map[string]interface{})
+	for _, def := range defs {
+		var arg *Argument
+		for _, a := range args {
+			if a.Name == def.Name {
+				arg = a
+			}
+		}
+		provided := arg != nil
+		if variable, ok := argValue(arg).(Variable); ok {
+			t, defined := v.varTypes[string(variable)]
+			if !defined {
+				return nil, fmt.Errorf("variable \"$%s\" is not defined", variable)
+			}
+			argType := def.Type
+			if nn, ok := argType.(*NonNull); ok && v.varDefaults[string(variable)] {
+				// variables with default values are allowed in non null positions.
+				argType = nn.Of
+			}
+			if !compatible(t, argType) {
+				return nil, fmt.Errorf("variable \"$%s\" of type %q used in position expecting type %q", variable, t, def.Type)
+			}
+			_, provided = v.vars[string(variable)]
+		}
+		if !provided {
+			if def.Default != nil {
+				ret[def.Name] = def.Default
+			} else if _, ok := def.Type.(*NonNull); ok {
+				return nil, fmt.Errorf("argument %q of type %q is required, but it was not provided", def.Name, def.Type)
+			}
+			continue
+		}
+		value, err := coerceLiteral(def.Type, arg.Value, v.vars)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q has an invalid value: %v", def.Name, err)
+		}
+		ret[def.Name] = value
+	}
+	return ret, nil
+}
+
+func findArgumentDefinition(defs []*ArgumentDefinition, name string) *ArgumentDefinition {
+	for _, def := range defs {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+func argValue(arg *Argument) Value {
+	if arg == nil {
+		return nil
+	}
+	return arg.Value
+}
+
+// compatible returns true if a variable of given type can be used as an argument of given type.
+func compatible(varType, argType Type) bool {
+	if nn, ok := argType.(*NonNull); ok {
+		varNonNull, ok := varType.(*NonNull)
+		return ok && compatible(varNonNull.Of, nn.Of)
+	}
+	if nn, ok := varType.(*NonNull); ok {
+		varType = nn.Of
+	}
+	if l, ok := argType.(*List); ok {
+		varList, ok := varType.(*List)
+		return ok && compatible(varList.Of, l.Of)
+	}
+	return varType == argType
+}
+
+func namedType(t Type) Type {
+	for {
+		switch tt := t.(type) {
+		case *List:
+			t = tt.Of
+		case *NonNull:
+			t = tt.Of
+		default:
+			return t
+		}
+	}
+}
+
+// coerceLiteral converts given literal of arguments to the value of given type.
+// Lists are []interface{} and variables are replaced with values of them.
+func coerceLiteral(t Type, value Value, vars map[string]interface{}) (interface{}, error) {
+	if variable, ok := value.(Variable); ok {
+		v := vars[string(variable)]
+		if _, nonNull := t.(*NonNull); nonNull && v == nil {
+			return nil, fmt.Errorf("expected non-null value of variable \"$%s\"", variable)
+		}
+		return v, nil
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if _, null := value.(NullValue); null {
+			return nil, fmt.Errorf("expected non-null value of type %q", t)
+		}
+		return coerceLiteral(nn.Of, value, vars)
+	}
+	if _, null := value.(NullValue); null {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		list, ok := value.(ListValue)
+		if !ok {
+			item, err := coerceLiteral(t.Of, value, vars)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		ret := make([]interface{}, 0, len(list))
+		for _, item := range list {
+			v, err := coerceLiteral(t.Of, item, vars)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, v)
+		}
+		return ret, nil
+	case *Scalar:
+		return t.ParseLiteral(value)
+	}
+	return nil, fmt.Errorf("unsupported input type %q", t)
+}
+
+// coerceValue converts given json value of variables to the value of given type.
+func coerceValue(t Type, value interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected non-null value of type %q", t)
+		}
+		return coerceValue(nn.Of, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		list, ok := value.([]interface{})
+		if !ok {
+			item, err := coerceValue(t.Of, value)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		ret := make([]interface{}, 0, len(list))
+		for _, item := range list {
+			v, err := coerceValue(t.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, v)
+		}
+		return ret, nil
+	case *Scalar:
+		return t.ParseValue(value)
+	}
+	return nil, fmt.Errorf("unsupported input type %q", t)
+}
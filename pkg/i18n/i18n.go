@@ -0,0 +1,204 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLocale is the locale of messages written in the code.
+const DefaultLocale = "en"
+
+type contextKey = string
+
+const localizerKey = contextKey("localizer")
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	defaultCatalog     *Catalog
+	defaultCatalogOnce sync.Once
+)
+
+// Args are named arguments replacing "{name}" placeholders of messages.
+type Args map[string]string
+
+// Catalog is messages of locales loaded from embedded locales/<locale>.json files.
+// Messages missing in a locale are found in the fallback locale.
+type Catalog struct {
+	fallback string
+	locales  []string
+	messages map[string]map[string]string
+}
+
+// NewCatalog returns a new Catalog of embedded locales with given fallback locale.
+func NewCatalog(fallback string) (*Catalog, error) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	c := Catalog{fallback: fallback, messages: make(map[string]map[string]string)}
+	for _, entry := range entries {
+		b, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		locale := strings.TrimSuffix(entry.Name(), ".json")
+		messages := make(map[string]string)
+		if err := json.Unmarshal(b, &messages); err != nil {
+			return nil, fmt.Errorf("invalid messages of locale %s: %w", locale, err)
+		}
+		c.locales = append(c.locales, locale)
+		c.messages[locale] = messages
+	}
+	if _, ok := c.messages[fallback]; !ok {
+		return nil, fmt.Errorf("unknown fallback locale: %s", fallback)
+	}
+	return &c, nil
+}
+
+// DefaultCatalog returns the Catalog of embedded locales with DefaultLocale as the fallback locale.
+func DefaultCatalog() *Catalog {
+	defaultCatalogOnce.Do(func() {
+		c, err := NewCatalog(DefaultLocale)
+		if err != nil {
+			panic(err)
+		}
+		defaultCatalog = c
+	})
+	return defaultCatalog
+}
+
+// Locales returns the supported locales.
+func (c *Catalog) Locales() []string {
+	return c.locales
+}
+
+// Fallback returns the fallback locale.
+func (c *Catalog) Fallback() string {
+	return c.fallback
+}
+
+// Keys returns the sorted keys of messages of given locale.
+func (c *Catalog) Keys(locale string) []string {
+	var keys []string
+	for key := range c.messages[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Match returns the supported locale preferred by given Accept-Language header value.
+// A language range matches a locale of the same language e.g. "en-US" matches "en".
+// The fallback locale is returned if no locales are matched.
+func (c *Catalog) Match(acceptLanguage string) string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		r := languageRange{tag: strings.ToLower(strings.TrimSpace(fields[0])), quality: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					r.quality = q
+				}
+			}
+		}
+		if r.tag != "" && r.quality > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, r := range ranges {
+		if r.tag == "*" {
+			return c.fallback
+		}
+		language := strings.SplitN(r.tag, "-", 2)[0]
+		for _, locale := range c.locales {
+			if l := strings.ToLower(locale); l == r.tag || l == language {
+				return locale
+			}
+		}
+	}
+	return c.fallback
+}
+
+// Localizer returns a Localizer of given locale.
+func (c *Catalog) Localizer(locale string) *Localizer {
+	return &Localizer{catalog: c, locale: locale}
+}
+
+// Localizer finds messages of a locale.
+type Localizer struct {
+	catalog *Catalog
+	locale  string
+}
+
+// Locale returns the locale of the Localizer.
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Lookup returns the message of given key with args in the locale or the fallback locale.
+// It returns false if the key is not found in both locales.
+func (l *Localizer) Lookup(key string, args Args) (string, bool) {
+	message, ok := l.catalog.messages[l.locale][key]
+	if !ok {
+		message, ok = l.catalog.messages[l.catalog.fallback][key]
+	}
+	if !ok {
+		return "", false
+	}
+	for name, value := range args {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+	return message, true
+}
+
+// Message returns the message of given key with args or the key itself if not found.
+func (l *Localizer) Message(key string, args Args) string {
+	if message, ok := l.Lookup(key, args); ok {
+		return message
+	}
+	return key
+}
+
+// WithLocalizer creates a new context with the given localizer attached.
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	if gCtx, ok := ctx.(*gin.Context); ok {
+		ctx = gCtx.Request.Context()
+	}
+	return context.WithValue(ctx, localizerKey, l)
+}
+
+// FromContext returns a localizer from given context if exist,
+// otherwise returns the localizer of DefaultLocale of DefaultCatalog.
+func FromContext(ctx context.Context) *Localizer {
+	if ctx == nil {
+		return DefaultCatalog().Localizer(DefaultLocale)
+	}
+	if gCtx, ok := ctx.(*gin.Context); ok && gCtx != nil {
+		ctx = gCtx.Request.Context()
+	}
+	if l, ok := ctx.Value(localizerKey).(*Localizer); ok {
+		return l
+	}
+	return DefaultCatalog().Localizer(DefaultLocale)
+}
@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_MissingKeys(t *testing.T) {
+	c := DefaultCatalog()
+	placeholder := regexp.MustCompile(`{\w+}`)
+	fallbackKeys := c.Keys(c.Fallback())
+	assert.NotEmpty(t, fallbackKeys)
+
+	for _, locale := range c.Locales() {
+		assert.Equal(t, fallbackKeys, c.Keys(locale), "keys of locale %s", locale)
+		for _, key := range fallbackKeys {
+			// translations must use the same placeholders
+			expected := placeholder.FindAllString(c.messages[c.Fallback()][key], -1)
+			actual := placeholder.FindAllString(c.messages[locale][key], -1)
+			assert.ElementsMatch(t, expected, actual, "placeholders of %s in locale %s", key, locale)
+		}
+	}
+}
+
+func TestCatalog_Match(t *testing.T) {
+	c := DefaultCatalog()
+	cases := []struct {
+		AcceptLanguage string
+		Expected       string
+	}{
+		{AcceptLanguage: "", Expected: "en"},
+		{AcceptLanguage: "ko", Expected: "ko"},
+		{AcceptLanguage: "ko-KR,ko;q=0.9,en-US;q=0.8", Expected: "ko"},
+		{AcceptLanguage: "en-US,en;q=0.9,ko;q=0.8", Expected: "en"},
+		{AcceptLanguage: "fr-FR, ko;q=0.5, en;q=0.1", Expected: "ko"},
+		{AcceptLanguage: "ko;q=0, en", Expected: "en"},
+		{AcceptLanguage: "fr, *;q=0.5", Expected: "en"},
+		{AcceptLanguage: "fr", Expected: "en"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.AcceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, c.Match(tc.AcceptLanguage))
+		})
+	}
+}
+
+func TestLocalizer_Message(t *testing.T) {
+	c := DefaultCatalog()
+
+	assert.Equal(t, "required email", c.Localizer("en").Message("validation.required", Args{"field": "email"}))
+	assert.Equal(t, "email은(는) 필수입니다", c.Localizer("ko").Message("validation.required", Args{"field": "email"}))
+	// unknown locales use the fallback locale
+	assert.Equal(t, "required email", c.Localizer("fr").Message("validation.required", Args{"field": "email"}))
+	// unknown keys
+	_, ok := c.Localizer("en").Lookup("unknown.key", nil)
+	assert.False(t, ok)
+	assert.Equal(t, "unknown.key", c.Localizer("en").Message("unknown.key", nil))
+}
+
+func TestNewCatalog(t *testing.T) {
+	c, err := NewCatalog("ko")
+	assert.NoError(t, err)
+	assert.Equal(t, "ko", c.Fallback())
+	assert.Equal(t, "ko", c.Match("fr"))
+
+	_, err = NewCatalog("fr")
+	assert.EqualError(t, err, "unknown fallback locale: fr")
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, DefaultLocale, FromContext(context.Background()).Locale())
+
+	ctx := WithLocalizer(context.Background(), DefaultCatalog().Localizer("ko"))
+	assert.Equal(t, "ko", FromContext(ctx).Locale())
+}
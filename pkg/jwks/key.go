@@ -0,0 +1,270 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Key is an asymmetric key pair identified by a key id(kid).
+// Private is nil if the key is only used to verify tokens.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+	Retired bool
+}
+
+// KeyConfig is a config of a key to load.
+type KeyConfig struct {
+	ID             string
+	Algorithm      string
+	PrivateKeyFile string
+	PublicKeyFile  string
+	Retired        bool
+}
+
+// LoadKey reads PEM encoded key files and returns a new Key.
+// The public key is derived from the private key if PublicKeyFile is empty,
+// and must match the private key if both files are given.
+func LoadKey(conf KeyConfig) (*Key, error) {
+	if conf.ID == "" {
+		return nil, fmt.Errorf("empty key id")
+	}
+	method := jwt.GetSigningMethod(conf.Algorithm)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s of key %s", conf.Algorithm, conf.ID)
+	}
+	key := Key{
+		ID:      conf.ID,
+		Method:  method,
+		Retired: conf.Retired,
+	}
+
+	if conf.PrivateKeyFile != "" {
+		block, err := readPEM(conf.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.Private, err = parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key of %s: %w", conf.ID, err)
+		}
+		key.Public = key.Private.(crypto.Signer).Public()
+	}
+	if conf.PublicKeyFile != "" {
+		block, err := readPEM(conf.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := parsePublicKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key of %s: %w", conf.ID, err)
+		}
+		// tokens signed by the private key must be verified by the published public key
+		if key.Public != nil {
+			if derived, ok := key.Public.(interface{ Equal(crypto.PublicKey) bool }); !ok || !derived.Equal(pub) {
+				return nil, fmt.Errorf("public key of %s does not match the private key", conf.ID)
+			}
+		}
+		key.Public = pub
+	}
+	if key.Public == nil {
+		return nil, fmt.Errorf("require private or public key file of %s", conf.ID)
+	}
+	if err := checkKeyType(method, key.Public); err != nil {
+		return nil, fmt.Errorf("key %s: %w", conf.ID, err)
+	}
+	return &key, nil
+}
+
+// JWK returns the public part of the key as a JSON Web Key(RFC 7517).
+func (k *Key) JWK() JWK {
+	jwk := JWK{
+		KeyID:     k.ID,
+		Algorithm: k.Method.Alg(),
+		Use:       "sig",
+	}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeBigInt(pub.N, 0)
+		jwk.E = encodeBigInt(big.NewInt(int64(pub.E)), 0)
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = encodeBigInt(pub.X, size)
+		jwk.Y = encodeBigInt(pub.Y, size)
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file %s: %w", path, err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+}
+
+func parsePublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+func checkKeyType(method jwt.SigningMethod, pub crypto.PublicKey) error {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA:
+		if _, ok := pub.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("%s requires a RSA key", m.Alg())
+		}
+	case *jwt.SigningMethodECDSA:
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an ECDSA key", m.Alg())
+		}
+		if ecKey.Curve.Params().BitSize != m.CurveBits {
+			return fmt.Errorf("%s requires a %d bits curve", m.Alg(), m.CurveBits)
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := pub.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("%s requires an Ed25519 key", m.Alg())
+		}
+	}
+	return nil
+}
+
+// encodeBigInt encodes given integer to base64url with left zero padding to given size.
+func encodeBigInt(i *big.Int, size int) string {
+	b := i.Bytes()
+	if len(b) < size {
+		padded := make([]byte, size)
+		copy(padded[size-len(b):], b)
+		b = padded
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Key returns a verification only Key of the JSON Web Key e.g. a key of an external identity provider.
+// The algorithm is derived from the key type if "alg" is empty.
+func (j JWK) Key() (*Key, error) {
+	alg := j.Algorithm
+	var pub crypto.PublicKey
+	switch j.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		pub = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		if alg == "" {
+			alg = "RS256"
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Curve {
+		case "P-256":
+			curve, alg = elliptic.P256(), orDefault(alg, "ES256")
+		case "P-384":
+			curve, alg = elliptic.P384(), orDefault(alg, "ES384")
+		case "P-521":
+			curve, alg = elliptic.P521(), orDefault(alg, "ES512")
+		default:
+			return nil, fmt.Errorf("unsupported curve %s of key %s", j.Curve, j.KeyID)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s of key %s", j.Curve, j.KeyID)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key %s", j.KeyID)
+		}
+		pub, alg = ed25519.PublicKey(x), orDefault(alg, "EdDSA")
+	default:
+		return nil, fmt.Errorf("unsupported key type %s of key %s", j.KeyType, j.KeyID)
+	}
+
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported algorithm %s of key %s", alg, j.KeyID)
+	}
+	if err := checkKeyType(method, pub); err != nil {
+		return nil, fmt.Errorf("key %s: %w", j.KeyID, err)
+	}
+	return &Key{ID: j.KeyID, Method: method, Public: pub}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
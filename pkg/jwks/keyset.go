@@ -0,0 +1,124 @@
+package jwks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrUnknownKeyID            = errors.New("unknown key id")
+	ErrInvalidSigningAlgorithm = errors.New("invalid signing algorithm")
+)
+
+// JWK is a public JSON Web Key(RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of JWK served from /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet signs and verifies jwt tokens.
+//
+// If there are no asymmetric keys, tokens are signed and verified with HS256 and a shared secret.
+// Otherwise tokens are signed with the signing key and "kid" header, and verified with
+// any of the keys including retired ones to accept tokens issued before a key rotation.
+type KeySet struct {
+	secret  []byte
+	signing *Key
+	keys    []*Key
+	byID    map[string]*Key
+}
+
+// NewKeySet creates a new KeySet with given secret and keys.
+// signingKeyID is the id of a key to sign, the first non retired key with a private key is used if empty.
+func NewKeySet(secret string, signingKeyID string, keys []*Key) (*KeySet, error) {
+	ks := KeySet{
+		secret: []byte(secret),
+		keys:   keys,
+		byID:   make(map[string]*Key, len(keys)),
+	}
+	for _, key := range keys {
+		if _, ok := ks.byID[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+		ks.byID[key.ID] = key
+		if signingKeyID == "" && ks.signing == nil && !key.Retired && key.Private != nil {
+			ks.signing = key
+		}
+	}
+	if signingKeyID != "" {
+		ks.signing = ks.byID[signingKeyID]
+		if ks.signing == nil {
+			return nil, fmt.Errorf("not found signing key %s", signingKeyID)
+		}
+	}
+	if len(keys) == 0 {
+		if len(ks.secret) == 0 {
+			return nil, errors.New("secret key is required")
+		}
+		return &ks, nil
+	}
+	if ks.signing == nil {
+		return nil, errors.New("no signing key")
+	}
+	if ks.signing.Retired || ks.signing.Private == nil {
+		return nil, fmt.Errorf("signing key %s must be active and have a private key", ks.signing.ID)
+	}
+	return &ks, nil
+}
+
+// Sign returns a signed token string with given claims.
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.Private)
+}
+
+// Parse parses and validates given token string.
+func (ks *KeySet) Parse(token string) (*jwt.Token, error) {
+	return jwt.Parse(token, ks.keyFunc)
+}
+
+// JWKS returns public keys of the key set. Retired keys are included
+// so that other services can verify tokens which are not expired yet.
+func (ks *KeySet) JWKS() *JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return &set
+}
+
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	if ks.signing == nil {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrInvalidSigningAlgorithm
+		}
+		return ks.secret, nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.byID[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if t.Method != key.Method {
+		return nil, ErrInvalidSigningAlgorithm
+	}
+	return key.Public, nil
+}
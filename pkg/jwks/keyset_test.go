@@ -0,0 +1,255 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySet_SignAndParse(t *testing.T) {
+	dir := tempDir(t)
+	cases := []struct {
+		Algorithm string
+		Key       crypto.Signer
+		KeyType   string
+	}{
+		{Algorithm: "RS256", Key: mustRSAKey(t), KeyType: "RSA"},
+		{Algorithm: "ES256", Key: mustECKey(t, elliptic.P256()), KeyType: "EC"},
+		{Algorithm: "EdDSA", Key: mustEd25519Key(t), KeyType: "OKP"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Algorithm, func(t *testing.T) {
+			key, err := LoadKey(KeyConfig{
+				ID:             "key-" + tc.Algorithm,
+				Algorithm:      tc.Algorithm,
+				PrivateKeyFile: writePrivateKey(t, dir, tc.Key),
+			})
+			assert.NoError(t, err)
+			ks, err := NewKeySet("", "", []*Key{key})
+			assert.NoError(t, err)
+
+			// when
+			signed, err := ks.Sign(jwt.MapClaims{"id": "user1@email.com"})
+			assert.NoError(t, err)
+			token, err := ks.Parse(signed)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, "key-"+tc.Algorithm, token.Header["kid"])
+			assert.Equal(t, tc.Algorithm, token.Header["alg"])
+			assert.Equal(t, "user1@email.com", token.Claims.(jwt.MapClaims)["id"])
+			jwks := ks.JWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tc.KeyType, jwks.Keys[0].KeyType)
+			assert.Equal(t, "key-"+tc.Algorithm, jwks.Keys[0].KeyID)
+			assert.Equal(t, tc.Algorithm, jwks.Keys[0].Algorithm)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := tempDir(t)
+	oldPrivate, newPrivate := mustRSAKey(t), mustEd25519Key(t)
+	oldKey, err := LoadKey(KeyConfig{ID: "old", Algorithm: "RS256", PrivateKeyFile: writePrivateKey(t, dir, oldPrivate)})
+	assert.NoError(t, err)
+	before, err := NewKeySet("", "", []*Key{oldKey})
+	assert.NoError(t, err)
+	oldToken, err := before.Sign(jwt.MapClaims{"id": "user1@email.com"})
+	assert.NoError(t, err)
+
+	// rotate keys: the old key is only used to verify tokens
+	retiredKey, err := LoadKey(KeyConfig{ID: "old", Algorithm: "RS256", PublicKeyFile: writePublicKey(t, dir, oldPrivate.Public()), Retired: true})
+	assert.NoError(t, err)
+	newKey, err := LoadKey(KeyConfig{ID: "new", Algorithm: "EdDSA", PrivateKeyFile: writePrivateKey(t, dir, newPrivate)})
+	assert.NoError(t, err)
+	after, err := NewKeySet("", "new", []*Key{retiredKey, newKey})
+	assert.NoError(t, err)
+
+	// when then: accept a token signed by the retired key
+	_, err = after.Parse(oldToken)
+	assert.NoError(t, err)
+	newToken, err := after.Sign(jwt.MapClaims{"id": "user1@email.com"})
+	assert.NoError(t, err)
+	token, err := after.Parse(newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+	assert.Len(t, after.JWKS().Keys, 2)
+
+	// when then: reject a token signed by an unknown key
+	_, err = before.Parse(newToken)
+	assert.Error(t, err)
+
+	// when then: reject an expired token
+	expired, err := after.Sign(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+	_, err = after.Parse(expired)
+	assert.Error(t, err)
+
+	// when then: retired key can not sign
+	_, err = NewKeySet("", "old", []*Key{retiredKey, newKey})
+	assert.Error(t, err)
+}
+
+func TestKeySet_Secret(t *testing.T) {
+	ks, err := NewKeySet("secret-key", "", nil)
+	assert.NoError(t, err)
+
+	signed, err := ks.Sign(jwt.MapClaims{"id": "user1@email.com"})
+	assert.NoError(t, err)
+	token, err := ks.Parse(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "HS256", token.Header["alg"])
+	assert.Empty(t, ks.JWKS().Keys)
+
+	other, err := NewKeySet("other-key", "", nil)
+	assert.NoError(t, err)
+	_, err = other.Parse(signed)
+	assert.Error(t, err)
+
+	_, err = NewKeySet("", "", nil)
+	assert.Error(t, err)
+}
+
+func TestLoadKey_Invalid(t *testing.T) {
+	dir := tempDir(t)
+	rsaKey := mustRSAKey(t)
+	rsaFile := writePrivateKey(t, dir, rsaKey)
+	otherPublicFile := writePublicKey(t, dir, mustRSAKey(t).Public())
+	cases := []struct {
+		Name string
+		Conf KeyConfig
+	}{
+		{Name: "empty id", Conf: KeyConfig{Algorithm: "RS256", PrivateKeyFile: rsaFile}},
+		{Name: "hmac algorithm", Conf: KeyConfig{ID: "k", Algorithm: "HS256", PrivateKeyFile: rsaFile}},
+		{Name: "mismatched key type", Conf: KeyConfig{ID: "k", Algorithm: "ES256", PrivateKeyFile: rsaFile}},
+		{Name: "no key files", Conf: KeyConfig{ID: "k", Algorithm: "RS256"}},
+		{Name: "not exist file", Conf: KeyConfig{ID: "k", Algorithm: "RS256", PrivateKeyFile: filepath.Join(dir, "none.pem")}},
+		{Name: "mismatched key pair", Conf: KeyConfig{ID: "k", Algorithm: "RS256", PrivateKeyFile: rsaFile, PublicKeyFile: otherPublicFile}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := LoadKey(tc.Conf)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadKey_KeyPair(t *testing.T) {
+	dir := tempDir(t)
+	private := mustECKey(t, elliptic.P256())
+
+	// when
+	key, err := LoadKey(KeyConfig{
+		ID:             "k",
+		Algorithm:      "ES256",
+		PrivateKeyFile: writePrivateKey(t, dir, private),
+		PublicKeyFile:  writePublicKey(t, dir, private.Public()),
+	})
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, private.PublicKey.Equal(key.Public))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "jwks")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func writePrivateKey(t *testing.T, dir string, key crypto.PrivateKey) string {
+	b, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return writePEM(t, dir, "PRIVATE KEY", b)
+}
+
+func writePublicKey(t *testing.T, dir string, key crypto.PublicKey) string {
+	b, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+	return writePEM(t, dir, "PUBLIC KEY", b)
+}
+
+func writePEM(t *testing.T, dir, blockType string, b []byte) string {
+	f, err := ioutil.TempFile(dir, "*.pem")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, pem.Encode(f, &pem.Block{Type: blockType, Bytes: b}))
+	return f.Name()
+}
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return key
+}
+
+func mustECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	assert.NoError(t, err)
+	return key
+}
+
+func mustEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestJWK_Key(t *testing.T) {
+	dir := tempDir(t)
+	cases := []struct {
+		Algorithm string
+		Key       crypto.Signer
+	}{
+		{Algorithm: "RS256", Key: mustRSAKey(t)},
+		{Algorithm: "ES384", Key: mustECKey(t, elliptic.P384())},
+		{Algorithm: "EdDSA", Key: mustEd25519Key(t)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Algorithm, func(t *testing.T) {
+			key, err := LoadKey(KeyConfig{ID: "k", Algorithm: tc.Algorithm, PrivateKeyFile: writePrivateKey(t, dir, tc.Key)})
+			assert.NoError(t, err)
+			ks, err := NewKeySet("", "", []*Key{key})
+			assert.NoError(t, err)
+			signed, err := ks.Sign(jwt.MapClaims{"id": "user1@email.com"})
+			assert.NoError(t, err)
+
+			// when
+			jwk := key.JWK()
+			jwk.Algorithm = ""
+			parsed, err := jwk.Key()
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Algorithm, parsed.Method.Alg())
+			assert.Equal(t, key.Public, parsed.Public)
+			assert.Nil(t, parsed.Private)
+			_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) {
+				return parsed.Public, nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err := JWK{KeyType: "oct", KeyID: "k"}.Key()
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	gitHubAuthURL  = "https://github.com/login/oauth/authorize"
+	gitHubTokenURL = "https://github.com/login/oauth/access_token"
+	gitHubAPIURL   = "https://api.github.com"
+)
+
+var defaultGitHubScopes = []string{"read:user", "user:email"}
+
+// gitHubProvider is a GitHub OAuth app. GitHub is not an OpenID Connect provider,
+// so the identity is read from the user and emails APIs.
+type gitHubProvider struct {
+	conf   Config
+	client *http.Client
+}
+
+func newGitHubProvider(conf Config, client *http.Client) *gitHubProvider {
+	if conf.AuthURL == "" {
+		conf.AuthURL = gitHubAuthURL
+	}
+	if conf.TokenURL == "" {
+		conf.TokenURL = gitHubTokenURL
+	}
+	if conf.APIURL == "" {
+		conf.APIURL = gitHubAPIURL
+	}
+	conf.APIURL = strings.TrimSuffix(conf.APIURL, "/")
+	return &gitHubProvider{
+		conf:   conf,
+		client: client,
+	}
+}
+
+func (p *gitHubProvider) AuthCodeURL(_ context.Context, state, _, codeChallenge string) (string, error) {
+	scopes := p.conf.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultGitHubScopes
+	}
+	return authCodeURL(p.conf.AuthURL, p.conf, scopes, url.Values{
+		"state":          {state},
+		"code_challenge": {codeChallenge},
+	})
+}
+
+func (p *gitHubProvider) Exchange(ctx context.Context, code, codeVerifier, _ string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.client, p.conf.TokenURL, p.conf, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, p.conf.APIURL+"/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, p.conf.APIURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := Identity{
+		Provider: p.conf.Name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email, identity.EmailVerified = e.Email, e.Verified
+			break
+		}
+	}
+	if identity.Email == "" {
+		return nil, ErrEmailNotProvided
+	}
+	return &identity, nil
+}
@@ -0,0 +1,210 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Types of providers
+const (
+	TypeOIDC   = "oidc"
+	TypeGitHub = "github"
+)
+
+var (
+	ErrUnknownProvider     = errors.New("unknown provider")
+	ErrInvalidIDToken      = errors.New("invalid id token")
+	ErrEmailNotProvided    = errors.New("email is not provided")
+	ErrUnsupportedProvider = errors.New("unsupported provider type")
+)
+
+// Config is a config of an OAuth2 provider.
+type Config struct {
+	Name         string
+	Type         string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+	// Issuer is the issuer of an OpenID Connect provider to discover endpoints
+	Issuer string
+	// AuthURL, TokenURL and APIURL override endpoints of a GitHub provider e.g. GitHub Enterprise
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// Identity is a user authenticated by a provider.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an OAuth2 provider supporting the authorization code flow with PKCE(RFC 7636).
+type Provider interface {
+	// AuthCodeURL returns an url of the authorization endpoint with given state, nonce and S256 code challenge
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+
+	// Exchange exchanges given authorization code to the identity of the user.
+	// nonce must be the nonce given to AuthCodeURL.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// Providers is a set of providers by the name
+type Providers map[string]Provider
+
+// Get returns a provider with given name or ErrUnknownProvider
+func (p Providers) Get(name string) (Provider, error) {
+	provider, ok := p[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// NewProviders creates providers with given configs.
+// client is used to call endpoints of providers, a client with 10 seconds timeout is used if nil.
+func NewProviders(confs []Config, client *http.Client) (Providers, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	providers := make(Providers, len(confs))
+	for _, conf := range confs {
+		if conf.Name == "" {
+			return nil, errors.New("empty provider name")
+		}
+		if _, ok := providers[conf.Name]; ok {
+			return nil, fmt.Errorf("duplicate provider %s", conf.Name)
+		}
+		switch conf.Type {
+		case TypeOIDC:
+			if conf.Issuer == "" {
+				return nil, fmt.Errorf("empty issuer of provider %s", conf.Name)
+			}
+			providers[conf.Name] = newOIDCProvider(conf, client)
+		case TypeGitHub:
+			providers[conf.Name] = newGitHubProvider(conf, client)
+		default:
+			return nil, fmt.Errorf("%w %s of provider %s", ErrUnsupportedProvider, conf.Type, conf.Name)
+		}
+	}
+	return providers, nil
+}
+
+// RandomString returns a url safe random string of given bytes
+func RandomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewCodeVerifier returns a new PKCE code verifier
+func NewCodeVerifier() (string, error) {
+	return RandomString(32)
+}
+
+// CodeChallenge returns a S256 PKCE code challenge of given verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode requests an access token to given token endpoint
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL string, conf Config, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", conf.RedirectURL)
+	form.Set("client_id", conf.ClientID)
+	form.Set("client_secret", conf.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request token: %w", err)
+	}
+	defer res.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("decode token response(status %d): %w", res.StatusCode, err)
+	}
+	// some providers e.g. GitHub return errors with 200 status code
+	if token.Error != "" {
+		return nil, fmt.Errorf("token error: %s %s", token.Error, token.ErrorDescription)
+	}
+	if res.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return nil, fmt.Errorf("token error: status %d", res.StatusCode)
+	}
+	return &token, nil
+}
+
+// getJSON requests GET to given url with the access token and decodes the response to v
+func getJSON(ctx context.Context, client *http.Client, u, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", u, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("request %s: status %d %s", u, res.StatusCode, b)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
+
+func authCodeURL(endpoint string, conf Config, scopes []string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", conf.ClientID)
+	q.Set("redirect_uri", conf.RedirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("code_challenge_method", "S256")
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
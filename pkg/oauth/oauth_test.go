@@ -0,0 +1,188 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"gin-rest-api-example/pkg/oauth/oauthtest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURL = "http://localhost:8080/v1/api/auth/mock/callback"
+
+func TestOIDCProvider(t *testing.T) {
+	srv, mock := oauthtest.NewServer("client-id", "client-secret")
+	defer srv.Close()
+	mock.SetUser(oauthtest.User{Subject: "user-1", Email: "user1@email.com", EmailVerified: true, Name: "user1"})
+	provider := mustProvider(t, Config{
+		Name:         "mock",
+		Type:         TypeOIDC,
+		Issuer:       srv.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  redirectURL,
+	})
+	verifier, err := NewCodeVerifier()
+	assert.NoError(t, err)
+
+	// when
+	code := authorize(t, provider, "state-1", "nonce-1", CodeChallenge(verifier))
+	identity, err := provider.Exchange(context.Background(), code, verifier, "nonce-1")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "mock",
+		Subject:       "user-1",
+		Email:         "user1@email.com",
+		EmailVerified: true,
+		Name:          "user1",
+	}, identity)
+}
+
+func TestOIDCProvider_Invalid(t *testing.T) {
+	srv, _ := oauthtest.NewServer("client-id", "client-secret")
+	defer srv.Close()
+	conf := Config{
+		Name:         "mock",
+		Type:         TypeOIDC,
+		Issuer:       srv.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  redirectURL,
+	}
+	provider := mustProvider(t, conf)
+	verifier, _ := NewCodeVerifier()
+
+	// when then: mismatched nonce
+	code := authorize(t, provider, "state", "nonce", CodeChallenge(verifier))
+	_, err := provider.Exchange(context.Background(), code, verifier, "other-nonce")
+	assert.Error(t, err)
+
+	// when then: mismatched code verifier
+	code = authorize(t, provider, "state", "nonce", CodeChallenge(verifier))
+	_, err = provider.Exchange(context.Background(), code, "other-verifier", "nonce")
+	assert.Error(t, err)
+
+	// when then: used code
+	code = authorize(t, provider, "state", "nonce", CodeChallenge(verifier))
+	_, err = provider.Exchange(context.Background(), code, verifier, "nonce")
+	assert.NoError(t, err)
+	_, err = provider.Exchange(context.Background(), code, verifier, "nonce")
+	assert.Error(t, err)
+
+	// when then: id token of another client
+	conf.ClientID = "other-client"
+	other := mustProvider(t, conf)
+	_, err = other.Exchange(context.Background(), "code", verifier, "nonce")
+	assert.Error(t, err)
+
+	// when then: unknown issuer
+	conf.Issuer = srv.URL + "/unknown"
+	unknown := mustProvider(t, conf)
+	_, err = unknown.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.Error(t, err)
+}
+
+func TestGitHubProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "code-1" || r.PostForm.Get("code_verifier") != "verifier-1" {
+			writeJSON(w, map[string]interface{}{"error": "bad_verification_code"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"access_token": "access-token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+		writeJSON(w, map[string]interface{}{"id": 1234, "login": "octocat", "name": ""})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]interface{}{
+			{"email": "other@email.com", "primary": false, "verified": true},
+			{"email": "octocat@email.com", "primary": true, "verified": true},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	provider := mustProvider(t, Config{
+		Name:        "github",
+		Type:        TypeGitHub,
+		ClientID:    "client-id",
+		RedirectURL: redirectURL,
+		AuthURL:     srv.URL + "/login/oauth/authorize",
+		TokenURL:    srv.URL + "/login/oauth/access_token",
+		APIURL:      srv.URL,
+	})
+
+	// when
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "", "challenge-1")
+	assert.NoError(t, err)
+	identity, err := provider.Exchange(context.Background(), "code-1", "verifier-1", "")
+
+	// then
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+	assert.Equal(t, "challenge-1", u.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "github",
+		Subject:       "1234",
+		Email:         "octocat@email.com",
+		EmailVerified: true,
+		Name:          "octocat",
+	}, identity)
+
+	_, err = provider.Exchange(context.Background(), "code-1", "other-verifier", "")
+	assert.Error(t, err)
+}
+
+func TestNewProviders(t *testing.T) {
+	_, err := NewProviders([]Config{{Name: "p", Type: "saml"}}, nil)
+	assert.Error(t, err)
+	_, err = NewProviders([]Config{{Name: "p", Type: TypeOIDC}}, nil)
+	assert.Error(t, err)
+	_, err = NewProviders([]Config{{Name: "p", Type: TypeGitHub}, {Name: "p", Type: TypeGitHub}}, nil)
+	assert.Error(t, err)
+
+	providers, err := NewProviders([]Config{{Name: "github", Type: TypeGitHub}}, nil)
+	assert.NoError(t, err)
+	_, err = providers.Get("github")
+	assert.NoError(t, err)
+	_, err = providers.Get("google")
+	assert.Equal(t, ErrUnknownProvider, err)
+}
+
+func mustProvider(t *testing.T, conf Config) Provider {
+	providers, err := NewProviders([]Config{conf}, nil)
+	assert.NoError(t, err)
+	return providers[conf.Name]
+}
+
+// authorize requests the authorization endpoint and returns the code of the redirect url
+func authorize(t *testing.T, provider Provider, state, nonce, challenge string) string {
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, challenge)
+	assert.NoError(t, err)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+	location, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
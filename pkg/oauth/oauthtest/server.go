@@ -0,0 +1,272 @@
+// Package oauthtest provides a mock OpenID Connect provider for tests and local development.
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"gin-rest-api-example/pkg/jwks"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// User is a user authenticated by the mock provider
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type authRequest struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	user          User
+}
+
+// Provider is a mock OpenID Connect provider which authorizes User without a login page.
+// The authorization endpoint redirects to the redirect uri with a code immediately.
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	mu     sync.Mutex
+	user   User
+	keys   *jwks.KeySet
+	jwks   *jwks.JSONWebKeySet
+	codes  map[string]*authRequest
+	tokens map[string]User
+	mux    *http.ServeMux
+}
+
+// NewProvider creates a new mock provider with given issuer and client
+func NewProvider(issuer, clientID, clientSecret string) (*Provider, error) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	key := &jwks.Key{ID: "oauthtest", Method: jwt.SigningMethodRS256, Private: private, Public: private.Public()}
+	keys, err := jwks.NewKeySet("", "", []*jwks.Key{key})
+	if err != nil {
+		return nil, err
+	}
+	p := Provider{
+		Issuer:       issuer,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		user: User{
+			Subject:       "oauthtest-user",
+			Email:         "oauthtest@email.com",
+			EmailVerified: true,
+			Name:          "oauthtest",
+		},
+		keys:   keys,
+		jwks:   keys.JWKS(),
+		codes:  make(map[string]*authRequest),
+		tokens: make(map[string]User),
+		mux:    http.NewServeMux(),
+	}
+	p.mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	p.mux.HandleFunc("/authorize", p.authorize)
+	p.mux.HandleFunc("/token", p.token)
+	p.mux.HandleFunc("/userinfo", p.userInfo)
+	p.mux.HandleFunc("/jwks", p.keySet)
+	return &p, nil
+}
+
+// NewServer starts a new httptest.Server serving a mock provider. The issuer is the url of the server.
+func NewServer(clientID, clientSecret string) (*httptest.Server, *Provider) {
+	srv := httptest.NewUnstartedServer(nil)
+	p, err := NewProvider("http://"+srv.Listener.Addr().String(), clientID, clientSecret)
+	if err != nil {
+		panic(err)
+	}
+	srv.Config.Handler = p
+	srv.Start()
+	return srv, p
+}
+
+// ListenAndServe serves a mock provider at given address e.g. "localhost:9000"
+func ListenAndServe(addr, clientID, clientSecret string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	p, err := NewProvider("http://"+l.Addr().String(), clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	return http.Serve(l, p)
+}
+
+// SetUser sets a user authorized by next authorization requests
+func (p *Provider) SetUser(user User) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.user = user
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"userinfo_endpoint":                     p.Issuer + "/userinfo",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = &authRequest{
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		user:          p.user,
+	}
+	p.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.ClientID || clientSecret != p.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	p.mu.Lock()
+	req, ok := p.codes[r.PostForm.Get("code")]
+	// codes can be used only once
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	if !ok || req.redirectURI != r.PostForm.Get("redirect_uri") {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != req.codeChallenge {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            req.user.Subject,
+		"aud":            p.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"email":          req.user.Email,
+		"email_verified": req.user.EmailVerified,
+		"name":           req.user.Name,
+	}
+	if req.nonce != "" {
+		claims["nonce"] = req.nonce
+	}
+	idToken, err := p.keys.Sign(claims)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	accessToken := randomString()
+	p.mu.Lock()
+	p.tokens[accessToken] = req.user
+	p.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) userInfo(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || auth[:len(prefix)] != prefix {
+		writeError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+	p.mu.Lock()
+	user, ok := p.tokens[auth[len(prefix):]]
+	p.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            user.Subject,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	})
+}
+
+func (p *Provider) keySet(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, p.jwks)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,213 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"gin-rest-api-example/pkg/jwks"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var defaultOIDCScopes = []string{"openid", "email", "profile"}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider is an OpenID Connect provider. Endpoints are discovered from the issuer at the first use
+// and id tokens are verified with keys of the jwks_uri.
+type oidcProvider struct {
+	conf   Config
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*jwks.Key
+}
+
+func newOIDCProvider(conf Config, client *http.Client) *oidcProvider {
+	return &oidcProvider{
+		conf:   conf,
+		client: client,
+	}
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	scopes := p.conf.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultOIDCScopes
+	}
+	return authCodeURL(d.AuthorizationEndpoint, p.conf, scopes, url.Values{
+		"state":          {state},
+		"nonce":          {nonce},
+		"code_challenge": {codeChallenge},
+	})
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := exchangeCode(ctx, p.client, d.TokenEndpoint, p.conf, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id token in the token response", ErrInvalidIDToken)
+	}
+	claims, err := p.verifyIDToken(ctx, d, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := identityFromClaims(p.conf.Name, claims)
+	if identity.Email == "" && d.UserInfoEndpoint != "" {
+		var userInfo map[string]interface{}
+		if err := getJSON(ctx, p.client, d.UserInfoEndpoint, token.AccessToken, &userInfo); err != nil {
+			return nil, err
+		}
+		if sub, _ := userInfo["sub"].(string); sub != identity.Subject {
+			return nil, fmt.Errorf("%w: subject of userinfo is mismatched", ErrInvalidIDToken)
+		}
+		fromUserInfo := identityFromClaims(p.conf.Name, userInfo)
+		identity.Email, identity.EmailVerified = fromUserInfo.Email, fromUserInfo.EmailVerified
+		if identity.Name == "" {
+			identity.Name = fromUserInfo.Name
+		}
+	}
+	if identity.Email == "" {
+		return nil, ErrEmailNotProvided
+	}
+	return identity, nil
+}
+
+func (p *oidcProvider) verifyIDToken(ctx context.Context, d *discovery, idToken, nonce string) (jwt.MapClaims, error) {
+	parsed, err := jwt.Parse(idToken, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := p.key(ctx, d, kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method != key.Method {
+			return nil, jwks.ErrInvalidSigningAlgorithm
+		}
+		return key.Public, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, fmt.Errorf("%w: issuer is mismatched", ErrInvalidIDToken)
+	}
+	if !verifyAudience(claims, p.conf.ClientID) {
+		return nil, fmt.Errorf("%w: audience is mismatched", ErrInvalidIDToken)
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidIDToken)
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("%w: nonce is mismatched", ErrInvalidIDToken)
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// discover returns the discovery document of the issuer. Failures are not cached to retry at the next use.
+func (p *oidcProvider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	u := strings.TrimSuffix(p.conf.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, p.client, u, "", &d); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", p.conf.Name, err)
+	}
+	if d.Issuer != p.conf.Issuer {
+		return nil, fmt.Errorf("discover %s: issuer %s is mismatched", p.conf.Name, d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("discover %s: missing endpoints", p.conf.Name)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// key returns a key of the provider with given key id. Keys are fetched again if the key id is unknown
+// because the provider may rotate keys.
+func (p *oidcProvider) key(ctx context.Context, d *discovery, kid string) (*jwks.Key, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set jwks.JSONWebKeySet
+	if err := getJSON(ctx, p.client, d.JWKSURI, "", &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*jwks.Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			// skip unsupported keys
+			continue
+		}
+		keys[key.ID] = key
+	}
+	p.keys = keys
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, jwks.ErrUnknownKeyID
+}
+
+func verifyAudience(claims jwt.MapClaims, clientID string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if s, _ := a.(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func identityFromClaims(provider string, claims map[string]interface{}) *Identity {
+	identity := Identity{Provider: provider}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	// some providers return email_verified as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	return &identity
+}
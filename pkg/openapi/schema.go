@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// rulePatterns is patterns of validations of binding tags.
+var rulePatterns = map[string]string{
+	"numeric":  `^[-+]?[0-9]+(\.[0-9]+)?$`,
+	"slug":     `^[a-z0-9]+(?:-[a-z0-9]+)*$`,
+	"username": `^[A-Za-z0-9][A-Za-z0-9._-]*$`,
+}
+
+// schemaGenerator generates schemas of Go types by json tags and validations of binding tags.
+// Named struct types are referred from components.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf returns the schema of given type.
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return &Schema{Ref: "#/components/schemas/" + g.register(t)}
+	}
+	return g.inlineSchemaOf(t)
+}
+
+func (g *schemaGenerator) inlineSchemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.structSchemaOf(t)
+	}
+	// interface{} is any value
+	return &Schema{}
+}
+
+// register adds the schema of given named struct type to components and returns the name.
+// Names are prefixed by package names if the same names are used by other packages.
+func (g *schemaGenerator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, ok := g.schemas[name]; ok {
+		pkg := t.PkgPath()
+		name = exportedName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	g.names[t] = name
+	// registers a placeholder first for recursive types
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchemaOf(t)
+	return name
+}
+
+func (g *schemaGenerator) structSchemaOf(t reflect.Type) *Schema {
+	s := Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// fields of unexported embedded structs are promoted as encoding/json does
+		if f.PkgPath != "" && !(f.Anonymous && indirect(f.Type).Kind() == reflect.Struct) {
+			continue
+		}
+		name, _ := tagName(f.Tag.Get("json"))
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			// fields of embedded structs are promoted
+			embedded := g.inlineSchemaOf(indirect(f.Type))
+			for _, k := range embedded.propertyOrder {
+				s.Properties[k] = embedded.Properties[k]
+			}
+			s.propertyOrder = append(s.propertyOrder, embedded.propertyOrder...)
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs, required := g.fieldSchemaOf(f)
+		s.Properties[name] = fs
+		s.propertyOrder = append(s.propertyOrder, name)
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return &s
+}
+
+// fieldSchemaOf returns the schema of given field with validations of the binding tag
+// and whether the field is required.
+func (g *schemaGenerator) fieldSchemaOf(f reflect.StructField) (*Schema, bool) {
+	s := g.schemaOf(f.Type)
+	if s.Ref != "" {
+		return s, hasRule(f.Tag.Get("binding"), "required")
+	}
+	// validations after "dive" are validations of elements
+	target, required := s, false
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		name, param := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		switch name {
+		case "required":
+			required = target == s
+		case "dive":
+			if target.Items == nil {
+				return s, required
+			}
+			copied := *target.Items
+			target.Items = &copied
+			target = &copied
+		default:
+			applyRule(target, name, param)
+		}
+	}
+	return s, required
+}
+
+// applyRule applies a validation of go-playground/validator to given inline schema.
+func applyRule(s *Schema, name, param string) {
+	if s.Ref != "" {
+		return
+	}
+	switch name {
+	case "min", "max":
+		n, err := strconv.Atoi(param)
+		if err != nil || s.Type != "string" {
+			return
+		}
+		if name == "min" {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "email":
+		s.Format = "email"
+	case "httpurl":
+		s.Format = "uri"
+	case "numeric", "slug", "username":
+		s.Pattern = rulePatterns[name]
+		s.patternTag = name
+	case "datetime":
+		s.Format = "date-time"
+	case "oneof":
+		s.Enum = strings.Fields(param)
+	}
+}
+
+// queryParametersOf returns query parameters of given struct type by form tags.
+func (g *schemaGenerator) queryParametersOf(t reflect.Type) []*Parameter {
+	t = indirect(t)
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, options := tagName(f.Tag.Get("form"))
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s, required := g.fieldSchemaOf(f)
+		for _, option := range options {
+			if strings.HasPrefix(option, "default=") {
+				s.Default = strings.TrimPrefix(option, "default=")
+			}
+		}
+		params = append(params, &Parameter{Name: name, In: "query", Required: required, Schema: s})
+	}
+	return params
+}
+
+// tagName returns the name and options of a json or form tag.
+func tagName(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
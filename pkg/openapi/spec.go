@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger.html
+var swaggerFiles embed.FS
+
+var swaggerTemplate = template.Must(template.ParseFS(swaggerFiles, "swagger.html"))
+
+// Route is the documentation of a route registered to gin.
+// Path parameters are found in the gin path e.g. "/v1/api/articles/:slug".
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Tags    []string
+	// Security is names of security schemes of which one is required e.g. BearerAuth.
+	Security []string
+	// Query is a struct of query parameters with form tags.
+	Query interface{}
+	// Request is a json request body.
+	Request interface{}
+	// Responses is json response bodies by status codes. A nil body is a response without a body.
+	Responses map[int]interface{}
+}
+
+// Spec builds an OpenAPI 3 document of routes with schemas of request and response types.
+type Spec struct {
+	mu          sync.Mutex
+	doc         Document
+	schemas     *schemaGenerator
+	errorSchema *Schema
+	json        []byte
+	// operations is operations by operationKey to be looked up by requests without the lock.
+	operations sync.Map
+}
+
+// NewSpec returns a new Spec of given info. Errors of operations are responded as errorBody
+// in application/problem+json.
+func NewSpec(info Info, errorBody interface{}) *Spec {
+	s := Spec{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				SecuritySchemes: map[string]*SecurityScheme{
+					BearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+					APIKeyAuth: {Type: "apiKey", In: "header", Name: "Authorization", Description: "ApiKey <key>"},
+				},
+			},
+		},
+		schemas: newSchemaGenerator(),
+	}
+	s.errorSchema = s.schemas.schemaOf(reflect.TypeOf(errorBody))
+	s.doc.Components.Schemas = s.schemas.schemas
+	return &s
+}
+
+// Add adds operations of given routes.
+func (s *Spec) Add(routes ...Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.json = nil
+	for _, r := range routes {
+		path, params := openAPIPath(r.Path)
+		item, ok := s.doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			s.doc.Paths[path] = item
+		}
+		op := s.operation(r, params)
+		(*item)[strings.ToLower(r.Method)] = op
+		s.operations.Store(operationKey(r.Method, path), op)
+	}
+}
+
+// operationKey returns the key of operations of given method and OpenAPI path e.g. "get /v1/api/articles/{slug}".
+func operationKey(method, path string) string {
+	return strings.ToLower(method) + " " + path
+}
+
+func (s *Spec) operation(r Route, pathParams []string) *Operation {
+	op := Operation{
+		OperationID: operationID(r.Method, r.Path),
+		Summary:     r.Summary,
+		Tags:        r.Tags,
+		Responses:   make(map[string]*Response),
+	}
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if r.Query != nil {
+		op.Parameters = append(op.Parameters, s.schemas.queryParametersOf(reflect.TypeOf(r.Query))...)
+	}
+	if r.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: s.schemas.schemaOf(reflect.TypeOf(r.Request))}},
+		}
+	}
+	for status, body := range r.Responses {
+		res := Response{Description: http.StatusText(status)}
+		if body != nil {
+			res.Content = map[string]*MediaType{"application/json": {Schema: s.schemas.schemaOf(reflect.TypeOf(body))}}
+		}
+		op.Responses[strconv.Itoa(status)] = &res
+	}
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]*MediaType{"application/problem+json": {Schema: s.errorSchema}},
+	}
+	for _, name := range r.Security {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+	return &op
+}
+
+// Has returns true if an operation of given method and gin path exists.
+func (s *Spec) Has(method, path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, _ := openAPIPath(path)
+	item, ok := s.doc.Paths[p]
+	if !ok {
+		return false
+	}
+	_, ok = (*item)[strings.ToLower(method)]
+	return ok
+}
+
+// Document returns the OpenAPI document.
+func (s *Spec) Document() *Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &s.doc
+}
+
+// MarshalJSON returns the json of the OpenAPI document.
+func (s *Spec) MarshalJSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.json == nil {
+		b, err := json.Marshal(&s.doc)
+		if err != nil {
+			return nil, err
+		}
+		s.json = b
+	}
+	return s.json, nil
+}
+
+// Handler returns a gin.HandlerFunc responding the OpenAPI document.
+func (s *Spec) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		b, err := s.MarshalJSON()
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", b)
+	}
+}
+
+// UIHandler returns a gin.HandlerFunc responding Swagger UI of the OpenAPI document of given url.
+// Assets of Swagger UI are loaded from the unpkg CDN.
+func UIHandler(specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := swaggerTemplate.Execute(c.Writer, map[string]string{"SpecURL": specURL}); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// openAPIPath returns the OpenAPI path of given gin path and names of path parameters
+// e.g. "/articles/{slug}" and ["slug"] of "articles/:slug".
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return "/" + strings.Join(segments, "/"), params
+}
+
+// operationID returns an id of the operation e.g. "postV1ApiArticlesSlugComments".
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		id += exportedName(word)
+	}
+	return id
+}
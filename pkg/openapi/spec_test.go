@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testError struct {
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+}
+
+type testRequest struct {
+	Article struct {
+		Title     string    `json:"title" binding:"required,min=5"`
+		Tags      []string  `json:"tagList" binding:"omitempty,dive,max=10,slug"`
+		Image     *string   `json:"image" binding:"omitempty,httpurl"`
+		Ignored   string    `json:"-"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"article"`
+}
+
+type testEmbedded struct {
+	ID uint `json:"id" binding:"required"`
+}
+
+type testResponse struct {
+	testEmbedded
+	Children []*testResponse `json:"children"`
+}
+
+type testQuery struct {
+	Tag   []string `form:"tag" binding:"omitempty,dive,max=10"`
+	Limit string   `form:"limit,default=5" binding:"numeric"`
+}
+
+func newTestSpec() *Spec {
+	s := NewSpec(Info{Title: "test", Version: "v1"}, testError{})
+	s.Add(Route{
+		Method:    http.MethodPost,
+		Path:      "/v1/api/articles/:slug/comments",
+		Summary:   "test",
+		Security:  []string{BearerAuth, APIKeyAuth},
+		Query:     &testQuery{},
+		Request:   &testRequest{},
+		Responses: map[int]interface{}{http.StatusCreated: &testResponse{}, http.StatusNotModified: nil},
+	})
+	return s
+}
+
+func TestSpec_Operation(t *testing.T) {
+	doc := newTestSpec().Document()
+
+	item, ok := doc.Paths["/v1/api/articles/{slug}/comments"]
+	assert.True(t, ok)
+	op, ok := (*item)["post"]
+	assert.True(t, ok)
+	assert.Equal(t, "postV1ApiArticlesSlugComments", op.OperationID)
+	assert.Equal(t, []map[string][]string{{BearerAuth: {}}, {APIKeyAuth: {}}}, op.Security)
+
+	// parameters
+	assert.Len(t, op.Parameters, 3)
+	assert.Equal(t, &Parameter{Name: "slug", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[0])
+	assert.Equal(t, "tag", op.Parameters[1].Name)
+	assert.Equal(t, "query", op.Parameters[1].In)
+	assert.Equal(t, "array", op.Parameters[1].Schema.Type)
+	assert.Equal(t, 10, *op.Parameters[1].Schema.Items.MaxLength)
+	assert.Equal(t, "limit", op.Parameters[2].Name)
+	assert.Equal(t, "5", op.Parameters[2].Schema.Default)
+	assert.NotEmpty(t, op.Parameters[2].Schema.Pattern)
+
+	// request and responses
+	assert.Equal(t, "#/components/schemas/TestRequest", op.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/TestResponse", op.Responses["201"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, "Not Modified", op.Responses["304"].Description)
+	assert.Nil(t, op.Responses["304"].Content)
+	assert.Equal(t, "#/components/schemas/TestError", op.Responses["default"].Content["application/problem+json"].Schema.Ref)
+}
+
+func TestSpec_Schemas(t *testing.T) {
+	schemas := newTestSpec().Document().Components.Schemas
+
+	article := schemas["TestRequest"].Properties["article"]
+	assert.Equal(t, "object", article.Type)
+	assert.Equal(t, []string{"title"}, article.Required)
+	assert.NotContains(t, article.Properties, "Ignored")
+	assert.Equal(t, 5, *article.Properties["title"].MinLength)
+	assert.Nil(t, article.Properties["title"].MaxLength)
+	tags := article.Properties["tagList"]
+	assert.Equal(t, "array", tags.Type)
+	assert.Nil(t, tags.MaxLength)
+	assert.Equal(t, 10, *tags.Items.MaxLength)
+	assert.NotEmpty(t, tags.Items.Pattern)
+	assert.Equal(t, "uri", article.Properties["image"].Format)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, article.Properties["createdAt"])
+
+	// embedded fields are promoted and recursive types are referred
+	res := schemas["TestResponse"]
+	assert.Equal(t, []string{"id"}, res.Required)
+	assert.Equal(t, "integer", res.Properties["id"].Type)
+	assert.Equal(t, "#/components/schemas/TestResponse", res.Properties["children"].Items.Ref)
+
+	assert.Equal(t, "integer", schemas["TestError"].Properties["status"].Type)
+}
+
+func TestSpec_Has(t *testing.T) {
+	s := newTestSpec()
+
+	assert.True(t, s.Has(http.MethodPost, "/v1/api/articles/:slug/comments"))
+	assert.True(t, s.Has(http.MethodPost, "/v1/api/articles/:slug/comments/"))
+	assert.False(t, s.Has(http.MethodGet, "/v1/api/articles/:slug/comments"))
+	assert.False(t, s.Has(http.MethodPost, "/v1/api/articles"))
+}
+
+func TestSpec_Handler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/openapi.json", newTestSpec().Handler())
+	r.GET("/swagger", UIHandler("/openapi.json"))
+
+	// openapi.json
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &doc))
+	assert.Equal(t, Version, doc["openapi"])
+	assert.Contains(t, doc["paths"], "/v1/api/articles/{slug}/comments")
+
+	// swagger ui
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/swagger", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.True(t, strings.HasPrefix(res.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, res.Body.String(), `url: "\/openapi.json"`)
+}
@@ -0,0 +1,379 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gin-rest-api-example/pkg/validate"
+	"io/ioutil"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locations of values of ValidationError.
+const (
+	InPath     = "path"
+	InQuery    = "query"
+	InBody     = "body"
+	InResponse = "response"
+)
+
+var patterns sync.Map
+
+// ValidationError is an error of a request or a response not conforming to the OpenAPI document.
+type ValidationError struct {
+	// In is the location of invalid values e.g. InBody.
+	In      string
+	Message string
+	Details []*validate.ValidationErrDetail
+}
+
+func (e *ValidationError) Error() string {
+	var fields []string
+	for _, d := range e.Details {
+		fields = append(fields, fmt.Sprintf("%s: %s", d.Field, d.Message))
+	}
+	return fmt.Sprintf("invalid %s: %s %v", e.In, e.Message, fields)
+}
+
+// Operation returns the operation of given method and gin path or nil if not exists.
+// It is called on every request, so operations are looked up without the lock of the Spec.
+func (s *Spec) Operation(method, path string) *Operation {
+	p, _ := openAPIPath(path)
+	op, ok := s.operations.Load(operationKey(method, p))
+	if !ok {
+		return nil
+	}
+	return op.(*Operation)
+}
+
+// ValidateRequest validates path parameters, query parameters and the json body of given request
+// of the gin path e.g. "/v1/api/articles/:slug". It returns a *ValidationError if the request is invalid.
+// The body is read and replaced to be bound again by handlers.
+//
+// Values are validated as binding tags of the schemas i.e. required strings must not be empty
+// and empty strings of optional fields are not validated.
+func (s *Spec) ValidateRequest(req *http.Request, path string, params gin.Params) error {
+	op := s.Operation(req.Method, path)
+	if op == nil {
+		return nil
+	}
+	v := schemaValidator{schemas: s.doc.Components.Schemas}
+
+	query := req.URL.Query()
+	var pathDetails, queryDetails []*validate.ValidationErrDetail
+	for _, p := range op.Parameters {
+		switch p.In {
+		case InPath:
+			value, _ := params.Get(p.Name)
+			pathDetails = append(pathDetails, v.validateParameter(p, []string{value}, true)...)
+		case InQuery:
+			values, ok := query[p.Name]
+			if !ok && p.Schema.Default != nil {
+				values, ok = []string{fmt.Sprint(p.Schema.Default)}, true
+			}
+			queryDetails = append(queryDetails, v.validateParameter(p, values, ok)...)
+		}
+	}
+	if len(pathDetails) != 0 {
+		return &ValidationError{In: InPath, Message: "invalid path parameters", Details: pathDetails}
+	}
+	if len(queryDetails) != 0 {
+		return &ValidationError{In: InQuery, Message: "invalid query parameters", Details: queryDetails}
+	}
+
+	if op.RequestBody == nil || req.Body == nil {
+		return nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if len(bytes.TrimSpace(b)) == 0 {
+		if op.RequestBody.Required {
+			return &ValidationError{In: InBody, Message: "empty request body"}
+		}
+		return nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	value, err := decodeJSON(b)
+	if err != nil {
+		return &ValidationError{In: InBody, Message: "invalid json in body"}
+	}
+	if details := v.validate(media.Schema, "", value); len(details) != 0 {
+		return &ValidationError{In: InBody, Message: "invalid request in body", Details: details}
+	}
+	return nil
+}
+
+// ValidateResponse validates the status code and the json body of a response of the gin path.
+// It returns a *ValidationError if the response is not documented e.g. an unknown field of an object.
+// Responses of error status codes are validated by the default response and bodies of other media types
+// e.g. html of redirects are not validated.
+func (s *Spec) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op := s.Operation(method, path)
+	if op == nil {
+		return nil
+	}
+	res, ok := op.Responses[strconv.Itoa(status)]
+	if !ok && status >= http.StatusBadRequest {
+		res, ok = op.Responses["default"]
+	}
+	if !ok {
+		return &ValidationError{In: InResponse, Message: fmt.Sprintf("undocumented status %d", status)}
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	if !strings.HasSuffix(mediaType, "json") || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	media, ok := res.Content[mediaType]
+	if !ok {
+		return &ValidationError{In: InResponse, Message: fmt.Sprintf("undocumented %s body of status %d", mediaType, status)}
+	}
+	value, err := decodeJSON(body)
+	if err != nil {
+		return &ValidationError{In: InResponse, Message: "invalid json in body"}
+	}
+	v := schemaValidator{schemas: s.doc.Components.Schemas, strict: true}
+	if details := v.validate(media.Schema, "", value); len(details) != 0 {
+		return &ValidationError{In: InResponse, Message: fmt.Sprintf("invalid body of status %d", status), Details: details}
+	}
+	return nil
+}
+
+func decodeJSON(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// schemaValidator validates values by schemas referring to schemas of components.
+type schemaValidator struct {
+	schemas map[string]*Schema
+	// strict reports unknown fields of objects.
+	strict bool
+}
+
+// validateParameter validates values of a path or query parameter.
+func (v *schemaValidator) validateParameter(p *Parameter, values []string, exists bool) []*validate.ValidationErrDetail {
+	if !exists || len(values) == 0 || values[0] == "" && p.Schema.Type != "array" {
+		if p.Required {
+			return []*validate.ValidationErrDetail{validate.NewTagErrorDetail(p.Name, "required", "", "")}
+		}
+		return nil
+	}
+	if p.Schema.Type == "array" {
+		var details []*validate.ValidationErrDetail
+		for i, value := range values {
+			details = append(details, v.validateParameterValue(p.Schema.Items, fmt.Sprintf("%s[%d]", p.Name, i), value)...)
+		}
+		return details
+	}
+	return v.validateParameterValue(p.Schema, p.Name, values[0])
+}
+
+func (v *schemaValidator) validateParameterValue(schema *Schema, field, value string) []*validate.ValidationErrDetail {
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return []*validate.ValidationErrDetail{validate.NewTagErrorDetail(field, "type", schema.Type, value)}
+		}
+		return nil
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return []*validate.ValidationErrDetail{validate.NewTagErrorDetail(field, "type", schema.Type, value)}
+		}
+		return nil
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return []*validate.ValidationErrDetail{validate.NewTagErrorDetail(field, "type", schema.Type, value)}
+		}
+		return nil
+	}
+	return v.validateString(schema, field, value)
+}
+
+// validate returns details of the value of the field not conforming to given schema.
+// A null value is valid and required fields are validated by the object.
+func (v *schemaValidator) validate(schema *Schema, field string, value interface{}) []*validate.ValidationErrDetail {
+	schema = v.resolve(schema)
+	if value == nil || schema == nil {
+		return nil
+	}
+	typeError := func() []*validate.ValidationErrDetail {
+		return []*validate.ValidationErrDetail{validate.NewTagErrorDetail(field, "type", schema.Type, value)}
+	}
+	switch schema.Type {
+	case "object":
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError()
+		}
+		return v.validateObject(schema, m)
+	case "array":
+		a, ok := value.([]interface{})
+		if !ok {
+			return typeError()
+		}
+		var details []*validate.ValidationErrDetail
+		for i, item := range a {
+			details = append(details, v.validate(schema.Items, fmt.Sprintf("%s[%d]", field, i), item)...)
+		}
+		return details
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return typeError()
+		}
+		return v.validateString(schema, field, s)
+	case "integer":
+		if n, ok := value.(json.Number); !ok || strings.ContainsAny(n.String(), ".eE") {
+			return typeError()
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return typeError()
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError()
+		}
+	}
+	return nil
+}
+
+// validateObject validates properties of the object in the order of fields of the struct.
+// Properties are named by the names without the name of the object as binding tags.
+func (v *schemaValidator) validateObject(schema *Schema, m map[string]interface{}) []*validate.ValidationErrDetail {
+	var details []*validate.ValidationErrDetail
+	for _, name := range schema.propertyNames() {
+		value, ok := m[name]
+		if contains(schema.Required, name) {
+			if !ok || value == nil || value == "" {
+				// missing strings are responded as empty strings as bound values
+				if p := v.resolve(schema.Properties[name]); value == nil && p != nil && p.Type == "string" {
+					value = ""
+				}
+				details = append(details, validate.NewTagErrorDetail(name, "required", "", value))
+				continue
+			}
+		} else if value == "" {
+			continue
+		}
+		details = append(details, v.validate(schema.Properties[name], name, value)...)
+	}
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := schema.Properties[name]; ok {
+			continue
+		}
+		if schema.AdditionalProperties != nil {
+			details = append(details, v.validate(schema.AdditionalProperties, name, m[name])...)
+		} else if v.strict && schema.Properties != nil {
+			details = append(details, &validate.ValidationErrDetail{Field: name, Value: m[name], Message: "unknown field " + name})
+		}
+	}
+	return details
+}
+
+func (v *schemaValidator) validateString(schema *Schema, field, s string) []*validate.ValidationErrDetail {
+	fail := func(tag, param string) []*validate.ValidationErrDetail {
+		return []*validate.ValidationErrDetail{validate.NewTagErrorDetail(field, tag, param, s)}
+	}
+	n := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && n < *schema.MinLength {
+		return fail("min", strconv.Itoa(*schema.MinLength))
+	}
+	if schema.MaxLength != nil && n > *schema.MaxLength {
+		return fail("max", strconv.Itoa(*schema.MaxLength))
+	}
+	if schema.Pattern != "" && !matchPattern(schema.Pattern, s) {
+		tag := schema.patternTag
+		if tag == "" {
+			tag = "invalid"
+		}
+		return fail(tag, "")
+	}
+	if len(schema.Enum) != 0 && !contains(schema.Enum, s) {
+		return fail("oneof", strings.Join(schema.Enum, " "))
+	}
+	switch schema.Format {
+	case "email":
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return fail("email", "")
+		}
+	case "uri":
+		if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fail("httpurl", "")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fail("datetime", time.RFC3339)
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema referred by given schema if it is a reference.
+func (v *schemaValidator) resolve(schema *Schema) *Schema {
+	if schema == nil || schema.Ref == "" {
+		return schema
+	}
+	return v.schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+}
+
+// propertyNames returns names of properties in the order of fields of the struct if known, otherwise sorted names.
+func (s *Schema) propertyNames() []string {
+	if len(s.propertyOrder) == len(s.Properties) {
+		return s.propertyOrder
+	}
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func matchPattern(pattern, s string) bool {
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return true
+		}
+		re, _ = patterns.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(s)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"encoding/json"
+	"gin-rest-api-example/pkg/validate"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpec_ValidateRequest(t *testing.T) {
+	s := newTestSpec()
+	path := "/v1/api/articles/:slug/comments"
+	params := gin.Params{{Key: "slug", Value: "article-1"}}
+	cases := []struct {
+		Name     string
+		Query    string
+		Body     string
+		In       string
+		Message  string
+		Expected []*validate.ValidationErrDetail
+	}{
+		{
+			Name: "valid",
+			Body: `{"article": {"title": "title1", "tagList": ["go"], "image": "", "createdAt": "2021-01-01T00:00:00Z"}}`,
+		},
+		{
+			Name: "required and empty strings",
+			Body: `{"article": {"title": "", "image": ""}}`,
+			In:   InBody,
+			Expected: []*validate.ValidationErrDetail{
+				{Field: "title", Value: "", Message: "required title"},
+			},
+		},
+		{
+			Name: "missing required string",
+			Body: `{"article": {}}`,
+			In:   InBody,
+			Expected: []*validate.ValidationErrDetail{
+				{Field: "title", Value: "", Message: "required title"},
+			},
+		},
+		{
+			Name: "constraints",
+			Body: `{"article": {"title": "t", "tagList": ["go", "Go Lang"], "image": "ftp://image", "createdAt": "2021"}}`,
+			In:   InBody,
+			Expected: []*validate.ValidationErrDetail{
+				{Field: "title", Value: "t", Message: "title required at least 5 length"},
+				{Field: "tagList[1]", Value: "Go Lang", Message: "tagList[1] must be a lowercase slug of letters, digits and '-'"},
+				{Field: "image", Value: "ftp://image", Message: "image must be an http or https url"},
+				{Field: "createdAt", Value: "2021", Message: "createdAt must be in the format 2006-01-02T15:04:05Z07:00"},
+			},
+		},
+		{
+			Name: "types",
+			Body: `{"article": {"title": 1, "tagList": "go"}}`,
+			In:   InBody,
+			Expected: []*validate.ValidationErrDetail{
+				{Field: "title", Value: json.Number("1"), Message: "title must be of type string"},
+				{Field: "tagList", Value: "go", Message: "tagList must be of type array"},
+			},
+		},
+		{
+			Name:    "empty body",
+			In:      InBody,
+			Message: "empty request body",
+		},
+		{
+			Name:    "invalid json",
+			Body:    `{"article":`,
+			In:      InBody,
+			Message: "invalid json in body",
+		},
+		{
+			Name:  "query",
+			Query: "?tag=go&tag=golang-is-fun&limit=a",
+			Body:  `{"article": {"title": "title1"}}`,
+			In:    InQuery,
+			Expected: []*validate.ValidationErrDetail{
+				{Field: "tag[1]", Value: "golang-is-fun", Message: "tag[1] required at most 10 length"},
+				{Field: "limit", Value: "a", Message: "limit must be numeric"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/api/articles/article-1/comments"+tc.Query, strings.NewReader(tc.Body))
+
+			err := s.ValidateRequest(req, path, params)
+
+			if tc.In == "" {
+				assert.NoError(t, err)
+				return
+			}
+			vErr, ok := err.(*ValidationError)
+			assert.True(t, ok)
+			assert.Equal(t, tc.In, vErr.In)
+			if tc.Message != "" {
+				assert.Equal(t, tc.Message, vErr.Message)
+			}
+			assert.Equal(t, tc.Expected, withoutKeys(vErr.Details))
+			// the body can be read again
+			b, _ := ioutil.ReadAll(req.Body)
+			assert.Equal(t, tc.Body, string(b))
+		})
+	}
+}
+
+func TestSpec_ValidateRequest_NotDocumented(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/api/articles", nil)
+
+	assert.NoError(t, newTestSpec().ValidateRequest(req, "/v1/api/articles", nil))
+}
+
+func TestSpec_ValidateRequest_Concurrent(t *testing.T) {
+	s := newTestSpec()
+	params := gin.Params{{Key: "slug", Value: "article-1"}}
+
+	// when then: requests are validated while routes are added
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/v1/api/articles/article-1/comments", strings.NewReader(`{"article": {"title": ""}}`))
+			_, ok := s.ValidateRequest(req, "/v1/api/articles/:slug/comments", params).(*ValidationError)
+			assert.True(t, ok)
+		}()
+	}
+	s.Add(Route{Method: http.MethodGet, Path: "/v1/api/tags"})
+	wg.Wait()
+	assert.NotNil(t, s.Operation(http.MethodGet, "/v1/api/tags"))
+}
+
+func TestSpec_ValidateResponse(t *testing.T) {
+	s := newTestSpec()
+	path := "/v1/api/articles/:slug/comments"
+	cases := []struct {
+		Name        string
+		Status      int
+		ContentType string
+		Body        string
+		Valid       bool
+	}{
+		{Name: "valid", Status: 201, ContentType: "application/json; charset=utf-8", Body: `{"id": 1, "children": [{"id": 2, "children": null}]}`, Valid: true},
+		{Name: "no body", Status: 304, Valid: true},
+		{Name: "error", Status: 404, ContentType: "application/problem+json", Body: `{"title": "Not Found", "status": 404}`, Valid: true},
+		{Name: "html", Status: 304, ContentType: "text/html", Body: `<a href="/">Found</a>`, Valid: true},
+		{Name: "unknown field", Status: 201, ContentType: "application/json", Body: `{"id": 1, "name": "name"}`},
+		{Name: "invalid type", Status: 201, ContentType: "application/json", Body: `{"id": "1"}`},
+		{Name: "invalid nested type", Status: 201, ContentType: "application/json", Body: `{"id": 1, "children": [{"id": 1.5}]}`},
+		{Name: "undocumented status", Status: 200, ContentType: "application/json", Body: `{"id": 1}`},
+		{Name: "undocumented body", Status: 304, ContentType: "application/json", Body: `{"id": 1}`},
+		{Name: "undocumented media type", Status: 404, ContentType: "application/json", Body: `{"status": 404}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := s.ValidateResponse(http.MethodPost, path, tc.Status, tc.ContentType, []byte(tc.Body))
+
+			if tc.Valid {
+				assert.NoError(t, err)
+				return
+			}
+			_, ok := err.(*ValidationError)
+			assert.True(t, ok, "error: %v", err)
+		})
+	}
+}
+
+// withoutKeys returns copies of given details without keys of messages to compare.
+func withoutKeys(details []*validate.ValidationErrDetail) []*validate.ValidationErrDetail {
+	var copied []*validate.ValidationErrDetail
+	for _, d := range details {
+		copied = append(copied, &validate.ValidationErrDetail{Field: d.Field, Value: d.Value, Message: d.Message})
+	}
+	return copied
+}
@@ -0,0 +1,79 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA1 secret of RFC 6238 test vectors
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateCode(t *testing.T) {
+	cases := []struct {
+		Time int64
+		Code string
+	}{
+		{Time: 59, Code: "287082"},
+		{Time: 1111111109, Code: "081804"},
+		{Time: 1111111111, Code: "050471"},
+		{Time: 1234567890, Code: "005924"},
+		{Time: 2000000000, Code: "279037"},
+		{Time: 20000000000, Code: "353130"},
+	}
+
+	for _, tc := range cases {
+		code, err := GenerateCode(rfc6238Secret, time.Unix(tc.Time, 0))
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.Code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	now := time.Now()
+	code, err := GenerateCode(secret, now)
+	assert.NoError(t, err)
+
+	step := now.Unix() / Period
+	cases := []struct {
+		Code   string
+		Secret string
+		Time   time.Time
+		// expected
+		Step int64
+		OK   bool
+	}{
+		{Code: code, Secret: secret, Time: now, Step: step, OK: true},
+		{Code: code, Secret: secret, Time: now.Add(Period * time.Second), Step: step, OK: true},
+		{Code: code, Secret: secret, Time: now.Add(-Period * time.Second), Step: step, OK: true},
+		{Code: code, Secret: secret, Time: now.Add(3 * Period * time.Second)},
+		{Code: "", Secret: secret, Time: now},
+		{Code: "12345", Secret: secret, Time: now},
+		{Code: code, Secret: "invalid secret!", Time: now},
+	}
+
+	for _, tc := range cases {
+		matched, ok := Validate(tc.Code, tc.Secret, tc.Time)
+		assert.Equal(t, tc.OK, ok)
+		assert.Equal(t, tc.Step, matched)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("article-server", "user1@email.com", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/article-server:user1@email.com", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "article-server", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+}
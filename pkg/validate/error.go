@@ -1,56 +1,89 @@
 package validate
 
 import (
-	"fmt"
+	"gin-rest-api-example/pkg/i18n"
 	"gin-rest-api-example/pkg/logging"
 	"github.com/go-playground/validator/v10"
 	"reflect"
+	"strings"
 )
 
 type ValidationErrDetail struct {
 	Field   string      `json:"field"`
 	Value   interface{} `json:"value"`
 	Message string      `json:"message"`
+	// key and args of the message in the i18n.Catalog if the message is localizable.
+	key  string
+	args i18n.Args
 }
 
-// NewValidationErrorDetail returns ValidationErrDetail list with given validation errors
+// NewValidationErrorDetail returns ValidationErrDetail list with given validation errors of obj.
+// Fields are named by given tag of the field e.g. "json" even if the field is of a nested struct,
+// and errors of elements are named with the index e.g. "tagList[1]".
+// Messages are "validation.<tag>" messages of i18n.DefaultLocale which are localized by Localize.
 func ValidationErrorDetails(obj interface{}, tag string, errs validator.ValidationErrors) []*ValidationErrDetail {
 	if len(errs) == 0 {
 		return []*ValidationErrDetail{}
 	}
 	var errors []*ValidationErrDetail
-	e := reflect.TypeOf(obj).Elem()
+	t := reflect.TypeOf(obj)
 	for _, err := range errs {
-		f, _ := e.FieldByName(err.Field())
-		tagName, _ := f.Tag.Lookup(tag)
-		val := err.Value()
-		var message string
+		field := fieldName(t, err.StructNamespace(), tag)
+		if field == "" {
+			field = err.Field()
+		}
+		errors = append(errors, NewTagErrorDetail(field, err.ActualTag(), err.Param(), err.Value()))
+	}
+	return errors
+}
 
-		switch err.ActualTag() {
-		case "required":
-			message = fmt.Sprintf("required %s", tagName)
-		case "email":
-			message = "required email format"
-		case "min":
-			message = fmt.Sprintf("%s required at least %s length", tagName, err.Param())
-		case "hexadecimal":
-			message = "required hexadecimal format"
-		case "gte":
-			message = fmt.Sprintf("greater than or quauls to %s", err.Param())
-		case "numeric":
-			message = fmt.Sprintf("%s must be numeric", tagName)
-		default:
-			logging.DefaultLogger().Warnf("unknown validation tag. tag:%s", err.ActualTag())
-			message = fmt.Sprintf("invalid %s", tagName)
+// fieldName returns the name of the tag of the field of given struct namespace e.g. "Request.Article.TagList[1]"
+// or an empty string if not found.
+func fieldName(t reflect.Type, namespace string, tag string) string {
+	var field string
+	// the first name is the name of the struct
+	for _, name := range strings.Split(namespace, ".")[1:] {
+		// elements e.g. "TagList[0]" are named by the field name with the index
+		index := ""
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			name, index = name[:i], name[i:]
 		}
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return ""
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return ""
+		}
+		t = f.Type
+		tagName, _ := f.Tag.Lookup(tag)
+		field = strings.SplitN(tagName, ",", 2)[0] + index
+	}
+	return field
+}
 
-		errors = append(errors, &ValidationErrDetail{
-			Field:   tagName,
-			Value:   val,
-			Message: message,
-		})
+// NewTagErrorDetail returns a ValidationErrDetail of given field failed by the validator tag with the param.
+// The message is the "validation.<tag>" message of i18n.DefaultLocale which is localized by Localize.
+func NewTagErrorDetail(field, tag, param string, value interface{}) *ValidationErrDetail {
+	l := i18n.DefaultCatalog().Localizer(i18n.DefaultLocale)
+	key := "validation." + tag
+	args := i18n.Args{"field": field, "param": param}
+	message, ok := l.Lookup(key, args)
+	if !ok {
+		logging.DefaultLogger().Warnf("unknown validation tag. tag:%s", tag)
+		key = "validation.invalid"
+		message = l.Message(key, args)
+	}
+	return &ValidationErrDetail{
+		Field:   field,
+		Value:   value,
+		Message: message,
+		key:     key,
+		args:    args,
 	}
-	return errors
 }
 
 func NewValidationErrorDetails(field, message string, value interface{}) []*ValidationErrDetail {
@@ -62,3 +95,17 @@ func NewValidationErrorDetails(field, message string, value interface{}) []*Vali
 		},
 	}
 }
+
+// Localize returns copies of given details of which messages are localized by the localizer.
+// Messages of details not created by ValidationErrorDetails are not changed.
+func Localize(l *i18n.Localizer, details []*ValidationErrDetail) []*ValidationErrDetail {
+	localized := make([]*ValidationErrDetail, len(details))
+	for i, d := range details {
+		copied := *d
+		if d.key != "" {
+			copied.Message = l.Message(d.key, d.args)
+		}
+		localized[i] = &copied
+	}
+	return localized
+}
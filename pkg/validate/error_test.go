@@ -0,0 +1,88 @@
+package validate
+
+import (
+	"gin-rest-api-example/pkg/i18n"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidationTagMessages fails if messages of validator tags used in binding tags of the module
+// are missing in the i18n.Catalog.
+func TestValidationTagMessages(t *testing.T) {
+	bindingTag := regexp.MustCompile("binding:\"([^\"]*)\"")
+	tags := make(map[string]struct{})
+	err := filepath.Walk("../..", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, match := range bindingTag.FindAllStringSubmatch(string(b), -1) {
+			for _, tag := range strings.Split(match[1], ",") {
+				tag = strings.SplitN(tag, "=", 2)[0]
+				if tag != "omitempty" && tag != "dive" {
+					tags[tag] = struct{}{}
+				}
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tags)
+
+	l := i18n.DefaultCatalog().Localizer(i18n.DefaultLocale)
+	for tag := range tags {
+		_, ok := l.Lookup("validation."+tag, nil)
+		assert.True(t, ok, "message of tag %s", tag)
+	}
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	type request struct {
+		Email   string   `json:"email" binding:"required,email"`
+		Count   string   `json:"count" binding:"numeric"`
+		TagList []string `json:"tagList" binding:"dive,max=3"`
+		Author  struct {
+			Name string `json:"name,omitempty" binding:"required"`
+		} `json:"author"`
+	}
+	body := request{Email: "email", Count: "a", TagList: []string{"tag", "tag1"}}
+	v := validator.New()
+	v.SetTagName("binding")
+	errs := v.Struct(&body).(validator.ValidationErrors)
+
+	// when
+	details := ValidationErrorDetails(&body, "json", errs)
+
+	// then
+	assert.Equal(t, []*ValidationErrDetail{
+		{Field: "email", Value: "email", Message: "required email format"},
+		{Field: "count", Value: "a", Message: "count must be numeric"},
+		{Field: "tagList[1]", Value: "tag1", Message: "tagList[1] required at most 3 length"},
+		// fields of nested structs are named by the names of the fields
+		{Field: "name", Value: "", Message: "required name"},
+	}, withoutKeys(details))
+
+	localized := Localize(i18n.DefaultCatalog().Localizer("ko"), details)
+	assert.Equal(t, "count은(는) 숫자여야 합니다", localized[1].Message)
+	// details are not changed
+	assert.Equal(t, "count must be numeric", details[1].Message)
+}
+
+// withoutKeys returns copies of given details without keys of messages to compare.
+func withoutKeys(details []*ValidationErrDetail) []*ValidationErrDetail {
+	var copied []*ValidationErrDetail
+	for _, d := range details {
+		copied = append(copied, &ValidationErrDetail{Field: d.Field, Value: d.Value, Message: d.Message})
+	}
+	return copied
+}
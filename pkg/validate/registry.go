@@ -0,0 +1,129 @@
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	slugPattern     = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+	defaultRegistry = NewRegistry()
+	setupBinding    sync.Once
+	setupBindingErr error
+)
+
+type structValidation struct {
+	fn    validator.StructLevelFunc
+	types []interface{}
+}
+
+// Registry is custom validator funcs of binding tags and struct-level validations of types.
+// Messages of errors are "validation.<tag>" messages of the i18n.Catalog.
+type Registry struct {
+	mu          sync.Mutex
+	validations map[string]validator.Func
+	structs     []structValidation
+}
+
+// NewRegistry returns a new Registry with validators below.
+//   - slug: a lowercase slug e.g. "go-lang"
+//   - username: starts with a letter or digit and contains only letters, digits, '.', '_' and '-'
+//   - httpurl: an absolute http or https url
+func NewRegistry() *Registry {
+	r := Registry{validations: make(map[string]validator.Func)}
+	r.RegisterValidation("slug", isSlug)
+	r.RegisterValidation("username", isUsername)
+	r.RegisterValidation("httpurl", isHTTPURL)
+	return &r
+}
+
+// DefaultRegistry returns the Registry applied to the validator engine of gin binding by SetupBinding.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// RegisterValidation registers given validator func of the binding tag.
+func (r *Registry) RegisterValidation(tag string, fn validator.Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validations[tag] = fn
+}
+
+// RegisterStructValidation registers given struct-level validation of the types.
+// Errors are reported by validator.StructLevel.ReportError with a tag of the message.
+func (r *Registry) RegisterStructValidation(fn validator.StructLevelFunc, types ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.structs = append(r.structs, structValidation{fn: fn, types: types})
+}
+
+// Tags returns the sorted binding tags of registered validator funcs.
+func (r *Registry) Tags() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tags []string
+	for tag := range r.validations {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Apply registers validator funcs and struct-level validations to given validator.
+func (r *Registry) Apply(v *validator.Validate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for tag, fn := range r.validations {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("failed to register validation %s: %w", tag, err)
+		}
+	}
+	for _, s := range r.structs {
+		v.RegisterStructValidation(s.fn, s.types...)
+	}
+	return nil
+}
+
+// SetupBinding applies the DefaultRegistry to the validator engine of gin binding once.
+// It must be called before binding requests with custom tags at startup.
+func SetupBinding() error {
+	setupBinding.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			setupBindingErr = fmt.Errorf("unknown validator engine of binding: %T", binding.Validator.Engine())
+			return
+		}
+		setupBindingErr = defaultRegistry.Apply(v)
+	})
+	return setupBindingErr
+}
+
+// IsUsername returns true if given username starts with a letter or digit and contains only letters,
+// digits, '.', '_' and '-'.
+func IsUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+func isSlug(fl validator.FieldLevel) bool {
+	return slugPattern.MatchString(fl.Field().String())
+}
+
+func isUsername(fl validator.FieldLevel) bool {
+	return IsUsername(fl.Field().String())
+}
+
+func isHTTPURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
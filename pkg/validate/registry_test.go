@@ -0,0 +1,140 @@
+package validate
+
+import (
+	"gin-rest-api-example/pkg/i18n"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestValidator(t *testing.T, r *Registry) *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	assert.NoError(t, r.Apply(v))
+	return v
+}
+
+func TestRegistry_Validations(t *testing.T) {
+	v := newTestValidator(t, NewRegistry())
+	cases := []struct {
+		Tag   string
+		Value string
+		Valid bool
+	}{
+		// slug
+		{Tag: "slug", Value: "golang", Valid: true},
+		{Tag: "slug", Value: "go-lang-1", Valid: true},
+		{Tag: "slug", Value: "Golang", Valid: false},
+		{Tag: "slug", Value: "go lang", Valid: false},
+		{Tag: "slug", Value: "-golang", Valid: false},
+		{Tag: "slug", Value: "go--lang", Valid: false},
+		{Tag: "slug", Value: "", Valid: false},
+		// username
+		{Tag: "username", Value: "user1", Valid: true},
+		{Tag: "username", Value: "User.name_1-a", Valid: true},
+		{Tag: "username", Value: ".user", Valid: false},
+		{Tag: "username", Value: "user name", Valid: false},
+		{Tag: "username", Value: "user@name", Valid: false},
+		// httpurl
+		{Tag: "httpurl", Value: "https://example.com/image.png", Valid: true},
+		{Tag: "httpurl", Value: "http://localhost:8080", Valid: true},
+		{Tag: "httpurl", Value: "ftp://example.com/image.png", Valid: false},
+		{Tag: "httpurl", Value: "javascript:alert(1)", Valid: false},
+		{Tag: "httpurl", Value: "/image.png", Valid: false},
+		{Tag: "httpurl", Value: "https://", Valid: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Tag+" "+tc.Value, func(t *testing.T) {
+			err := v.Var(tc.Value, tc.Tag)
+			if tc.Valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_StructValidation(t *testing.T) {
+	type dateRange struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+	}
+	r := NewRegistry()
+	r.RegisterStructValidation(func(sl validator.StructLevel) {
+		dr := sl.Current().Interface().(dateRange)
+		if dr.To <= dr.From {
+			sl.ReportError(dr.To, "To", "To", "after", "from")
+		}
+	}, dateRange{})
+	v := newTestValidator(t, r)
+	cases := []struct {
+		Name     string
+		Value    dateRange
+		Expected []*ValidationErrDetail
+	}{
+		{
+			Name:  "valid",
+			Value: dateRange{From: 1, To: 2},
+		}, {
+			Name:  "invalid",
+			Value: dateRange{From: 2, To: 2},
+			Expected: []*ValidationErrDetail{
+				{Field: "to", Value: 2, Message: "to must be after from"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := v.Struct(&tc.Value)
+			if tc.Expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			details := ValidationErrorDetails(&tc.Value, "json", err.(validator.ValidationErrors))
+			assert.Equal(t, tc.Expected, withoutKeys(details))
+		})
+	}
+}
+
+func TestRegistry_Messages(t *testing.T) {
+	type request struct {
+		Tag   string `json:"tag" binding:"slug"`
+		Name  string `json:"name" binding:"username"`
+		Image string `json:"image" binding:"httpurl"`
+	}
+	v := newTestValidator(t, NewRegistry())
+	body := request{Tag: "Tag", Name: "@name", Image: "image"}
+
+	// when
+	details := ValidationErrorDetails(&body, "json", v.Struct(&body).(validator.ValidationErrors))
+
+	// then
+	assert.Equal(t, []*ValidationErrDetail{
+		{Field: "tag", Value: "Tag", Message: "tag must be a lowercase slug of letters, digits and '-'"},
+		{Field: "name", Value: "@name", Message: "name must start with a letter or digit and contain only letters, digits, '.', '_' and '-'"},
+		{Field: "image", Value: "image", Message: "image must be an http or https url"},
+	}, withoutKeys(details))
+
+	// every validator has messages
+	l := i18n.DefaultCatalog().Localizer(i18n.DefaultLocale)
+	for _, tag := range DefaultRegistry().Tags() {
+		_, ok := l.Lookup("validation."+tag, nil)
+		assert.True(t, ok, "message of tag %s", tag)
+	}
+}
+
+func TestSetupBinding(t *testing.T) {
+	type request struct {
+		Tag string `json:"tag" binding:"slug"`
+	}
+	assert.NoError(t, SetupBinding())
+	assert.NoError(t, SetupBinding())
+
+	assert.NoError(t, binding.Validator.ValidateStruct(&request{Tag: "tag"}))
+	assert.Error(t, binding.Validator.ValidateStruct(&request{Tag: "Tag"}))
+}